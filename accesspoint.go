@@ -0,0 +1,148 @@
+package wifimanager
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ottopress/WifiManager/linux"
+)
+
+var (
+	// accessPoints holds the running hostapd instance of every
+	// interface that is in access point mode
+	accessPoints      = map[string]*linux.Hostapd{}
+	accessPointsMutex sync.Mutex
+
+	// ErrAPRunning is returned when starting access point mode on an
+	// interface that is already in access point mode
+	ErrAPRunning = errors.New("wifi: interface is already in access point mode")
+	// ErrAPNotRunning is returned when an access point operation is
+	// requested on an interface that isn't in access point mode
+	ErrAPNotRunning = errors.New("wifi: interface is not in access point mode")
+	// ErrAPSecurity is returned when the requested access point
+	// security protocol isn't supported
+	ErrAPSecurity = errors.New("wifi: unsupported access point security protocol")
+)
+
+// AccessPoint represents the network an interface hosts while it
+// is in access point mode
+type AccessPoint struct {
+	SSID        string
+	Channel     int
+	Security    int
+	SecurityKey string
+	CountryCode string
+	Hidden      bool
+}
+
+// Station represents a client associated with an access point
+type Station struct {
+	MAC           string
+	Signal        int
+	Authorized    bool
+	RxBytes       uint64
+	TxBytes       uint64
+	ConnectedTime time.Duration
+}
+
+// StartAccessPoint turns the interface into an access point hosting
+// the provided network. SecurityWPA2, SecurityWPA3 and SecurityNone
+// are supported, with SecurityWPA3 running in WPA2/WPA3 transition
+// mode so older clients can still join. The security key of a secured
+// access point must pass ValidatePassphrase. Backends implementing
+// AccessPointBackend handle it themselves, otherwise hostapd is used.
+func (wifiInterface *WifiInterface) StartAccessPoint(accessPoint AccessPoint) error {
+	RegisterSecret(accessPoint.SecurityKey)
+	defer ForgetSecret(accessPoint.SecurityKey)
+	if accessPoint.Security != SecurityNone {
+		if validateErr := ValidatePassphrase(accessPoint.SecurityKey); validateErr != nil {
+			return validateErr
+		}
+	}
+	if apBackend, ok := wifiInterface.Backend().(AccessPointBackend); ok {
+		return apBackend.StartAccessPoint(wifiInterface.Name, accessPoint)
+	}
+	accessPointsMutex.Lock()
+	defer accessPointsMutex.Unlock()
+	if _, running := accessPoints[wifiInterface.Name]; running {
+		return ErrAPRunning
+	}
+	config := linux.HostapdConfig{
+		SSID:        accessPoint.SSID,
+		Channel:     accessPoint.Channel,
+		Passphrase:  accessPoint.SecurityKey,
+		CountryCode: accessPoint.CountryCode,
+		Hidden:      accessPoint.Hidden,
+	}
+	switch accessPoint.Security {
+	case SecurityNone:
+		config.Security = linux.HostapdOpen
+	case SecurityWPA2:
+		config.Security = linux.HostapdWPA2
+	case SecurityWPA3:
+		config.Security = linux.HostapdWPA2WPA3
+	default:
+		return ErrAPSecurity
+	}
+	if config.Channel == 0 {
+		config.Channel = 6
+	}
+	hostapd := linux.NewHostapd(wifiInterface.Name)
+	startErr := hostapd.Start(config)
+	if startErr != nil {
+		return startErr
+	}
+	accessPoints[wifiInterface.Name] = hostapd
+	return nil
+}
+
+// StopAccessPoint takes the interface out of access point mode
+func (wifiInterface *WifiInterface) StopAccessPoint() error {
+	if apBackend, ok := wifiInterface.Backend().(AccessPointBackend); ok {
+		return apBackend.StopAccessPoint(wifiInterface.Name)
+	}
+	accessPointsMutex.Lock()
+	defer accessPointsMutex.Unlock()
+	hostapd, running := accessPoints[wifiInterface.Name]
+	if !running {
+		return ErrAPNotRunning
+	}
+	delete(accessPoints, wifiInterface.Name)
+	return hostapd.Stop()
+}
+
+// Stations returns the clients connected to the interface while it
+// is in access point mode
+func (wifiInterface *WifiInterface) Stations() ([]Station, error) {
+	if apBackend, ok := wifiInterface.Backend().(AccessPointBackend); ok {
+		return apBackend.Stations(wifiInterface.Name)
+	}
+	accessPointsMutex.Lock()
+	hostapd, running := accessPoints[wifiInterface.Name]
+	accessPointsMutex.Unlock()
+	if !running {
+		return nil, ErrAPNotRunning
+	}
+	hostapdStations, stationsErr := hostapd.Stations()
+	if stationsErr != nil {
+		return nil, stationsErr
+	}
+	stations := []Station{}
+	for _, hostapdStation := range hostapdStations {
+		station := Station{
+			MAC:           hostapdStation.MAC,
+			Signal:        hostapdStation.Signal,
+			RxBytes:       hostapdStation.RxBytes,
+			TxBytes:       hostapdStation.TxBytes,
+			ConnectedTime: time.Duration(hostapdStation.ConnectedTime) * time.Second,
+		}
+		for _, flag := range hostapdStation.Flags {
+			if flag == "AUTHORIZED" {
+				station.Authorized = true
+			}
+		}
+		stations = append(stations, station)
+	}
+	return stations, nil
+}
@@ -0,0 +1,137 @@
+package wifimanager
+
+import (
+	"net"
+	"runtime"
+	"sync"
+)
+
+var (
+	// defaultBackend is used by GetWifiInterfaces and by any
+	// WifiInterface that wasn't created by a backend. It is detected on
+	// first use unless set with SetBackend.
+	defaultBackend      Backend
+	defaultBackendMutex sync.Mutex
+)
+
+// Backend is the set of platform specific operations a WifiInterface
+// is driven by. Interfaces are addressed by name.
+type Backend interface {
+	Name() string
+	IsInstalled() bool
+	Interfaces() ([]WifiInterface, error)
+	Scan(iface string) ([]WifiNetwork, error)
+	Connect(iface string, network WifiNetwork) error
+	Disconnect(iface string) error
+	Up(iface string) error
+	Down(iface string) error
+	Status(iface string) (bool, error)
+}
+
+// AccessPointBackend is implemented by backends that manage access
+// point mode themselves instead of relying on hostapd
+type AccessPointBackend interface {
+	StartAccessPoint(iface string, accessPoint AccessPoint) error
+	StopAccessPoint(iface string) error
+	Stations(iface string) ([]Station, error)
+}
+
+// DefaultBackend returns the backend used by GetWifiInterfaces. When
+// detection fails the Mac OS X backend is returned on darwin, and
+// elsewhere a backend whose operations fail with the detection error.
+func DefaultBackend() Backend {
+	backend, _ := detectDefaultBackend()
+	return backend
+}
+
+// SetBackend replaces the backend used by GetWifiInterfaces
+func SetBackend(backend Backend) {
+	defaultBackendMutex.Lock()
+	defer defaultBackendMutex.Unlock()
+	defaultBackend = backend
+}
+
+// detectDefaultBackend returns the default backend, along with the
+// error detection failed with. Failed detections aren't kept, so a
+// daemon started later is found on the next call.
+func detectDefaultBackend() (Backend, error) {
+	defaultBackendMutex.Lock()
+	defer defaultBackendMutex.Unlock()
+	if defaultBackend != nil {
+		return defaultBackend, nil
+	}
+	backend, detectErr := DetectBackend()
+	if detectErr != nil {
+		if runtime.GOOS == "darwin" {
+			return NewDarwinBackend(), detectErr
+		}
+		return &unavailableBackend{err: detectErr}, detectErr
+	}
+	defaultBackend = backend
+	return backend, nil
+}
+
+// unavailableBackend stands in for the default backend when detection
+// failed, failing every operation with the detection error
+type unavailableBackend struct {
+	err error
+}
+
+// Name returns the name of the backend
+func (backend *unavailableBackend) Name() string {
+	return "unavailable"
+}
+
+// IsInstalled returns false
+func (backend *unavailableBackend) IsInstalled() bool {
+	return false
+}
+
+// Interfaces returns the detection error
+func (backend *unavailableBackend) Interfaces() ([]WifiInterface, error) {
+	return nil, backend.err
+}
+
+// Scan returns the detection error
+func (backend *unavailableBackend) Scan(iface string) ([]WifiNetwork, error) {
+	return nil, backend.err
+}
+
+// Connect returns the detection error
+func (backend *unavailableBackend) Connect(iface string, network WifiNetwork) error {
+	return backend.err
+}
+
+// Disconnect returns the detection error
+func (backend *unavailableBackend) Disconnect(iface string) error {
+	return backend.err
+}
+
+// Up returns the detection error
+func (backend *unavailableBackend) Up(iface string) error {
+	return backend.err
+}
+
+// Down returns the detection error
+func (backend *unavailableBackend) Down(iface string) error {
+	return backend.err
+}
+
+// Status returns the detection error
+func (backend *unavailableBackend) Status(iface string) (bool, error) {
+	return false, backend.err
+}
+
+// Backend returns the backend driving the interface
+func (wifiInterface *WifiInterface) Backend() Backend {
+	if wifiInterface.backend != nil {
+		return wifiInterface.backend
+	}
+	return DefaultBackend()
+}
+
+// NewBackendInterface returns the interface driven by the provided
+// backend, for backends implemented outside of this package
+func NewBackendInterface(iface net.Interface, backend Backend) WifiInterface {
+	return WifiInterface{Interface: iface, backend: backend}
+}
@@ -0,0 +1,39 @@
+package wifimanager
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestDefaultBackendDetectionFailure(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("darwin falls back to its own backend")
+	}
+	defaultBackendMutex.Lock()
+	previous := defaultBackend
+	defaultBackend = nil
+	defaultBackendMutex.Unlock()
+	t.Cleanup(func() { SetBackend(previous) })
+
+	t.Setenv(BackendEnv, "bogus")
+	if _, ifaceErr := GetWifiInterfaces(); ifaceErr != ErrUnknownBackend {
+		t.Errorf("GetWifiInterfaces error = %v, want %v", ifaceErr, ErrUnknownBackend)
+	}
+	backend := DefaultBackend()
+	if backend.Name() == "darwin" {
+		t.Fatal("DefaultBackend fell back to darwin")
+	}
+	wifiInterface := WifiInterface{}
+	wifiInterface.Name = "wlan0"
+	if _, scanErr := wifiInterface.Scan(); scanErr != ErrUnknownBackend {
+		t.Errorf("Scan error = %v, want %v", scanErr, ErrUnknownBackend)
+	}
+	if upErr := wifiInterface.Up(); upErr != ErrUnknownBackend {
+		t.Errorf("Up error = %v, want %v", upErr, ErrUnknownBackend)
+	}
+
+	t.Setenv(BackendEnv, "iw")
+	if backend := DefaultBackend(); backend.Name() != "iw" {
+		t.Errorf("DefaultBackend after a failed detection = %s, want iw", backend.Name())
+	}
+}
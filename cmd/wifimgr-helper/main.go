@@ -0,0 +1,100 @@
+// Command wifimgr-helper runs as root and performs the WiFi operations
+// that need it on behalf of the users and groups it is told to allow.
+// Point the library at it with helper.NewClient.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/ottopress/WifiManager"
+	"github.com/ottopress/WifiManager/helper"
+)
+
+func main() {
+	socket := flag.String("socket", helper.DefaultSocket, "unix socket to listen on")
+	backendName := flag.String("backend", os.Getenv(wifimanager.BackendEnv), "backend to use: "+strings.Join(wifimanager.BackendNames(), ", ")+" (default: detected)")
+	users := flag.String("allow-users", "", "comma separated users allowed to use the helper")
+	groups := flag.String("allow-groups", "", "comma separated groups whose members may use the helper")
+	interfaces := flag.String("interfaces", "", "comma separated interfaces the helper may operate (default: all)")
+	flag.Parse()
+	logger := log.New(wifimanager.RedactWriter(os.Stderr), "", log.LstdFlags)
+
+	allowlist, allowErr := parseAllowlist(*users, *groups, *interfaces)
+	if allowErr != nil {
+		logger.Fatal("wifimgr-helper: ", allowErr)
+	}
+	backend, backendErr := wifimanager.SelectBackend(*backendName)
+	if backendErr != nil {
+		logger.Fatal("wifimgr-helper: ", backendErr)
+	}
+	listener, listenErr := helper.Listen(*socket)
+	if listenErr != nil {
+		logger.Fatal("wifimgr-helper: ", listenErr)
+	}
+	server := helper.NewServer(backend, allowlist)
+	server.Logger = logger
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		listener.Close()
+	}()
+	logger.Printf("wifimgr-helper: serving the %s backend on %s", backend.Name(), *socket)
+	server.Serve(listener)
+	os.Remove(*socket)
+}
+
+// parseAllowlist resolves the comma separated user and group names,
+// or numeric IDs, into an allowlist
+func parseAllowlist(users, groups, interfaces string) (helper.Allowlist, error) {
+	allowlist := helper.Allowlist{}
+	for _, name := range splitList(users) {
+		account, lookupErr := user.Lookup(name)
+		if lookupErr != nil {
+			account, lookupErr = user.LookupId(name)
+		}
+		if lookupErr != nil {
+			return allowlist, fmt.Errorf("unknown user %q", name)
+		}
+		uid, parseErr := strconv.ParseUint(account.Uid, 10, 32)
+		if parseErr != nil {
+			return allowlist, parseErr
+		}
+		allowlist.UIDs = append(allowlist.UIDs, uint32(uid))
+	}
+	for _, name := range splitList(groups) {
+		group, lookupErr := user.LookupGroup(name)
+		if lookupErr != nil {
+			group, lookupErr = user.LookupGroupId(name)
+		}
+		if lookupErr != nil {
+			return allowlist, fmt.Errorf("unknown group %q", name)
+		}
+		gid, parseErr := strconv.ParseUint(group.Gid, 10, 32)
+		if parseErr != nil {
+			return allowlist, parseErr
+		}
+		allowlist.GIDs = append(allowlist.GIDs, uint32(gid))
+	}
+	allowlist.Interfaces = splitList(interfaces)
+	return allowlist, nil
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,457 @@
+// Command wifimgr scans for, connects to and manages WiFi networks
+// from the command line.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ottopress/WifiManager"
+	"github.com/ottopress/WifiManager/helper"
+)
+
+const usage = `usage: wifimgr [flags] <command> [arguments]
+
+commands:
+  ifaces                      list WiFi interfaces
+  scan [flags]                list reachable networks
+  connect [flags] <ssid>      join a network
+  disconnect                  leave the current network
+  up                          turn the interface on
+  down                        turn the interface off
+  status                      show the power state of the interface
+  watch                       print the events of the interface as they happen
+  profiles add [flags] <ssid> save a network profile
+  profiles list               list saved profiles
+  profiles rm <ssid>          remove a saved profile
+  doctor                      check the backends and recommend one
+  tui [flags]                 live scanner for the terminal
+
+flags:
+`
+
+var (
+	// checkKinds names the kinds of checks doctor lists
+	checkKinds = map[int]string{
+		wifimanager.CheckCommand: "command",
+		wifimanager.CheckSocket:  "socket",
+		wifimanager.CheckService: "service",
+		wifimanager.CheckKernel:  "kernel",
+	}
+
+	// errUsage is returned when a command is used incorrectly
+	errUsage = errors.New("invalid usage")
+)
+
+// app holds the global options shared by all commands
+type app struct {
+	backendName  string
+	ifaceName    string
+	profilesPath string
+	helperSocket string
+	stdout       io.Writer
+}
+
+func main() {
+	cli := &app{stdout: os.Stdout}
+	flags := flag.NewFlagSet("wifimgr", flag.ExitOnError)
+	flags.StringVar(&cli.backendName, "backend", os.Getenv(wifimanager.BackendEnv), "backend to use: "+strings.Join(wifimanager.BackendNames(), ", ")+" or simulated (default: detected)")
+	flags.StringVar(&cli.ifaceName, "iface", "", "interface to use (default: the first WiFi interface)")
+	flags.StringVar(&cli.profilesPath, "profiles", defaultProfilesPath(), "profile store location")
+	flags.StringVar(&cli.helperSocket, "helper", "", "socket of a wifimgr-helper to connect, disconnect and switch power through")
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	runErr := cli.run(flags.Arg(0), flags.Args()[1:])
+	if runErr == errUsage {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if runErr != nil {
+		fmt.Fprintln(os.Stderr, "wifimgr:", wifimanager.Redact(runErr.Error()))
+		os.Exit(1)
+	}
+}
+
+// run runs a command
+func (cli *app) run(command string, args []string) error {
+	switch command {
+	case "ifaces":
+		return cli.ifaces()
+	case "scan":
+		return cli.scan(args)
+	case "connect":
+		return cli.connect(args)
+	case "disconnect":
+		return cli.withInterface(func(iface *wifimanager.WifiInterface) error {
+			return iface.Disconnect()
+		})
+	case "up":
+		return cli.withInterface(func(iface *wifimanager.WifiInterface) error {
+			return iface.Up()
+		})
+	case "down":
+		return cli.withInterface(func(iface *wifimanager.WifiInterface) error {
+			return iface.Down()
+		})
+	case "status":
+		return cli.withInterface(func(iface *wifimanager.WifiInterface) error {
+			status, statusErr := iface.PowerStatus()
+			if statusErr != nil {
+				return statusErr
+			}
+			fmt.Fprintf(cli.stdout, "%s: %s%s\n", iface.Name, powerName(status.Powered), blockedName(status))
+			if state, stateErr := iface.State(); stateErr == nil {
+				// the time is unknown until a change was seen
+				since := ""
+				if !state.Changed.IsZero() {
+					since = " since " + state.Changed.Format(time.RFC3339)
+				}
+				fmt.Fprintf(cli.stdout, "state: %s%s\n", wifimanager.StateName(state.State), since)
+			}
+			return nil
+		})
+	case "watch":
+		return cli.withInterface(cli.watch)
+	case "profiles":
+		return cli.profiles(args)
+	case "doctor":
+		return cli.doctor()
+	case "tui":
+		return cli.tui(args)
+	}
+	return errUsage
+}
+
+// backend returns the selected backend, or the detected one, going
+// through the helper when one is set
+func (cli *app) backend() (wifimanager.Backend, error) {
+	backend, backendErr := cli.localBackend()
+	if backendErr != nil || cli.helperSocket == "" {
+		return backend, backendErr
+	}
+	return helper.NewClient(cli.helperSocket, backend), nil
+}
+
+// localBackend returns the selected backend, or the detected one. The
+// simulated backend is only used when selected.
+func (cli *app) localBackend() (wifimanager.Backend, error) {
+	if cli.backendName == "simulated" {
+		return newDemoBackend(), nil
+	}
+	backend, backendErr := wifimanager.SelectBackend(cli.backendName)
+	if backendErr == wifimanager.ErrUnknownBackend {
+		return nil, fmt.Errorf("unknown backend %q, choose from %s or simulated", cli.backendName, strings.Join(wifimanager.BackendNames(), ", "))
+	}
+	if backendErr == wifimanager.ErrNoBackend {
+		return nil, errors.New("no usable backend found, run wifimgr doctor")
+	}
+	return backend, backendErr
+}
+
+// interfaces returns the interfaces of the selected backend
+func (cli *app) interfaces() ([]wifimanager.WifiInterface, error) {
+	backend, backendErr := cli.backend()
+	if backendErr != nil {
+		return nil, backendErr
+	}
+	wifiInterfaces, ifaceErr := backend.Interfaces()
+	if ifaceErr != nil {
+		return nil, ifaceErr
+	}
+	if len(wifiInterfaces) < 1 {
+		return nil, wifimanager.ErrMissingIface
+	}
+	return wifiInterfaces, nil
+}
+
+// withInterface runs the function on the selected interface
+func (cli *app) withInterface(function func(iface *wifimanager.WifiInterface) error) error {
+	wifiInterfaces, ifaceErr := cli.interfaces()
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	if cli.ifaceName == "" {
+		return function(&wifiInterfaces[0])
+	}
+	for index := range wifiInterfaces {
+		if wifiInterfaces[index].Name == cli.ifaceName {
+			return function(&wifiInterfaces[index])
+		}
+	}
+	return fmt.Errorf("no WiFi interface named %s", cli.ifaceName)
+}
+
+// ifaces lists the WiFi interfaces
+func (cli *app) ifaces() error {
+	wifiInterfaces, ifaceErr := cli.interfaces()
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	table := newTable(cli.stdout, "NAME", "MAC", "MTU", "BACKEND", "POWER")
+	for _, iface := range wifiInterfaces {
+		power := "?"
+		if powered, statusErr := iface.Status(); statusErr == nil {
+			power = powerName(powered)
+		}
+		table.row(iface.Name, iface.HardwareAddr.String(), fmt.Sprint(iface.MTU), iface.Backend().Name(), power)
+	}
+	return table.flush()
+}
+
+// watch prints the events of the interface until it is interrupted
+func (cli *app) watch(iface *wifimanager.WifiInterface) error {
+	events, stop, subscribeErr := iface.Subscribe()
+	if subscribeErr != nil {
+		return subscribeErr
+	}
+	defer stop()
+	for event := range events {
+		detail := ""
+		switch event.Type {
+		case wifimanager.InterfaceEventPower, wifimanager.InterfaceEventAdded:
+			detail = powerName(event.Powered)
+		case wifimanager.InterfaceEventAssociate, wifimanager.InterfaceEventRoam:
+			detail = event.BSSID
+		case wifimanager.InterfaceEventDisassociate:
+			detail = fmt.Sprintf("%s reason %d", orDash(event.BSSID), event.ReasonCode)
+		case wifimanager.InterfaceEventAddress:
+			addresses := []string{}
+			for _, address := range event.Addresses {
+				addresses = append(addresses, address.String())
+			}
+			detail = strings.Join(addresses, " ")
+		}
+		fmt.Fprintf(cli.stdout, "%s %s %s %s\n", event.Time.Format(time.RFC3339), event.Interface, wifimanager.InterfaceEventName(event.Type), detail)
+	}
+	return nil
+}
+
+// connect joins a network, using its saved profile if there is one
+func (cli *app) connect(args []string) error {
+	flags := flag.NewFlagSet("connect", flag.ContinueOnError)
+	save := flags.Bool("save", false, "save the network as a profile once connected")
+	derive := flags.Bool("psk", false, "connect and save with the derived PSK instead of the passphrase")
+	hidden := flags.Bool("hidden", false, "the network doesn't broadcast its SSID")
+	security := flags.String("security", "", "security of a hidden network: open, wep, wpa, wpa2 or wpa3")
+	if flags.Parse(args) != nil || flags.NArg() != 1 {
+		return errUsage
+	}
+	ssid := flags.Arg(0)
+	store := wifimanager.NewProfileStore(cli.profilesPath)
+	profile, profileErr := store.Get(ssid)
+	if profileErr != nil && profileErr != wifimanager.ErrMissingProfile {
+		return profileErr
+	}
+	saved := profileErr == nil
+	if !saved {
+		profile = wifimanager.Profile{SSID: ssid, Security: wifimanager.SecurityNone, AutoJoin: true, Hidden: *hidden}
+	}
+	return cli.withInterface(func(iface *wifimanager.WifiInterface) error {
+		if *security != "" {
+			protocol, parseErr := parseSecurity(*security)
+			if parseErr != nil {
+				return parseErr
+			}
+			profile.Security = protocol
+		} else if !profile.Hidden {
+			networks, scanErr := iface.Scan()
+			if scanErr != nil {
+				return scanErr
+			}
+			accessPoints, apErr := wifimanager.GetAPs(ssid, networks)
+			if apErr != nil {
+				return apErr
+			}
+			bestAP, _ := wifimanager.GetBestAP(accessPoints)
+			if len(bestAP.Security) > 0 {
+				profile.Security = bestAP.Security[0].Protocol
+			}
+		}
+		if profile.Security != wifimanager.SecurityNone && profile.SecurityKey == "" {
+			key, keyErr := readSecret("security key for " + ssid + ": ")
+			if keyErr != nil {
+				return keyErr
+			}
+			profile.SecurityKey = key
+		}
+		if *derive {
+			if !wifimanager.SupportsRawPSK(iface.Backend()) {
+				return errors.New("the " + iface.Backend().Name() + " backend can't connect with a derived psk")
+			}
+			deriveErr := profile.DerivePSK()
+			if deriveErr != nil {
+				return deriveErr
+			}
+		}
+		iface.UpdateNetwork(profile.Network())
+		connectErr := iface.Connect()
+		if connectErr != nil {
+			return connectErr
+		}
+		fmt.Fprintf(cli.stdout, "%s: connected to %s\n", iface.Name, ssid)
+		if *save || saved {
+			return saveProfile(store, profile)
+		}
+		return nil
+	})
+}
+
+// doctor reports on the tools, privileges and features of every
+// backend, or of the selected one, and recommends one to use
+func (cli *app) doctor() error {
+	selected := []wifimanager.Backend{}
+	if cli.backendName != "" {
+		backend, backendErr := cli.backend()
+		if backendErr != nil {
+			return backendErr
+		}
+		selected = append(selected, backend)
+	}
+	diagnosis := wifimanager.Diagnose(selected...)
+	for _, report := range diagnosis.Backends {
+		fmt.Fprintf(cli.stdout, "%s: usable %s, privileged %s\n", report.Name, yesNo(report.Usable), yesNo(report.Privileged))
+		if len(report.Checks) > 0 {
+			table := newTable(cli.stdout, "  CHECK", "KIND", "FOUND", "VERSION", "DETAIL")
+			for _, check := range report.Checks {
+				found := yesNo(check.Present)
+				if !check.Present && !check.Required {
+					found = "no (optional)"
+				}
+				table.row("  "+check.Name, checkKinds[check.Kind], found, orDash(check.Version), orDash(check.Detail))
+			}
+			flushErr := table.flush()
+			if flushErr != nil {
+				return flushErr
+			}
+		}
+		fmt.Fprintln(cli.stdout, "  features:  ", orDash(strings.Join(featureNames(report.Features), " ")))
+		fmt.Fprintln(cli.stdout, "  interfaces:", orDash(strings.Join(report.Interfaces, " ")))
+		for _, problem := range report.Problems {
+			fmt.Fprintln(cli.stdout, "  problem:   ", problem)
+		}
+		fmt.Fprintln(cli.stdout)
+	}
+	if diagnosis.Recommended == "" {
+		return errors.New("no backend can reach a WiFi interface")
+	}
+	fmt.Fprintln(cli.stdout, "recommended backend:", diagnosis.Recommended)
+	return nil
+}
+
+// featureNames lists the available features
+func featureNames(features wifimanager.Features) []string {
+	names := []string{}
+	for _, feature := range []struct {
+		name      string
+		available bool
+	}{
+		{"scan", features.Scan},
+		{"connect", features.Connect},
+		{"hotspot", features.Hotspot},
+		{"enterprise", features.Enterprise},
+		{"enterprise-profiles", features.EnterpriseProfiles},
+	} {
+		if feature.available {
+			names = append(names, feature.name)
+		}
+	}
+	return names
+}
+
+// readSecret reads a secret from the terminal without echoing it, or
+// from the first line of stdin when it isn't a terminal, so secrets
+// never have to be passed as arguments
+func readSecret(prompt string) (string, error) {
+	stat, statErr := os.Stdin.Stat()
+	terminal := statErr == nil && stat.Mode()&os.ModeCharDevice != 0
+	if terminal {
+		fmt.Fprint(os.Stderr, prompt)
+		if sttyErr := stty("-echo"); sttyErr == nil {
+			defer func() {
+				stty("echo")
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+	line, readErr := bufio.NewReader(os.Stdin).ReadString('\n')
+	if readErr != nil && readErr != io.EOF {
+		return "", readErr
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// stty changes the settings of the terminal on stdin
+func stty(args ...string) error {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// defaultProfilesPath returns the profile store location in the
+// user's configuration directory
+func defaultProfilesPath() string {
+	configDir, dirErr := os.UserConfigDir()
+	if dirErr != nil {
+		return "wifimgr-profiles.json"
+	}
+	return filepath.Join(configDir, "wifimgr", "profiles.json")
+}
+
+// parseSecurity returns the security protocol with the provided name
+func parseSecurity(name string) (int, error) {
+	protocol, parseErr := wifimanager.ParseSecurity(name)
+	if parseErr != nil {
+		return 0, fmt.Errorf("unknown security %q", name)
+	}
+	return protocol, nil
+}
+
+// powerName returns the name of a power state
+func powerName(powered bool) string {
+	if powered {
+		return "on"
+	}
+	return "off"
+}
+
+// blockedName describes the rfkill blocks of the status, if any
+func blockedName(status wifimanager.PowerStatus) string {
+	switch {
+	case status.HardBlocked:
+		return " (hard blocked by rfkill)"
+	case status.SoftBlocked:
+		return " (soft blocked by rfkill)"
+	}
+	return ""
+}
+
+// yesNo returns yes or no
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// orDash returns the text, or a dash when it is empty
+func orDash(text string) string {
+	if text == "" {
+		return "-"
+	}
+	return text
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ottopress/WifiManager"
+)
+
+// newTestApp returns an app on the simulated backend with a profile
+// store in a temporary directory, and the buffer it prints to
+func newTestApp(t *testing.T) (*app, *bytes.Buffer) {
+	t.Helper()
+	output := &bytes.Buffer{}
+	cli := &app{
+		backendName:  "simulated",
+		profilesPath: filepath.Join(t.TempDir(), "wifimgr", "profiles.json"),
+		stdout:       output,
+	}
+	return cli, output
+}
+
+// setStdin makes the text the standard input until the test ends
+func setStdin(t *testing.T, text string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if writeErr := ioutil.WriteFile(path, []byte(text), 0600); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	stdin, openErr := os.Open(path)
+	if openErr != nil {
+		t.Fatal(openErr)
+	}
+	previous := os.Stdin
+	os.Stdin = stdin
+	t.Cleanup(func() {
+		os.Stdin = previous
+		stdin.Close()
+	})
+}
+
+func TestConnectSecurity(t *testing.T) {
+	derived, _ := wifimanager.DerivePSK("password", "Home")
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		security int
+		key      string
+		hidden   bool
+		err      bool
+	}{
+		{name: "scanned wpa2", args: []string{"--save", "Home"}, stdin: "password\n", security: wifimanager.SecurityWPA2, key: "password"},
+		{name: "scanned wpa3", args: []string{"--save", "Office"}, stdin: "password\n", security: wifimanager.SecurityWPA3, key: "password"},
+		{name: "scanned open", args: []string{"--save", "Cafe Guest"}, security: wifimanager.SecurityNone},
+		{name: "hidden", args: []string{"--save", "--hidden", "--security", "wpa2", "Home"}, stdin: "password\n", security: wifimanager.SecurityWPA2, key: "password", hidden: true},
+		{name: "security overrides the scan", args: []string{"--save", "--security", "wpa", "Home"}, stdin: "password\n", security: wifimanager.SecurityWPA, key: "password"},
+		{name: "derived psk", args: []string{"--save", "--psk", "Home"}, stdin: "password\n", security: wifimanager.SecurityWPA2, key: hex.EncodeToString(derived)},
+		{name: "wrong key", args: []string{"--save", "Home"}, stdin: "wrong password\n", err: true},
+		{name: "unknown security", args: []string{"--security", "rot13", "Home"}, err: true},
+		{name: "out of range", args: []string{"Elsewhere"}, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cli, output := newTestApp(t)
+			setStdin(t, test.stdin)
+			connectErr := cli.run("connect", test.args)
+			if test.err {
+				if connectErr == nil {
+					t.Fatal("connect succeeded")
+				}
+				return
+			}
+			if connectErr != nil {
+				t.Fatal(connectErr)
+			}
+			ssid := test.args[len(test.args)-1]
+			if output.String() != "sim0: connected to "+ssid+"\n" {
+				t.Errorf("output = %q", output.String())
+			}
+			profile, getErr := wifimanager.NewProfileStore(cli.profilesPath).Get(ssid)
+			if getErr != nil {
+				t.Fatal(getErr)
+			}
+			if profile.Security != test.security || profile.SecurityKey != test.key || profile.Hidden != test.hidden {
+				t.Errorf("saved security %d, key %q, hidden %v, want %d, %q, %v", profile.Security, profile.SecurityKey, profile.Hidden, test.security, test.key, test.hidden)
+			}
+		})
+	}
+}
+
+func TestConnectSavedProfile(t *testing.T) {
+	cli, _ := newTestApp(t)
+	setStdin(t, "password\n")
+	if connectErr := cli.run("connect", []string{"--save", "Home"}); connectErr != nil {
+		t.Fatal(connectErr)
+	}
+	// the saved key is used, nothing is read from stdin
+	setStdin(t, "")
+	if connectErr := cli.run("connect", []string{"Home"}); connectErr != nil {
+		t.Fatal(connectErr)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ottopress/WifiManager"
+)
+
+// profiles manages the saved profiles
+func (cli *app) profiles(args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	store := wifimanager.NewProfileStore(cli.profilesPath)
+	switch args[0] {
+	case "add":
+		return cli.addProfile(store, args[1:])
+	case "list":
+		if len(args) != 1 {
+			return errUsage
+		}
+		profiles, listErr := store.List()
+		if listErr != nil {
+			return listErr
+		}
+		// keys are never printed, only whether one is saved
+		table := newTable(cli.stdout, "SSID", "SECURITY", "KEY", "HIDDEN", "AUTOJOIN", "PRIORITY")
+		for _, profile := range profiles {
+			table.row(profile.SSID, wifimanager.SecurityName(profile.Security), yesNo(profile.SecurityKey != ""), yesNo(profile.Hidden), yesNo(profile.AutoJoin), fmt.Sprint(profile.Priority))
+		}
+		return table.flush()
+	case "rm":
+		if len(args) != 2 {
+			return errUsage
+		}
+		return store.Remove(args[1])
+	}
+	return errUsage
+}
+
+// addProfile saves a profile, reading its key from the terminal or
+// stdin
+func (cli *app) addProfile(store *wifimanager.ProfileStore, args []string) error {
+	flags := flag.NewFlagSet("profiles add", flag.ContinueOnError)
+	security := flags.String("security", "wpa2", "security of the network: open, wep, wpa, wpa2 or wpa3")
+	hidden := flags.Bool("hidden", false, "the network doesn't broadcast its SSID")
+	noAutoJoin := flags.Bool("no-autojoin", false, "don't join the network automatically")
+	priority := flags.Int("priority", 0, "priority of the network, higher is preferred")
+	derive := flags.Bool("psk", false, "save the derived PSK instead of the passphrase")
+	if flags.Parse(args) != nil || flags.NArg() != 1 {
+		return errUsage
+	}
+	protocol, parseErr := parseSecurity(*security)
+	if parseErr != nil {
+		return parseErr
+	}
+	profile := wifimanager.Profile{
+		SSID:     flags.Arg(0),
+		Security: protocol,
+		Hidden:   *hidden,
+		AutoJoin: !*noAutoJoin,
+		Priority: *priority,
+	}
+	if protocol != wifimanager.SecurityNone {
+		key, keyErr := readSecret("security key for " + profile.SSID + ": ")
+		if keyErr != nil {
+			return keyErr
+		}
+		profile.SecurityKey = key
+	}
+	if *derive {
+		deriveErr := profile.DerivePSK()
+		if deriveErr != nil {
+			return deriveErr
+		}
+	}
+	return saveProfile(store, profile)
+}
+
+// saveProfile saves the profile, creating the directory of the store
+// if needed
+func saveProfile(store *wifimanager.ProfileStore, profile wifimanager.Profile) error {
+	dirErr := os.MkdirAll(filepath.Dir(store.Path), 0700)
+	if dirErr != nil {
+		return dirErr
+	}
+	return store.Save(profile)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ottopress/WifiManager"
+)
+
+func TestProfiles(t *testing.T) {
+	cli, output := newTestApp(t)
+	setStdin(t, "password\n")
+	if addErr := cli.run("profiles", []string{"add", "--priority", "3", "Home"}); addErr != nil {
+		t.Fatal(addErr)
+	}
+	if addErr := cli.run("profiles", []string{"add", "--security", "open", "--hidden", "--no-autojoin", "Cafe Guest"}); addErr != nil {
+		t.Fatal(addErr)
+	}
+	setStdin(t, "password\n")
+	if addErr := cli.run("profiles", []string{"add", "--psk", "Office"}); addErr != nil {
+		t.Fatal(addErr)
+	}
+	if addErr := cli.run("profiles", []string{"add", "--security", "rot13", "Work"}); addErr == nil {
+		t.Error("profiles add with unknown security succeeded")
+	}
+
+	store := wifimanager.NewProfileStore(cli.profilesPath)
+	home, _ := store.Get("Home")
+	if home.Security != wifimanager.SecurityWPA2 || home.SecurityKey != "password" || home.Priority != 3 || !home.AutoJoin {
+		t.Errorf("Home = %+v", home)
+	}
+	cafe, _ := store.Get("Cafe Guest")
+	if cafe.Security != wifimanager.SecurityNone || cafe.SecurityKey != "" || !cafe.Hidden || cafe.AutoJoin {
+		t.Errorf("Cafe Guest = %+v", cafe)
+	}
+	office, _ := store.Get("Office")
+	if !wifimanager.IsRawPSK(office.SecurityKey) {
+		t.Errorf("Office key = %q, want a raw PSK", office.SecurityKey)
+	}
+
+	output.Reset()
+	if listErr := cli.run("profiles", []string{"list"}); listErr != nil {
+		t.Fatal(listErr)
+	}
+	expected := []string{
+		"SSID        SECURITY  KEY  HIDDEN  AUTOJOIN  PRIORITY",
+		"Home        wpa2      yes  no      yes       3",
+		"Cafe Guest  open      no   yes     no        0",
+		"Office      wpa2      yes  no      yes       0",
+	}
+	if list := strings.Split(strings.TrimSpace(output.String()), "\n"); strings.Join(list, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("profiles list =\n%s\nwant\n%s", output, strings.Join(expected, "\n"))
+	}
+	if strings.Contains(output.String(), "password") {
+		t.Error("profiles list printed a key")
+	}
+
+	if removeErr := cli.run("profiles", []string{"rm", "Home"}); removeErr != nil {
+		t.Fatal(removeErr)
+	}
+	if removeErr := cli.run("profiles", []string{"rm", "Home"}); removeErr != wifimanager.ErrMissingProfile {
+		t.Errorf("second profiles rm error = %v, want %v", removeErr, wifimanager.ErrMissingProfile)
+	}
+	if _, getErr := store.Get("Home"); getErr != wifimanager.ErrMissingProfile {
+		t.Errorf("Get after rm error = %v, want %v", getErr, wifimanager.ErrMissingProfile)
+	}
+	for _, args := range [][]string{{}, {"list", "extra"}, {"rm"}, {"add"}, {"rename", "Home"}} {
+		if usageErr := cli.run("profiles", args); usageErr != errUsage {
+			t.Errorf("profiles %q error = %v, want %v", args, usageErr, errUsage)
+		}
+	}
+}
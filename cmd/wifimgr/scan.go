@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ottopress/WifiManager"
+)
+
+// scanResult is a network as printed by the scan command
+type scanResult struct {
+	SSID       string   `json:"ssid"`
+	BSSID      string   `json:"bssid"`
+	RSSI       int      `json:"rssi"`
+	Channel    int      `json:"channel"`
+	Security   []string `json:"security"`
+	Interfaces []string `json:"interfaces,omitempty"`
+}
+
+// table writes aligned columns
+type table struct {
+	writer *tabwriter.Writer
+}
+
+// newTable creates a table and writes its header
+func newTable(output io.Writer, header ...string) *table {
+	newTable := &table{writer: tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)}
+	newTable.row(header...)
+	return newTable
+}
+
+// row writes a row of the table
+func (table *table) row(cells ...string) {
+	fmt.Fprintln(table.writer, strings.Join(cells, "\t"))
+}
+
+// flush writes out the aligned table
+func (table *table) flush() error {
+	return table.writer.Flush()
+}
+
+// scan lists the reachable networks
+func (cli *app) scan(args []string) error {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: table, json or csv")
+	sortBy := flags.String("sort", "rssi", "sort by: ssid, rssi or channel")
+	reverse := flags.Bool("reverse", false, "reverse the sort order")
+	ssid := flags.String("ssid", "", "only list networks whose SSID contains the text")
+	security := flags.String("security", "", "only list networks using the security: open, wep, wpa, wpa2 or wpa3")
+	minRSSI := flags.Int("min-rssi", 0, "only list networks with at least the RSSI in dBm, such as -70")
+	channel := flags.Int("channel", 0, "only list networks on the channel")
+	all := flags.Bool("all", false, "scan on every interface at once and list which ones saw each network")
+	if flags.Parse(args) != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+	less, known := map[string]func(a, b scanResult) bool{
+		"ssid":    func(a, b scanResult) bool { return strings.ToLower(a.SSID) < strings.ToLower(b.SSID) },
+		"rssi":    func(a, b scanResult) bool { return a.RSSI > b.RSSI },
+		"channel": func(a, b scanResult) bool { return a.Channel < b.Channel },
+	}[*sortBy]
+	if !known {
+		return fmt.Errorf("unknown sort order %q", *sortBy)
+	}
+	protocol := -1
+	if *security != "" {
+		var parseErr error
+		protocol, parseErr = parseSecurity(*security)
+		if parseErr != nil {
+			return parseErr
+		}
+	}
+	keep := func(network wifimanager.WifiNetwork) bool {
+		if *ssid != "" && !strings.Contains(strings.ToLower(network.SSID), strings.ToLower(*ssid)) {
+			return false
+		}
+		if *minRSSI != 0 && network.RSSI < *minRSSI {
+			return false
+		}
+		if *channel != 0 && network.Channel != *channel {
+			return false
+		}
+		return protocol == -1 || hasProtocol(network, protocol)
+	}
+	write := func(results []scanResult) error {
+		sort.SliceStable(results, func(i, j int) bool {
+			if *reverse {
+				return less(results[j], results[i])
+			}
+			return less(results[i], results[j])
+		})
+		return writeScanResults(cli.stdout, *format, results, *all)
+	}
+	if *all {
+		wifiInterfaces, ifaceErr := cli.interfaces()
+		if ifaceErr != nil {
+			return ifaceErr
+		}
+		networks, scanErr := wifimanager.ScanAll(wifiInterfaces)
+		if networks == nil {
+			return scanErr
+		}
+		if scanErr != nil {
+			fmt.Fprintln(os.Stderr, "wifimgr:", wifimanager.Redact(scanErr.Error()))
+		}
+		results := []scanResult{}
+		for _, network := range networks {
+			if keep(network.WifiNetwork) {
+				result := newScanResult(network.WifiNetwork)
+				result.Interfaces = network.Interfaces
+				results = append(results, result)
+			}
+		}
+		return write(results)
+	}
+	return cli.withInterface(func(iface *wifimanager.WifiInterface) error {
+		networks, scanErr := iface.Scan()
+		if scanErr != nil {
+			return scanErr
+		}
+		results := []scanResult{}
+		for _, network := range networks {
+			if keep(network) {
+				results = append(results, newScanResult(network))
+			}
+		}
+		return write(results)
+	})
+}
+
+// newScanResult converts a network for printing
+func newScanResult(network wifimanager.WifiNetwork) scanResult {
+	result := scanResult{
+		SSID:     network.SSID,
+		BSSID:    network.BSSID,
+		RSSI:     network.RSSI,
+		Channel:  network.Channel,
+		Security: []string{},
+	}
+	for _, security := range network.Security {
+		if name := wifimanager.SecurityName(security.Protocol); name != "" {
+			result.Security = append(result.Security, name)
+		}
+	}
+	return result
+}
+
+// hasProtocol returns whether or not the network supports the
+// security protocol
+func hasProtocol(network wifimanager.WifiNetwork, protocol int) bool {
+	if len(network.Security) == 0 {
+		return protocol == wifimanager.SecurityNone
+	}
+	for _, security := range network.Security {
+		if security.Protocol == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// writeScanResults writes the results in the provided format, with
+// the interfaces that saw each network when sourced is set
+func writeScanResults(output io.Writer, format string, results []scanResult, sourced bool) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case "csv":
+		writer := csv.NewWriter(output)
+		header := []string{"ssid", "bssid", "rssi", "channel", "security"}
+		if sourced {
+			header = append(header, "interfaces")
+		}
+		writer.Write(header)
+		for _, result := range results {
+			record := []string{result.SSID, result.BSSID, fmt.Sprint(result.RSSI), fmt.Sprint(result.Channel), strings.Join(result.Security, " ")}
+			if sourced {
+				record = append(record, strings.Join(result.Interfaces, " "))
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	case "table":
+		header := []string{"SSID", "BSSID", "RSSI", "CHANNEL", "SECURITY"}
+		if sourced {
+			header = append(header, "INTERFACES")
+		}
+		table := newTable(output, header...)
+		for _, result := range results {
+			cells := []string{result.SSID, result.BSSID, fmt.Sprint(result.RSSI), fmt.Sprint(result.Channel), strings.Join(result.Security, " ")}
+			if sourced {
+				cells = append(cells, strings.Join(result.Interfaces, ","))
+			}
+			table.row(cells...)
+		}
+		return table.flush()
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
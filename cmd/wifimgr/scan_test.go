@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ottopress/WifiManager"
+)
+
+func TestScanFilterAndSort(t *testing.T) {
+	tests := []struct {
+		args  []string
+		ssids []string
+	}{
+		{args: nil, ssids: []string{"Home", "Home", "Office", "Cafe Guest", "Neighbour"}},
+		{args: []string{"--reverse"}, ssids: []string{"Neighbour", "Cafe Guest", "Office", "Home", "Home"}},
+		{args: []string{"--sort", "ssid"}, ssids: []string{"Cafe Guest", "Home", "Home", "Neighbour", "Office"}},
+		{args: []string{"--sort", "channel"}, ssids: []string{"Cafe Guest", "Home", "Neighbour", "Office", "Home"}},
+		{args: []string{"--ssid", "HOME"}, ssids: []string{"Home", "Home"}},
+		{args: []string{"--security", "open"}, ssids: []string{"Cafe Guest"}},
+		{args: []string{"--security", "WPA3"}, ssids: []string{"Office"}},
+		{args: []string{"--security", "wep"}, ssids: []string{}},
+		{args: []string{"--min-rssi", "-70"}, ssids: []string{"Home", "Home", "Office"}},
+		{args: []string{"--channel", "6", "--sort", "rssi"}, ssids: []string{"Home", "Neighbour"}},
+		{args: []string{"--all", "--channel", "36"}, ssids: []string{"Home"}},
+	}
+	for _, test := range tests {
+		cli, output := newTestApp(t)
+		if scanErr := cli.run("scan", append([]string{"--format", "json"}, test.args...)); scanErr != nil {
+			t.Errorf("scan %q: %s", test.args, scanErr)
+			continue
+		}
+		results := []scanResult{}
+		if decodeErr := json.Unmarshal(output.Bytes(), &results); decodeErr != nil {
+			t.Errorf("scan %q: %s", test.args, decodeErr)
+			continue
+		}
+		ssids := []string{}
+		for _, result := range results {
+			ssids = append(ssids, result.SSID)
+		}
+		if !reflect.DeepEqual(ssids, test.ssids) {
+			t.Errorf("scan %q = %q, want %q", test.args, ssids, test.ssids)
+		}
+	}
+	cli, _ := newTestApp(t)
+	for _, args := range [][]string{{"--sort", "bssid"}, {"--security", "rot13"}, {"--format", "xml"}} {
+		if scanErr := cli.run("scan", args); scanErr == nil {
+			t.Errorf("scan %q succeeded", args)
+		}
+	}
+}
+
+func TestHasProtocol(t *testing.T) {
+	wpa := []wifimanager.WifiNetworkSecurity{{Protocol: wifimanager.SecurityWPA}, {Protocol: wifimanager.SecurityWPA2}}
+	tests := []struct {
+		security []wifimanager.WifiNetworkSecurity
+		protocol int
+		has      bool
+	}{
+		{security: nil, protocol: wifimanager.SecurityNone, has: true},
+		{security: nil, protocol: wifimanager.SecurityWPA2, has: false},
+		{security: wpa, protocol: wifimanager.SecurityWPA2, has: true},
+		{security: wpa, protocol: wifimanager.SecurityWPA, has: true},
+		{security: wpa, protocol: wifimanager.SecurityWPA3, has: false},
+		{security: wpa, protocol: wifimanager.SecurityNone, has: false},
+	}
+	for _, test := range tests {
+		network := wifimanager.WifiNetwork{SSID: "home", Security: test.security}
+		if has := hasProtocol(network, test.protocol); has != test.has {
+			t.Errorf("hasProtocol(%v, %d) = %v, want %v", test.security, test.protocol, has, test.has)
+		}
+	}
+}
+
+func TestWriteScanResults(t *testing.T) {
+	results := []scanResult{
+		{SSID: "Home", BSSID: "02:11:22:33:44:01", RSSI: -48, Channel: 6, Security: []string{"wpa2"}, Interfaces: []string{"wlan0", "wlan1"}},
+		{SSID: "Cafe, Guest", BSSID: "02:11:22:33:44:04", RSSI: -79, Channel: 1, Security: []string{}},
+	}
+	tests := []struct {
+		format  string
+		sourced bool
+		output  string
+	}{
+		{
+			format: "table",
+			output: "SSID         BSSID              RSSI  CHANNEL  SECURITY\n" +
+				"Home         02:11:22:33:44:01  -48   6        wpa2\n" +
+				"Cafe, Guest  02:11:22:33:44:04  -79   1        \n",
+		},
+		{
+			format:  "table",
+			sourced: true,
+			output: "SSID         BSSID              RSSI  CHANNEL  SECURITY  INTERFACES\n" +
+				"Home         02:11:22:33:44:01  -48   6        wpa2      wlan0,wlan1\n" +
+				"Cafe, Guest  02:11:22:33:44:04  -79   1                  \n",
+		},
+		{
+			format: "csv",
+			output: "ssid,bssid,rssi,channel,security\n" +
+				"Home,02:11:22:33:44:01,-48,6,wpa2\n" +
+				"\"Cafe, Guest\",02:11:22:33:44:04,-79,1,\n",
+		},
+		{
+			format:  "csv",
+			sourced: true,
+			output: "ssid,bssid,rssi,channel,security,interfaces\n" +
+				"Home,02:11:22:33:44:01,-48,6,wpa2,wlan0 wlan1\n" +
+				"\"Cafe, Guest\",02:11:22:33:44:04,-79,1,,\n",
+		},
+	}
+	for _, test := range tests {
+		output := &bytes.Buffer{}
+		if writeErr := writeScanResults(output, test.format, results, test.sourced); writeErr != nil {
+			t.Errorf("%s: %s", test.format, writeErr)
+			continue
+		}
+		if output.String() != test.output {
+			t.Errorf("%s sourced %v =\n%s\nwant\n%s", test.format, test.sourced, output, test.output)
+		}
+	}
+
+	output := &bytes.Buffer{}
+	if writeErr := writeScanResults(output, "json", results, true); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	decoded := []scanResult{}
+	if decodeErr := json.Unmarshal(output.Bytes(), &decoded); decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if !reflect.DeepEqual(decoded, results) {
+		t.Errorf("json = %+v, want %+v", decoded, results)
+	}
+	if !strings.Contains(output.String(), `"security": []`) {
+		t.Errorf("json doesn't list an empty security: %s", output)
+	}
+	if writeScanResults(output, "yaml", results, false) == nil {
+		t.Error("unknown format succeeded")
+	}
+}
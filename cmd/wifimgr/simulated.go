@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/ottopress/WifiManager"
+	"github.com/ottopress/WifiManager/darwin"
+)
+
+// newDemoBackend returns a simulated backend with a single interface
+// and a handful of networks in range, for trying out the commands
+// without WiFi hardware. The secured networks use the key "password".
+func newDemoBackend() wifimanager.Backend {
+	simBackend := wifimanager.NewSimulatedBackend("sim0")
+	wpa2 := []wifimanager.WifiNetworkSecurity{{Protocol: wifimanager.SecurityWPA2, Method: darwin.PSK}}
+	wpa3 := []wifimanager.WifiNetworkSecurity{{Protocol: wifimanager.SecurityWPA3, Method: darwin.PSK}}
+	open := []wifimanager.WifiNetworkSecurity{{Protocol: wifimanager.SecurityNone}}
+	for _, network := range []wifimanager.WifiNetwork{
+		{SSID: "Home", BSSID: "02:11:22:33:44:01", RSSI: -48, Channel: 6, Security: wpa2, SecurityKey: "password"},
+		{SSID: "Home", BSSID: "02:11:22:33:44:02", RSSI: -61, Channel: 36, Security: wpa2, SecurityKey: "password"},
+		{SSID: "Office", BSSID: "02:11:22:33:44:03", RSSI: -70, Channel: 11, Security: wpa3, SecurityKey: "password"},
+		{SSID: "Cafe Guest", BSSID: "02:11:22:33:44:04", RSSI: -79, Channel: 1, Security: open},
+		{SSID: "Neighbour", BSSID: "02:11:22:33:44:05", RSSI: -86, Channel: 6, Security: wpa2, SecurityKey: "password"},
+	} {
+		simBackend.AddNetwork(network)
+	}
+	return simBackend
+}
@@ -0,0 +1,516 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ottopress/WifiManager"
+)
+
+const (
+	// historyLength is the number of RSSI samples kept per BSSID
+	historyLength = 24
+
+	// tuiHelp lists the key bindings at the bottom of the screen
+	tuiHelp = "↑/↓ select  s sort  r reverse  v channels  c connect  d disconnect  space rescan  q quit"
+
+	// terminal control sequences
+	ansiClear      = "\x1b[H\x1b[2J"
+	ansiAltScreen  = "\x1b[?1049h\x1b[?25l"
+	ansiMainScreen = "\x1b[?25h\x1b[?1049l"
+	ansiReverse    = "\x1b[7m"
+	ansiBold       = "\x1b[1m"
+	ansiReset      = "\x1b[0m"
+	// key names produced by parseKeys
+	keyUp        = "up"
+	keyDown      = "down"
+	keyEscape    = "esc"
+	keyEnter     = "enter"
+	keyBackspace = "backspace"
+	keyInterrupt = "ctrl-c"
+	// sparklineLevels are the bars of an RSSI sparkline, lowest first
+	sparklineLevels = "▁▂▃▄▅▆▇█"
+)
+
+var (
+	// tuiColumns are the columns the network table can be sorted by
+	tuiColumns = []string{"SSID", "BSSID", "SIGNAL", "CHANNEL", "SECURITY"}
+)
+
+// tuiResult carries the outcome of a background operation back to the
+// event loop
+type tuiResult struct {
+	networks  []wifimanager.WifiNetwork
+	scanned   bool
+	connected string
+	message   string
+	err       error
+}
+
+// tui holds the state of the interactive scanner. It is only touched
+// by the event loop, background operations report back over results.
+type tui struct {
+	cli       *app
+	iface     *wifimanager.WifiInterface
+	store     *wifimanager.ProfileStore
+	networks  []wifimanager.WifiNetwork
+	history   map[string][]int
+	sortBy    int
+	reverse   bool
+	selected  int
+	channels  bool
+	busy      bool
+	connected string
+	message   string
+	// prompt is set while a security key is being typed for the
+	// network in promptFor
+	prompt    bool
+	promptFor wifimanager.WifiNetwork
+	input     []rune
+	results   chan tuiResult
+}
+
+// tui runs the interactive scanner until q is pressed
+func (cli *app) tui(args []string) error {
+	flags := flag.NewFlagSet("tui", flag.ContinueOnError)
+	interval := flags.Duration("interval", 5*time.Second, "time between scans")
+	if flags.Parse(args) != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("invalid interval %s", *interval)
+	}
+	return cli.withInterface(func(iface *wifimanager.WifiInterface) error {
+		screen := &tui{
+			cli:     cli,
+			iface:   iface,
+			store:   wifimanager.NewProfileStore(cli.profilesPath),
+			history: map[string][]int{},
+			sortBy:  2,
+			results: make(chan tuiResult, 1),
+		}
+		return screen.run(*interval)
+	})
+}
+
+// run puts the terminal in raw mode and runs the event loop
+func (screen *tui) run(interval time.Duration) error {
+	sttyErr := stty("raw", "-echo")
+	if sttyErr != nil {
+		return fmt.Errorf("tui needs a terminal: %s", sttyErr)
+	}
+	fmt.Fprint(os.Stdout, ansiAltScreen)
+	defer func() {
+		fmt.Fprint(os.Stdout, ansiMainScreen)
+		stty("sane")
+	}()
+
+	keys := make(chan string)
+	go readKeys(keys)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	screen.scan()
+	screen.render()
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok || !screen.key(key) {
+				return nil
+			}
+		case result := <-screen.results:
+			screen.finish(result)
+		case <-ticker.C:
+			screen.scan()
+		}
+		screen.render()
+	}
+}
+
+// scan starts a scan unless another operation is running
+func (screen *tui) scan() {
+	if screen.busy {
+		return
+	}
+	screen.busy = true
+	go func() {
+		networks, scanErr := screen.iface.Scan()
+		screen.results <- tuiResult{networks: networks, scanned: true, err: scanErr}
+	}()
+}
+
+// connect starts joining the network with the provided key
+func (screen *tui) connect(network wifimanager.WifiNetwork, key string) {
+	if screen.busy {
+		screen.message = "busy, try again"
+		return
+	}
+	screen.busy = true
+	screen.message = "connecting to " + network.SSID + "..."
+	network.SecurityKey = key
+	go func() {
+		screen.iface.UpdateNetwork(network)
+		connectErr := screen.iface.Connect()
+		screen.results <- tuiResult{connected: network.SSID, message: "connected to " + network.SSID, err: connectErr}
+	}()
+}
+
+// disconnect starts leaving the current network
+func (screen *tui) disconnect() {
+	if screen.busy {
+		screen.message = "busy, try again"
+		return
+	}
+	screen.busy = true
+	go func() {
+		disconnectErr := screen.iface.Disconnect()
+		screen.results <- tuiResult{message: "disconnected", err: disconnectErr}
+	}()
+}
+
+// finish applies the result of a background operation
+func (screen *tui) finish(result tuiResult) {
+	screen.busy = false
+	if result.err != nil {
+		screen.message = "error: " + wifimanager.Redact(result.err.Error())
+		return
+	}
+	if result.message != "" {
+		screen.message = result.message
+		screen.connected = result.connected
+	}
+	if !result.scanned {
+		return
+	}
+	selectedBSSID := screen.selectedBSSID()
+	screen.networks = result.networks
+	for _, network := range result.networks {
+		samples := append(screen.history[network.BSSID], network.RSSI)
+		if len(samples) > historyLength {
+			samples = samples[len(samples)-historyLength:]
+		}
+		screen.history[network.BSSID] = samples
+	}
+	screen.sort(selectedBSSID)
+}
+
+// key handles a key press, returning false once the scanner should
+// exit
+func (screen *tui) key(key string) bool {
+	if key == keyInterrupt {
+		return false
+	}
+	if screen.prompt {
+		switch key {
+		case keyEscape:
+			screen.prompt = false
+			screen.message = ""
+		case keyEnter:
+			screen.prompt = false
+			screen.connect(screen.promptFor, string(screen.input))
+		case keyBackspace:
+			if len(screen.input) > 0 {
+				screen.input = screen.input[:len(screen.input)-1]
+			}
+		default:
+			if len([]rune(key)) == 1 {
+				screen.input = append(screen.input, []rune(key)...)
+			}
+		}
+		return true
+	}
+	switch key {
+	case "q":
+		return false
+	case keyUp, "k":
+		if screen.selected > 0 {
+			screen.selected--
+		}
+	case keyDown, "j":
+		if screen.selected < len(screen.networks)-1 {
+			screen.selected++
+		}
+	case "s":
+		screen.sortBy = (screen.sortBy + 1) % len(tuiColumns)
+		screen.sort(screen.selectedBSSID())
+	case "r":
+		screen.reverse = !screen.reverse
+		screen.sort(screen.selectedBSSID())
+	case "v":
+		screen.channels = !screen.channels
+	case " ":
+		screen.scan()
+	case "d":
+		screen.disconnect()
+	case "c", keyEnter:
+		if len(screen.networks) == 0 {
+			return true
+		}
+		network := screen.networks[screen.selected]
+		if !hasProtocol(network, wifimanager.SecurityNone) {
+			profile, profileErr := screen.store.Get(network.SSID)
+			if profileErr != nil || profile.SecurityKey == "" {
+				screen.prompt = true
+				screen.promptFor = network
+				screen.input = nil
+				return true
+			}
+			screen.connect(network, profile.SecurityKey)
+			return true
+		}
+		screen.connect(network, "")
+	}
+	return true
+}
+
+// selectedBSSID returns the BSSID under the cursor
+func (screen *tui) selectedBSSID() string {
+	if screen.selected < len(screen.networks) {
+		return screen.networks[screen.selected].BSSID
+	}
+	return ""
+}
+
+// sort orders the networks by the selected column, keeping the
+// provided BSSID under the cursor
+func (screen *tui) sort(selectedBSSID string) {
+	less := func(a, b wifimanager.WifiNetwork) bool {
+		switch screen.sortBy {
+		case 0:
+			return strings.ToLower(a.SSID) < strings.ToLower(b.SSID)
+		case 1:
+			return a.BSSID < b.BSSID
+		case 2:
+			return a.RSSI > b.RSSI
+		case 3:
+			return a.Channel < b.Channel
+		}
+		return securityText(a) < securityText(b)
+	}
+	sort.SliceStable(screen.networks, func(i, j int) bool {
+		if screen.reverse {
+			return less(screen.networks[j], screen.networks[i])
+		}
+		return less(screen.networks[i], screen.networks[j])
+	})
+	screen.selected = 0
+	for index, network := range screen.networks {
+		if network.BSSID == selectedBSSID {
+			screen.selected = index
+		}
+	}
+}
+
+// render draws the whole screen
+func (screen *tui) render() {
+	rows, _ := terminalSize()
+	lines := []string{}
+	state := "idle"
+	if screen.busy {
+		state = "working"
+	}
+	lines = append(lines, fmt.Sprintf("%swifimgr%s  %s (%s)  %d networks  %s", ansiBold, ansiReset, screen.iface.Name, screen.iface.Backend().Name(), len(screen.networks), state))
+	lines = append(lines, "")
+	if screen.channels {
+		lines = append(lines, screen.channelLines()...)
+	} else {
+		lines = append(lines, screen.networkLines(rows-5)...)
+	}
+	for len(lines) < rows-2 {
+		lines = append(lines, "")
+	}
+	if screen.prompt {
+		lines = append(lines, "security key for "+screen.promptFor.SSID+": "+strings.Repeat("*", len(screen.input)))
+	} else {
+		lines = append(lines, screen.message)
+	}
+	lines = append(lines, tuiHelp)
+	// the terminal is in raw mode, so lines need a carriage return
+	fmt.Fprint(os.Stdout, ansiClear+strings.Join(lines, "\r\n"))
+}
+
+// networkLines renders the network table, scrolled to keep the
+// selected row visible
+func (screen *tui) networkLines(height int) []string {
+	header := []string{}
+	for index, column := range tuiColumns {
+		if index == screen.sortBy {
+			arrow := "↓"
+			if screen.reverse {
+				arrow = "↑"
+			}
+			column += arrow
+		}
+		header = append(header, column)
+	}
+	lines := []string{fmt.Sprintf("  %-24s %-17s %-9s %-7s %-12s %s", header[0], header[1], header[2], header[3], header[4], "HISTORY")}
+	first := 0
+	if height > 1 && screen.selected >= height-1 {
+		first = screen.selected - height + 2
+	}
+	for index := first; index < len(screen.networks) && index-first < height-1; index++ {
+		network := screen.networks[index]
+		marker := " "
+		if screen.connected != "" && network.SSID == screen.connected {
+			marker = "*"
+		}
+		line := fmt.Sprintf("%s %-24s %-17s %s %4d %-7d %-12s %s",
+			marker, truncate(network.SSID, 24), network.BSSID, signalBars(network.RSSI), network.RSSI,
+			network.Channel, securityText(network), sparkline(screen.history[network.BSSID]))
+		if index == screen.selected {
+			line = ansiReverse + line + ansiReset
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// channelLines renders how many networks use each channel
+func (screen *tui) channelLines() []string {
+	occupancy := map[int][]string{}
+	for _, network := range screen.networks {
+		occupancy[network.Channel] = append(occupancy[network.Channel], network.SSID)
+	}
+	channels := []int{}
+	for channel := range occupancy {
+		channels = append(channels, channel)
+	}
+	sort.Ints(channels)
+	lines := []string{"CHANNEL  NETWORKS"}
+	for _, channel := range channels {
+		ssids := occupancy[channel]
+		sort.Strings(ssids)
+		lines = append(lines, fmt.Sprintf("%7d  %-20s %2d  %s", channel, strings.Repeat("█", len(ssids)), len(ssids), truncate(strings.Join(ssids, ", "), 60)))
+	}
+	return lines
+}
+
+// readKeys sends key presses read from stdin until it is closed
+func readKeys(keys chan<- string) {
+	defer close(keys)
+	buffer := make([]byte, 64)
+	pending := ""
+	for {
+		count, readErr := os.Stdin.Read(buffer)
+		if readErr != nil {
+			return
+		}
+		var parsed []string
+		parsed, pending = parseKeys(pending + string(buffer[:count]))
+		for _, key := range parsed {
+			keys <- key
+		}
+	}
+}
+
+// parseKeys splits raw terminal input into key names. A trailing
+// incomplete UTF-8 sequence is returned to be completed by the next
+// read, invalid bytes are dropped.
+func parseKeys(input string) ([]string, string) {
+	keys := []string{}
+	for len(input) > 0 {
+		switch {
+		case strings.HasPrefix(input, "\x1b[A"), strings.HasPrefix(input, "\x1bOA"):
+			keys = append(keys, keyUp)
+			input = input[3:]
+			continue
+		case strings.HasPrefix(input, "\x1b[B"), strings.HasPrefix(input, "\x1bOB"):
+			keys = append(keys, keyDown)
+			input = input[3:]
+			continue
+		case strings.HasPrefix(input, "\x1b["):
+			// skip other escape sequences up to their final byte
+			end := strings.IndexAny(input[2:], "ABCDEFGHPQRS~")
+			if end < 0 {
+				return keys, ""
+			}
+			input = input[end+3:]
+			continue
+		}
+		if !utf8.FullRuneInString(input) {
+			return keys, input
+		}
+		key, size := utf8.DecodeRuneInString(input)
+		input = input[size:]
+		switch key {
+		case utf8.RuneError:
+			// not UTF-8, as from a terminal set to another encoding
+		case 0x03:
+			keys = append(keys, keyInterrupt)
+		case 0x1b:
+			keys = append(keys, keyEscape)
+		case '\r', '\n':
+			keys = append(keys, keyEnter)
+		case 0x7f, 0x08:
+			keys = append(keys, keyBackspace)
+		default:
+			keys = append(keys, string(key))
+		}
+	}
+	return keys, ""
+}
+
+// terminalSize returns the rows and columns of the terminal on stdin,
+// falling back to 24x80 when it doesn't report a size
+func terminalSize() (int, int) {
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	cmdOut, cmdErr := cmd.Output()
+	if cmdErr == nil {
+		var rows, cols int
+		fmt.Sscan(string(cmdOut), &rows, &cols)
+		if rows > 0 && cols > 0 {
+			return rows, cols
+		}
+	}
+	return 24, 80
+}
+
+// signalBars draws the RSSI as four bars
+func signalBars(rssi int) string {
+	bars := []rune("▂▄▆█")
+	count := 0
+	for _, threshold := range []int{-85, -75, -67, -55} {
+		if rssi >= threshold {
+			count++
+		}
+	}
+	return string(bars[:count]) + strings.Repeat(" ", len(bars)-count)
+}
+
+// sparkline draws the RSSI samples between -100 and -30 dBm
+func sparkline(samples []int) string {
+	levels := []rune(sparklineLevels)
+	line := []rune{}
+	for _, sample := range samples {
+		level := (sample + 100) * (len(levels) - 1) / 70
+		if level < 0 {
+			level = 0
+		}
+		if level >= len(levels) {
+			level = len(levels) - 1
+		}
+		line = append(line, levels[level])
+	}
+	return string(line)
+}
+
+// securityText lists the security protocols of the network
+func securityText(network wifimanager.WifiNetwork) string {
+	return strings.Join(newScanResult(network).Security, " ")
+}
+
+// truncate shortens the text to the provided number of runes
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length-1]) + "…"
+}
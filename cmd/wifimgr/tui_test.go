@@ -0,0 +1,171 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ottopress/WifiManager"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		input   string
+		keys    []string
+		pending string
+	}{
+		{input: "", keys: []string{}},
+		{input: "qs r", keys: []string{"q", "s", " ", "r"}},
+		{input: "\x1b[A\x1b[B\x1bOA\x1bOB", keys: []string{keyUp, keyDown, keyUp, keyDown}},
+		{input: "\x1b[C\x1b[3~j", keys: []string{"j"}},
+		{input: "k\x1b[1;5", keys: []string{"k"}},
+		{input: "\x1b", keys: []string{keyEscape}},
+		{input: "\x03\r\n\x7f\x08", keys: []string{keyInterrupt, keyEnter, keyEnter, keyBackspace, keyBackspace}},
+		{input: "é日🙂", keys: []string{"é", "日", "🙂"}},
+		{input: "a\xc3", keys: []string{"a"}, pending: "\xc3"},
+		{input: "\xe6\x97", keys: []string{}, pending: "\xe6\x97"},
+		{input: "\xf0\x9f\x99", keys: []string{}, pending: "\xf0\x9f\x99"},
+		{input: "\xc3a", keys: []string{"a"}},
+		{input: "\xff\xfeb", keys: []string{"b"}},
+	}
+	for _, test := range tests {
+		keys, pending := parseKeys(test.input)
+		if !reflect.DeepEqual(keys, test.keys) || pending != test.pending {
+			t.Errorf("parseKeys(%q) = %q, %q, want %q, %q", test.input, keys, pending, test.keys, test.pending)
+		}
+	}
+}
+
+func TestParseKeysSplitRead(t *testing.T) {
+	input := "x日é"
+	for split := 0; split <= len(input); split++ {
+		first, pending := parseKeys(input[:split])
+		second, rest := parseKeys(pending + input[split:])
+		keys := append(first, second...)
+		if !reflect.DeepEqual(keys, []string{"x", "日", "é"}) || rest != "" {
+			t.Errorf("split at %d: keys = %q, pending %q", split, keys, rest)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := map[string]string{
+		"":                 "",
+		"-100":             "▁",
+		"-30":              "█",
+		"-120 -10":         "▁█",
+		"-100 -90 -80 -70": "▁▂▃▄",
+		"-65 -50 -40 -31":  "▄▆▇▇",
+	}
+	for samples, line := range tests {
+		rssi := []int{}
+		for _, field := range strings.Fields(samples) {
+			value, _ := strconv.Atoi(field)
+			rssi = append(rssi, value)
+		}
+		if drawn := sparkline(rssi); drawn != line {
+			t.Errorf("sparkline(%v) = %q, want %q", rssi, drawn, line)
+		}
+	}
+}
+
+func TestSignalBars(t *testing.T) {
+	tests := map[int]string{
+		-95: "    ",
+		-85: "▂   ",
+		-76: "▂   ",
+		-75: "▂▄  ",
+		-67: "▂▄▆ ",
+		-56: "▂▄▆ ",
+		-55: "▂▄▆█",
+		-30: "▂▄▆█",
+	}
+	for rssi, bars := range tests {
+		if drawn := signalBars(rssi); drawn != bars {
+			t.Errorf("signalBars(%d) = %q, want %q", rssi, drawn, bars)
+		}
+	}
+}
+
+func TestChannelLines(t *testing.T) {
+	screen := &tui{networks: []wifimanager.WifiNetwork{
+		{SSID: "Neighbour", Channel: 6},
+		{SSID: "Home", Channel: 36},
+		{SSID: "Home", Channel: 6},
+		{SSID: "Cafe Guest", Channel: 1},
+	}}
+	expected := []string{
+		"CHANNEL  NETWORKS",
+		"      1  █                     1  Cafe Guest",
+		"      6  ██                    2  Home, Neighbour",
+		"     36  █                     1  Home",
+	}
+	if lines := screen.channelLines(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("channelLines =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestSortKeepsSelection(t *testing.T) {
+	wpa2 := []wifimanager.WifiNetworkSecurity{{Protocol: wifimanager.SecurityWPA2}}
+	screen := &tui{networks: []wifimanager.WifiNetwork{
+		{SSID: "office", BSSID: "02:00:00:00:00:03", RSSI: -70, Channel: 11, Security: wpa2},
+		{SSID: "Home", BSSID: "02:00:00:00:00:01", RSSI: -48, Channel: 6, Security: wpa2},
+		{SSID: "cafe", BSSID: "02:00:00:00:00:02", RSSI: -79, Channel: 1},
+	}}
+	tests := []struct {
+		sortBy   int
+		reverse  bool
+		bssids   []string
+		selected int
+	}{
+		{sortBy: 0, bssids: []string{"02:00:00:00:00:02", "02:00:00:00:00:01", "02:00:00:00:00:03"}, selected: 1},
+		{sortBy: 1, bssids: []string{"02:00:00:00:00:01", "02:00:00:00:00:02", "02:00:00:00:00:03"}, selected: 0},
+		{sortBy: 2, bssids: []string{"02:00:00:00:00:01", "02:00:00:00:00:03", "02:00:00:00:00:02"}, selected: 0},
+		{sortBy: 2, reverse: true, bssids: []string{"02:00:00:00:00:02", "02:00:00:00:00:03", "02:00:00:00:00:01"}, selected: 2},
+		{sortBy: 3, bssids: []string{"02:00:00:00:00:02", "02:00:00:00:00:01", "02:00:00:00:00:03"}, selected: 1},
+		{sortBy: 4, bssids: []string{"02:00:00:00:00:02", "02:00:00:00:00:01", "02:00:00:00:00:03"}, selected: 1},
+	}
+	for _, test := range tests {
+		screen.sortBy = test.sortBy
+		screen.reverse = test.reverse
+		screen.sort("02:00:00:00:00:01")
+		bssids := []string{}
+		for _, network := range screen.networks {
+			bssids = append(bssids, network.BSSID)
+		}
+		if !reflect.DeepEqual(bssids, test.bssids) {
+			t.Errorf("sort by %s reverse %v = %q, want %q", tuiColumns[test.sortBy], test.reverse, bssids, test.bssids)
+		}
+		if screen.selected != test.selected {
+			t.Errorf("sort by %s reverse %v selected %d, want %d", tuiColumns[test.sortBy], test.reverse, screen.selected, test.selected)
+		}
+	}
+	screen.sort("02:00:00:00:00:09")
+	if screen.selected != 0 {
+		t.Errorf("selected %d after the selected network went away, want 0", screen.selected)
+	}
+}
+
+func TestFinishKeepsSelection(t *testing.T) {
+	screen := &tui{history: map[string][]int{}, sortBy: 2, networks: []wifimanager.WifiNetwork{
+		{SSID: "Home", BSSID: "02:00:00:00:00:01", RSSI: -48},
+		{SSID: "cafe", BSSID: "02:00:00:00:00:02", RSSI: -79},
+	}}
+	screen.selected = 1
+	screen.busy = true
+	screen.finish(tuiResult{scanned: true, networks: []wifimanager.WifiNetwork{
+		{SSID: "Home", BSSID: "02:00:00:00:00:01", RSSI: -80},
+		{SSID: "cafe", BSSID: "02:00:00:00:00:02", RSSI: -40},
+		{SSID: "office", BSSID: "02:00:00:00:00:03", RSSI: -60},
+	}})
+	if screen.busy {
+		t.Error("busy after the scan finished")
+	}
+	if selected := screen.selectedBSSID(); selected != "02:00:00:00:00:02" {
+		t.Errorf("selected %s after a rescan, want 02:00:00:00:00:02", selected)
+	}
+	if history := screen.history["02:00:00:00:00:01"]; !reflect.DeepEqual(history, []int{-80}) {
+		t.Errorf("history = %v, want [-80]", history)
+	}
+}
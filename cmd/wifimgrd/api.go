@@ -0,0 +1,548 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ottopress/WifiManager"
+)
+
+const (
+	// maxBodySize is the largest request body accepted
+	maxBodySize = 64 << 10
+	// keepAliveInterval is the time between comments sent on idle
+	// event streams so proxies don't close them
+	keepAliveInterval = 30 * time.Second
+)
+
+// api serves the REST endpoints on top of a Manager
+type api struct {
+	manager *wifimanager.Manager
+	token   string
+}
+
+// networkJSON is a network as sent by the API. Security keys are
+// never sent back.
+type networkJSON struct {
+	SSID       string   `json:"ssid"`
+	BSSID      string   `json:"bssid,omitempty"`
+	RSSI       int      `json:"rssi,omitempty"`
+	Channel    int      `json:"channel,omitempty"`
+	Security   []string `json:"security"`
+	Interfaces []string `json:"interfaces,omitempty"`
+}
+
+// interfaceJSON is an interface as sent by the API
+type interfaceJSON struct {
+	Name        string       `json:"name"`
+	MAC         string       `json:"mac"`
+	MTU         int          `json:"mtu"`
+	Backend     string       `json:"backend"`
+	Powered     *bool        `json:"powered,omitempty"`
+	SoftBlocked bool         `json:"soft_blocked,omitempty"`
+	HardBlocked bool         `json:"hard_blocked,omitempty"`
+	State       string       `json:"state,omitempty"`
+	Connection  *networkJSON `json:"connection,omitempty"`
+}
+
+// profileJSON is a profile as sent and received by the API. The key
+// is only ever received, HasKey tells whether one is saved.
+type profileJSON struct {
+	SSID        string `json:"ssid"`
+	Security    string `json:"security"`
+	SecurityKey string `json:"security_key,omitempty"`
+	HasKey      bool   `json:"has_key"`
+	Hidden      bool   `json:"hidden"`
+	AutoJoin    *bool  `json:"auto_join,omitempty"`
+	Priority    int    `json:"priority"`
+}
+
+// eventJSON is an event as sent on the event stream
+type eventJSON struct {
+	Type      string        `json:"type"`
+	Interface string        `json:"interface"`
+	Time      time.Time     `json:"time"`
+	Network   *networkJSON  `json:"network,omitempty"`
+	Networks  []networkJSON `json:"networks,omitempty"`
+	Powered   *bool         `json:"powered,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// connectRequest is the body of POST /connect
+type connectRequest struct {
+	Interface   string `json:"interface"`
+	SSID        string `json:"ssid"`
+	Security    string `json:"security"`
+	SecurityKey string `json:"security_key"`
+}
+
+// powerRequest is the body of PUT /power
+type powerRequest struct {
+	Interface string `json:"interface"`
+	Powered   *bool  `json:"powered"`
+}
+
+// httpError is an error with the status code it is reported with
+type httpError struct {
+	status  int
+	message string
+}
+
+// Error returns the message of the error
+func (err *httpError) Error() string {
+	return err.message
+}
+
+// badRequest returns an error reported as 400 Bad Request
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+// ServeHTTP authenticates and routes the request
+func (server *api) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if !server.authorized(request) {
+		writer.Header().Set("WWW-Authenticate", `Bearer realm="wifimgrd"`)
+		writeError(writer, &httpError{status: http.StatusUnauthorized, message: "missing or invalid token"})
+		return
+	}
+	path := strings.Trim(request.URL.EscapedPath(), "/")
+	parts := strings.Split(path, "/")
+	for index := range parts {
+		part, unescapeErr := url.PathUnescape(parts[index])
+		if unescapeErr != nil {
+			writeError(writer, badRequest("invalid path"))
+			return
+		}
+		parts[index] = part
+	}
+	route := request.Method + " " + parts[0]
+	switch {
+	case route == "GET interfaces" && len(parts) == 1:
+		server.interfaces(writer, request)
+	case route == "POST interfaces" && len(parts) == 3 && parts[2] == "scan":
+		server.scan(writer, request, parts[1])
+	case route == "POST scan" && len(parts) == 1:
+		server.scanAll(writer, request)
+	case route == "POST connect" && len(parts) == 1:
+		server.connect(writer, request)
+	case route == "DELETE connection" && len(parts) == 1:
+		server.disconnect(writer, request)
+	case route == "PUT power" && len(parts) == 1:
+		server.power(writer, request)
+	case route == "GET profiles" && len(parts) == 1:
+		server.listProfiles(writer, request)
+	case route == "POST profiles" && len(parts) == 1:
+		server.saveProfile(writer, request, "")
+	case route == "GET profiles" && len(parts) == 2:
+		server.getProfile(writer, request, parts[1])
+	case route == "PUT profiles" && len(parts) == 2:
+		server.saveProfile(writer, request, parts[1])
+	case route == "DELETE profiles" && len(parts) == 2:
+		server.removeProfile(writer, request, parts[1])
+	case route == "GET events" && len(parts) == 1:
+		server.events(writer, request)
+	default:
+		writeError(writer, &httpError{status: http.StatusNotFound, message: "no such endpoint"})
+	}
+}
+
+// authorized checks the bearer token. Browsers can't set headers on
+// an EventSource, so the event stream also takes it as the
+// access_token query parameter, but only from local clients as URLs
+// end up in proxy and server logs.
+func (server *api) authorized(request *http.Request) bool {
+	if server.token == "" {
+		return true
+	}
+	token := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+	if token == "" && request.URL.Path == "/events" && localRequest(request) {
+		token = request.URL.Query().Get("access_token")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(server.token)) == 1
+}
+
+// localRequest returns whether the request came over loopback or the
+// unix socket, whose clients have no address
+func localRequest(request *http.Request) bool {
+	if request.RemoteAddr == "" || request.RemoteAddr == "@" {
+		return true
+	}
+	return isLoopback(request.RemoteAddr)
+}
+
+// iface returns the name of the requested interface, or of the first
+// interface when none was requested
+func (server *api) iface(name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	wifiInterfaces, ifaceErr := server.manager.Interfaces()
+	if ifaceErr != nil {
+		return "", ifaceErr
+	}
+	if len(wifiInterfaces) < 1 {
+		return "", wifimanager.ErrMissingIface
+	}
+	return wifiInterfaces[0].Name, nil
+}
+
+// interfaces handles GET /interfaces
+func (server *api) interfaces(writer http.ResponseWriter, request *http.Request) {
+	wifiInterfaces, ifaceErr := server.manager.Interfaces()
+	if ifaceErr != nil {
+		writeError(writer, ifaceErr)
+		return
+	}
+	response := []interfaceJSON{}
+	for _, wifiInterface := range wifiInterfaces {
+		ifaceJSON := interfaceJSON{
+			Name:    wifiInterface.Name,
+			MAC:     wifiInterface.HardwareAddr.String(),
+			MTU:     wifiInterface.MTU,
+			Backend: wifiInterface.Backend().Name(),
+		}
+		if status, statusErr := wifiInterface.PowerStatus(); statusErr == nil {
+			ifaceJSON.Powered = &status.Powered
+			ifaceJSON.SoftBlocked = status.SoftBlocked
+			ifaceJSON.HardBlocked = status.HardBlocked
+		}
+		if state, stateErr := wifiInterface.State(); stateErr == nil {
+			ifaceJSON.State = wifimanager.StateName(state.State)
+		}
+		if wifiInterface.Connection.SSID != "" {
+			connection := newNetworkJSON(wifiInterface.Connection)
+			ifaceJSON.Connection = &connection
+		}
+		response = append(response, ifaceJSON)
+	}
+	writeJSON(writer, http.StatusOK, response)
+}
+
+// scan handles POST /interfaces/{name}/scan
+func (server *api) scan(writer http.ResponseWriter, request *http.Request, name string) {
+	networks, scanErr := server.manager.Scan(name)
+	if scanErr != nil {
+		writeError(writer, scanErr)
+		return
+	}
+	writeJSON(writer, http.StatusOK, newNetworksJSON(networks))
+}
+
+// scanAll handles POST /scan, scanning on every interface at once.
+// Interfaces whose scan failed are left out unless all of them failed.
+func (server *api) scanAll(writer http.ResponseWriter, request *http.Request) {
+	networks, scanErr := server.manager.ScanAll()
+	if networks == nil {
+		writeError(writer, scanErr)
+		return
+	}
+	response := []networkJSON{}
+	for _, network := range networks {
+		converted := newNetworkJSON(network.WifiNetwork)
+		converted.Interfaces = network.Interfaces
+		response = append(response, converted)
+	}
+	writeJSON(writer, http.StatusOK, response)
+}
+
+// connect handles POST /connect. Without a security key the key of
+// the saved profile is used.
+func (server *api) connect(writer http.ResponseWriter, request *http.Request) {
+	body := connectRequest{}
+	if decodeErr := readJSON(request, &body); decodeErr != nil {
+		writeError(writer, decodeErr)
+		return
+	}
+	if body.SSID == "" {
+		writeError(writer, badRequest("ssid is required"))
+		return
+	}
+	wifimanager.RegisterSecret(body.SecurityKey)
+	defer wifimanager.ForgetSecret(body.SecurityKey)
+	network := wifimanager.WifiNetwork{SSID: body.SSID, SecurityKey: body.SecurityKey}
+	if body.Security != "" {
+		protocol, parseErr := wifimanager.ParseSecurity(body.Security)
+		if parseErr != nil {
+			writeError(writer, badRequest("unknown security %q", body.Security))
+			return
+		}
+		network.Security = []wifimanager.WifiNetworkSecurity{{Protocol: protocol}}
+	}
+	name, ifaceErr := server.iface(body.Interface)
+	if ifaceErr != nil {
+		writeError(writer, ifaceErr)
+		return
+	}
+	connectErr := server.manager.Connect(name, network)
+	if connectErr != nil {
+		writeError(writer, connectErr)
+		return
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// disconnect handles DELETE /connection?interface={name}
+func (server *api) disconnect(writer http.ResponseWriter, request *http.Request) {
+	name, ifaceErr := server.iface(request.URL.Query().Get("interface"))
+	if ifaceErr != nil {
+		writeError(writer, ifaceErr)
+		return
+	}
+	disconnectErr := server.manager.Disconnect(name)
+	if disconnectErr != nil {
+		writeError(writer, disconnectErr)
+		return
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// power handles PUT /power
+func (server *api) power(writer http.ResponseWriter, request *http.Request) {
+	body := powerRequest{}
+	if decodeErr := readJSON(request, &body); decodeErr != nil {
+		writeError(writer, decodeErr)
+		return
+	}
+	if body.Powered == nil {
+		writeError(writer, badRequest("powered is required"))
+		return
+	}
+	name, ifaceErr := server.iface(body.Interface)
+	if ifaceErr != nil {
+		writeError(writer, ifaceErr)
+		return
+	}
+	powerErr := server.manager.SetPower(name, *body.Powered)
+	if powerErr != nil {
+		writeError(writer, powerErr)
+		return
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// listProfiles handles GET /profiles
+func (server *api) listProfiles(writer http.ResponseWriter, request *http.Request) {
+	profiles, listErr := server.manager.Profiles.List()
+	if listErr != nil {
+		writeError(writer, listErr)
+		return
+	}
+	response := []profileJSON{}
+	for _, profile := range profiles {
+		response = append(response, newProfileJSON(profile))
+	}
+	writeJSON(writer, http.StatusOK, response)
+}
+
+// getProfile handles GET /profiles/{ssid}
+func (server *api) getProfile(writer http.ResponseWriter, request *http.Request, ssid string) {
+	profile, getErr := server.manager.Profiles.Get(ssid)
+	if getErr != nil {
+		writeError(writer, getErr)
+		return
+	}
+	writeJSON(writer, http.StatusOK, newProfileJSON(profile))
+}
+
+// saveProfile handles POST /profiles and PUT /profiles/{ssid}. An
+// update without a security key keeps the saved key. Passphrases are
+// saved as their PSK when the backend can join with it.
+func (server *api) saveProfile(writer http.ResponseWriter, request *http.Request, ssid string) {
+	body := profileJSON{}
+	if decodeErr := readJSON(request, &body); decodeErr != nil {
+		writeError(writer, decodeErr)
+		return
+	}
+	wifimanager.RegisterSecret(body.SecurityKey)
+	defer wifimanager.ForgetSecret(body.SecurityKey)
+	if ssid != "" {
+		body.SSID = ssid
+	}
+	if body.SSID == "" {
+		writeError(writer, badRequest("ssid is required"))
+		return
+	}
+	protocol, parseErr := wifimanager.ParseSecurity(body.Security)
+	if parseErr != nil {
+		writeError(writer, badRequest("unknown security %q", body.Security))
+		return
+	}
+	profile := wifimanager.Profile{
+		SSID:        body.SSID,
+		Security:    protocol,
+		SecurityKey: body.SecurityKey,
+		Hidden:      body.Hidden,
+		AutoJoin:    body.AutoJoin == nil || *body.AutoJoin,
+		Priority:    body.Priority,
+	}
+	saved, getErr := server.manager.Profiles.Get(body.SSID)
+	exists := getErr == nil
+	if ssid != "" && !exists {
+		writeError(writer, getErr)
+		return
+	}
+	if ssid == "" && exists {
+		writeError(writer, &httpError{status: http.StatusConflict, message: "a profile for " + body.SSID + " already exists"})
+		return
+	}
+	if exists && profile.SecurityKey == "" {
+		profile.SecurityKey = saved.SecurityKey
+		profile.EAP = saved.EAP
+		profile.MACRandomization = saved.MACRandomization
+	}
+	if deriveErr := profile.DerivePSKFor(server.manager.Backend()); deriveErr != nil {
+		writeError(writer, badRequest("invalid security key: %s", deriveErr))
+		return
+	}
+	saveErr := server.manager.Profiles.Save(profile)
+	if saveErr != nil {
+		writeError(writer, saveErr)
+		return
+	}
+	status := http.StatusOK
+	if !exists {
+		status = http.StatusCreated
+	}
+	writeJSON(writer, status, newProfileJSON(profile))
+}
+
+// removeProfile handles DELETE /profiles/{ssid}
+func (server *api) removeProfile(writer http.ResponseWriter, request *http.Request, ssid string) {
+	removeErr := server.manager.Profiles.Remove(ssid)
+	if removeErr != nil {
+		writeError(writer, removeErr)
+		return
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// events handles GET /events, streaming manager events as
+// Server-Sent Events until the client goes away
+func (server *api) events(writer http.ResponseWriter, request *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		writeError(writer, &httpError{status: http.StatusInternalServerError, message: "streaming is not supported"})
+		return
+	}
+	events, unsubscribe := server.manager.Subscribe()
+	defer unsubscribe()
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(writer, ": keep-alive\n\n")
+		case event := <-events:
+			data, marshalErr := json.Marshal(newEventJSON(event))
+			if marshalErr != nil {
+				continue
+			}
+			fmt.Fprintf(writer, "event: %s\ndata: %s\n\n", wifimanager.EventName(event.Type), data)
+		}
+		flusher.Flush()
+	}
+}
+
+// newNetworkJSON converts a network for the API
+func newNetworkJSON(network wifimanager.WifiNetwork) networkJSON {
+	converted := networkJSON{
+		SSID:     network.SSID,
+		BSSID:    network.BSSID,
+		RSSI:     network.RSSI,
+		Channel:  network.Channel,
+		Security: []string{},
+	}
+	for _, security := range network.Security {
+		if name := wifimanager.SecurityName(security.Protocol); name != "" {
+			converted.Security = append(converted.Security, name)
+		}
+	}
+	return converted
+}
+
+// newNetworksJSON converts networks for the API
+func newNetworksJSON(networks []wifimanager.WifiNetwork) []networkJSON {
+	converted := []networkJSON{}
+	for _, network := range networks {
+		converted = append(converted, newNetworkJSON(network))
+	}
+	return converted
+}
+
+// newProfileJSON converts a profile for the API, leaving out its key
+func newProfileJSON(profile wifimanager.Profile) profileJSON {
+	autoJoin := profile.AutoJoin
+	return profileJSON{
+		SSID:     profile.SSID,
+		Security: wifimanager.SecurityName(profile.Security),
+		HasKey:   profile.SecurityKey != "",
+		Hidden:   profile.Hidden,
+		AutoJoin: &autoJoin,
+		Priority: profile.Priority,
+	}
+}
+
+// newEventJSON converts an event for the event stream
+func newEventJSON(event wifimanager.Event) eventJSON {
+	converted := eventJSON{
+		Type:      wifimanager.EventName(event.Type),
+		Interface: event.Interface,
+		Time:      event.Time,
+	}
+	if event.Network != nil {
+		network := newNetworkJSON(*event.Network)
+		converted.Network = &network
+	}
+	if event.Networks != nil {
+		converted.Networks = newNetworksJSON(event.Networks)
+	}
+	if event.Type == wifimanager.EventPower {
+		converted.Powered = &event.Powered
+	}
+	if event.Err != nil {
+		converted.Error = event.Err.Error()
+	}
+	return converted
+}
+
+// readJSON decodes the request body, rejecting unknown fields
+func readJSON(request *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, request.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if decodeErr := decoder.Decode(value); decodeErr != nil {
+		return badRequest("invalid request body: %s", decodeErr)
+	}
+	return nil
+}
+
+// writeJSON writes the value as the response
+func writeJSON(writer http.ResponseWriter, status int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(value)
+}
+
+// writeError writes the error as the response with a matching status
+// code, with any secret removed from its message
+func writeError(writer http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case wifimanager.ErrUnknownIface, wifimanager.ErrMissingIface, wifimanager.ErrMissingProfile, wifimanager.ErrMissingAP:
+		status = http.StatusNotFound
+	}
+	if requestErr, ok := err.(*httpError); ok {
+		status = requestErr.status
+	}
+	writeJSON(writer, status, map[string]string{"error": wifimanager.Redact(err.Error())})
+}
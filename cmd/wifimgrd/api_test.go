@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ottopress/WifiManager"
+)
+
+func TestIsLoopback(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:8080":   true,
+		"[::1]:8080":       true,
+		"localhost:8080":   true,
+		":8080":            false,
+		"0.0.0.0:8080":     false,
+		"192.168.1.2:8080": false,
+		"127.0.0.1":        false,
+	}
+	for address, loopback := range tests {
+		if isLoopback(address) != loopback {
+			t.Errorf("isLoopback(%q) = %v, want %v", address, !loopback, loopback)
+		}
+	}
+}
+
+func TestAuthorizedAccessToken(t *testing.T) {
+	server := &api{token: "secret-token"}
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		header     string
+		authorized bool
+	}{
+		{name: "header", path: "/interfaces", remoteAddr: "192.168.1.2:4000", header: "Bearer secret-token", authorized: true},
+		{name: "wrong header", path: "/interfaces", remoteAddr: "127.0.0.1:4000", header: "Bearer wrong", authorized: false},
+		{name: "query on loopback", path: "/events?access_token=secret-token", remoteAddr: "127.0.0.1:4000", authorized: true},
+		{name: "query on unix socket", path: "/events?access_token=secret-token", remoteAddr: "@", authorized: true},
+		{name: "query from the network", path: "/events?access_token=secret-token", remoteAddr: "192.168.1.2:4000", authorized: false},
+		{name: "query off the event stream", path: "/interfaces?access_token=secret-token", remoteAddr: "127.0.0.1:4000", authorized: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", test.path, nil)
+			request.RemoteAddr = test.remoteAddr
+			if test.header != "" {
+				request.Header.Set("Authorization", test.header)
+			}
+			if authorized := server.authorized(request); authorized != test.authorized {
+				t.Fatalf("authorized = %v, want %v", authorized, test.authorized)
+			}
+		})
+	}
+}
+
+// newTestAPI returns an API over a simulated backend with one
+// interface and one network in range, and a profile store in a
+// temporary directory
+func newTestAPI(t *testing.T) (*api, *wifimanager.SimulatedBackend) {
+	t.Helper()
+	backend := wifimanager.NewSimulatedBackend("sim0")
+	backend.AddNetwork(wifimanager.WifiNetwork{
+		SSID:        "home",
+		BSSID:       "02:11:22:33:44:01",
+		RSSI:        -48,
+		Security:    []wifimanager.WifiNetworkSecurity{{Protocol: wifimanager.SecurityWPA2}},
+		SecurityKey: "correct horse",
+	})
+	profiles := wifimanager.NewProfileStore(filepath.Join(t.TempDir(), "profiles.json"))
+	return &api{manager: wifimanager.NewManager(backend, profiles), token: "secret-token"}, backend
+}
+
+// serve sends the request to the API with a valid token
+func serve(server *api, method, path, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Authorization", "Bearer secret-token")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRouting(t *testing.T) {
+	server, _ := newTestAPI(t)
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{method: "GET", path: "/interfaces", status: http.StatusOK},
+		{method: "GET", path: "/interfaces/", status: http.StatusOK},
+		{method: "POST", path: "/interfaces/sim0/scan", status: http.StatusOK},
+		{method: "POST", path: "/interfaces/wlan9/scan", status: http.StatusNotFound},
+		{method: "POST", path: "/scan", status: http.StatusOK},
+		{method: "GET", path: "/profiles", status: http.StatusOK},
+		{method: "GET", path: "/profiles/home", status: http.StatusNotFound},
+		{method: "DELETE", path: "/profiles/home", status: http.StatusNotFound},
+		{method: "PUT", path: "/profiles/home", body: `{"security":"wpa2"}`, status: http.StatusNotFound},
+		{method: "POST", path: "/connect", body: `{"ssid":"home","security_key":"correct horse"}`, status: http.StatusNoContent},
+		{method: "POST", path: "/connect", body: `{"ssid":"elsewhere"}`, status: http.StatusNotFound},
+		{method: "POST", path: "/connect", body: `{"ssid":"home","unknown":true}`, status: http.StatusBadRequest},
+		{method: "POST", path: "/connect", body: `{}`, status: http.StatusBadRequest},
+		{method: "DELETE", path: "/connection?interface=sim0", status: http.StatusNoContent},
+		{method: "PUT", path: "/power", body: `{"interface":"sim0"}`, status: http.StatusBadRequest},
+		{method: "PUT", path: "/power", body: `{"interface":"sim0","powered":true}`, status: http.StatusNoContent},
+		{method: "GET", path: "/", status: http.StatusNotFound},
+		{method: "GET", path: "/unknown", status: http.StatusNotFound},
+		{method: "DELETE", path: "/interfaces", status: http.StatusNotFound},
+		{method: "GET", path: "/interfaces/sim0/scan", status: http.StatusNotFound},
+		{method: "POST", path: "/interfaces/sim0/scan/more", status: http.StatusNotFound},
+		{method: "GET", path: "/profiles/home/key", status: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		recorder := serve(server, test.method, test.path, test.body)
+		if recorder.Code != test.status {
+			t.Errorf("%s %s = %d, want %d: %s", test.method, test.path, recorder.Code, test.status, recorder.Body)
+		}
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	server, _ := newTestAPI(t)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/interfaces", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+	if recorder.Header().Get("WWW-Authenticate") == "" {
+		t.Error("WWW-Authenticate header is missing")
+	}
+}
+
+func TestSaveProfile(t *testing.T) {
+	server, _ := newTestAPI(t)
+	created := serve(server, "POST", "/profiles", `{"ssid":"home","security":"wpa2","security_key":"correct horse"}`)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("POST /profiles = %d, want %d: %s", created.Code, http.StatusCreated, created.Body)
+	}
+	conflict := serve(server, "POST", "/profiles", `{"ssid":"home","security":"wpa2","security_key":"other key"}`)
+	if conflict.Code != http.StatusConflict {
+		t.Fatalf("second POST /profiles = %d, want %d", conflict.Code, http.StatusConflict)
+	}
+	updated := serve(server, "PUT", "/profiles/home", `{"security":"wpa2","priority":5}`)
+	if updated.Code != http.StatusOK {
+		t.Fatalf("PUT /profiles/home = %d, want %d: %s", updated.Code, http.StatusOK, updated.Body)
+	}
+	profile, getErr := server.manager.Profiles.Get("home")
+	if getErr != nil {
+		t.Fatal(getErr)
+	}
+	if saved := derivedKey(t, "correct horse", "home"); profile.SecurityKey != saved {
+		t.Errorf("saved key = %q after an update without a key, want %q", profile.SecurityKey, saved)
+	}
+	if profile.Priority != 5 {
+		t.Errorf("saved priority = %d, want 5", profile.Priority)
+	}
+	replaced := serve(server, "PUT", "/profiles/home", `{"security":"wpa2","security_key":"new passphrase"}`)
+	if replaced.Code != http.StatusOK {
+		t.Fatalf("PUT /profiles/home with a key = %d, want %d", replaced.Code, http.StatusOK)
+	}
+	profile, _ = server.manager.Profiles.Get("home")
+	if saved := derivedKey(t, "new passphrase", "home"); profile.SecurityKey != saved {
+		t.Errorf("saved key = %q after an update with a key, want %q", profile.SecurityKey, saved)
+	}
+	serve(server, "POST", "/profiles", `{"ssid":"home/work","security":"open"}`)
+	if escaped := serve(server, "GET", "/profiles/home%2Fwork", ""); escaped.Code != http.StatusOK {
+		t.Errorf("GET /profiles/home%%2Fwork = %d, want %d", escaped.Code, http.StatusOK)
+	}
+	if invalid := serve(server, "POST", "/profiles", `{"ssid":"work","security":"rot13"}`); invalid.Code != http.StatusBadRequest {
+		t.Errorf("POST /profiles with unknown security = %d, want %d", invalid.Code, http.StatusBadRequest)
+	}
+	if short := serve(server, "POST", "/profiles", `{"ssid":"work","security":"wpa2","security_key":"short"}`); short.Code != http.StatusBadRequest {
+		t.Errorf("POST /profiles with a short passphrase = %d, want %d", short.Code, http.StatusBadRequest)
+	}
+	// SAE needs the passphrase itself
+	serve(server, "POST", "/profiles", `{"ssid":"office","security":"wpa3","security_key":"correct horse"}`)
+	if office, _ := server.manager.Profiles.Get("office"); office.SecurityKey != "correct horse" {
+		t.Errorf("saved wpa3 key = %q, want the passphrase", office.SecurityKey)
+	}
+}
+
+// derivedKey returns the hex encoded PSK of the passphrase
+func derivedKey(t *testing.T, passphrase, ssid string) string {
+	t.Helper()
+	psk, deriveErr := wifimanager.DerivePSK(passphrase, ssid)
+	if deriveErr != nil {
+		t.Fatal(deriveErr)
+	}
+	return hex.EncodeToString(psk)
+}
+
+func TestProfileKeyNotSent(t *testing.T) {
+	server, _ := newTestAPI(t)
+	responses := []*httptest.ResponseRecorder{
+		serve(server, "POST", "/profiles", `{"ssid":"home","security":"wpa2","security_key":"correct horse"}`),
+		serve(server, "PUT", "/profiles/home", `{"security":"wpa2","security_key":"correct horse"}`),
+		serve(server, "GET", "/profiles/home", ""),
+		serve(server, "GET", "/profiles", ""),
+	}
+	for _, response := range responses {
+		body := response.Body.String()
+		if strings.Contains(body, "security_key") || strings.Contains(body, "correct horse") {
+			t.Errorf("response leaks the security key: %s", body)
+		}
+		if !strings.Contains(body, `"has_key":true`) {
+			t.Errorf("response doesn't report the saved key: %s", body)
+		}
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{err: wifimanager.ErrUnknownIface, status: http.StatusNotFound},
+		{err: wifimanager.ErrMissingIface, status: http.StatusNotFound},
+		{err: wifimanager.ErrMissingProfile, status: http.StatusNotFound},
+		{err: wifimanager.ErrMissingAP, status: http.StatusNotFound},
+		{err: badRequest("ssid is required"), status: http.StatusBadRequest},
+		{err: &httpError{status: http.StatusConflict, message: "exists"}, status: http.StatusConflict},
+		{err: errors.New("backend failed"), status: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		writeError(recorder, test.err)
+		if recorder.Code != test.status {
+			t.Errorf("writeError(%q) status = %d, want %d", test.err, recorder.Code, test.status)
+		}
+		body := map[string]string{}
+		if decodeErr := json.NewDecoder(recorder.Body).Decode(&body); decodeErr != nil {
+			t.Errorf("writeError(%q) body: %s", test.err, decodeErr)
+		} else if body["error"] != test.err.Error() {
+			t.Errorf("writeError(%q) error = %q, want %q", test.err, body["error"], test.err.Error())
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	server, _ := newTestAPI(t)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	request, _ := http.NewRequest("GET", httpServer.URL+"/events", nil)
+	request.Header.Set("Authorization", "Bearer secret-token")
+	response, requestErr := httpServer.Client().Do(request)
+	if requestErr != nil {
+		t.Fatal(requestErr)
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", contentType)
+	}
+	if powerErr := server.manager.SetPower("sim0", false); powerErr != nil {
+		t.Fatal(powerErr)
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	timeout := time.After(5 * time.Second)
+	var received []string
+	for len(received) < 2 {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("stream ended after %q", received)
+			}
+			received = append(received, line)
+		case <-timeout:
+			t.Fatalf("timed out after %q", received)
+		}
+	}
+	if received[0] != "event: power" {
+		t.Errorf("event line = %q, want %q", received[0], "event: power")
+	}
+	event := eventJSON{}
+	if decodeErr := json.Unmarshal([]byte(strings.TrimPrefix(received[1], "data: ")), &event); decodeErr != nil {
+		t.Fatalf("data line %q: %s", received[1], decodeErr)
+	}
+	if event.Type != "power" || event.Interface != "sim0" || event.Powered == nil || *event.Powered {
+		t.Errorf("event = %+v, want sim0 powered off", event)
+	}
+}
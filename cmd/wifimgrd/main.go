@@ -0,0 +1,198 @@
+// Command wifimgrd serves a JSON API for managing WiFi interfaces
+// remotely.
+//
+// Endpoints:
+//
+//	GET    /interfaces                 list interfaces
+//	POST   /interfaces/{name}/scan     scan for networks
+//	POST   /connect                    join a network
+//	DELETE /connection?interface=name  leave the current network
+//	PUT    /power                      turn an interface on or off
+//	GET    /profiles                   list saved profiles
+//	POST   /profiles                   save a new profile
+//	GET    /profiles/{ssid}            get a saved profile
+//	PUT    /profiles/{ssid}            update a saved profile
+//	DELETE /profiles/{ssid}            remove a saved profile
+//	GET    /events                     stream changes as Server-Sent Events
+//
+// With -grpc the same manager is also served over gRPC, see the
+// wifirpc package.
+//
+// Requests carry the token as "Authorization: Bearer <token>". The
+// token is read from the file given with -token-file or from the
+// WIFIMGRD_TOKEN environment variable, never from the command line.
+// gRPC calls carry it the same way, as "authorization" metadata. A
+// token is required when listening on TCP. On a unix socket it is
+// optional, as access is controlled by the socket's permissions.
+//
+// Both listeners only serve plain text on loopback addresses. Anywhere
+// else the token would cross the network in the clear, so a
+// certificate has to be provided with -tls-cert and -tls-key.
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/ottopress/WifiManager"
+	"github.com/ottopress/WifiManager/wifirpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8080", "TCP address to listen on")
+	unixSocket := flag.String("unix", "", "unix socket to listen on instead of TCP")
+	grpcListen := flag.String("grpc", "", "TCP address to serve gRPC on")
+	tokenFile := flag.String("token-file", "", "file holding the API token")
+	tlsCert := flag.String("tls-cert", "", "certificate file to serve TLS with, required off loopback")
+	tlsKey := flag.String("tls-key", "", "private key file of the -tls-cert certificate")
+	backendName := flag.String("backend", os.Getenv(wifimanager.BackendEnv), "backend to use: "+strings.Join(wifimanager.BackendNames(), ", ")+" (default: detected)")
+	profilesPath := flag.String("profiles", "/var/lib/wifimgrd/profiles.json", "profile store location")
+	flag.Parse()
+	log.SetOutput(wifimanager.RedactWriter(os.Stderr))
+
+	token, tokenErr := readToken(*tokenFile)
+	if tokenErr != nil {
+		log.Fatal("wifimgrd: ", tokenErr)
+	}
+	if token == "" && (*unixSocket == "" || *grpcListen != "") {
+		log.Fatal("wifimgrd: a token is required when listening on TCP, use -token-file or WIFIMGRD_TOKEN")
+	}
+	if (*tlsCert == "") != (*tlsKey == "") {
+		log.Fatal("wifimgrd: -tls-cert and -tls-key have to be used together")
+	}
+	if *tlsCert == "" {
+		if *unixSocket == "" && !isLoopback(*listen) {
+			log.Fatalf("wifimgrd: refusing to serve %s without TLS, use -tls-cert and -tls-key", *listen)
+		}
+		if *grpcListen != "" && !isLoopback(*grpcListen) {
+			log.Fatalf("wifimgrd: refusing to serve gRPC on %s without TLS, use -tls-cert and -tls-key", *grpcListen)
+		}
+	}
+	backend, backendErr := wifimanager.SelectBackend(*backendName)
+	if backendErr != nil {
+		log.Fatal("wifimgrd: ", backendErr)
+	}
+	dirErr := os.MkdirAll(filepath.Dir(*profilesPath), 0700)
+	if dirErr != nil {
+		log.Fatal("wifimgrd: ", dirErr)
+	}
+	manager := wifimanager.NewManager(backend, wifimanager.NewProfileStore(*profilesPath))
+	stopWatching, watchErr := manager.Watch()
+	if watchErr != nil {
+		log.Print("wifimgrd: not watching for interfaces: ", watchErr)
+	} else {
+		defer stopWatching()
+	}
+
+	listener, listenErr := listenOn(*listen, *unixSocket)
+	if listenErr != nil {
+		log.Fatal("wifimgrd: ", listenErr)
+	}
+	server := &http.Server{Handler: &api{manager: manager, token: token}}
+	grpcOptions := wifirpc.TokenAuth(token)
+	if *tlsCert != "" {
+		grpcCredentials, credentialsErr := credentials.NewServerTLSFromFile(*tlsCert, *tlsKey)
+		if credentialsErr != nil {
+			log.Fatal("wifimgrd: ", credentialsErr)
+		}
+		grpcOptions = append(grpcOptions, grpc.Creds(grpcCredentials))
+	}
+	grpcServer := grpc.NewServer(grpcOptions...)
+	wifirpc.RegisterWifiManagerServer(grpcServer, wifirpc.NewServer(manager))
+	if *grpcListen != "" {
+		grpcListener, grpcErr := net.Listen("tcp", *grpcListen)
+		if grpcErr != nil {
+			log.Fatal("wifimgrd: ", grpcErr)
+		}
+		log.Printf("wifimgrd: serving gRPC on %s", grpcListener.Addr())
+		go func() {
+			serveErr := grpcServer.Serve(grpcListener)
+			if serveErr != nil {
+				log.Fatal("wifimgrd: ", serveErr)
+			}
+		}()
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		grpcServer.Stop()
+		server.Close()
+	}()
+	log.Printf("wifimgrd: serving the %s backend on %s", backend.Name(), listener.Addr())
+	var serveErr error
+	if *tlsCert != "" {
+		serveErr = server.ServeTLS(listener, *tlsCert, *tlsKey)
+	} else {
+		serveErr = server.Serve(listener)
+	}
+	if serveErr != nil && serveErr != http.ErrServerClosed {
+		log.Fatal("wifimgrd: ", serveErr)
+	}
+	if *unixSocket != "" {
+		os.Remove(*unixSocket)
+	}
+}
+
+// readToken returns the API token from the file, or from the
+// environment when no file is provided
+func readToken(path string) (string, error) {
+	if path == "" {
+		return os.Getenv("WIFIMGRD_TOKEN"), nil
+	}
+	data, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return "", readErr
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.New(path + " is empty")
+	}
+	return token, nil
+}
+
+// isLoopback returns whether the host:port address is a loopback
+// address. An empty host listens on every address, so it isn't.
+func isLoopback(address string) bool {
+	host, _, splitErr := net.SplitHostPort(address)
+	if splitErr != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// listenOn listens on the unix socket if one is provided, replacing a
+// stale socket file, or on the TCP address otherwise
+func listenOn(address, socket string) (net.Listener, error) {
+	if socket == "" {
+		return net.Listen("tcp", address)
+	}
+	if info, statErr := os.Lstat(socket); statErr == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(socket)
+	}
+	listener, listenErr := net.Listen("unix", socket)
+	if listenErr != nil {
+		return nil, listenErr
+	}
+	chmodErr := os.Chmod(socket, 0660)
+	if chmodErr != nil {
+		listener.Close()
+		return nil, chmodErr
+	}
+	return listener, nil
+}
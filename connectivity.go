@@ -0,0 +1,199 @@
+package wifimanager
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// ConnectivityNone means none of the probes could be reached
+	ConnectivityNone int = iota
+	// ConnectivityLimited means the probes could be reached but
+	// answered with unexpected errors
+	ConnectivityLimited
+	// ConnectivityCaptive means the probes were intercepted by a
+	// captive portal that has to be logged into first
+	ConnectivityCaptive
+	// ConnectivityFull means at least one probe answered as expected
+	ConnectivityFull
+	// ConnectivityTimeout is the default time each probe is given
+	ConnectivityTimeout = 5 * time.Second
+	// connectivityBodyLimit caps how much of a response is read
+	connectivityBodyLimit = 64 * 1024
+)
+
+var (
+	// DefaultProbes are the endpoints checked when a checker isn't
+	// given any
+	DefaultProbes = []ConnectivityProbe{
+		{URL: "http://connectivitycheck.gstatic.com/generate_204", StatusCode: http.StatusNoContent},
+		{URL: "http://captive.apple.com/hotspot-detect.html", StatusCode: http.StatusOK, Body: "Success"},
+	}
+
+	// portalRefreshRE captures the target of a meta refresh tag
+	portalRefreshRE = regexp.MustCompile(`(?i)<meta[^>]+http-equiv=["']?refresh["']?[^>]+content=["']?\s*\d*\s*;\s*url=([^"'>\s]+)`)
+	// portalLocationRE captures the target of a javascript redirect
+	portalLocationRE = regexp.MustCompile(`(?i)(?:window\.)?location(?:\.href)?\s*=\s*["']([^"']+)["']`)
+)
+
+// ConnectivityProbe is an HTTP endpoint with a known answer. A probe
+// succeeds when the response has the expected status code and, if
+// Body is set, the response body contains it.
+type ConnectivityProbe struct {
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+// Connectivity is the result of a connectivity check
+type Connectivity struct {
+	State     int
+	PortalURL string
+	CheckedAt time.Time
+}
+
+// ConnectivityChecker classifies the internet access of the current
+// connection by fetching its probes
+type ConnectivityChecker struct {
+	Probes  []ConnectivityProbe
+	Timeout time.Duration
+	// Client replaces the default client, e.g. to probe through a
+	// specific transport. Redirects are still never followed.
+	Client *http.Client
+}
+
+// NewConnectivityChecker creates a checker for the provided probes,
+// falling back to DefaultProbes if none are given
+func NewConnectivityChecker(probes ...ConnectivityProbe) *ConnectivityChecker {
+	if len(probes) == 0 {
+		probes = DefaultProbes
+	}
+	return &ConnectivityChecker{
+		Probes:  probes,
+		Timeout: ConnectivityTimeout,
+	}
+}
+
+// Check fetches every probe and returns the best state any of them
+// reached
+func (checker *ConnectivityChecker) Check() Connectivity {
+	return checker.check(checker.client("", nil))
+}
+
+// check runs the probes with the provided client
+func (checker *ConnectivityChecker) check(client *http.Client) Connectivity {
+	connectivity := Connectivity{State: ConnectivityNone}
+	for _, probe := range checker.Probes {
+		state, portalURL := checker.probe(client, probe)
+		if state > connectivity.State {
+			connectivity.State = state
+			connectivity.PortalURL = portalURL
+		}
+		if state == ConnectivityFull {
+			break
+		}
+	}
+	connectivity.CheckedAt = time.Now()
+	return connectivity
+}
+
+// client returns the HTTP client used for probing, bound to the
+// provided interface and local address if they are given. Redirects
+// are never followed since they are how most portals reveal
+// themselves.
+func (checker *ConnectivityChecker) client(device string, localAddr net.IP) *http.Client {
+	noRedirect := func(request *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	if checker.Client != nil {
+		client := *checker.Client
+		client.CheckRedirect = noRedirect
+		return &client
+	}
+	dialer := &net.Dialer{Timeout: checker.Timeout}
+	if localAddr != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: localAddr}
+	}
+	if device != "" {
+		dialer.Control = bindToDevice(device)
+	}
+	return &http.Client{
+		Timeout: checker.Timeout,
+		Transport: &http.Transport{
+			DialContext:       dialer.DialContext,
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: noRedirect,
+	}
+}
+
+// probe fetches a single probe and classifies the response
+func (checker *ConnectivityChecker) probe(client *http.Client, probe ConnectivityProbe) (int, string) {
+	response, getErr := client.Get(probe.URL)
+	if getErr != nil {
+		return ConnectivityNone, ""
+	}
+	defer response.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, connectivityBodyLimit))
+
+	if response.StatusCode == probe.StatusCode && strings.Contains(string(body), probe.Body) {
+		return ConnectivityFull, ""
+	}
+	if response.StatusCode >= 300 && response.StatusCode < 400 {
+		location, locationErr := response.Location()
+		if locationErr != nil {
+			return ConnectivityCaptive, ""
+		}
+		return ConnectivityCaptive, location.String()
+	}
+	if response.StatusCode == http.StatusOK || response.StatusCode == http.StatusNetworkAuthenticationRequired {
+		return ConnectivityCaptive, portalURL(probe.URL, string(body))
+	}
+	return ConnectivityLimited, ""
+}
+
+// portalURL extracts the login page a portal's interception page
+// points to, falling back to the probed URL which the portal served
+func portalURL(probeURL string, body string) string {
+	for _, portalRE := range []*regexp.Regexp{portalRefreshRE, portalLocationRE} {
+		matches := portalRE.FindStringSubmatch(body)
+		if matches == nil {
+			continue
+		}
+		base, baseErr := url.Parse(probeURL)
+		target, targetErr := url.Parse(matches[1])
+		if baseErr != nil || targetErr != nil {
+			return matches[1]
+		}
+		return base.ResolveReference(target).String()
+	}
+	return probeURL
+}
+
+// CheckConnectivity runs the checker through the interface and stores
+// the result on the interface. The probes are sent from the
+// interface's own address and, on Linux, the socket is bound to the
+// interface so they leave through it even on multi-homed hosts.
+// Elsewhere only the source address is bound, and the route to the
+// probes picks the egress interface. Probe names are always resolved
+// with the system resolver.
+func (wifiInterface *WifiInterface) CheckConnectivity(checker *ConnectivityChecker) Connectivity {
+	var localAddr net.IP
+	addrs, addrsErr := wifiInterface.Addrs()
+	if addrsErr == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil && !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+				localAddr = ipNet.IP
+				break
+			}
+		}
+	}
+	wifiInterface.Connectivity = checker.check(checker.client(wifiInterface.Name, localAddr))
+	return wifiInterface.Connectivity
+}
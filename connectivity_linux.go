@@ -0,0 +1,25 @@
+//go:build linux
+
+package wifimanager
+
+import "syscall"
+
+// bindToDevice binds the probe sockets to the interface with
+// SO_BINDTODEVICE so they can't be routed out of another one. Without
+// CAP_NET_RAW on kernels before 5.7 the option is refused and the
+// probes fall back to the source address binding alone.
+func bindToDevice(device string) func(network, address string, rawConn syscall.RawConn) error {
+	return func(network, address string, rawConn syscall.RawConn) error {
+		var sockErr error
+		controlErr := rawConn.Control(func(fd uintptr) {
+			sockErr = syscall.BindToDevice(int(fd), device)
+		})
+		if controlErr != nil {
+			return controlErr
+		}
+		if sockErr == syscall.EPERM || sockErr == syscall.EACCES {
+			return nil
+		}
+		return sockErr
+	}
+}
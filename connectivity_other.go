@@ -0,0 +1,11 @@
+//go:build !linux
+
+package wifimanager
+
+import "syscall"
+
+// bindToDevice does nothing as SO_BINDTODEVICE is Linux only, so the
+// probes are only bound to the interface's source address
+func bindToDevice(device string) func(network, address string, rawConn syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package wifimanager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConnectivityCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		state     int
+		portalURL string
+	}{
+		{
+			name: "full",
+			handler: func(writer http.ResponseWriter, request *http.Request) {
+				writer.Write([]byte("<html>Success</html>"))
+			},
+			state: ConnectivityFull,
+		},
+		{
+			name: "redirect",
+			handler: func(writer http.ResponseWriter, request *http.Request) {
+				http.Redirect(writer, request, "http://portal.example/login", http.StatusFound)
+			},
+			state:     ConnectivityCaptive,
+			portalURL: "http://portal.example/login",
+		},
+		{
+			name: "meta refresh",
+			handler: func(writer http.ResponseWriter, request *http.Request) {
+				writer.Write([]byte(`<meta http-equiv="refresh" content="0; url=/login?next=probe">`))
+			},
+			state:     ConnectivityCaptive,
+			portalURL: "/login?next=probe",
+		},
+		{
+			name: "network authentication required",
+			handler: func(writer http.ResponseWriter, request *http.Request) {
+				writer.WriteHeader(http.StatusNetworkAuthenticationRequired)
+				writer.Write([]byte(`<script>window.location.href = "https://portal.example/";</script>`))
+			},
+			state:     ConnectivityCaptive,
+			portalURL: "https://portal.example/",
+		},
+		{
+			name: "server error",
+			handler: func(writer http.ResponseWriter, request *http.Request) {
+				writer.WriteHeader(http.StatusBadGateway)
+			},
+			state: ConnectivityLimited,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(test.handler)
+			defer server.Close()
+			probeURL := server.URL + "/hotspot-detect.html"
+			checker := NewConnectivityChecker(ConnectivityProbe{URL: probeURL, StatusCode: http.StatusOK, Body: "Success"})
+			connectivity := checker.Check()
+			if connectivity.State != test.state {
+				t.Fatalf("state = %d, want %d", connectivity.State, test.state)
+			}
+			portalURL := test.portalURL
+			if len(portalURL) > 0 && portalURL[0] == '/' {
+				portalURL = server.URL + portalURL
+			}
+			if connectivity.PortalURL != portalURL {
+				t.Errorf("portal url = %q, want %q", connectivity.PortalURL, portalURL)
+			}
+			if connectivity.CheckedAt.IsZero() {
+				t.Error("checked at is not set")
+			}
+		})
+	}
+}
+
+func TestConnectivityCheckUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	probeURL := server.URL + "/generate_204"
+	server.Close()
+	checker := NewConnectivityChecker(ConnectivityProbe{URL: probeURL, StatusCode: http.StatusNoContent})
+	if connectivity := checker.Check(); connectivity.State != ConnectivityNone {
+		t.Fatalf("state = %d, want %d", connectivity.State, ConnectivityNone)
+	}
+}
+
+func TestConnectivityCheckBestProbe(t *testing.T) {
+	limited := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer limited.Close()
+	full := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	defer full.Close()
+	checker := NewConnectivityChecker(
+		ConnectivityProbe{URL: limited.URL, StatusCode: http.StatusNoContent},
+		ConnectivityProbe{URL: full.URL, StatusCode: http.StatusNoContent},
+	)
+	if connectivity := checker.Check(); connectivity.State != ConnectivityFull {
+		t.Fatalf("state = %d, want %d", connectivity.State, ConnectivityFull)
+	}
+}
+
+func TestConnectivityClientBindsDevice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	checker := NewConnectivityChecker(ConnectivityProbe{URL: server.URL, StatusCode: http.StatusNoContent})
+	if connectivity := checker.check(checker.client("lo", nil)); connectivity.State != ConnectivityFull {
+		t.Fatalf("state = %d, want %d", connectivity.State, ConnectivityFull)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 const (
@@ -69,8 +70,11 @@ var (
 	}
 )
 
-// AirPort is a wrapper for the Mac OS X airport command
+// AirPort is a wrapper for the Mac OS X airport command. airport
+// takes the interface to use as its first argument and falls back to
+// the first WiFi interface when none is provided.
 type AirPort struct {
+	mutex       sync.Mutex
 	outputCache []AirPortNetwork
 }
 
@@ -112,9 +116,10 @@ func (airport *AirPort) IsInstalled() bool {
 	return true
 }
 
-// Scan using the airport command and both cache and return the output
-func (airport *AirPort) Scan() ([]AirPortNetwork, error) {
-	cmd := exec.Command("/System/Library/PrivateFrameworks/Apple80211.framework/Versions/A/Resources/airport", "-s")
+// Scan using the airport command on the provided interface and both
+// cache and return the output
+func (airport *AirPort) Scan(iface string) ([]AirPortNetwork, error) {
+	cmd := airport.command(iface, "-s")
 	cmdOut, cmdErr := cmd.CombinedOutput()
 	if cmdErr != nil {
 		return nil, cmdErr
@@ -123,12 +128,16 @@ func (airport *AirPort) Scan() ([]AirPortNetwork, error) {
 	if parseErr != nil {
 		return nil, parseErr
 	}
+	airport.mutex.Lock()
 	airport.outputCache = parseOut
+	airport.mutex.Unlock()
 	return parseOut, nil
 }
 
 // Get all networks that match the provided SSID
 func (airport *AirPort) Get(ssid string) []AirPortNetwork {
+	airport.mutex.Lock()
+	defer airport.mutex.Unlock()
 	possibleNetworks := []AirPortNetwork{}
 	for index := range airport.outputCache {
 		network := airport.outputCache[index]
@@ -139,10 +148,22 @@ func (airport *AirPort) Get(ssid string) []AirPortNetwork {
 	return possibleNetworks
 }
 
-// Disconnect disconnects from the current network without shutting
-// down the interface
-func (airport *AirPort) Disconnect() error {
-	cmd := exec.Command("/System/Library/PrivateFrameworks/Apple80211.framework/Versions/A/Resources/airport", "--disassociate")
+// Info returns the fields airport -I prints about the current
+// connection of the interface, such as "SSID", "BSSID" and
+// "agrCtlRSSI"
+func (airport *AirPort) Info(iface string) (map[string]string, error) {
+	cmd := airport.command(iface, "-I")
+	cmdOut, cmdErr := cmd.CombinedOutput()
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+	return ParseAirPortInfo(cmdOut), nil
+}
+
+// Disconnect disconnects the interface from the current network
+// without shutting it down
+func (airport *AirPort) Disconnect(iface string) error {
+	cmd := airport.command(iface, "--disassociate")
 	_, cmdErr := cmd.CombinedOutput()
 	if cmdErr != nil {
 		return cmdErr
@@ -150,6 +171,29 @@ func (airport *AirPort) Disconnect() error {
 	return nil
 }
 
+// command builds an airport command run on the provided interface
+func (airport *AirPort) command(iface string, args ...string) *exec.Cmd {
+	if iface != "" {
+		args = append([]string{iface}, args...)
+	}
+	return exec.Command("/System/Library/PrivateFrameworks/Apple80211.framework/Versions/A/Resources/airport", args...)
+}
+
+// ParseAirPortInfo parses the "key: value" lines of airport -I
+func ParseAirPortInfo(output []byte) map[string]string {
+	info := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		separator := strings.Index(scanner.Text(), ":")
+		if separator < 0 {
+			continue
+		}
+		key := strings.TrimSpace(scanner.Text()[:separator])
+		info[key] = strings.TrimSpace(scanner.Text()[separator+1:])
+	}
+	return info
+}
+
 func (airport *AirPort) parseOutput(output []byte) ([]AirPortNetwork, error) {
 	var networks []AirPortNetwork
 	scanner := bufio.NewScanner(bytes.NewReader(output))
@@ -0,0 +1,75 @@
+package darwin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// coreWLANScript joins a network through the CoreWLAN framework
+	// using the JavaScript for Automation bridge. It is read by
+	// osascript from stdin so the password never shows up in the
+	// argument list.
+	coreWLANScript = `ObjC.import('CoreWLAN');
+var params = %s;
+var client = $.CWWiFiClient.sharedWiFiClient;
+var iface = params.iface ? client.interfaceWithName(params.iface) : client.interface;
+var error = Ref();
+var networks = params.hidden ? iface.scanForNetworksWithNameIncludeHiddenError(params.ssid, true, error) : iface.scanForNetworksWithNameError(params.ssid, error);
+if (!networks || networks.count == 0) {
+	throw new Error('network not found');
+}
+var password = params.password === '' ? null : params.password;
+if (!iface.associateToNetworkPasswordError(networks.anyObject, password, error)) {
+	throw new Error(ObjC.unwrap(error[0].localizedDescription));
+}
+`
+)
+
+// CoreWLAN joins networks through the Mac OS X CoreWLAN framework,
+// driven by osascript.
+type CoreWLAN struct{}
+
+// NewCoreWLAN creates a new instance of a CoreWLAN wrapper.
+func NewCoreWLAN() *CoreWLAN {
+	return &CoreWLAN{}
+}
+
+// IsInstalled returns whether or not the osascript executable
+// can be found in the current PATH environment variable.
+func (coreWLAN *CoreWLAN) IsInstalled() bool {
+	_, err := exec.LookPath("osascript")
+	if err != nil {
+		return false
+	}
+	return true
+}
+
+// Associate joins the provided interface to the given network, which
+// is scanned for among the hidden networks when hidden is set. The
+// script, password included, is passed to osascript on stdin.
+func (coreWLAN *CoreWLAN) Associate(iface, ssid, password string, hidden bool) error {
+	params, marshalErr := json.Marshal(map[string]interface{}{
+		"iface":    iface,
+		"ssid":     ssid,
+		"password": password,
+		"hidden":   hidden,
+	})
+	if marshalErr != nil {
+		return marshalErr
+	}
+	cmd := exec.Command("osascript", "-l", "JavaScript", "-")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf(coreWLANScript, params))
+	cmdOut, cmdErr := cmd.CombinedOutput()
+	if cmdErr != nil {
+		message := strings.TrimSpace(string(cmdOut))
+		if message == "" {
+			return cmdErr
+		}
+		return errors.New("corewlan: " + message)
+	}
+	return nil
+}
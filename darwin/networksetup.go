@@ -26,10 +26,13 @@ func (networkSetup *NetworkSetup) IsInstalled() bool {
 	return true
 }
 
-// Connect initializes a connection on the provided interface to the given
-// network.
-func (networkSetup *NetworkSetup) Connect(iface, ssid, password string) error {
-	cmd := exec.Command("networksetup", "-setairportnetwork", iface, ssid, password)
+// Connect initializes a connection on the provided interface to the
+// given open network, or to a secured one whose password is already in
+// the keychain. networksetup only takes passwords as an argument, where
+// any user can see them in the process list, so secured networks are
+// joined with CoreWLAN.Associate instead.
+func (networkSetup *NetworkSetup) Connect(iface, ssid string) error {
+	cmd := exec.Command("networksetup", "-setairportnetwork", iface, ssid)
 	_, cmdErr := cmd.CombinedOutput()
 	if cmdErr != nil {
 		return cmdErr
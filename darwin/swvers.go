@@ -0,0 +1,16 @@
+package darwin
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// SystemVersion returns the Mac OS X version reported by sw_vers, such
+// as "14.4.1"
+func SystemVersion() (string, error) {
+	cmdOut, cmdErr := exec.Command("sw_vers", "-productVersion").CombinedOutput()
+	if cmdErr != nil {
+		return "", cmdErr
+	}
+	return strings.TrimSpace(string(cmdOut)), nil
+}
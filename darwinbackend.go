@@ -0,0 +1,204 @@
+package wifimanager
+
+import (
+	"net"
+	"os"
+
+	"github.com/ottopress/WifiManager/darwin"
+)
+
+var (
+	// darwinProtocols maps the airport security protocols to their
+	// respective WifiNetworkSecurity protocol values
+	darwinProtocols = map[int]int{
+		darwin.WPA:  SecurityWPA,
+		darwin.WEP:  SecurityWEP,
+		darwin.WPA2: SecurityWPA2,
+		darwin.NONE: SecurityNone,
+	}
+)
+
+// DarwinBackend drives WiFi interfaces using the Mac OS X airport,
+// networksetup and system_profiler commands
+type DarwinBackend struct{}
+
+// NewDarwinBackend creates a new instance of the Mac OS X backend
+func NewDarwinBackend() *DarwinBackend {
+	return &DarwinBackend{}
+}
+
+// Name returns the name of the backend
+func (darwinBackend *DarwinBackend) Name() string {
+	return "darwin"
+}
+
+// IsInstalled returns whether or not all the commands the backend
+// relies on are installed
+func (darwinBackend *DarwinBackend) IsInstalled() bool {
+	return airport.IsInstalled() && networkSetup.IsInstalled() && systemProfiler.IsInstalled() && coreWLAN.IsInstalled()
+}
+
+// Interfaces returns all WiFi interfaces known to system_profiler
+func (darwinBackend *DarwinBackend) Interfaces() ([]WifiInterface, error) {
+	wifiInterfaces := []WifiInterface{}
+
+	netInterfaces, netErr := net.Interfaces()
+	if netErr != nil {
+		return wifiInterfaces, netErr
+	}
+
+	_, runErr := systemProfiler.Run(networkSetup)
+	if runErr != nil {
+		return wifiInterfaces, runErr
+	}
+	for _, iface := range netInterfaces {
+		_, spErr := systemProfiler.Get(iface.Name)
+		if spErr == nil {
+			wifiInterface, _ := NewWifiInterface(iface)
+			wifiInterface.backend = darwinBackend
+			wifiInterfaces = append(wifiInterfaces, wifiInterface)
+		}
+	}
+	return wifiInterfaces, nil
+}
+
+// Scan returns a list of all WiFi networks reachable from the
+// interface
+func (darwinBackend *DarwinBackend) Scan(iface string) ([]WifiNetwork, error) {
+	airportNetworks, airportErr := airport.Scan(iface)
+	if airportErr != nil {
+		return nil, airportErr
+	}
+	wifiNetworks := []WifiNetwork{}
+	for _, network := range airportNetworks {
+		security := []WifiNetworkSecurity{}
+		for _, airSecurity := range network.Security {
+			security = append(security, WifiNetworkSecurity{
+				Protocol: darwinProtocols[airSecurity.Protocol],
+				Method:   airSecurity.Method,
+				Unicasts: airSecurity.Unicasts,
+				Group:    airSecurity.Group,
+			})
+		}
+		wifiNetworks = append(wifiNetworks, WifiNetwork{
+			SSID:     network.SSID,
+			BSSID:    network.BSSID,
+			RSSI:     network.RSSI,
+			Channel:  network.Channel,
+			Security: security,
+			HT:       network.HT,
+		})
+	}
+	return wifiNetworks, nil
+}
+
+// Connect connects the interface to the provided network. Secured
+// networks are joined through CoreWLAN so the security key is passed
+// on stdin rather than as an argument, as are hidden networks so they
+// are scanned for by name.
+func (darwinBackend *DarwinBackend) Connect(iface string, network WifiNetwork) error {
+	if network.SecurityKey == "" && !network.Hidden {
+		return networkSetup.Connect(iface, network.SSID)
+	}
+	RegisterSecret(network.SecurityKey)
+	defer ForgetSecret(network.SecurityKey)
+	return RedactError(coreWLAN.Associate(iface, network.SSID, network.SecurityKey, network.Hidden))
+}
+
+// Disconnect disconnects the interface from the current network
+// without shutting it down
+func (darwinBackend *DarwinBackend) Disconnect(iface string) error {
+	return airport.Disconnect(iface)
+}
+
+// Up turns on the interface
+func (darwinBackend *DarwinBackend) Up(iface string) error {
+	return networkSetup.Up(iface)
+}
+
+// Down turns off the interface
+func (darwinBackend *DarwinBackend) Down(iface string) error {
+	return networkSetup.Down(iface)
+}
+
+// Status returns the power state of the interface
+func (darwinBackend *DarwinBackend) Status(iface string) (bool, error) {
+	return networkSetup.Status(iface)
+}
+
+// State returns the connection state system_profiler reports for the
+// interface
+func (darwinBackend *DarwinBackend) State(iface string) (int, error) {
+	if _, runErr := systemProfiler.Run(networkSetup); runErr != nil {
+		return StateOff, runErr
+	}
+	spInfo, spErr := systemProfiler.Get(iface)
+	if spErr != nil {
+		return StateOff, spErr
+	}
+	switch spInfo.Status {
+	case darwin.IfaceConnected:
+		return StateConnected, nil
+	case darwin.IfaceDisassociated:
+		return StateDisconnected, nil
+	}
+	return StateOff, nil
+}
+
+// BSSID returns the BSSID airport reports the interface is connected
+// to. Mac OS 14 hides it from processes without location access.
+func (darwinBackend *DarwinBackend) BSSID(iface string) (string, error) {
+	info, infoErr := airport.Info(iface)
+	if infoErr != nil {
+		return "", infoErr
+	}
+	return info["BSSID"], nil
+}
+
+// linkState reads the radio power, association and BSSID of the
+// interface from a single airport -I, which polling runs in place of
+// networksetup, system_profiler and a second airport
+func (darwinBackend *DarwinBackend) linkState(iface string) (interfaceSnapshot, error) {
+	info, infoErr := airport.Info(iface)
+	if infoErr != nil {
+		return interfaceSnapshot{}, infoErr
+	}
+	// airport prints nothing but "AirPort: Off" while the radio is off
+	if info["AirPort"] == "Off" {
+		return interfaceSnapshot{}, nil
+	}
+	snapshot := interfaceSnapshot{powered: true, bssid: info["BSSID"]}
+	snapshot.connected = info["state"] == "running" || snapshot.bssid != ""
+	return snapshot, nil
+}
+
+// Diagnose reports on the commands the backend relies on and the
+// version of Mac OS X. airport was removed in Mac OS 14.4, which
+// leaves the backend unusable from that release on.
+func (darwinBackend *DarwinBackend) Diagnose() BackendReport {
+	systemCheck := Check{Name: "Mac OS X", Kind: CheckService}
+	systemVersion, versionErr := darwin.SystemVersion()
+	if versionErr == nil {
+		systemCheck.Present = true
+		systemCheck.Version = systemVersion
+	}
+	report := BackendReport{Checks: []Check{
+		systemCheck,
+		commandCheck("airport", airport.IsInstalled(), true, nil),
+		commandCheck("networksetup", networkSetup.IsInstalled(), true, nil),
+		commandCheck("system_profiler", systemProfiler.IsInstalled(), true, nil),
+		commandCheck("osascript", coreWLAN.IsInstalled(), true, nil),
+	}}
+	report.Problems, report.Usable = missingRequired(report.Checks)
+	// airport --disassociate needs root
+	report.Privileged = report.Usable && os.Geteuid() == 0
+	if report.Usable && !report.Privileged {
+		report.Problems = append(report.Problems, "disconnecting with airport needs root")
+	}
+	report.Features = Features{
+		Scan:               report.Usable,
+		Connect:            report.Usable,
+		EnterpriseProfiles: report.Usable,
+	}
+	return report
+}
@@ -0,0 +1,88 @@
+package wifimanager
+
+import (
+	"errors"
+	"os"
+)
+
+const (
+	// BackendEnv is the environment variable that overrides backend
+	// detection with the name of a backend
+	BackendEnv = "WIFIMGR_BACKEND"
+)
+
+var (
+	// ErrUnknownBackend is returned when selecting a backend by a name
+	// that doesn't exist
+	ErrUnknownBackend = errors.New("wifi: unknown backend")
+	// ErrNoBackend is returned when detection finds no usable backend
+	ErrNoBackend = errors.New("wifi: no usable backend found")
+
+	// backendProbes holds the constructors of the built in backends in
+	// order of preference, daemons first so that a running one is
+	// never bypassed
+	backendProbes = []struct {
+		name       string
+		newBackend func() Backend
+	}{
+		{"iwd", func() Backend { return NewIWDBackend() }},
+		{"networkmanager", func() Backend { return NewNetworkManagerBackend() }},
+		{"wpa_supplicant", func() Backend { return NewWPASupplicantBackend() }},
+		{"nl80211", func() Backend { return NewNL80211Backend() }},
+		{"iw", func() Backend { return NewIWBackend() }},
+		{"darwin", func() Backend { return NewDarwinBackend() }},
+	}
+)
+
+// BackendNames returns the names of the built in backends in order of
+// preference
+func BackendNames() []string {
+	names := []string{}
+	for _, probe := range backendProbes {
+		names = append(names, probe.name)
+	}
+	return names
+}
+
+// NewBackend returns the built in backend with the provided name
+func NewBackend(name string) (Backend, error) {
+	for _, probe := range backendProbes {
+		if probe.name == name {
+			return probe.newBackend(), nil
+		}
+	}
+	return nil, ErrUnknownBackend
+}
+
+// SelectBackend returns the built in backend with the provided name, or
+// the detected one when the name is empty
+func SelectBackend(name string) (Backend, error) {
+	if name != "" {
+		return NewBackend(name)
+	}
+	return DetectBackend()
+}
+
+// DetectBackend returns the backend named by the WIFIMGR_BACKEND
+// environment variable, or else the one Diagnose recommends out of the
+// built in backends. iwd, NetworkManager and wpa_supplicant are only
+// usable while their daemon runs.
+func DetectBackend() (Backend, error) {
+	if name := os.Getenv(BackendEnv); name != "" {
+		return NewBackend(name)
+	}
+	diagnosis := Diagnose()
+	if diagnosis.Recommended == "" {
+		return nil, ErrNoBackend
+	}
+	return NewBackend(diagnosis.Recommended)
+}
+
+// knownBackends returns the built in backends in order of preference
+func knownBackends() []Backend {
+	backends := []Backend{}
+	for _, probe := range backendProbes {
+		backends = append(backends, probe.newBackend())
+	}
+	return backends
+}
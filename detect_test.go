@@ -0,0 +1,60 @@
+package wifimanager
+
+import (
+	"testing"
+)
+
+func TestNewBackendNames(t *testing.T) {
+	for _, name := range BackendNames() {
+		backend, backendErr := NewBackend(name)
+		if backendErr != nil {
+			t.Errorf("NewBackend(%q) error = %v", name, backendErr)
+			continue
+		}
+		if backend.Name() != name {
+			t.Errorf("NewBackend(%q).Name() = %q", name, backend.Name())
+		}
+	}
+}
+
+func TestSelectBackendOverride(t *testing.T) {
+	tests := []struct {
+		env     string
+		name    string
+		backend string
+		err     error
+	}{
+		{env: "iw", backend: "iw"},
+		{env: "wpa_supplicant", backend: "wpa_supplicant"},
+		{env: "iw", name: "nl80211", backend: "nl80211"},
+		{env: "bogus", err: ErrUnknownBackend},
+		{env: "iw", name: "bogus", err: ErrUnknownBackend},
+		{env: "bogus", name: "iw", backend: "iw"},
+	}
+	for _, test := range tests {
+		t.Setenv(BackendEnv, test.env)
+		backend, selectErr := SelectBackend(test.name)
+		if selectErr != test.err {
+			t.Errorf("%s=%s SelectBackend(%q) error = %v, want %v", BackendEnv, test.env, test.name, selectErr, test.err)
+			continue
+		}
+		if backend != nil && backend.Name() != test.backend {
+			t.Errorf("%s=%s SelectBackend(%q) = %s, want %s", BackendEnv, test.env, test.name, backend.Name(), test.backend)
+		}
+	}
+}
+
+func TestDetectBackendOverride(t *testing.T) {
+	t.Setenv(BackendEnv, "networkmanager")
+	backend, detectErr := DetectBackend()
+	if detectErr != nil {
+		t.Fatal(detectErr)
+	}
+	if backend.Name() != "networkmanager" {
+		t.Errorf("DetectBackend() = %s, want networkmanager", backend.Name())
+	}
+	t.Setenv(BackendEnv, "bogus")
+	if _, detectErr = DetectBackend(); detectErr != ErrUnknownBackend {
+		t.Errorf("DetectBackend() with %s=bogus error = %v, want %v", BackendEnv, detectErr, ErrUnknownBackend)
+	}
+}
@@ -0,0 +1,151 @@
+package wifimanager
+
+import (
+	"os"
+)
+
+const (
+	// CheckCommand is an executable looked up in PATH
+	CheckCommand int = iota
+	// CheckSocket is a control socket or socket directory
+	CheckSocket
+	// CheckService is a running system service such as a D-Bus daemon
+	CheckService
+	// CheckKernel is a kernel interface such as a netlink family
+	CheckKernel
+)
+
+// Check is the result of looking for a single tool, socket or service
+// a backend relies on
+type Check struct {
+	Name     string
+	Kind     int
+	Present  bool
+	Required bool
+	Version  string
+	Detail   string
+}
+
+// Features lists what a backend can do on this system
+type Features struct {
+	Scan    bool
+	Connect bool
+	Hotspot bool
+	// Enterprise is set when Connect can join 802.1X networks, which
+	// only wpa_supplicant does, with the network blocks set up through
+	// the wpaconf package
+	Enterprise bool
+	// EnterpriseProfiles is set when 802.1X networks can be set up by
+	// installing a profile in the format the backend reads, as written
+	// by the wpaconf, nmkeyfile and mobileconfig packages, but not
+	// joined through Connect
+	EnterpriseProfiles bool
+}
+
+// BackendReport describes the state of a single backend. Usable is set
+// when everything Required is present, Privileged when the process may
+// perform every operation without asking for more rights.
+type BackendReport struct {
+	Name       string
+	Usable     bool
+	Privileged bool
+	Checks     []Check
+	Features   Features
+	Interfaces []string
+	Problems   []string
+}
+
+// Diagnosis is the report built by Diagnose
+type Diagnosis struct {
+	Root        bool
+	Backends    []BackendReport
+	Recommended string
+}
+
+// DiagnosticBackend is implemented by backends that can report on the
+// tools and rights they depend on
+type DiagnosticBackend interface {
+	Diagnose() BackendReport
+}
+
+// Diagnose reports on every provided backend, or on all the built in
+// ones when none are provided, and recommends the first backend that
+// is usable, privileged, able to scan and connect and has interfaces.
+// Failing that, the first usable backend with interfaces is
+// recommended.
+func Diagnose(backends ...Backend) Diagnosis {
+	if len(backends) == 0 {
+		backends = knownBackends()
+	}
+	diagnosis := Diagnosis{Root: os.Geteuid() == 0}
+	for _, backend := range backends {
+		diagnosis.Backends = append(diagnosis.Backends, diagnoseBackend(backend))
+	}
+	for _, report := range diagnosis.Backends {
+		if report.Usable && report.Privileged && report.Features.Scan && report.Features.Connect && len(report.Interfaces) > 0 {
+			diagnosis.Recommended = report.Name
+			return diagnosis
+		}
+	}
+	for _, report := range diagnosis.Backends {
+		if report.Usable && len(report.Interfaces) > 0 {
+			diagnosis.Recommended = report.Name
+			return diagnosis
+		}
+	}
+	return diagnosis
+}
+
+// diagnoseBackend reports on the backend and lists its interfaces.
+// Backends that can't report on themselves are judged by IsInstalled.
+func diagnoseBackend(backend Backend) BackendReport {
+	report := BackendReport{}
+	if diagnosticBackend, ok := backend.(DiagnosticBackend); ok {
+		report = diagnosticBackend.Diagnose()
+	} else {
+		report.Usable = backend.IsInstalled()
+		report.Privileged = report.Usable
+		report.Features = Features{Scan: report.Usable, Connect: report.Usable}
+	}
+	report.Name = backend.Name()
+	if !report.Usable {
+		return report
+	}
+	wifiInterfaces, ifaceErr := backend.Interfaces()
+	if ifaceErr != nil {
+		report.Problems = append(report.Problems, "listing interfaces failed: "+ifaceErr.Error())
+	}
+	for _, wifiInterface := range wifiInterfaces {
+		report.Interfaces = append(report.Interfaces, wifiInterface.Name)
+	}
+	if ifaceErr == nil && len(wifiInterfaces) == 0 {
+		report.Problems = append(report.Problems, "no WiFi interfaces found")
+	}
+	return report
+}
+
+// commandCheck checks for a command, asking for its version when it is
+// present
+func commandCheck(name string, installed bool, required bool, version func() (string, error)) Check {
+	check := Check{Name: name, Kind: CheckCommand, Present: installed, Required: required}
+	if !installed {
+		check.Detail = "not found"
+		return check
+	}
+	if version != nil {
+		check.Version, _ = version()
+	}
+	return check
+}
+
+// missingRequired returns a problem for every required check that
+// failed, and whether or not all of them passed
+func missingRequired(checks []Check) ([]string, bool) {
+	problems := []string{}
+	for _, check := range checks {
+		if check.Required && !check.Present {
+			problems = append(problems, check.Name+" is missing")
+		}
+	}
+	return problems, len(problems) == 0
+}
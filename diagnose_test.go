@@ -0,0 +1,92 @@
+package wifimanager
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+// diagnosticBackend reports a fixed diagnosis and interfaces
+type diagnosticBackend struct {
+	recordingBackend
+	name       string
+	report     BackendReport
+	interfaces []string
+}
+
+func (backend *diagnosticBackend) Name() string { return backend.name }
+
+func (backend *diagnosticBackend) Diagnose() BackendReport { return backend.report }
+
+func (backend *diagnosticBackend) Interfaces() ([]WifiInterface, error) {
+	wifiInterfaces := []WifiInterface{}
+	for _, name := range backend.interfaces {
+		wifiInterfaces = append(wifiInterfaces, WifiInterface{Interface: net.Interface{Name: name}, backend: backend})
+	}
+	return wifiInterfaces, nil
+}
+
+func TestDiagnoseRecommended(t *testing.T) {
+	capable := Features{Scan: true, Connect: true}
+	unusable := &diagnosticBackend{name: "unusable", interfaces: []string{"wlan0"}}
+	empty := &diagnosticBackend{name: "empty", report: BackendReport{Usable: true, Privileged: true, Features: capable}}
+	unprivileged := &diagnosticBackend{name: "unprivileged", report: BackendReport{Usable: true, Features: capable}, interfaces: []string{"wlan0"}}
+	unprivileged2 := &diagnosticBackend{name: "unprivileged2", report: BackendReport{Usable: true, Features: capable}, interfaces: []string{"wlan0"}}
+	scanOnly := &diagnosticBackend{name: "scan-only", report: BackendReport{Usable: true, Privileged: true, Features: Features{Scan: true}}, interfaces: []string{"wlan0"}}
+	privileged := &diagnosticBackend{name: "privileged", report: BackendReport{Usable: true, Privileged: true, Features: capable}, interfaces: []string{"wlan0"}}
+	tests := []struct {
+		backends    []Backend
+		recommended string
+	}{
+		{backends: []Backend{unusable, empty, unprivileged, privileged}, recommended: "privileged"},
+		{backends: []Backend{unprivileged, scanOnly, privileged}, recommended: "privileged"},
+		{backends: []Backend{unusable, empty, unprivileged, unprivileged2}, recommended: "unprivileged"},
+		{backends: []Backend{scanOnly, unprivileged}, recommended: "scan-only"},
+		{backends: []Backend{unusable, empty}, recommended: ""},
+	}
+	for _, test := range tests {
+		diagnosis := Diagnose(test.backends...)
+		names := []string{}
+		for _, report := range diagnosis.Backends {
+			names = append(names, report.Name)
+		}
+		if diagnosis.Recommended != test.recommended {
+			t.Errorf("Diagnose(%v) recommended %q, want %q", names, diagnosis.Recommended, test.recommended)
+		}
+	}
+}
+
+func TestDiagnoseInterfaces(t *testing.T) {
+	unusable := &diagnosticBackend{name: "unusable", interfaces: []string{"wlan0"}}
+	empty := &diagnosticBackend{name: "empty", report: BackendReport{Usable: true}}
+	present := &diagnosticBackend{name: "present", report: BackendReport{Usable: true}, interfaces: []string{"wlan0", "wlan1"}}
+	diagnosis := Diagnose(unusable, empty, present, &recordingBackend{})
+	if len(diagnosis.Backends) != 4 {
+		t.Fatalf("Diagnose returned %d reports, want 4", len(diagnosis.Backends))
+	}
+	tests := []struct {
+		report     BackendReport
+		name       string
+		interfaces []string
+		problems   []string
+	}{
+		{report: diagnosis.Backends[0], name: "unusable"},
+		{report: diagnosis.Backends[1], name: "empty", problems: []string{"no WiFi interfaces found"}},
+		{report: diagnosis.Backends[2], name: "present", interfaces: []string{"wlan0", "wlan1"}},
+		{report: diagnosis.Backends[3], name: "recording", interfaces: []string{"wlan0"}},
+	}
+	for _, test := range tests {
+		if test.report.Name != test.name {
+			t.Errorf("report name = %q, want %q", test.report.Name, test.name)
+		}
+		if !reflect.DeepEqual(test.report.Interfaces, test.interfaces) {
+			t.Errorf("%s: interfaces = %v, want %v", test.name, test.report.Interfaces, test.interfaces)
+		}
+		if !reflect.DeepEqual(test.report.Problems, test.problems) {
+			t.Errorf("%s: problems = %v, want %v", test.name, test.report.Problems, test.problems)
+		}
+	}
+	if recording := diagnosis.Backends[3]; !recording.Usable || !recording.Privileged || !recording.Features.Connect {
+		t.Errorf("report without Diagnose = %+v, want usable, privileged and able to connect", recording)
+	}
+}
@@ -0,0 +1,140 @@
+package dpp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"net"
+)
+
+var (
+	// ErrUnsupportedKey is returned when a bootstrapping key isn't an
+	// EC key on one of the NIST curves
+	ErrUnsupportedKey = errors.New("dpp: unsupported public key")
+
+	oidPublicKeyEC = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	// curveOIDs maps the curves DPP allows, besides the brainpool ones
+	// Go doesn't implement, to their named curve identifiers
+	curveOIDs = map[elliptic.Curve]asn1.ObjectIdentifier{
+		elliptic.P256(): {1, 2, 840, 10045, 3, 1, 7},
+		elliptic.P384(): {1, 3, 132, 0, 34},
+		elliptic.P521(): {1, 3, 132, 0, 35},
+	}
+)
+
+// subjectPublicKeyInfo is the ASN.1 structure of a public key
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// Bootstrap represents this device's bootstrapping information: its
+// key pair and the details advertised alongside the public key. The
+// key has to be kept across restarts, as the URI of a printed QR code
+// can't change.
+type Bootstrap struct {
+	Key      *ecdsa.PrivateKey
+	Channels []Channel
+	MAC      net.HardwareAddr
+	Info     string
+}
+
+// NewBootstrap creates bootstrapping information with a new P-256
+// key pair
+func NewBootstrap() (*Bootstrap, error) {
+	key, keyErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if keyErr != nil {
+		return nil, keyErr
+	}
+	return &Bootstrap{Key: key}, nil
+}
+
+// URI returns the bootstrapping URI to show in the device's QR code
+func (bootstrap *Bootstrap) URI() (URI, error) {
+	key, keyErr := MarshalPublicKey(&bootstrap.Key.PublicKey)
+	if keyErr != nil {
+		return URI{}, keyErr
+	}
+	return URI{
+		Channels: bootstrap.Channels,
+		MAC:      bootstrap.MAC,
+		Info:     bootstrap.Info,
+		Key:      key,
+	}, nil
+}
+
+// MarshalPrivateKey returns the DER encoded EC private key, the form
+// wpa_supplicant accepts for DPP_BOOTSTRAP_GEN
+func (bootstrap *Bootstrap) MarshalPrivateKey() ([]byte, error) {
+	return x509.MarshalECPrivateKey(bootstrap.Key)
+}
+
+// MarshalPublicKey returns the DER encoded SubjectPublicKeyInfo with
+// the point in compressed form, which is how DPP URIs carry keys
+func MarshalPublicKey(key *ecdsa.PublicKey) ([]byte, error) {
+	curveOID, known := curveOIDs[key.Curve]
+	if !known {
+		return nil, ErrUnsupportedKey
+	}
+	parameters, paramErr := asn1.Marshal(curveOID)
+	if paramErr != nil {
+		return nil, paramErr
+	}
+	point := elliptic.MarshalCompressed(key.Curve, key.X, key.Y)
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyEC,
+			Parameters: asn1.RawValue{FullBytes: parameters},
+		},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: len(point) * 8},
+	})
+}
+
+// PublicKey decodes the bootstrapping public key of the URI, which
+// may carry its point in compressed or uncompressed form
+func (uri URI) PublicKey() (*ecdsa.PublicKey, error) {
+	var info subjectPublicKeyInfo
+	rest, parseErr := asn1.Unmarshal(uri.Key, &info)
+	if parseErr != nil || len(rest) > 0 || !info.Algorithm.Algorithm.Equal(oidPublicKeyEC) {
+		return nil, ErrUnsupportedKey
+	}
+	var curveOID asn1.ObjectIdentifier
+	_, curveErr := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &curveOID)
+	if curveErr != nil {
+		return nil, ErrUnsupportedKey
+	}
+	point := info.PublicKey.RightAlign()
+	for curve, oid := range curveOIDs {
+		if !oid.Equal(curveOID) {
+			continue
+		}
+		if len(point) > 0 && (point[0] == 2 || point[0] == 3) {
+			x, y := elliptic.UnmarshalCompressed(curve, point)
+			if x == nil {
+				return nil, ErrUnsupportedKey
+			}
+			return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+		}
+		key, keyErr := x509.ParsePKIXPublicKey(uri.Key)
+		if keyErr != nil {
+			return nil, ErrUnsupportedKey
+		}
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, ErrUnsupportedKey
+		}
+		return ecKey, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// Hash returns the bootstrapping key hash, the SHA-256 digest of the
+// DER encoded public key that DPP uses to tell peers apart
+func (uri URI) Hash() [sha256.Size]byte {
+	return sha256.Sum256(uri.Key)
+}
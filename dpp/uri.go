@@ -0,0 +1,147 @@
+package dpp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// URIScheme is the prefix of every DPP bootstrapping URI
+	URIScheme = "DPP:"
+)
+
+var (
+	// ErrInvalidURI is returned when a URI isn't a DPP bootstrapping URI
+	ErrInvalidURI = errors.New("dpp: invalid DPP: uri")
+	// ErrMissingKey is returned when a URI has no K: field
+	ErrMissingKey = errors.New("dpp: uri has no public key")
+)
+
+// Channel represents an entry of the C: channel list, which names a
+// channel by its global operating class and channel number
+type Channel struct {
+	Class   int
+	Channel int
+}
+
+// URI represents a DPP bootstrapping URI, as shown in a device's QR
+// code. Key holds the DER encoded SubjectPublicKeyInfo of the device's
+// bootstrapping key exactly as it appears in the URI. Fields holds any
+// other fields, such as V: or H:, so they survive a round trip.
+type URI struct {
+	Channels []Channel
+	MAC      net.HardwareAddr
+	Info     string
+	Key      []byte
+	Fields   map[string]string
+}
+
+// ParseURI parses a DPP:C:81/1,115/36;M:5254005828e5;I:SN=4774;K:...;;
+// URI, checking that the public key is one we can use
+func ParseURI(uri string) (URI, error) {
+	parsed := URI{Fields: map[string]string{}}
+	if !strings.HasPrefix(uri, URIScheme) || !strings.HasSuffix(uri, ";;") {
+		return parsed, ErrInvalidURI
+	}
+	body := strings.TrimSuffix(uri[len(URIScheme):], ";")
+	for _, field := range strings.Split(body, ";") {
+		if field == "" {
+			continue
+		}
+		pair := strings.SplitN(field, ":", 2)
+		if len(pair) != 2 {
+			return parsed, ErrInvalidURI
+		}
+		switch pair[0] {
+		case "C":
+			channels, channelErr := parseChannels(pair[1])
+			if channelErr != nil {
+				return parsed, channelErr
+			}
+			parsed.Channels = channels
+		case "M":
+			mac, macErr := hex.DecodeString(strings.Replace(pair[1], ":", "", -1))
+			if macErr != nil || len(mac) != 6 {
+				return parsed, fmt.Errorf("dpp: invalid mac address %q", pair[1])
+			}
+			parsed.MAC = net.HardwareAddr(mac)
+		case "I":
+			parsed.Info = pair[1]
+		case "K":
+			key, keyErr := base64.StdEncoding.DecodeString(pair[1])
+			if keyErr != nil {
+				return parsed, fmt.Errorf("dpp: invalid public key encoding: %v", keyErr)
+			}
+			parsed.Key = key
+		default:
+			parsed.Fields[pair[0]] = pair[1]
+		}
+	}
+	if len(parsed.Key) == 0 {
+		return parsed, ErrMissingKey
+	}
+	_, keyErr := parsed.PublicKey()
+	if keyErr != nil {
+		return parsed, keyErr
+	}
+	return parsed, nil
+}
+
+// String returns the URI representation
+func (uri URI) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(URIScheme)
+	if len(uri.Channels) > 0 {
+		channels := []string{}
+		for _, channel := range uri.Channels {
+			channels = append(channels, channel.String())
+		}
+		buffer.WriteString("C:" + strings.Join(channels, ",") + ";")
+	}
+	if len(uri.MAC) > 0 {
+		buffer.WriteString("M:" + hex.EncodeToString(uri.MAC) + ";")
+	}
+	if uri.Info != "" {
+		buffer.WriteString("I:" + uri.Info + ";")
+	}
+	names := []string{}
+	for name := range uri.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		buffer.WriteString(name + ":" + uri.Fields[name] + ";")
+	}
+	buffer.WriteString("K:" + base64.StdEncoding.EncodeToString(uri.Key) + ";;")
+	return buffer.String()
+}
+
+// String returns the class/channel form of the channel
+func (channel Channel) String() string {
+	return strconv.Itoa(channel.Class) + "/" + strconv.Itoa(channel.Channel)
+}
+
+// parseChannels parses the comma separated class/channel list
+func parseChannels(list string) ([]Channel, error) {
+	channels := []Channel{}
+	for _, item := range strings.Split(list, ",") {
+		pair := strings.SplitN(item, "/", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("dpp: invalid channel %q", item)
+		}
+		class, classErr := strconv.Atoi(pair[0])
+		number, numberErr := strconv.Atoi(pair[1])
+		if classErr != nil || numberErr != nil {
+			return nil, fmt.Errorf("dpp: invalid channel %q", item)
+		}
+		channels = append(channels, Channel{Class: class, Channel: number})
+	}
+	return channels, nil
+}
@@ -0,0 +1,125 @@
+package dpp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"reflect"
+	"testing"
+)
+
+const (
+	// specURI is the example bootstrapping URI of the Easy Connect
+	// specification, its key point in compressed form
+	specURI = "DPP:C:81/1,115/36;M:5254005828e5;I:SN=4774LH2b4044;K:MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgADM2206avxHJaHXgLMkq/24e0rsrfMP9K1Tm8gx+ovP0I=;;"
+	// minimalURI only carries the key
+	minimalURI = "DPP:K:MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgADM2206avxHJaHXgLMkq/24e0rsrfMP9K1Tm8gx+ovP0I=;;"
+)
+
+func TestParseURI(t *testing.T) {
+	parsed, parseErr := ParseURI(specURI)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	if want := []Channel{{Class: 81, Channel: 1}, {Class: 115, Channel: 36}}; !reflect.DeepEqual(parsed.Channels, want) {
+		t.Errorf("channels = %v, want %v", parsed.Channels, want)
+	}
+	if parsed.MAC.String() != "52:54:00:58:28:e5" || parsed.Info != "SN=4774LH2b4044" {
+		t.Errorf("mac %s info %q", parsed.MAC, parsed.Info)
+	}
+	key, keyErr := parsed.PublicKey()
+	if keyErr != nil || key.Curve != elliptic.P256() {
+		t.Fatalf("public key = %v, %v", key, keyErr)
+	}
+	for _, uri := range []string{specURI, minimalURI, "DPP:V:2;K:MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgADM2206avxHJaHXgLMkq/24e0rsrfMP9K1Tm8gx+ovP0I=;;"} {
+		parsed, parseErr := ParseURI(uri)
+		if parseErr != nil {
+			t.Fatalf("%s: %v", uri, parseErr)
+		}
+		if parsed.String() != uri {
+			t.Errorf("String() = %s, want %s", parsed.String(), uri)
+		}
+	}
+}
+
+func TestParseURIUncompressedKey(t *testing.T) {
+	private, generateErr := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if generateErr != nil {
+		t.Fatal(generateErr)
+	}
+	uncompressed, marshalErr := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	parsed, parseErr := ParseURI("DPP:K:" + base64.StdEncoding.EncodeToString(uncompressed) + ";;")
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	key, keyErr := parsed.PublicKey()
+	if keyErr != nil || !key.Equal(&private.PublicKey) {
+		t.Fatalf("public key = %v, %v", key, keyErr)
+	}
+
+	// MarshalPublicKey compresses the same point, which still decodes
+	// to the same key
+	compressed, compressErr := MarshalPublicKey(&private.PublicKey)
+	if compressErr != nil {
+		t.Fatal(compressErr)
+	}
+	if len(compressed) >= len(uncompressed) {
+		t.Errorf("compressed key is %d bytes, uncompressed %d", len(compressed), len(uncompressed))
+	}
+	key, keyErr = URI{Key: compressed}.PublicKey()
+	if keyErr != nil || !key.Equal(&private.PublicKey) {
+		t.Fatalf("compressed public key = %v, %v", key, keyErr)
+	}
+}
+
+func TestBootstrapURI(t *testing.T) {
+	bootstrap, newErr := NewBootstrap()
+	if newErr != nil {
+		t.Fatal(newErr)
+	}
+	bootstrap.Channels = []Channel{{Class: 81, Channel: 6}}
+	bootstrap.MAC = net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+	uri, uriErr := bootstrap.URI()
+	if uriErr != nil {
+		t.Fatal(uriErr)
+	}
+	parsed, parseErr := ParseURI(uri.String())
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	key, keyErr := parsed.PublicKey()
+	if keyErr != nil || !key.Equal(&bootstrap.Key.PublicKey) {
+		t.Fatalf("public key = %v, %v", key, keyErr)
+	}
+	if parsed.Hash() != uri.Hash() {
+		t.Error("hash changed in the round trip")
+	}
+}
+
+func TestParseURIInvalid(t *testing.T) {
+	const key = "K:MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgADM2206avxHJaHXgLMkq/24e0rsrfMP9K1Tm8gx+ovP0I="
+	tests := map[string]error{
+		"WIFI:S:home;;":                ErrInvalidURI,
+		"DPP:" + key + ";":             ErrInvalidURI,
+		"DPP:C:81/1;;":                 ErrMissingKey,
+		"DPP:K:MDkwEwYHKoZIzj0CAQ==;;": ErrUnsupportedKey,
+		"DPP:M:52540058;" + key + ";;": nil,
+		"DPP:C:81-1;" + key + ";;":     nil,
+		"DPP:C:81/x;" + key + ";;":     nil,
+	}
+	for uri, want := range tests {
+		_, parseErr := ParseURI(uri)
+		if parseErr == nil || (want != nil && parseErr != want) {
+			t.Errorf("%s: error = %v, want %v", uri, parseErr, want)
+		}
+	}
+	if _, marshalErr := MarshalPublicKey(&ecdsa.PublicKey{Curve: elliptic.P224()}); marshalErr != ErrUnsupportedKey {
+		t.Errorf("P-224 key error = %v, want ErrUnsupportedKey", marshalErr)
+	}
+}
@@ -0,0 +1,474 @@
+package wifimanager
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ottopress/WifiManager/linux"
+)
+
+const (
+	// InterfaceEventPower is sent when an interface was turned on or
+	// off. Polled backends report the power of the radio, the others
+	// the IFF_UP flag of the link, which iwd, NetworkManager and
+	// wpa_supplicant clear along with the radio.
+	InterfaceEventPower int = iota
+	// InterfaceEventAssociate is sent when an interface joined a BSS
+	InterfaceEventAssociate
+	// InterfaceEventDisassociate is sent when an interface left its BSS
+	InterfaceEventDisassociate
+	// InterfaceEventRoam is sent when an interface moved to another BSS
+	// of the same network
+	InterfaceEventRoam
+	// InterfaceEventAddress is sent when the IP addresses of an
+	// interface changed
+	InterfaceEventAddress
+	// InterfaceEventAdded is sent when an interface appeared
+	InterfaceEventAdded
+	// InterfaceEventRemoved is sent when an interface went away
+	InterfaceEventRemoved
+)
+
+var (
+	// EventPollInterval is how often the state of an interface is
+	// compared with the last one seen when its backend can't push
+	// events
+	EventPollInterval = 2 * time.Second
+
+	// interfaceEventNames are the names of the event types used by
+	// InterfaceEventName
+	interfaceEventNames = map[int]string{
+		InterfaceEventPower:        "power",
+		InterfaceEventAssociate:    "associate",
+		InterfaceEventDisassociate: "disassociate",
+		InterfaceEventRoam:         "roam",
+		InterfaceEventAddress:      "address",
+		InterfaceEventAdded:        "added",
+		InterfaceEventRemoved:      "removed",
+	}
+
+	// kernelLink and kernelWireless listen to the rtnetlink and nl80211
+	// multicast groups of the kernel for the Linux backends
+	kernelLink     = linux.NewRTNetlink()
+	kernelWireless = linux.NewNL80211()
+
+	// wpaConnectedRE and wpaDisconnectedRE match the wpa_supplicant
+	// events of an interface joining and leaving a BSS
+	wpaConnectedRE    = regexp.MustCompile(`^CTRL-EVENT-CONNECTED - Connection to ([0-9a-fA-F:]{17}) completed`)
+	wpaDisconnectedRE = regexp.MustCompile(`^CTRL-EVENT-DISCONNECTED bssid=([0-9a-fA-F:]{17}) reason=([0-9]+)`)
+)
+
+// InterfaceEvent is a change of an interface reported by the system
+// rather than made through this package. BSSID is set on association
+// changes, ReasonCode on disassociations when the system tells it, and
+// Addresses holds every address of the interface after an address
+// change.
+type InterfaceEvent struct {
+	Type       int
+	Interface  string
+	Time       time.Time
+	Powered    bool
+	BSSID      string
+	ReasonCode int
+	Addresses  []net.IPNet
+}
+
+// EventBackend is implemented by backends that are told about the
+// changes of an interface as they happen. Events are delivered until
+// the returned function is called, after which the channel is closed.
+type EventBackend interface {
+	Subscribe(iface string) (<-chan InterfaceEvent, func(), error)
+}
+
+// linkStateBackend is implemented by backends that read the power,
+// association and BSSID of an interface with a single command, which
+// polling prefers over asking for each of them
+type linkStateBackend interface {
+	linkState(iface string) (interfaceSnapshot, error)
+}
+
+// bssidBackend is implemented by backends that can tell the BSSID an
+// interface is associated with, which lets polling notice roaming
+type bssidBackend interface {
+	BSSID(iface string) (string, error)
+}
+
+// interfaceSnapshot is what polling compares to find changes
+type interfaceSnapshot struct {
+	present   bool
+	powered   bool
+	connected bool
+	bssid     string
+	addresses []net.IPNet
+}
+
+// eventStream merges the events of several sources into one channel,
+// which is closed once every source is done
+type eventStream struct {
+	events chan InterfaceEvent
+	done   chan struct{}
+	once   sync.Once
+	group  sync.WaitGroup
+}
+
+// InterfaceEventName returns the name of the interface event type
+func InterfaceEventName(eventType int) string {
+	return interfaceEventNames[eventType]
+}
+
+// Subscribe returns a channel receiving the power, association,
+// roaming, address and hotplug events of the WiFi interface, and a
+// function that stops the subscription and closes the channel.
+// Backends that can't push events, such as the Mac OS X one, or that
+// fail to subscribe are polled every EventPollInterval.
+func (wifiInterface *WifiInterface) Subscribe() (<-chan InterfaceEvent, func(), error) {
+	backend := wifiInterface.Backend()
+	if eventBackend, ok := backend.(EventBackend); ok {
+		events, stop, subscribeErr := eventBackend.Subscribe(wifiInterface.Name)
+		if subscribeErr == nil {
+			return events, stop, nil
+		}
+	}
+	return pollEvents(backend, wifiInterface.Name)
+}
+
+// pollEvents sends the differences between snapshots of the interface
+// taken every EventPollInterval. Ticks that pass while a snapshot is
+// still being taken are skipped rather than queued up.
+func pollEvents(backend Backend, iface string) (<-chan InterfaceEvent, func(), error) {
+	stream := newEventStream()
+	poller := &snapshotPoller{backend: backend, iface: iface}
+	last := poller.take()
+	stream.run(func() {
+		ticker := time.NewTicker(EventPollInterval)
+		defer ticker.Stop()
+		snapshots := make(chan interfaceSnapshot, 1)
+		busy := false
+		for {
+			select {
+			case <-stream.done:
+				return
+			case <-ticker.C:
+				if busy {
+					continue
+				}
+				busy = true
+				go func() { snapshots <- poller.take() }()
+			case current := <-snapshots:
+				busy = false
+				for _, event := range diffSnapshots(iface, last, current) {
+					if !stream.send(event) {
+						return
+					}
+				}
+				last = current
+			}
+		}
+	})
+	return stream.subscription()
+}
+
+// snapshotPoller takes snapshots of an interface. The interface list
+// of the backend, which takes system_profiler on Mac OS X, is only
+// read again once the interface went away or was replaced.
+type snapshotPoller struct {
+	backend Backend
+	iface   string
+	cached  *net.Interface
+}
+
+// take reads the state of the interface from the backend
+func (poller *snapshotPoller) take() interfaceSnapshot {
+	snapshot := interfaceSnapshot{}
+	netInterface, netErr := net.InterfaceByName(poller.iface)
+	if netErr != nil || poller.cached == nil || netInterface.Index != poller.cached.Index {
+		netInterface = poller.lookup()
+	}
+	if netInterface == nil {
+		return snapshot
+	}
+	snapshot.present = true
+	snapshot.addresses = interfaceAddresses(netInterface)
+	if stateSource, ok := poller.backend.(linkStateBackend); ok {
+		state, _ := stateSource.linkState(poller.iface)
+		snapshot.powered, snapshot.connected, snapshot.bssid = state.powered, state.connected, state.bssid
+		return snapshot
+	}
+	snapshot.powered, _ = poller.backend.Status(poller.iface)
+	if stateBackend, ok := poller.backend.(StateBackend); ok {
+		state, _ := stateBackend.State(poller.iface)
+		snapshot.connected = state == StateConnected
+	}
+	if bssidSource, ok := poller.backend.(bssidBackend); ok {
+		snapshot.bssid, _ = bssidSource.BSSID(poller.iface)
+		snapshot.connected = snapshot.connected || snapshot.bssid != ""
+	}
+	return snapshot
+}
+
+// lookup finds the interface in the interface list of the backend
+func (poller *snapshotPoller) lookup() *net.Interface {
+	poller.cached = nil
+	wifiInterfaces, ifaceErr := poller.backend.Interfaces()
+	if ifaceErr != nil {
+		return nil
+	}
+	for index := range wifiInterfaces {
+		if wifiInterfaces[index].Name == poller.iface {
+			poller.cached = &wifiInterfaces[index].Interface
+		}
+	}
+	return poller.cached
+}
+
+// diffSnapshots returns the events that lead from one snapshot of the
+// interface to the next
+func diffSnapshots(iface string, last, current interfaceSnapshot) []InterfaceEvent {
+	now := time.Now()
+	events := []InterfaceEvent{}
+	if last.present != current.present {
+		eventType := InterfaceEventRemoved
+		if current.present {
+			eventType = InterfaceEventAdded
+		}
+		events = append(events, InterfaceEvent{Type: eventType, Interface: iface, Time: now, Powered: current.powered})
+		if !current.present {
+			return events
+		}
+	}
+	if last.powered != current.powered {
+		events = append(events, InterfaceEvent{Type: InterfaceEventPower, Interface: iface, Time: now, Powered: current.powered})
+	}
+	switch {
+	case !last.connected && current.connected:
+		events = append(events, InterfaceEvent{Type: InterfaceEventAssociate, Interface: iface, Time: now, BSSID: current.bssid})
+	case last.connected && !current.connected:
+		events = append(events, InterfaceEvent{Type: InterfaceEventDisassociate, Interface: iface, Time: now, BSSID: last.bssid})
+	case current.connected && last.bssid != "" && current.bssid != "" && last.bssid != current.bssid:
+		events = append(events, InterfaceEvent{Type: InterfaceEventRoam, Interface: iface, Time: now, BSSID: current.bssid})
+	}
+	if !sameAddresses(last.addresses, current.addresses) {
+		events = append(events, InterfaceEvent{Type: InterfaceEventAddress, Interface: iface, Time: now, Addresses: current.addresses})
+	}
+	return events
+}
+
+// netlinkEvents sends the power, address and hotplug events rtnetlink
+// reports for the interface and, when association is set, the
+// association events of the nl80211 mlme group. iwd and
+// NetworkManager drive the kernel through nl80211 as well, so this
+// covers them without talking D-Bus.
+func netlinkEvents(iface string, association bool) (*eventStream, error) {
+	links, stopLinks, linkErr := kernelLink.Subscribe()
+	if linkErr != nil {
+		return nil, linkErr
+	}
+	stream := newEventStream()
+	index, powered := 0, false
+	if netInterface, ifaceErr := net.InterfaceByName(iface); ifaceErr == nil {
+		index, powered = netInterface.Index, netInterface.Flags&net.FlagUp != 0
+	}
+	stream.run(func() {
+		defer stopLinks()
+		for {
+			var link linux.RTNetlinkEvent
+			var open bool
+			select {
+			case <-stream.done:
+				return
+			case link, open = <-links:
+				if !open {
+					return
+				}
+			}
+			event := InterfaceEvent{Interface: iface, Time: time.Now(), Powered: link.Up}
+			switch {
+			case link.Type == "newlink" && link.Name == iface && link.Index != index:
+				index, powered = link.Index, link.Up
+				event.Type = InterfaceEventAdded
+			case link.Type == "newlink" && link.Index == index && link.Up != powered:
+				powered = link.Up
+				event.Type = InterfaceEventPower
+			case link.Type == "dellink" && link.Index == index:
+				index, powered = 0, false
+				event.Type = InterfaceEventRemoved
+			case (link.Type == "newaddr" || link.Type == "deladdr") && link.Index == index:
+				event.Type = InterfaceEventAddress
+				event.Powered = powered
+				if netInterface, ifaceErr := net.InterfaceByName(iface); ifaceErr == nil {
+					event.Addresses = interfaceAddresses(netInterface)
+				}
+			default:
+				continue
+			}
+			if !stream.send(event) {
+				return
+			}
+		}
+	})
+	if !association {
+		return stream, nil
+	}
+	wireless, stopWireless, wirelessErr := kernelWireless.Subscribe("mlme")
+	if wirelessErr != nil {
+		stream.stop()
+		return nil, wirelessErr
+	}
+	stream.run(func() {
+		defer stopWireless()
+		for {
+			var mlme linux.NL80211Event
+			var open bool
+			select {
+			case <-stream.done:
+				return
+			case mlme, open = <-wireless:
+				if !open {
+					return
+				}
+			}
+			if mlme.Interface != iface {
+				continue
+			}
+			event := InterfaceEvent{Interface: iface, Time: time.Now(), Powered: true, BSSID: mlme.BSSID}
+			switch mlme.Name {
+			case "connect":
+				if mlme.StatusCode != 0 {
+					continue
+				}
+				event.Type = InterfaceEventAssociate
+			case "roam":
+				event.Type = InterfaceEventRoam
+			case "disconnect":
+				event.Type = InterfaceEventDisassociate
+				event.ReasonCode = mlme.ReasonCode
+			default:
+				continue
+			}
+			if !stream.send(event) {
+				return
+			}
+		}
+	})
+	return stream, nil
+}
+
+// supplicantEvents sends the association events wpa_supplicant reports
+// on the control interface of the interface. Joining another BSS while
+// connected is reported as roaming.
+func supplicantEvents(stream *eventStream, supplicant *linux.WPASupplicant) error {
+	messages, stopMessages, eventsErr := supplicant.Events()
+	if eventsErr != nil {
+		return eventsErr
+	}
+	stream.run(func() {
+		defer stopMessages()
+		bssid := ""
+		for {
+			var message string
+			var open bool
+			select {
+			case <-stream.done:
+				return
+			case message, open = <-messages:
+				if !open {
+					return
+				}
+			}
+			event := InterfaceEvent{Interface: supplicant.Interface, Time: time.Now(), Powered: true}
+			if match := wpaConnectedRE.FindStringSubmatch(message); match != nil {
+				event.Type = InterfaceEventAssociate
+				if bssid != "" && bssid != match[1] {
+					event.Type = InterfaceEventRoam
+				}
+				bssid, event.BSSID = match[1], match[1]
+			} else if match := wpaDisconnectedRE.FindStringSubmatch(message); match != nil {
+				event.Type = InterfaceEventDisassociate
+				event.BSSID = match[1]
+				event.ReasonCode, _ = strconv.Atoi(match[2])
+				bssid = ""
+			} else {
+				continue
+			}
+			if !stream.send(event) {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// interfaceAddresses returns the IP addresses of the interface
+func interfaceAddresses(netInterface *net.Interface) []net.IPNet {
+	addresses := []net.IPNet{}
+	addrs, addrsErr := netInterface.Addrs()
+	if addrsErr != nil {
+		return addresses
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			addresses = append(addresses, *ipNet)
+		}
+	}
+	return addresses
+}
+
+// sameAddresses returns whether or not both lists hold the same
+// addresses in the same order
+func sameAddresses(first, second []net.IPNet) bool {
+	if len(first) != len(second) {
+		return false
+	}
+	for index := range first {
+		if first[index].String() != second[index].String() {
+			return false
+		}
+	}
+	return true
+}
+
+// newEventStream creates an event stream without sources
+func newEventStream() *eventStream {
+	return &eventStream{
+		events: make(chan InterfaceEvent, eventBuffer),
+		done:   make(chan struct{}),
+	}
+}
+
+// run starts a source of the stream. The channel is closed once every
+// source started returned.
+func (stream *eventStream) run(source func()) {
+	stream.group.Add(1)
+	go func() {
+		defer stream.group.Done()
+		source()
+	}()
+}
+
+// send delivers the event, returning false once the stream is stopped
+func (stream *eventStream) send(event InterfaceEvent) bool {
+	select {
+	case stream.events <- event:
+		return true
+	case <-stream.done:
+		return false
+	}
+}
+
+// stop stops every source of the stream
+func (stream *eventStream) stop() {
+	stream.once.Do(func() { close(stream.done) })
+}
+
+// subscription returns the channel and stop function of the stream,
+// closing the channel once its sources are done
+func (stream *eventStream) subscription() (<-chan InterfaceEvent, func(), error) {
+	go func() {
+		stream.group.Wait()
+		close(stream.events)
+	}()
+	return stream.events, stream.stop, nil
+}
@@ -0,0 +1,143 @@
+package wifimanager
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// polledBackend is a backend without events of its own whose link
+// state is read the way the Mac OS X one reads airport -I
+type polledBackend struct {
+	mutex      sync.Mutex
+	iface      net.Interface
+	states     []interfaceSnapshot
+	listed     int
+	polled     int
+	running    int
+	maxRunning int
+	release    chan struct{}
+}
+
+func (backend *polledBackend) Name() string                                    { return "polled" }
+func (backend *polledBackend) IsInstalled() bool                               { return true }
+func (backend *polledBackend) Scan(iface string) ([]WifiNetwork, error)        { return nil, nil }
+func (backend *polledBackend) Connect(iface string, network WifiNetwork) error { return nil }
+func (backend *polledBackend) Disconnect(iface string) error                   { return nil }
+func (backend *polledBackend) Up(iface string) error                           { return nil }
+func (backend *polledBackend) Down(iface string) error                         { return nil }
+func (backend *polledBackend) Status(iface string) (bool, error)               { return false, nil }
+
+func (backend *polledBackend) Interfaces() ([]WifiInterface, error) {
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+	backend.listed++
+	return []WifiInterface{{Interface: backend.iface, backend: backend}}, nil
+}
+
+// linkState returns the next of the states, staying on the last one
+func (backend *polledBackend) linkState(iface string) (interfaceSnapshot, error) {
+	backend.mutex.Lock()
+	backend.polled++
+	backend.running++
+	if backend.running > backend.maxRunning {
+		backend.maxRunning = backend.running
+	}
+	state := backend.states[0]
+	if len(backend.states) > 1 {
+		backend.states = backend.states[1:]
+	}
+	// the snapshot taken when subscribing never hangs
+	release := backend.release
+	if backend.polled == 1 {
+		release = nil
+	}
+	backend.mutex.Unlock()
+	if release != nil {
+		<-release
+	}
+	backend.mutex.Lock()
+	backend.running--
+	backend.mutex.Unlock()
+	return state, nil
+}
+
+// loopback returns the loopback interface, which stands in for a WiFi
+// interface the kernel knows
+func loopback(t *testing.T) net.Interface {
+	t.Helper()
+	interfaces, interfacesErr := net.Interfaces()
+	if interfacesErr != nil {
+		t.Fatal(interfacesErr)
+	}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			return iface
+		}
+	}
+	t.Skip("no loopback interface")
+	return net.Interface{}
+}
+
+// setPollInterval shortens EventPollInterval for the test
+func setPollInterval(t *testing.T, interval time.Duration) {
+	previous := EventPollInterval
+	EventPollInterval = interval
+	t.Cleanup(func() { EventPollInterval = previous })
+}
+
+func TestPollEvents(t *testing.T) {
+	setPollInterval(t, 5*time.Millisecond)
+	backend := &polledBackend{iface: loopback(t), states: []interfaceSnapshot{
+		{},
+		{powered: true},
+		{powered: true, connected: true, bssid: "3c:37:86:1a:2b:3c"},
+		{powered: true, connected: true, bssid: "3c:37:86:1a:2b:3d"},
+	}}
+	events, stop, subscribeErr := pollEvents(backend, backend.iface.Name)
+	if subscribeErr != nil {
+		t.Fatal(subscribeErr)
+	}
+	defer stop()
+	want := []InterfaceEvent{
+		{Type: InterfaceEventPower, Powered: true},
+		{Type: InterfaceEventAssociate, BSSID: "3c:37:86:1a:2b:3c"},
+		{Type: InterfaceEventRoam, BSSID: "3c:37:86:1a:2b:3d"},
+	}
+	for _, wanted := range want {
+		select {
+		case event := <-events:
+			if event.Type != wanted.Type || event.Powered != wanted.Powered || event.BSSID != wanted.BSSID || event.Interface != backend.iface.Name {
+				t.Fatalf("event = %+v, want %+v", event, wanted)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s event", InterfaceEventName(wanted.Type))
+		}
+	}
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+	// the interface list is read once as long as the interface stays
+	if backend.listed != 1 {
+		t.Errorf("interface list read %d times, want once", backend.listed)
+	}
+}
+
+func TestPollEventsSkipsBusyTicks(t *testing.T) {
+	setPollInterval(t, time.Millisecond)
+	backend := &polledBackend{iface: loopback(t), states: []interfaceSnapshot{{}}, release: make(chan struct{})}
+	_, stop, subscribeErr := pollEvents(backend, backend.iface.Name)
+	if subscribeErr != nil {
+		t.Fatal(subscribeErr)
+	}
+	defer stop()
+	// the first polled snapshot hangs while ticks keep coming
+	time.Sleep(50 * time.Millisecond)
+	backend.mutex.Lock()
+	polled, maxRunning := backend.polled, backend.maxRunning
+	backend.mutex.Unlock()
+	close(backend.release)
+	if polled != 2 || maxRunning != 1 {
+		t.Fatalf("link state read %d times, %d at once, want twice and one at a time", polled, maxRunning)
+	}
+}
@@ -0,0 +1,134 @@
+package helper
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+
+	"github.com/ottopress/WifiManager"
+)
+
+// Client is a Backend that performs Connect, Disconnect, Up and Down
+// through the privileged helper and everything else through the local
+// backend it wraps, which runs unprivileged.
+type Client struct {
+	Socket string
+	local  wifimanager.Backend
+}
+
+// NewClient creates a client for the helper listening on the socket,
+// reading state through the local backend
+func NewClient(socket string, local wifimanager.Backend) *Client {
+	return &Client{Socket: socket, local: local}
+}
+
+// Name returns the name of the local backend
+func (client *Client) Name() string {
+	return client.local.Name()
+}
+
+// IsInstalled returns whether or not the local backend is installed
+// and the helper's socket accepts connections
+func (client *Client) IsInstalled() bool {
+	if !client.local.IsInstalled() {
+		return false
+	}
+	conn, dialErr := net.Dial("unix", client.Socket)
+	if dialErr != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Interfaces returns the interfaces of the local backend, driven by
+// this client
+func (client *Client) Interfaces() ([]wifimanager.WifiInterface, error) {
+	localInterfaces, ifaceErr := client.local.Interfaces()
+	if ifaceErr != nil {
+		return nil, ifaceErr
+	}
+	wifiInterfaces := []wifimanager.WifiInterface{}
+	for _, localInterface := range localInterfaces {
+		wifiInterface := wifimanager.NewBackendInterface(localInterface.Interface, client)
+		wifiInterface.Model = localInterface.Model
+		wifiInterface.Vendor = localInterface.Vendor
+		wifiInterfaces = append(wifiInterfaces, wifiInterface)
+	}
+	return wifiInterfaces, nil
+}
+
+// Scan scans through the local backend
+func (client *Client) Scan(iface string) ([]wifimanager.WifiNetwork, error) {
+	return client.local.Scan(iface)
+}
+
+// Status reads the power state through the local backend
+func (client *Client) Status(iface string) (bool, error) {
+	return client.local.Status(iface)
+}
+
+// State reads the connection state through the local backend
+func (client *Client) State(iface string) (int, error) {
+	stateBackend, ok := client.local.(wifimanager.StateBackend)
+	if !ok {
+		powered, statusErr := client.local.Status(iface)
+		if powered {
+			return wifimanager.StateDisconnected, statusErr
+		}
+		return wifimanager.StateOff, statusErr
+	}
+	return stateBackend.State(iface)
+}
+
+// SupportsRawPSK returns whether or not the local backend, and so the
+// helper's, takes a raw PSK in place of the passphrase
+func (client *Client) SupportsRawPSK() bool {
+	return wifimanager.SupportsRawPSK(client.local)
+}
+
+// Connect asks the helper to join the interface to the network
+func (client *Client) Connect(iface string, network wifimanager.WifiNetwork) error {
+	return client.call(request{Operation: OpConnect, Interface: iface, Network: &network})
+}
+
+// Disconnect asks the helper to leave the network of the interface
+func (client *Client) Disconnect(iface string) error {
+	return client.call(request{Operation: OpDisconnect, Interface: iface})
+}
+
+// Up asks the helper to turn on the interface
+func (client *Client) Up(iface string) error {
+	return client.call(request{Operation: OpUp, Interface: iface})
+}
+
+// Down asks the helper to turn off the interface
+func (client *Client) Down(iface string) error {
+	return client.call(request{Operation: OpDown, Interface: iface})
+}
+
+// call sends a single request to the helper and waits for its answer
+func (client *Client) call(outgoing request) error {
+	conn, dialErr := net.Dial("unix", client.Socket)
+	if dialErr != nil {
+		return dialErr
+	}
+	defer conn.Close()
+	data, marshalErr := json.Marshal(outgoing)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, writeErr := conn.Write(append(data, '\n'))
+	if writeErr != nil {
+		return writeErr
+	}
+	reply := response{}
+	decodeErr := json.NewDecoder(bufio.NewReader(conn)).Decode(&reply)
+	if decodeErr != nil {
+		return decodeErr
+	}
+	if reply.Error != "" {
+		return knownError(reply.Error)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Package helper splits the operations that need root into a small
+// privileged process. The helper listens on a unix socket, checks the
+// credentials of every peer against an allowlist and only performs
+// Connect, Disconnect, Up and Down. The unprivileged library uses
+// Client as its backend, which delegates those operations to the
+// helper and performs everything else itself.
+package helper
+
+import (
+	"errors"
+
+	"github.com/ottopress/WifiManager"
+)
+
+const (
+	// DefaultSocket is where the helper listens by default
+	DefaultSocket = "/run/wifimgr-helper.sock"
+
+	// OpConnect joins an interface to a network
+	OpConnect = "connect"
+	// OpDisconnect leaves the network of an interface
+	OpDisconnect = "disconnect"
+	// OpUp turns an interface on
+	OpUp = "up"
+	// OpDown turns an interface off
+	OpDown = "down"
+
+	// maxRequestSize is the largest request the helper reads
+	maxRequestSize = 64 << 10
+)
+
+var (
+	// ErrDenied is returned when the peer or the interface isn't on
+	// the allowlist
+	ErrDenied = errors.New("helper: operation not permitted")
+	// ErrUnknownOp is returned for operations the helper doesn't
+	// perform
+	ErrUnknownOp = errors.New("helper: unknown operation")
+	// ErrPeerCredentials is returned when the credentials of a peer
+	// can't be read, such as on systems without SO_PEERCRED
+	ErrPeerCredentials = errors.New("helper: peer credentials unavailable")
+
+	// knownErrors are the errors the client recreates from the
+	// helper's responses so callers can compare against them
+	knownErrors = []error{
+		ErrDenied,
+		ErrUnknownOp,
+		ErrPeerCredentials,
+		wifimanager.ErrUnknownIface,
+		wifimanager.ErrMissingAP,
+		wifimanager.ErrRawPSK,
+		wifimanager.ErrInvalidPassphrase,
+		wifimanager.ErrUnsupportedSecurity,
+	}
+)
+
+// Credentials identify the process on the other end of the socket
+type Credentials struct {
+	PID    int32
+	UID    uint32
+	GID    uint32
+	Groups []uint32
+}
+
+// Allowlist decides which peers may use the helper and on which
+// interfaces. Root is allowed whatever its groups, but only on the
+// listed interfaces. An empty Interfaces list allows every interface.
+type Allowlist struct {
+	UIDs       []uint32
+	GIDs       []uint32
+	Interfaces []string
+}
+
+// request is a single operation sent to the helper, one JSON object
+// per line
+type request struct {
+	Operation string                   `json:"operation"`
+	Interface string                   `json:"interface"`
+	Network   *wifimanager.WifiNetwork `json:"network,omitempty"`
+}
+
+// response answers a request
+type response struct {
+	Error string `json:"error,omitempty"`
+}
+
+// Allows returns whether or not the peer may operate the interface
+func (allowlist *Allowlist) Allows(peer Credentials, iface string) bool {
+	if len(allowlist.Interfaces) > 0 && !containsString(allowlist.Interfaces, iface) {
+		return false
+	}
+	if peer.UID == 0 || containsID(allowlist.UIDs, peer.UID) || containsID(allowlist.GIDs, peer.GID) {
+		return true
+	}
+	for _, group := range peer.Groups {
+		if containsID(allowlist.GIDs, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// knownError returns the well known error with the message, or a
+// plain error holding it
+func knownError(message string) error {
+	for _, known := range knownErrors {
+		if known.Error() == message {
+			return known
+		}
+	}
+	return errors.New(message)
+}
+
+// containsID returns whether or not the ID is in the list
+func containsID(list []uint32, id uint32) bool {
+	for _, item := range list {
+		if item == id {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString returns whether or not the text is in the list
+func containsString(list []string, text string) bool {
+	for _, item := range list {
+		if item == text {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"testing"
+)
+
+func TestAllowlistAllows(t *testing.T) {
+	allowlist := Allowlist{UIDs: []uint32{1000}, GIDs: []uint32{100}}
+	restricted := Allowlist{UIDs: []uint32{1000}, Interfaces: []string{"wlan1"}}
+	tests := []struct {
+		name      string
+		allowlist Allowlist
+		peer      Credentials
+		iface     string
+		allowed   bool
+	}{
+		{name: "root", allowlist: allowlist, peer: Credentials{UID: 0, GID: 0}, iface: "wlan0", allowed: true},
+		{name: "uid", allowlist: allowlist, peer: Credentials{UID: 1000, GID: 1000}, iface: "wlan0", allowed: true},
+		{name: "primary group", allowlist: allowlist, peer: Credentials{UID: 1001, GID: 100}, iface: "wlan0", allowed: true},
+		{name: "supplementary group", allowlist: allowlist, peer: Credentials{UID: 1001, GID: 1001, Groups: []uint32{27, 100}}, iface: "wlan0", allowed: true},
+		{name: "denied", allowlist: allowlist, peer: Credentials{UID: 1001, GID: 1001, Groups: []uint32{27}}, iface: "wlan0", allowed: false},
+		{name: "allowed interface", allowlist: restricted, peer: Credentials{UID: 1000}, iface: "wlan1", allowed: true},
+		{name: "other interface", allowlist: restricted, peer: Credentials{UID: 1000}, iface: "wlan0", allowed: false},
+		{name: "root on other interface", allowlist: restricted, peer: Credentials{UID: 0}, iface: "wlan0", allowed: false},
+	}
+	for _, test := range tests {
+		if allowed := test.allowlist.Allows(test.peer, test.iface); allowed != test.allowed {
+			t.Errorf("%s: Allows = %v, want %v", test.name, allowed, test.allowed)
+		}
+	}
+}
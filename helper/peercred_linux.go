@@ -0,0 +1,66 @@
+//go:build linux
+
+package helper
+
+import (
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// soPeerGroups is SO_PEERGROUPS, which the syscall package lacks.
+	// It has the same value on every architecture Go supports.
+	soPeerGroups = 0x3b
+	// peerGroupsLength is how many groups are asked for at first
+	peerGroupsLength = 32
+)
+
+// peerCredentials reads the credentials of the peer with SO_PEERCRED
+// and its supplementary groups with SO_PEERGROUPS. Both are recorded
+// by the kernel when the peer connected, so a process that has exited
+// since can't have its pid reused to pass as another one. Kernels
+// before 4.13 don't have SO_PEERGROUPS and only the primary group is
+// known.
+func peerCredentials(conn *net.UnixConn) (Credentials, error) {
+	rawConn, rawErr := conn.SyscallConn()
+	if rawErr != nil {
+		return Credentials{}, rawErr
+	}
+	var ucred *syscall.Ucred
+	var groups []uint32
+	var sockErr error
+	controlErr := rawConn.Control(func(fd uintptr) {
+		ucred, sockErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+		if sockErr == nil {
+			groups, sockErr = peerGroups(int(fd))
+		}
+	})
+	if controlErr != nil {
+		return Credentials{}, controlErr
+	}
+	if sockErr != nil {
+		return Credentials{}, sockErr
+	}
+	return Credentials{PID: ucred.Pid, UID: ucred.Uid, GID: ucred.Gid, Groups: groups}, nil
+}
+
+// peerGroups returns the supplementary groups of the peer, growing the
+// buffer as long as the kernel reports it is too small
+func peerGroups(fd int) ([]uint32, error) {
+	groups := make([]uint32, peerGroupsLength)
+	for {
+		length := uint32(len(groups) * 4)
+		errno := getsockopt(fd, syscall.SOL_SOCKET, soPeerGroups, unsafe.Pointer(&groups[0]), &length)
+		switch errno {
+		case 0:
+			return groups[:length/4], nil
+		case syscall.ERANGE:
+			groups = make([]uint32, length/4+1)
+		case syscall.ENOPROTOOPT:
+			return nil, nil
+		default:
+			return nil, errno
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package helper
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestPeerCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "helper.sock")
+	listener, listenErr := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if listenErr != nil {
+		t.Fatal(listenErr)
+	}
+	defer listener.Close()
+	client, dialErr := net.Dial("unix", path)
+	if dialErr != nil {
+		t.Fatal(dialErr)
+	}
+	defer client.Close()
+	conn, acceptErr := listener.AcceptUnix()
+	if acceptErr != nil {
+		t.Fatal(acceptErr)
+	}
+	defer conn.Close()
+
+	peer, credErr := peerCredentials(conn)
+	if credErr != nil {
+		t.Fatal(credErr)
+	}
+	if peer.PID != int32(os.Getpid()) || peer.UID != uint32(os.Getuid()) || peer.GID != uint32(os.Getgid()) {
+		t.Errorf("credentials = %+v", peer)
+	}
+	groups, groupsErr := os.Getgroups()
+	if groupsErr != nil {
+		t.Fatal(groupsErr)
+	}
+	if peer.Groups == nil {
+		t.Skip("kernel has no SO_PEERGROUPS")
+	}
+	want := []uint32{}
+	for _, group := range groups {
+		want = append(want, uint32(group))
+	}
+	sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+	sort.Slice(peer.Groups, func(i, j int) bool { return peer.Groups[i] < peer.Groups[j] })
+	if len(peer.Groups) != len(want) {
+		t.Fatalf("groups = %v, want %v", peer.Groups, want)
+	}
+	for index := range want {
+		if peer.Groups[index] != want[index] {
+			t.Fatalf("groups = %v, want %v", peer.Groups, want)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package helper
+
+import "net"
+
+// peerCredentials always fails as SO_PEERCRED is Linux only, so every
+// request is refused
+func peerCredentials(conn *net.UnixConn) (Credentials, error) {
+	return Credentials{}, ErrPeerCredentials
+}
@@ -0,0 +1,132 @@
+package helper
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"github.com/ottopress/WifiManager"
+)
+
+const (
+	// idleTimeout is how long the helper waits for the next request
+	// on a connection
+	idleTimeout = 2 * time.Minute
+)
+
+// Server performs the privileged operations of a backend for the
+// peers on its allowlist
+type Server struct {
+	Backend   wifimanager.Backend
+	Allowlist Allowlist
+	// Logger reports denied and failed requests. Nil disables logging.
+	Logger *log.Logger
+}
+
+// NewServer creates a helper server for the backend
+func NewServer(backend wifimanager.Backend, allowlist Allowlist) *Server {
+	return &Server{Backend: backend, Allowlist: allowlist}
+}
+
+// Listen listens on the unix socket, replacing a stale socket file.
+// The socket is open to every user as access is decided by the
+// allowlist.
+func Listen(socket string) (*net.UnixListener, error) {
+	if info, statErr := os.Lstat(socket); statErr == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(socket)
+	}
+	listener, listenErr := net.ListenUnix("unix", &net.UnixAddr{Name: socket, Net: "unix"})
+	if listenErr != nil {
+		return nil, listenErr
+	}
+	chmodErr := os.Chmod(socket, 0666)
+	if chmodErr != nil {
+		listener.Close()
+		return nil, chmodErr
+	}
+	return listener, nil
+}
+
+// Serve accepts connections until the listener is closed
+func (server *Server) Serve(listener *net.UnixListener) error {
+	for {
+		conn, acceptErr := listener.AcceptUnix()
+		if acceptErr != nil {
+			return acceptErr
+		}
+		go server.handle(conn)
+	}
+}
+
+// handle answers the requests of a connection in order
+func (server *Server) handle(conn *net.UnixConn) {
+	defer conn.Close()
+	peer, credErr := peerCredentials(conn)
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxRequestSize)
+	encoder := json.NewEncoder(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		if !scanner.Scan() {
+			return
+		}
+		var result error
+		incoming := request{}
+		switch {
+		case credErr != nil:
+			result = ErrPeerCredentials
+		case json.Unmarshal(scanner.Bytes(), &incoming) != nil:
+			result = ErrUnknownOp
+		default:
+			result = server.perform(peer, incoming)
+		}
+		reply := response{}
+		if result != nil {
+			reply.Error = wifimanager.Redact(result.Error())
+		}
+		if encoder.Encode(reply) != nil {
+			return
+		}
+	}
+}
+
+// perform checks the peer against the allowlist and runs the
+// operation
+func (server *Server) perform(peer Credentials, incoming request) error {
+	if !server.Allowlist.Allows(peer, incoming.Interface) {
+		server.logf("helper: denied %s on %s to uid %d pid %d", incoming.Operation, incoming.Interface, peer.UID, peer.PID)
+		return ErrDenied
+	}
+	var result error
+	switch incoming.Operation {
+	case OpConnect:
+		if incoming.Network == nil {
+			return ErrUnknownOp
+		}
+		wifimanager.RegisterSecret(incoming.Network.SecurityKey)
+		defer wifimanager.ForgetSecret(incoming.Network.SecurityKey)
+		result = server.Backend.Connect(incoming.Interface, *incoming.Network)
+	case OpDisconnect:
+		result = server.Backend.Disconnect(incoming.Interface)
+	case OpUp:
+		result = server.Backend.Up(incoming.Interface)
+	case OpDown:
+		result = server.Backend.Down(incoming.Interface)
+	default:
+		return ErrUnknownOp
+	}
+	if result != nil {
+		server.logf("helper: %s on %s for uid %d failed: %s", incoming.Operation, incoming.Interface, peer.UID, wifimanager.Redact(result.Error()))
+	}
+	return result
+}
+
+// logf logs to the server's logger if it has one
+func (server *Server) logf(format string, args ...interface{}) {
+	if server.Logger != nil {
+		server.Logger.Printf(format, args...)
+	}
+}
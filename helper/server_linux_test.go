@@ -0,0 +1,119 @@
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ottopress/WifiManager"
+)
+
+// fakeBackend records the privileged calls the helper makes
+type fakeBackend struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (backend *fakeBackend) record(call string) {
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+	backend.calls = append(backend.calls, call)
+}
+
+func (backend *fakeBackend) recorded() []string {
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+	return append([]string{}, backend.calls...)
+}
+
+func (backend *fakeBackend) Name() string      { return "fake" }
+func (backend *fakeBackend) IsInstalled() bool { return true }
+
+func (backend *fakeBackend) Interfaces() ([]wifimanager.WifiInterface, error) {
+	return nil, nil
+}
+
+func (backend *fakeBackend) Scan(iface string) ([]wifimanager.WifiNetwork, error) {
+	return nil, nil
+}
+
+func (backend *fakeBackend) Connect(iface string, network wifimanager.WifiNetwork) error {
+	backend.record("connect " + iface + " " + network.SSID + " " + network.SecurityKey)
+	if iface != "wlan1" {
+		return wifimanager.ErrUnknownIface
+	}
+	return nil
+}
+
+func (backend *fakeBackend) Disconnect(iface string) error {
+	backend.record("disconnect " + iface)
+	return nil
+}
+
+func (backend *fakeBackend) Up(iface string) error {
+	backend.record("up " + iface)
+	return nil
+}
+
+func (backend *fakeBackend) Down(iface string) error {
+	backend.record("down " + iface)
+	return nil
+}
+
+func (backend *fakeBackend) Status(iface string) (bool, error) {
+	return true, nil
+}
+
+// startServer serves the helper for the backend on a temporary socket
+// and returns a client of it
+func startServer(t *testing.T, backend wifimanager.Backend, allowlist Allowlist) *Client {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "helper.sock")
+	listener, listenErr := Listen(socket)
+	if listenErr != nil {
+		t.Fatal(listenErr)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go NewServer(backend, allowlist).Serve(listener)
+	return NewClient(socket, backend)
+}
+
+func TestServerDeniesPeer(t *testing.T) {
+	backend := &fakeBackend{}
+	// the interface restriction applies to every peer, root included
+	client := startServer(t, backend, Allowlist{UIDs: []uint32{uint32(os.Getuid())}, Interfaces: []string{"wlan1"}})
+	network := wifimanager.WifiNetwork{SSID: "home", SecurityKey: "correct horse"}
+	if connectErr := client.Connect("wlan0", network); connectErr != ErrDenied {
+		t.Errorf("Connect = %v, want ErrDenied", connectErr)
+	}
+	if downErr := client.Down("wlan0"); downErr != ErrDenied {
+		t.Errorf("Down = %v, want ErrDenied", downErr)
+	}
+	if calls := backend.recorded(); len(calls) != 0 {
+		t.Errorf("backend called for a denied peer: %v", calls)
+	}
+}
+
+func TestServerPerformsAllowedOperations(t *testing.T) {
+	backend := &fakeBackend{}
+	client := startServer(t, backend, Allowlist{UIDs: []uint32{uint32(os.Getuid())}})
+	network := wifimanager.WifiNetwork{SSID: "home", SecurityKey: "correct horse"}
+	if connectErr := client.Connect("wlan1", network); connectErr != nil {
+		t.Fatal(connectErr)
+	}
+	if connectErr := client.Connect("wlan9", network); connectErr != wifimanager.ErrUnknownIface {
+		t.Errorf("Connect = %v, want ErrUnknownIface", connectErr)
+	}
+	if upErr := client.Up("wlan1"); upErr != nil {
+		t.Fatal(upErr)
+	}
+	if disconnectErr := client.Disconnect("wlan1"); disconnectErr != nil {
+		t.Fatal(disconnectErr)
+	}
+	want := []string{"connect wlan1 home correct horse", "connect wlan9 home correct horse", "up wlan1", "disconnect wlan1"}
+	if calls := backend.recorded(); !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build linux && !386
+
+package helper
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// getsockopt reads a socket option of a variable length, which the
+// syscall package has no function for
+func getsockopt(fd, level, option int, value unsafe.Pointer, length *uint32) syscall.Errno {
+	_, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, uintptr(fd), uintptr(level), uintptr(option), uintptr(value), uintptr(unsafe.Pointer(length)), 0)
+	return errno
+}
@@ -0,0 +1,18 @@
+package helper
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// socketGetsockopt is the socketcall number of getsockopt
+const socketGetsockopt = 15
+
+// getsockopt reads a socket option of a variable length, which the
+// syscall package has no function for. Socket calls go through
+// socketcall on 386.
+func getsockopt(fd, level, option int, value unsafe.Pointer, length *uint32) syscall.Errno {
+	args := [5]uintptr{uintptr(fd), uintptr(level), uintptr(option), uintptr(value), uintptr(unsafe.Pointer(length))}
+	_, _, errno := syscall.Syscall(syscall.SYS_SOCKETCALL, socketGetsockopt, uintptr(unsafe.Pointer(&args[0])), 0)
+	return errno
+}
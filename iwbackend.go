@@ -0,0 +1,206 @@
+package wifimanager
+
+import (
+	"net"
+	"os"
+
+	"github.com/ottopress/WifiManager/darwin"
+	"github.com/ottopress/WifiManager/linux"
+)
+
+// IWBackend drives WiFi interfaces with the nl80211 iw and ip commands
+// alone, for systems without a supplicant. It can only join open and
+// WEP networks and, like the commands, needs root.
+type IWBackend struct {
+	iw *linux.IW
+}
+
+// NewIWBackend creates a new instance of the iw backend
+func NewIWBackend() *IWBackend {
+	return &IWBackend{iw: linux.NewIW()}
+}
+
+// Name returns the name of the backend
+func (iwBackend *IWBackend) Name() string {
+	return "iw"
+}
+
+// IsInstalled returns whether or not the iw and ip commands are
+// installed
+func (iwBackend *IWBackend) IsInstalled() bool {
+	return iwBackend.iw.IsInstalled() && ipCommand.IsInstalled()
+}
+
+// Interfaces returns all wireless interfaces in sysfs
+func (iwBackend *IWBackend) Interfaces() ([]WifiInterface, error) {
+	wifiInterfaces := []WifiInterface{}
+	wirelessInterfaces, sysfsErr := sysfs.WirelessInterfaces()
+	if sysfsErr != nil {
+		return wifiInterfaces, sysfsErr
+	}
+	for _, wirelessInterface := range wirelessInterfaces {
+		iface, ifaceErr := net.InterfaceByName(wirelessInterface.Name)
+		if ifaceErr != nil {
+			continue
+		}
+		wifiInterfaces = append(wifiInterfaces, newSysfsInterface(*iface, iwBackend))
+	}
+	return wifiInterfaces, nil
+}
+
+// Scan returns a list of all reachable WiFi networks
+func (iwBackend *IWBackend) Scan(iface string) ([]WifiNetwork, error) {
+	results, scanErr := iwBackend.iw.Scan(iface)
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	wifiNetworks := []WifiNetwork{}
+	for _, result := range results {
+		wifiNetworks = append(wifiNetworks, WifiNetwork{
+			SSID:     result.SSID,
+			BSSID:    result.BSSID,
+			RSSI:     result.Signal,
+			HT:       result.HT,
+			Channel:  result.Channel,
+			Security: iwSecurity(result),
+		})
+	}
+	return wifiNetworks, nil
+}
+
+// Connect joins the interface to an open or WEP network. The kernel
+// probes for the SSID it is given, so hidden networks need nothing
+// more.
+func (iwBackend *IWBackend) Connect(iface string, network WifiNetwork) error {
+	RegisterSecret(network.SecurityKey)
+	defer ForgetSecret(network.SecurityKey)
+	protocol := SecurityNone
+	if len(network.Security) > 0 {
+		protocol = network.Security[0].Protocol
+	}
+	switch protocol {
+	case SecurityNone:
+		return iwBackend.iw.Connect(iface, network.SSID, "")
+	case SecurityWEP:
+		return RedactError(iwBackend.iw.Connect(iface, network.SSID, network.SecurityKey))
+	}
+	return ErrUnsupportedSecurity
+}
+
+// Disconnect disconnects from the current network without shutting
+// down the interface
+func (iwBackend *IWBackend) Disconnect(iface string) error {
+	return iwBackend.iw.Disconnect(iface)
+}
+
+// Up turns on the interface
+func (iwBackend *IWBackend) Up(iface string) error {
+	if unblockErr := unblockRFKill(iface); unblockErr != nil {
+		return unblockErr
+	}
+	return ipCommand.Up(iface)
+}
+
+// Down turns off the interface
+func (iwBackend *IWBackend) Down(iface string) error {
+	return ipCommand.Down(iface)
+}
+
+// Status returns the power state of the interface
+func (iwBackend *IWBackend) Status(iface string) (bool, error) {
+	return ipCommand.Status(iface)
+}
+
+// State returns whether the interface is off, connected or not
+func (iwBackend *IWBackend) State(iface string) (int, error) {
+	powered, statusErr := ipCommand.Status(iface)
+	if statusErr != nil || !powered {
+		return StateOff, statusErr
+	}
+	link, linkErr := iwBackend.iw.Link(iface)
+	if linkErr != nil {
+		return StateOff, linkErr
+	}
+	if link.Connected {
+		return StateConnected, nil
+	}
+	return StateDisconnected, nil
+}
+
+// Subscribe delivers the power, association, address and hotplug
+// events the kernel reports for the interface
+func (iwBackend *IWBackend) Subscribe(iface string) (<-chan InterfaceEvent, func(), error) {
+	stream, streamErr := netlinkEvents(iface, true)
+	if streamErr != nil {
+		return nil, nil, streamErr
+	}
+	return stream.subscription()
+}
+
+// Diagnose reports on the iw and ip commands. Scanning and connecting
+// through them needs root and connecting is limited to open and WEP
+// networks.
+func (iwBackend *IWBackend) Diagnose() BackendReport {
+	report := BackendReport{Checks: []Check{
+		commandCheck("iw", iwBackend.iw.IsInstalled(), true, iwBackend.iw.Version),
+		commandCheck("ip", ipCommand.IsInstalled(), true, ipCommand.Version),
+	}}
+	report.Problems, report.Usable = missingRequired(report.Checks)
+	root := os.Geteuid() == 0
+	if report.Usable && !root {
+		report.Problems = append(report.Problems, "scanning and connecting with iw needs root")
+	}
+	if report.Usable {
+		report.Problems = append(report.Problems, "only open and WEP networks can be joined without a supplicant")
+	}
+	report.Privileged = report.Usable && root
+	report.Features = Features{
+		Scan:    report.Privileged,
+		Connect: report.Privileged,
+	}
+	return report
+}
+
+// iwSecurity returns the security configurations advertised by the BSS
+func iwSecurity(result linux.IWScanResult) []WifiNetworkSecurity {
+	return bssSecurity(result.Privacy, result.RSN, result.WPA, result.GroupCipher, result.PairwiseCiphers, result.AuthSuites)
+}
+
+// bssSecurity returns the security configurations of a BSS from its
+// privacy capability, whether it has RSN and WPA elements and the
+// cipher and authentication suite names iw prints
+func bssSecurity(privacy, rsn, wpa bool, groupCipher string, pairwiseCiphers, authSuites []string) []WifiNetworkSecurity {
+	method := darwin.PSK
+	for _, suite := range authSuites {
+		if suite == "802.1X" {
+			method = darwin.EAP
+		}
+	}
+	unicasts := []int{}
+	for _, cipher := range pairwiseCiphers {
+		if value, known := wpaCiphers[cipher]; known {
+			unicasts = append(unicasts, value)
+		}
+	}
+	group := wpaCiphers[groupCipher]
+	security := []WifiNetworkSecurity{}
+	if rsn {
+		protocol := SecurityWPA2
+		for _, suite := range authSuites {
+			if suite == "SAE" {
+				protocol = SecurityWPA3
+			}
+		}
+		security = append(security, WifiNetworkSecurity{Protocol: protocol, Method: method, Unicasts: unicasts, Group: group})
+	}
+	if wpa {
+		security = append(security, WifiNetworkSecurity{Protocol: SecurityWPA, Method: method, Unicasts: unicasts, Group: group})
+	}
+	if len(security) == 0 && privacy {
+		security = append(security, WifiNetworkSecurity{Protocol: SecurityWEP})
+	}
+	if len(security) == 0 {
+		security = append(security, WifiNetworkSecurity{Protocol: SecurityNone})
+	}
+	return security
+}
@@ -0,0 +1,251 @@
+package wifimanager
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/ottopress/WifiManager/darwin"
+	"github.com/ottopress/WifiManager/linux"
+)
+
+var (
+	// ErrUnsupportedSecurity is returned when connecting to a network
+	// whose security protocol the backend can't handle
+	ErrUnsupportedSecurity = errors.New("wifi: security protocol not supported by the backend")
+
+	// iwdSecurities maps the iwd network types to their respective
+	// WifiNetworkSecurity values
+	iwdSecurities = map[string]WifiNetworkSecurity{
+		"psk":   {Protocol: SecurityWPA2, Method: darwin.PSK},
+		"8021x": {Protocol: SecurityWPA2, Method: darwin.EAP},
+		"wep":   {Protocol: SecurityWEP},
+		"open":  {Protocol: SecurityNone},
+	}
+)
+
+// IWDBackend drives WiFi interfaces through the iwd daemon using the
+// iwctl command. iwctl can't be handed credentials without prompting,
+// so they are written to known network files in StateDir first, which
+// takes root.
+type IWDBackend struct {
+	StateDir string
+	iwctl    *linux.IWCtl
+}
+
+// NewIWDBackend creates a new instance of the iwd backend using the
+// default state directory
+func NewIWDBackend() *IWDBackend {
+	return &IWDBackend{StateDir: "/var/lib/iwd", iwctl: linux.NewIWCtl()}
+}
+
+// Name returns the name of the backend
+func (iwdBackend *IWDBackend) Name() string {
+	return "iwd"
+}
+
+// IsInstalled returns whether or not iwctl is installed and iwd is
+// running
+func (iwdBackend *IWDBackend) IsInstalled() bool {
+	return iwdBackend.iwctl.IsInstalled() && iwdBackend.iwctl.Running()
+}
+
+// Interfaces returns all devices iwd runs in station mode
+func (iwdBackend *IWDBackend) Interfaces() ([]WifiInterface, error) {
+	wifiInterfaces := []WifiInterface{}
+	devices, devicesErr := iwdBackend.iwctl.Devices()
+	if devicesErr != nil {
+		return wifiInterfaces, devicesErr
+	}
+	for _, device := range devices {
+		if device.Mode != "station" {
+			continue
+		}
+		iface, ifaceErr := net.InterfaceByName(device.Name)
+		if ifaceErr != nil {
+			continue
+		}
+		wifiInterfaces = append(wifiInterfaces, newSysfsInterface(*iface, iwdBackend))
+	}
+	return wifiInterfaces, nil
+}
+
+// Scan returns a list of all reachable WiFi networks. iwd lists
+// networks rather than access points, so BSSID and Channel are left
+// empty and RSSI is that of the strongest access point.
+func (iwdBackend *IWDBackend) Scan(iface string) ([]WifiNetwork, error) {
+	networks, scanErr := iwdBackend.iwctl.Scan(iface)
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	wifiNetworks := []WifiNetwork{}
+	for _, network := range networks {
+		security, known := iwdSecurities[network.Security]
+		if !known {
+			continue
+		}
+		wifiNetworks = append(wifiNetworks, WifiNetwork{
+			SSID:     network.SSID,
+			RSSI:     network.Signal,
+			Security: []WifiNetworkSecurity{security},
+		})
+	}
+	return wifiNetworks, nil
+}
+
+// Connect writes the known network file of the network and connects
+// the interface to it
+func (iwdBackend *IWDBackend) Connect(iface string, network WifiNetwork) error {
+	RegisterSecret(network.SecurityKey)
+	defer ForgetSecret(network.SecurityKey)
+	return RedactError(iwdBackend.connect(iface, network))
+}
+
+// connect does the work of Connect
+func (iwdBackend *IWDBackend) connect(iface string, network WifiNetwork) error {
+	protocol := SecurityNone
+	if len(network.Security) > 0 {
+		protocol = network.Security[0].Protocol
+	}
+	security := "open"
+	contents := ""
+	switch protocol {
+	case SecurityWPA, SecurityWPA2, SecurityWPA3:
+		security = "psk"
+		// a line break would let the key add settings of its own. SAE
+		// passwords may be longer than passphrases but are just as
+		// free of control characters.
+		validateErr := ValidatePassphrase(network.SecurityKey)
+		if protocol == SecurityWPA3 && strings.IndexFunc(network.SecurityKey, unicode.IsControl) < 0 {
+			validateErr = nil
+		}
+		if validateErr != nil {
+			return validateErr
+		}
+		if !IsRawPSK(network.SecurityKey) {
+			contents = "[Security]\nPassphrase=" + network.SecurityKey + "\n"
+		} else if protocol == SecurityWPA3 {
+			return ErrRawPSK
+		} else {
+			contents = "[Security]\nPreSharedKey=" + network.SecurityKey + "\n"
+		}
+	case SecurityNone:
+	default:
+		return ErrUnsupportedSecurity
+	}
+	if network.Hidden {
+		contents = "[Settings]\nHidden=true\n" + contents
+	}
+	path := filepath.Join(iwdBackend.StateDir, linux.IWDNetworkFile(network.SSID, security))
+	writeErr := linux.WriteSecretFile(path, []byte(contents))
+	if writeErr != nil {
+		return writeErr
+	}
+	if network.Hidden {
+		return iwdBackend.iwctl.ConnectHidden(iface, network.SSID)
+	}
+	return iwdBackend.iwctl.Connect(iface, network.SSID)
+}
+
+// SupportsRawPSK returns true as iwd takes a PSK of 64 hex digits in
+// place of the passphrase
+func (iwdBackend *IWDBackend) SupportsRawPSK() bool {
+	return true
+}
+
+// Disconnect disconnects from the current network without shutting
+// down the interface
+func (iwdBackend *IWDBackend) Disconnect(iface string) error {
+	return iwdBackend.iwctl.Disconnect(iface)
+}
+
+// Up turns on the device
+func (iwdBackend *IWDBackend) Up(iface string) error {
+	if unblockErr := unblockRFKill(iface); unblockErr != nil {
+		return unblockErr
+	}
+	return iwdBackend.iwctl.SetPowered(iface, true)
+}
+
+// Down turns off the device
+func (iwdBackend *IWDBackend) Down(iface string) error {
+	return iwdBackend.iwctl.SetPowered(iface, false)
+}
+
+// Status returns the power state of the device
+func (iwdBackend *IWDBackend) Status(iface string) (bool, error) {
+	devices, devicesErr := iwdBackend.iwctl.Devices()
+	if devicesErr != nil {
+		return false, devicesErr
+	}
+	for _, device := range devices {
+		if device.Name == iface {
+			return device.Powered, nil
+		}
+	}
+	return false, ErrUnknownIface
+}
+
+// State returns the connection state of the station
+func (iwdBackend *IWDBackend) State(iface string) (int, error) {
+	powered, statusErr := iwdBackend.Status(iface)
+	if statusErr != nil || !powered {
+		return StateOff, statusErr
+	}
+	station, stationErr := iwdBackend.iwctl.Station(iface)
+	if stationErr != nil {
+		return StateOff, stationErr
+	}
+	switch station.State {
+	case "connected", "roaming":
+		return StateConnected, nil
+	case "connecting":
+		return StateAssociating, nil
+	}
+	if station.Scanning {
+		return StateScanning, nil
+	}
+	return StateDisconnected, nil
+}
+
+// Subscribe delivers the power, association, address and hotplug
+// events the kernel reports for the interface
+func (iwdBackend *IWDBackend) Subscribe(iface string) (<-chan InterfaceEvent, func(), error) {
+	stream, streamErr := netlinkEvents(iface, true)
+	if streamErr != nil {
+		return nil, nil, streamErr
+	}
+	return stream.subscription()
+}
+
+// Diagnose reports on iwctl, the iwd daemon and access to its state
+// directory, which Connect writes credentials to
+func (iwdBackend *IWDBackend) Diagnose() BackendReport {
+	serviceCheck := Check{Name: "iwd", Kind: CheckService, Present: iwdBackend.iwctl.Running(), Required: true}
+	if !serviceCheck.Present {
+		serviceCheck.Detail = "iwd is not running"
+	}
+	report := BackendReport{Checks: []Check{
+		commandCheck("iwctl", iwdBackend.iwctl.IsInstalled(), true, iwdBackend.iwctl.Version),
+		serviceCheck,
+	}}
+	report.Problems, report.Usable = missingRequired(report.Checks)
+	writable := false
+	if probe, probeErr := ioutil.TempFile(iwdBackend.StateDir, ".wifimgr"); probeErr == nil {
+		probe.Close()
+		os.Remove(probe.Name())
+		writable = true
+	} else if report.Usable {
+		report.Problems = append(report.Problems, "can't write known networks to "+iwdBackend.StateDir+", run as root")
+	}
+	report.Privileged = report.Usable && writable
+	report.Features = Features{
+		Scan:    report.Usable,
+		Connect: report.Usable && writable,
+	}
+	return report
+}
@@ -0,0 +1,74 @@
+package wifimanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ottopress/WifiManager/linux"
+)
+
+// newTestIWDBackend returns an iwd backend writing to a temporary
+// state directory, with iwd never found running so iwctl isn't run
+func newTestIWDBackend(t *testing.T) *IWDBackend {
+	return &IWDBackend{StateDir: t.TempDir(), iwctl: &linux.IWCtl{ProcDir: t.TempDir()}}
+}
+
+func TestIWDConnectWritesNetworkFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  WifiNetwork
+		file     string
+		contents string
+	}{
+		{
+			name:     "passphrase",
+			network:  WifiNetwork{SSID: "home", SecurityKey: "correct horse", Security: []WifiNetworkSecurity{{Protocol: SecurityWPA2}}},
+			file:     "home.psk",
+			contents: "[Security]\nPassphrase=correct horse\n",
+		},
+		{
+			name:     "hidden open network",
+			network:  WifiNetwork{SSID: "attic", Hidden: true},
+			file:     "attic.open",
+			contents: "[Settings]\nHidden=true\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			iwdBackend := newTestIWDBackend(t)
+			path := filepath.Join(iwdBackend.StateDir, test.file)
+			if writeErr := ioutil.WriteFile(path, []byte("stale"), 0644); writeErr != nil {
+				t.Fatal(writeErr)
+			}
+			if connectErr := iwdBackend.Connect("wlan0", test.network); connectErr != linux.ErrIWDNotRunning {
+				t.Fatalf("Connect = %v, want ErrIWDNotRunning", connectErr)
+			}
+			data, readErr := ioutil.ReadFile(path)
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			if string(data) != test.contents {
+				t.Errorf("wrote %q, want %q", data, test.contents)
+			}
+			info, _ := os.Stat(path)
+			if info.Mode().Perm() != 0600 {
+				t.Errorf("mode = %o, want 600", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestIWDConnectRejectsInjectedSettings(t *testing.T) {
+	for _, protocol := range []int{SecurityWPA2, SecurityWPA3} {
+		iwdBackend := newTestIWDBackend(t)
+		network := WifiNetwork{SSID: "home", SecurityKey: "correct horse\n[Settings]\nAutoConnect=true", Security: []WifiNetworkSecurity{{Protocol: protocol}}}
+		if connectErr := iwdBackend.Connect("wlan0", network); connectErr != ErrInvalidPassphrase {
+			t.Errorf("protocol %d: Connect = %v, want ErrInvalidPassphrase", protocol, connectErr)
+		}
+		if files, _ := ioutil.ReadDir(iwdBackend.StateDir); len(files) != 0 {
+			t.Errorf("protocol %d: wrote %d files", protocol, len(files))
+		}
+	}
+}
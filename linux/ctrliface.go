@@ -0,0 +1,132 @@
+package linux
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// CtrlTimeout is how long a request on a control interface socket
+	// may take before it is abandoned.
+	CtrlTimeout = 10 * time.Second
+	// ctrlBufferSize is large enough for the biggest replies hostapd
+	// and wpa_supplicant send (SCAN_RESULTS, STA dumps).
+	ctrlBufferSize = 16384
+)
+
+var (
+	// ctrlCounter keeps the local socket names unique within the process
+	ctrlCounter uint32
+	// ErrCtrlFail is returned when the daemon answers a request with FAIL
+	ErrCtrlFail = errors.New("ctrl: request failed")
+)
+
+// ctrlConn is a connection to a hostapd or wpa_supplicant control
+// interface. Both daemons speak the same datagram protocol: the
+// client binds its own socket and every request gets a single reply.
+type ctrlConn struct {
+	conn      *net.UnixConn
+	localPath string
+}
+
+// dialCtrl opens a connection to the control socket at the provided path
+func dialCtrl(remotePath string) (*ctrlConn, error) {
+	localPath := filepath.Join(os.TempDir(), fmt.Sprintf("wifimanager-%d-%d", os.Getpid(), atomic.AddUint32(&ctrlCounter, 1)))
+	localAddr := &net.UnixAddr{Name: localPath, Net: "unixgram"}
+	remoteAddr := &net.UnixAddr{Name: remotePath, Net: "unixgram"}
+	conn, dialErr := net.DialUnix("unixgram", localAddr, remoteAddr)
+	if dialErr != nil {
+		os.Remove(localPath)
+		return nil, dialErr
+	}
+	return &ctrlConn{conn: conn, localPath: localPath}, nil
+}
+
+// Request sends the command and returns the reply. Unsolicited event
+// messages, which start with "<", are skipped.
+func (ctrl *ctrlConn) Request(command string) (string, error) {
+	return ctrl.RequestTimeout(command, CtrlTimeout)
+}
+
+// RequestTimeout sends the command and returns the reply, giving up
+// after the provided timeout
+func (ctrl *ctrlConn) RequestTimeout(command string, timeout time.Duration) (string, error) {
+	deadlineErr := ctrl.conn.SetDeadline(time.Now().Add(timeout))
+	if deadlineErr != nil {
+		return "", deadlineErr
+	}
+	_, writeErr := ctrl.conn.Write([]byte(command))
+	if writeErr != nil {
+		return "", writeErr
+	}
+	buffer := make([]byte, ctrlBufferSize)
+	for {
+		length, readErr := ctrl.conn.Read(buffer)
+		if readErr != nil {
+			return "", readErr
+		}
+		reply := string(buffer[:length])
+		if strings.HasPrefix(reply, "<") {
+			continue
+		}
+		if strings.HasPrefix(reply, "FAIL") {
+			return reply, ErrCtrlFail
+		}
+		return reply, nil
+	}
+}
+
+// WaitEvent waits for an unsolicited event message with one of the
+// provided names, which the daemon only sends once the connection is
+// attached, and returns the message without its "<level>" prefix
+func (ctrl *ctrlConn) WaitEvent(timeout time.Duration, events ...string) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		message, readErr := ctrl.ReadEvent(deadline.Sub(time.Now()))
+		if readErr != nil {
+			return "", readErr
+		}
+		for _, event := range events {
+			if strings.HasPrefix(message, event) {
+				return message, nil
+			}
+		}
+	}
+}
+
+// ReadEvent waits for the next unsolicited event message and returns
+// it without its "<level>" prefix
+func (ctrl *ctrlConn) ReadEvent(timeout time.Duration) (string, error) {
+	deadlineErr := ctrl.conn.SetDeadline(time.Now().Add(timeout))
+	if deadlineErr != nil {
+		return "", deadlineErr
+	}
+	buffer := make([]byte, ctrlBufferSize)
+	for {
+		length, readErr := ctrl.conn.Read(buffer)
+		if readErr != nil {
+			return "", readErr
+		}
+		message := string(buffer[:length])
+		if !strings.HasPrefix(message, "<") {
+			continue
+		}
+		if end := strings.Index(message, ">"); end >= 0 {
+			message = message[end+1:]
+		}
+		return message, nil
+	}
+}
+
+// Close closes the connection and removes the local socket
+func (ctrl *ctrlConn) Close() error {
+	closeErr := ctrl.conn.Close()
+	os.Remove(ctrl.localPath)
+	return closeErr
+}
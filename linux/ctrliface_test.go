@@ -0,0 +1,81 @@
+package linux
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCtrl is a control interface socket answering requests the way
+// hostapd and wpa_supplicant do, from canned replies
+type fakeCtrl struct {
+	conn     *net.UnixConn
+	mutex    sync.Mutex
+	replies  map[string]string
+	requests []string
+}
+
+// newFakeCtrl listens on the control socket of the interface in dir.
+// Requests without a canned reply are answered with "FAIL".
+func newFakeCtrl(t *testing.T, dir, iface string, replies map[string]string) *fakeCtrl {
+	t.Helper()
+	path := filepath.Join(dir, iface)
+	conn, listenErr := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if listenErr != nil {
+		t.Fatalf("listening on %s: %v", path, listenErr)
+	}
+	ctrl := &fakeCtrl{conn: conn, replies: replies}
+	t.Cleanup(func() {
+		conn.Close()
+		os.Remove(path)
+	})
+	go ctrl.serve()
+	return ctrl
+}
+
+// serve answers every request until the socket is closed
+func (ctrl *fakeCtrl) serve() {
+	buffer := make([]byte, ctrlBufferSize)
+	for {
+		length, addr, readErr := ctrl.conn.ReadFromUnix(buffer)
+		if readErr != nil {
+			return
+		}
+		request := string(buffer[:length])
+		ctrl.mutex.Lock()
+		ctrl.requests = append(ctrl.requests, request)
+		reply, found := ctrl.replies[request]
+		ctrl.mutex.Unlock()
+		if !found {
+			reply = "FAIL\n"
+		}
+		ctrl.conn.WriteToUnix([]byte(reply), addr)
+	}
+}
+
+// received returns the requests received so far
+func (ctrl *fakeCtrl) received() []string {
+	ctrl.mutex.Lock()
+	defer ctrl.mutex.Unlock()
+	return append([]string{}, ctrl.requests...)
+}
+
+func TestCtrlRequest(t *testing.T) {
+	dir := t.TempDir()
+	newFakeCtrl(t, dir, "wlan0", map[string]string{"PING": "PONG\n"})
+	ctrl, dialErr := dialCtrl(filepath.Join(dir, "wlan0"))
+	if dialErr != nil {
+		t.Fatal(dialErr)
+	}
+	defer ctrl.Close()
+	reply, requestErr := ctrl.Request("PING")
+	if requestErr != nil || strings.TrimSpace(reply) != "PONG" {
+		t.Fatalf("PING = %q, %v", reply, requestErr)
+	}
+	if _, failErr := ctrl.Request("UNKNOWN"); failErr != ErrCtrlFail {
+		t.Fatalf("UNKNOWN error = %v, want ErrCtrlFail", failErr)
+	}
+}
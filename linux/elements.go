@@ -0,0 +1,186 @@
+package linux
+
+import (
+	"encoding/binary"
+)
+
+const (
+	elementSSID           = 0
+	elementDSParameterSet = 3
+	elementCountry        = 7
+	elementHTCapabilities = 45
+	elementRSN            = 48
+	elementHTOperation    = 61
+	elementVHTCapability  = 191
+	elementVHTOperation   = 192
+	elementVendorSpecific = 221
+	elementExtension      = 255
+	// extensionHECapabilities is the extension ID of the HE
+	// capabilities element
+	extensionHECapabilities = 35
+)
+
+var (
+	// cipherSuites names the cipher suites of the 00-0F-AC OUI the way
+	// iw prints them
+	cipherSuites = map[byte]string{
+		1:  "WEP-40",
+		2:  "TKIP",
+		4:  "CCMP",
+		5:  "WEP-104",
+		8:  "GCMP",
+		9:  "GCMP-256",
+		10: "CCMP-256",
+	}
+	// authSuites names the authentication suites of the 00-0F-AC OUI
+	// the way IWScanResult holds them
+	authSuites = map[byte]string{
+		1:  "802.1X",
+		2:  "PSK",
+		3:  "FT/802.1X",
+		4:  "FT/PSK",
+		5:  "802.1X/SHA-256",
+		6:  "PSK/SHA-256",
+		8:  "SAE",
+		9:  "FT/SAE",
+		11: "802.1X/SUITE-B",
+		12: "802.1X/SUITE-B-192",
+		18: "OWE",
+		24: "SAE-EXT-KEY",
+	}
+	// rsnOUI and wpaOUI prefix the suites of the RSN and WPA elements
+	rsnOUI = []byte{0x00, 0x0f, 0xac}
+	wpaOUI = []byte{0x00, 0x50, 0xf2}
+)
+
+// InformationElements holds what a BSS advertises in the information
+// elements of its beacons and probe responses. ChannelWidth is in MHz.
+type InformationElements struct {
+	SSID            string
+	Channel         int
+	ChannelWidth    int
+	Country         string
+	WPA             bool
+	RSN             bool
+	GroupCipher     string
+	PairwiseCiphers []string
+	AuthSuites      []string
+	HT              bool
+	VHT             bool
+	HE              bool
+}
+
+// ParseInformationElements parses the information elements of a BSS.
+// Truncated elements are skipped.
+func ParseInformationElements(data []byte) InformationElements {
+	elements := InformationElements{}
+	secondaryChannel := false
+	for len(data) >= 2 {
+		id, length := data[0], int(data[1])
+		if 2+length > len(data) {
+			break
+		}
+		body := data[2 : 2+length]
+		data = data[2+length:]
+		switch id {
+		case elementSSID:
+			elements.SSID = string(body)
+		case elementDSParameterSet:
+			if len(body) >= 1 {
+				elements.Channel = int(body[0])
+			}
+		case elementCountry:
+			if len(body) >= 2 {
+				elements.Country = string(body[:2])
+			}
+		case elementHTCapabilities:
+			elements.HT = true
+		case elementHTOperation:
+			// the secondary channel offset is 1 above and 3 below
+			if len(body) >= 2 {
+				secondaryChannel = body[1]&0x3 == 1 || body[1]&0x3 == 3
+			}
+		case elementVHTCapability:
+			elements.VHT = true
+		case elementVHTOperation:
+			if len(body) >= 1 {
+				switch body[0] {
+				case 1:
+					elements.ChannelWidth = 80
+				case 2, 3:
+					elements.ChannelWidth = 160
+				}
+			}
+		case elementExtension:
+			if len(body) >= 1 && body[0] == extensionHECapabilities {
+				elements.HE = true
+			}
+		case elementRSN:
+			elements.RSN = true
+			elements.parseSuites(body, rsnOUI, true)
+		case elementVendorSpecific:
+			if len(body) >= 4 && string(body[:3]) == string(wpaOUI) && body[3] == 1 {
+				elements.WPA = true
+				elements.parseSuites(body[4:], wpaOUI, !elements.RSN)
+			}
+		}
+	}
+	if elements.ChannelWidth == 0 {
+		elements.ChannelWidth = 20
+		if secondaryChannel {
+			elements.ChannelWidth = 40
+		}
+	}
+	return elements
+}
+
+// parseSuites parses the version, group cipher, pairwise ciphers and
+// authentication suites the RSN and WPA elements share. The group
+// cipher is only taken over when group is set, so that RSN wins over
+// WPA.
+func (elements *InformationElements) parseSuites(body []byte, oui []byte, group bool) {
+	// skip the version
+	if len(body) < 2 {
+		return
+	}
+	body = body[2:]
+	if len(body) < 4 {
+		return
+	}
+	if name := suiteName(body[:4], oui, cipherSuites); name != "" && group {
+		elements.GroupCipher = name
+	}
+	body = body[4:]
+	pairwise, body := readSuites(body)
+	for _, suite := range pairwise {
+		elements.PairwiseCiphers = appendMissing(elements.PairwiseCiphers, suiteName(suite, oui, cipherSuites))
+	}
+	auth, _ := readSuites(body)
+	for _, suite := range auth {
+		elements.AuthSuites = appendMissing(elements.AuthSuites, suiteName(suite, oui, authSuites))
+	}
+}
+
+// readSuites reads a count prefixed list of four byte suites and
+// returns the rest of the body
+func readSuites(body []byte) ([][]byte, []byte) {
+	if len(body) < 2 {
+		return nil, nil
+	}
+	count := int(binary.LittleEndian.Uint16(body[:2]))
+	body = body[2:]
+	suites := [][]byte{}
+	for index := 0; index < count && len(body) >= 4; index++ {
+		suites = append(suites, body[:4])
+		body = body[4:]
+	}
+	return suites, body
+}
+
+// suiteName returns the name of a suite of the provided OUI
+func suiteName(suite []byte, oui []byte, names map[byte]string) string {
+	if string(suite[:3]) != string(oui) {
+		return ""
+	}
+	return names[suite[3]]
+}
@@ -0,0 +1,31 @@
+package linux
+
+// FrequencyChannel returns the channel number of the provided
+// frequency in MHz, or 0 if it isn't a WiFi channel frequency
+func FrequencyChannel(frequency int) int {
+	switch {
+	case frequency == 2484:
+		return 14
+	case frequency >= 2412 && frequency < 2484:
+		return (frequency - 2407) / 5
+	case frequency >= 5160 && frequency <= 5885:
+		return (frequency - 5000) / 5
+	case frequency >= 5955 && frequency <= 7115:
+		return (frequency - 5950) / 5
+	}
+	return 0
+}
+
+// ChannelFrequency returns the frequency in MHz of a 2.4GHz or 5GHz
+// channel, or 0 if the channel number isn't known
+func ChannelFrequency(channel int) int {
+	switch {
+	case channel == 14:
+		return 2484
+	case channel >= 1 && channel < 14:
+		return 2407 + channel*5
+	case channel >= 32 && channel <= 177:
+		return 5000 + channel*5
+	}
+	return 0
+}
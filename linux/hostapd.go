@@ -0,0 +1,405 @@
+package linux
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// HostapdOpen runs the access point without any security
+	HostapdOpen int = iota
+	// HostapdWPA2 runs the access point with WPA2-PSK
+	HostapdWPA2
+	// HostapdWPA3 runs the access point with WPA3-SAE
+	HostapdWPA3
+	// HostapdWPA2WPA3 runs the access point in WPA2/WPA3 transition
+	// mode so older clients can still join
+	HostapdWPA2WPA3
+	// HostapdStartTimeout is how long Start waits for the control
+	// socket of a freshly launched hostapd to appear
+	HostapdStartTimeout = 10 * time.Second
+)
+
+var (
+	// ErrHostapdRunning is returned when starting an instance that
+	// already manages a hostapd process
+	ErrHostapdRunning = errors.New("hostapd: already running")
+	// ErrHostapdNotRunning is returned when stopping an instance that
+	// doesn't manage a hostapd process
+	ErrHostapdNotRunning = errors.New("hostapd: not running")
+	// ErrHostapdPassphrase is returned when the passphrase of a secured
+	// access point is neither 8 to 63 printable ASCII characters nor a
+	// raw PSK of 64 hex digits
+	ErrHostapdPassphrase = errors.New("hostapd: passphrase must be 8 to 63 printable ASCII characters or 64 hex digits")
+	// ErrHostapdSAEPSK is returned when a raw PSK is given for an
+	// access point using SAE, which needs the passphrase itself
+	ErrHostapdSAEPSK = errors.New("hostapd: wpa3 needs a passphrase rather than a raw psk")
+	// ErrHostapdSetting is returned when a setting holds characters
+	// that would end its line in the configuration file
+	ErrHostapdSetting = errors.New("hostapd: setting contains control characters")
+)
+
+// Hostapd is a wrapper for the hostapd daemon running an access
+// point on a single interface.
+type Hostapd struct {
+	Interface  string
+	Executable string
+	ConfigDir  string
+	CtrlDir    string
+	cmd        *exec.Cmd
+	exited     chan error
+}
+
+// HostapdConfig represents the settings of the access point hostapd
+// is launched with
+type HostapdConfig struct {
+	SSID        string
+	Channel     int
+	Security    int
+	Passphrase  string
+	CountryCode string
+	Hidden      bool
+	Driver      string
+}
+
+// HostapdStation represents a client associated with the access point
+type HostapdStation struct {
+	MAC           string
+	Flags         []string
+	Signal        int
+	RxBytes       uint64
+	TxBytes       uint64
+	ConnectedTime int
+	Attributes    map[string]string
+}
+
+// NewHostapd creates a new instance of the hostapd wrapper for the
+// provided interface.
+func NewHostapd(iface string) *Hostapd {
+	return &Hostapd{
+		Interface:  iface,
+		Executable: "hostapd",
+		ConfigDir:  os.TempDir(),
+		CtrlDir:    "/var/run/hostapd",
+	}
+}
+
+// IsInstalled returns whether or not the hostapd executable
+// can be found in the current PATH environment variable.
+func (hostapd *Hostapd) IsInstalled() bool {
+	_, err := exec.LookPath(hostapd.Executable)
+	if err != nil {
+		return false
+	}
+	return true
+}
+
+// Version returns the version hostapd reports, such as "v2.10". hostapd
+// exits with an error after printing it, so only the output counts.
+func (hostapd *Hostapd) Version() (string, error) {
+	cmdOut, _ := exec.Command(hostapd.Executable, "-v").CombinedOutput()
+	return toolVersion(cmdOut, "hostapd")
+}
+
+// Marshal returns the hostapd.conf representation of the config
+// for the provided interface. Every value is checked first so that
+// none of them can end its line and inject other settings.
+func (config HostapdConfig) Marshal(iface, ctrlDir string) ([]byte, error) {
+	driver := config.Driver
+	if driver == "" {
+		driver = "nl80211"
+	}
+	for _, setting := range []string{iface, ctrlDir, driver, config.CountryCode} {
+		if hasControlCharacters(setting) {
+			return nil, ErrHostapdSetting
+		}
+	}
+	rawPSK := false
+	if config.Security != HostapdOpen {
+		var passphraseErr error
+		rawPSK, passphraseErr = checkPassphrase(config.Passphrase)
+		if passphraseErr != nil {
+			return nil, passphraseErr
+		}
+		if rawPSK && config.Security != HostapdWPA2 {
+			return nil, ErrHostapdSAEPSK
+		}
+	}
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "interface=%s\n", iface)
+	fmt.Fprintf(&buffer, "driver=%s\n", driver)
+	fmt.Fprintf(&buffer, "ctrl_interface=%s\n", ctrlDir)
+	// ssid2 without quotes is read as hex, which sidesteps any
+	// escaping issues with unusual SSIDs
+	fmt.Fprintf(&buffer, "ssid2=%s\n", hex.EncodeToString([]byte(config.SSID)))
+	if config.Channel > 14 {
+		buffer.WriteString("hw_mode=a\n")
+	} else {
+		buffer.WriteString("hw_mode=g\n")
+	}
+	fmt.Fprintf(&buffer, "channel=%d\n", config.Channel)
+	if config.CountryCode != "" {
+		fmt.Fprintf(&buffer, "country_code=%s\n", strings.ToUpper(config.CountryCode))
+		buffer.WriteString("ieee80211d=1\n")
+	}
+	if config.Hidden {
+		buffer.WriteString("ignore_broadcast_ssid=1\n")
+	}
+	switch config.Security {
+	case HostapdWPA2:
+		buffer.WriteString("wpa=2\nwpa_key_mgmt=WPA-PSK\nrsn_pairwise=CCMP\n")
+	case HostapdWPA3:
+		buffer.WriteString("wpa=2\nwpa_key_mgmt=SAE\nrsn_pairwise=CCMP\nieee80211w=2\n")
+	case HostapdWPA2WPA3:
+		buffer.WriteString("wpa=2\nwpa_key_mgmt=WPA-PSK SAE\nrsn_pairwise=CCMP\nieee80211w=1\n")
+	}
+	switch {
+	case config.Security == HostapdOpen:
+	case rawPSK:
+		fmt.Fprintf(&buffer, "wpa_psk=%s\n", strings.ToLower(config.Passphrase))
+	default:
+		fmt.Fprintf(&buffer, "wpa_passphrase=%s\n", config.Passphrase)
+	}
+	return buffer.Bytes(), nil
+}
+
+// checkPassphrase returns whether the passphrase is a raw PSK of 64 hex
+// digits, failing unless it is one or 8 to 63 printable ASCII
+// characters
+func checkPassphrase(passphrase string) (bool, error) {
+	if len(passphrase) == 64 {
+		if _, decodeErr := hex.DecodeString(passphrase); decodeErr == nil {
+			return true, nil
+		}
+	}
+	if len(passphrase) < 8 || len(passphrase) > 63 {
+		return false, ErrHostapdPassphrase
+	}
+	for index := 0; index < len(passphrase); index++ {
+		if passphrase[index] < 0x20 || passphrase[index] > 0x7e {
+			return false, ErrHostapdPassphrase
+		}
+	}
+	return false, nil
+}
+
+// hasControlCharacters returns whether or not the text holds ASCII
+// control characters, such as the newline ending a setting
+func hasControlCharacters(text string) bool {
+	for index := 0; index < len(text); index++ {
+		if text[index] < 0x20 || text[index] == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteSecretFile writes a file holding secrets, readable by its owner
+// only. Any existing file is
+// replaced rather than reused, as a file created ahead of time by
+// another user would keep its own permissions.
+func WriteSecretFile(path string, data []byte) error {
+	removeErr := os.Remove(path)
+	if removeErr != nil && !os.IsNotExist(removeErr) {
+		return removeErr
+	}
+	file, openErr := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if openErr != nil {
+		return openErr
+	}
+	_, writeErr := file.Write(data)
+	closeErr := file.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		os.Remove(path)
+	}
+	return writeErr
+}
+
+// configPath returns the location of the generated configuration file
+func (hostapd *Hostapd) configPath() string {
+	return filepath.Join(hostapd.ConfigDir, "hostapd-"+hostapd.Interface+".conf")
+}
+
+// ctrlPath returns the location of the control socket for the interface
+func (hostapd *Hostapd) ctrlPath() string {
+	return filepath.Join(hostapd.CtrlDir, hostapd.Interface)
+}
+
+// Start writes the configuration and launches hostapd, returning once
+// its control socket answers. A control socket left behind by a
+// hostapd that is gone is removed first, while one that still answers
+// means another hostapd runs on the interface.
+func (hostapd *Hostapd) Start(config HostapdConfig) error {
+	if hostapd.cmd != nil {
+		return ErrHostapdRunning
+	}
+	configData, marshalErr := config.Marshal(hostapd.Interface, hostapd.CtrlDir)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	if _, statErr := os.Stat(hostapd.ctrlPath()); statErr == nil {
+		if hostapd.ping() {
+			return ErrHostapdRunning
+		}
+		if removeErr := os.Remove(hostapd.ctrlPath()); removeErr != nil {
+			return removeErr
+		}
+	}
+	writeErr := WriteSecretFile(hostapd.configPath(), configData)
+	if writeErr != nil {
+		return writeErr
+	}
+	cmd := exec.Command(hostapd.Executable, hostapd.configPath())
+	startErr := cmd.Start()
+	if startErr != nil {
+		os.Remove(hostapd.configPath())
+		return startErr
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	deadline := time.Now().Add(HostapdStartTimeout)
+	for {
+		select {
+		case exitErr := <-exited:
+			os.Remove(hostapd.configPath())
+			if exitErr == nil {
+				exitErr = errors.New("hostapd: exited during startup")
+			}
+			return exitErr
+		case <-time.After(100 * time.Millisecond):
+		}
+		if hostapd.ping() {
+			break
+		}
+		if time.Now().After(deadline) {
+			cmd.Process.Kill()
+			<-exited
+			os.Remove(hostapd.configPath())
+			return errors.New("hostapd: control socket did not appear")
+		}
+	}
+	hostapd.cmd = cmd
+	hostapd.exited = exited
+	return nil
+}
+
+// ping returns whether or not a hostapd answers on the control socket
+func (hostapd *Hostapd) ping() bool {
+	ctrl, dialErr := dialCtrl(hostapd.ctrlPath())
+	if dialErr != nil {
+		return false
+	}
+	defer ctrl.Close()
+	reply, requestErr := ctrl.RequestTimeout("PING", time.Second)
+	return requestErr == nil && strings.TrimSpace(reply) == "PONG"
+}
+
+// Stop terminates the hostapd process and removes its configuration
+func (hostapd *Hostapd) Stop() error {
+	if hostapd.cmd == nil {
+		return ErrHostapdNotRunning
+	}
+	signalErr := hostapd.cmd.Process.Signal(syscall.SIGTERM)
+	if signalErr == nil {
+		select {
+		case <-hostapd.exited:
+		case <-time.After(HostapdStartTimeout):
+			hostapd.cmd.Process.Kill()
+			<-hostapd.exited
+		}
+	}
+	hostapd.cmd = nil
+	hostapd.exited = nil
+	os.Remove(hostapd.configPath())
+	return nil
+}
+
+// Running returns whether or not the instance manages a hostapd process
+func (hostapd *Hostapd) Running() bool {
+	return hostapd.cmd != nil
+}
+
+// Request sends a raw command to the hostapd control interface
+func (hostapd *Hostapd) Request(command string) (string, error) {
+	ctrl, dialErr := dialCtrl(hostapd.ctrlPath())
+	if dialErr != nil {
+		return "", dialErr
+	}
+	defer ctrl.Close()
+	return ctrl.Request(command)
+}
+
+// Stations returns all clients currently known to the access point
+func (hostapd *Hostapd) Stations() ([]HostapdStation, error) {
+	ctrl, dialErr := dialCtrl(hostapd.ctrlPath())
+	if dialErr != nil {
+		return nil, dialErr
+	}
+	defer ctrl.Close()
+
+	stations := []HostapdStation{}
+	reply, replyErr := ctrl.Request("STA-FIRST")
+	for {
+		if replyErr != nil {
+			return stations, replyErr
+		}
+		station := parseStation(reply)
+		if station == nil {
+			return stations, nil
+		}
+		stations = append(stations, *station)
+		reply, replyErr = ctrl.Request("STA-NEXT " + station.MAC)
+	}
+}
+
+// parseStation parses a STA-FIRST/STA-NEXT reply, which is the MAC
+// address on the first line followed by key=value pairs, or nil if
+// the reply is empty.
+func parseStation(reply string) *HostapdStation {
+	scanner := bufio.NewScanner(strings.NewReader(reply))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) == "" {
+		return nil
+	}
+	station := &HostapdStation{
+		MAC:        strings.TrimSpace(scanner.Text()),
+		Attributes: map[string]string{},
+	}
+	for scanner.Scan() {
+		pair := strings.SplitN(scanner.Text(), "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+		station.Attributes[pair[0]] = pair[1]
+		switch pair[0] {
+		case "flags":
+			flags := strings.Trim(pair[1], "[]")
+			if flags != "" {
+				station.Flags = strings.Split(flags, "][")
+			}
+		case "signal":
+			station.Signal, _ = strconv.Atoi(pair[1])
+		case "rx_bytes":
+			station.RxBytes, _ = strconv.ParseUint(pair[1], 10, 64)
+		case "tx_bytes":
+			station.TxBytes, _ = strconv.ParseUint(pair[1], 10, 64)
+		case "connected_time":
+			station.ConnectedTime, _ = strconv.Atoi(pair[1])
+		}
+	}
+	return station
+}
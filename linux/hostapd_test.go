@@ -0,0 +1,138 @@
+package linux
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHostapdConfigMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   HostapdConfig
+		contains []string
+		absent   []string
+		err      error
+	}{
+		{
+			name:     "wpa2",
+			config:   HostapdConfig{SSID: "Setup\nchannel=1", Channel: 6, Security: HostapdWPA2, Passphrase: "password1", CountryCode: "de"},
+			contains: []string{"ssid2=" + hex.EncodeToString([]byte("Setup\nchannel=1")) + "\n", "wpa_key_mgmt=WPA-PSK\n", "wpa_passphrase=password1\n", "country_code=DE\n", "hw_mode=g\n"},
+			absent:   []string{"channel=1\n"},
+		},
+		{
+			name:     "raw psk",
+			config:   HostapdConfig{SSID: "Setup", Channel: 36, Security: HostapdWPA2, Passphrase: strings.Repeat("AB", 32)},
+			contains: []string{"wpa_psk=" + strings.Repeat("ab", 32) + "\n", "hw_mode=a\n"},
+			absent:   []string{"wpa_passphrase="},
+		},
+		{
+			name:     "open",
+			config:   HostapdConfig{SSID: "Setup", Channel: 1, Hidden: true},
+			contains: []string{"ignore_broadcast_ssid=1\n"},
+			absent:   []string{"wpa="},
+		},
+		{
+			name:   "injected passphrase",
+			config: HostapdConfig{SSID: "Setup", Channel: 6, Security: HostapdWPA2, Passphrase: "password1\nwpa=0"},
+			err:    ErrHostapdPassphrase,
+		},
+		{
+			name:   "short passphrase",
+			config: HostapdConfig{SSID: "Setup", Channel: 6, Security: HostapdWPA2WPA3, Passphrase: "short"},
+			err:    ErrHostapdPassphrase,
+		},
+		{
+			name:   "sae raw psk",
+			config: HostapdConfig{SSID: "Setup", Channel: 6, Security: HostapdWPA3, Passphrase: strings.Repeat("ab", 32)},
+			err:    ErrHostapdSAEPSK,
+		},
+		{
+			name:   "injected country",
+			config: HostapdConfig{SSID: "Setup", Channel: 6, CountryCode: "DE\nwpa=0"},
+			err:    ErrHostapdSetting,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, marshalErr := test.config.Marshal("wlan0", "/var/run/hostapd")
+			if marshalErr != test.err {
+				t.Fatalf("error = %v, want %v", marshalErr, test.err)
+			}
+			for _, text := range test.contains {
+				if !strings.Contains(string(data), text) {
+					t.Errorf("config lacks %q:\n%s", text, data)
+				}
+			}
+			for _, text := range test.absent {
+				if strings.Contains(string(data), text) {
+					t.Errorf("config holds %q:\n%s", text, data)
+				}
+			}
+		})
+	}
+}
+
+func TestHostapdStations(t *testing.T) {
+	dir := t.TempDir()
+	newFakeCtrl(t, dir, "wlan0", map[string]string{
+		"STA-FIRST":                  "02:00:00:00:00:01\nflags=[AUTH][ASSOC][AUTHORIZED]\nsignal=-42\nrx_bytes=100\ntx_bytes=200\nconnected_time=30\n",
+		"STA-NEXT 02:00:00:00:00:01": "02:00:00:00:00:02\nflags=[AUTH]\nsignal=-70\n",
+		"STA-NEXT 02:00:00:00:00:02": "",
+	})
+	hostapd := NewHostapd("wlan0")
+	hostapd.CtrlDir = dir
+	stations, stationsErr := hostapd.Stations()
+	if stationsErr != nil {
+		t.Fatal(stationsErr)
+	}
+	if len(stations) != 2 {
+		t.Fatalf("got %d stations, want 2", len(stations))
+	}
+	first := stations[0]
+	if first.MAC != "02:00:00:00:00:01" || first.Signal != -42 || first.RxBytes != 100 || first.TxBytes != 200 || first.ConnectedTime != 30 {
+		t.Errorf("first station = %+v", first)
+	}
+	if strings.Join(first.Flags, ",") != "AUTH,ASSOC,AUTHORIZED" {
+		t.Errorf("first station flags = %v", first.Flags)
+	}
+	if stations[1].MAC != "02:00:00:00:00:02" || stations[1].Signal != -70 {
+		t.Errorf("second station = %+v", stations[1])
+	}
+}
+
+func TestHostapdStartWithRunningSocket(t *testing.T) {
+	dir := t.TempDir()
+	newFakeCtrl(t, dir, "wlan0", map[string]string{"PING": "PONG\n"})
+	hostapd := NewHostapd("wlan0")
+	hostapd.CtrlDir = dir
+	hostapd.ConfigDir = t.TempDir()
+	hostapd.Executable = "false"
+	startErr := hostapd.Start(HostapdConfig{SSID: "Setup", Channel: 6})
+	if startErr != ErrHostapdRunning {
+		t.Fatalf("Start error = %v, want ErrHostapdRunning", startErr)
+	}
+}
+
+func TestHostapdStartRemovesStaleSocket(t *testing.T) {
+	dir := t.TempDir()
+	stalePath := filepath.Join(dir, "wlan0")
+	if writeErr := ioutil.WriteFile(stalePath, nil, 0600); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	hostapd := NewHostapd("wlan0")
+	hostapd.CtrlDir = dir
+	hostapd.ConfigDir = t.TempDir()
+	// false exits straight away, so Start fails once the stale socket
+	// is out of the way
+	hostapd.Executable = "false"
+	if startErr := hostapd.Start(HostapdConfig{SSID: "Setup", Channel: 6}); startErr == nil || startErr == ErrHostapdRunning {
+		t.Fatalf("Start error = %v, want the exit of hostapd", startErr)
+	}
+	if _, statErr := os.Stat(stalePath); !os.IsNotExist(statErr) {
+		t.Fatalf("stale socket still exists: %v", statErr)
+	}
+}
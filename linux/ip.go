@@ -0,0 +1,70 @@
+package linux
+
+import (
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// IP is a wrapper for the iproute2 ip command.
+type IP struct{}
+
+// NewIP creates a new instance of an IP command wrapper.
+func NewIP() *IP {
+	return &IP{}
+}
+
+// IsInstalled returns whether or not the ip executable
+// can be found in the current PATH environment variable.
+func (ip *IP) IsInstalled() bool {
+	_, err := exec.LookPath("ip")
+	if err != nil {
+		return false
+	}
+	return true
+}
+
+// Version returns the iproute2 version the ip command reports, such
+// as "6.1.0"
+func (ip *IP) Version() (string, error) {
+	cmdOut, cmdErr := exec.Command("ip", "-V").CombinedOutput()
+	if cmdErr != nil {
+		return "", cmdErr
+	}
+	for _, field := range strings.Fields(string(cmdOut)) {
+		field = strings.TrimRight(field, ",")
+		if strings.HasPrefix(field, "iproute2-") {
+			return strings.TrimPrefix(strings.TrimPrefix(field, "iproute2-"), "ss"), nil
+		}
+	}
+	return "", ErrNoVersion
+}
+
+// Up brings the provided interface up
+func (ip *IP) Up(iface string) error {
+	cmd := exec.Command("ip", "link", "set", "dev", iface, "up")
+	_, cmdErr := cmd.CombinedOutput()
+	if cmdErr != nil {
+		return cmdErr
+	}
+	return nil
+}
+
+// Down brings the provided interface down
+func (ip *IP) Down(iface string) error {
+	cmd := exec.Command("ip", "link", "set", "dev", iface, "down")
+	_, cmdErr := cmd.CombinedOutput()
+	if cmdErr != nil {
+		return cmdErr
+	}
+	return nil
+}
+
+// Status returns whether or not the provided interface is up
+func (ip *IP) Status(iface string) (bool, error) {
+	netIface, ifaceErr := net.InterfaceByName(iface)
+	if ifaceErr != nil {
+		return false, ifaceErr
+	}
+	return netIface.Flags&net.FlagUp != 0, nil
+}
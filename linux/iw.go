@@ -0,0 +1,594 @@
+package linux
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// IW is a wrapper for the nl80211 iw command. It needs root for
+// anything but reading state.
+type IW struct{}
+
+// IWScanResult represents a BSS from the iw scan listing. Signal is in
+// dBm, Frequency in MHz and LastSeen in milliseconds. AuthSuites holds
+// the authentication suites of the RSN and WPA elements, such as PSK,
+// SAE or 802.1X. ChannelWidth is in MHz, as advertised by the HT and
+// VHT operation elements.
+type IWScanResult struct {
+	BSSID           string
+	SSID            string
+	Frequency       int
+	Channel         int
+	ChannelWidth    int
+	Signal          int
+	LastSeen        int
+	Country         string
+	Privacy         bool
+	WPA             bool
+	RSN             bool
+	GroupCipher     string
+	PairwiseCiphers []string
+	AuthSuites      []string
+	HT              bool
+	VHT             bool
+	HE              bool
+	Associated      bool
+}
+
+// IWLink represents the link of a station interface. Bitrates are in
+// MBit/s.
+type IWLink struct {
+	Connected  bool
+	BSSID      string
+	SSID       string
+	Frequency  int
+	Signal     int
+	RxBytes    uint64
+	TxBytes    uint64
+	RxBitrate  float64
+	TxBitrate  float64
+	Attributes map[string]string
+}
+
+// IWStation represents a peer from the iw station dump, the access
+// point of a station interface or a client of an access point.
+// InactiveTime is in milliseconds and ConnectedTime in seconds.
+type IWStation struct {
+	MAC           string
+	InactiveTime  int
+	RxBytes       uint64
+	RxPackets     uint64
+	TxBytes       uint64
+	TxPackets     uint64
+	TxRetries     uint64
+	TxFailed      uint64
+	Signal        int
+	SignalAverage int
+	RxBitrate     float64
+	TxBitrate     float64
+	Authorized    bool
+	Authenticated bool
+	Associated    bool
+	ConnectedTime int
+	Attributes    map[string]string
+}
+
+// IWPhy represents a wireless PHY from the iw phy listing
+type IWPhy struct {
+	Name           string
+	Index          int
+	InterfaceModes []string
+	Bands          []IWBand
+}
+
+// IWBand represents a band supported by a PHY. HTCapabilities holds
+// the HT capability flags, such as HT20/HT40 or RX LDPC, VHTCapabilities
+// the VHT ones and HEIftypes the interface types HE is supported in.
+// Bitrates are in MBit/s.
+type IWBand struct {
+	Number          int
+	HTCapabilities  []string
+	HTMCS           string
+	VHTCapabilities []string
+	HEIftypes       []string
+	Bitrates        []float64
+	Frequencies     []IWFrequency
+}
+
+// IWFrequency represents a channel of a band. MaxPower is in dBm and
+// NoIR is set when the channel may not initiate radiation.
+type IWFrequency struct {
+	Frequency int
+	Channel   int
+	MaxPower  float64
+	Disabled  bool
+	NoIR      bool
+	Radar     bool
+}
+
+// NewIW creates a new instance of an IW command wrapper.
+func NewIW() *IW {
+	return &IW{}
+}
+
+// IsInstalled returns whether or not the iw executable
+// can be found in the current PATH environment variable.
+func (iw *IW) IsInstalled() bool {
+	_, err := exec.LookPath("iw")
+	if err != nil {
+		return false
+	}
+	return true
+}
+
+// Version returns the version iw reports, such as "5.19"
+func (iw *IW) Version() (string, error) {
+	cmdOut, cmdErr := exec.Command("iw", "--version").CombinedOutput()
+	if cmdErr != nil {
+		return "", cmdErr
+	}
+	fields := strings.Fields(string(cmdOut))
+	if len(fields) < 3 || fields[1] != "version" {
+		return "", ErrNoVersion
+	}
+	return fields[2], nil
+}
+
+// run runs iw, returning its output or an error holding the message
+// iw printed
+func (iw *IW) run(args ...string) ([]byte, error) {
+	cmdOut, cmdErr := exec.Command("iw", args...).CombinedOutput()
+	if cmdErr != nil {
+		message := strings.TrimSpace(string(cmdOut))
+		if message == "" {
+			return nil, cmdErr
+		}
+		return nil, errors.New("iw: " + message)
+	}
+	return cmdOut, nil
+}
+
+// Scan scans on the interface and returns the BSSs found
+func (iw *IW) Scan(iface string) ([]IWScanResult, error) {
+	cmdOut, cmdErr := iw.run("dev", iface, "scan")
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+	return ParseIWScan(cmdOut), nil
+}
+
+// Link returns the link of the station interface
+func (iw *IW) Link(iface string) (IWLink, error) {
+	cmdOut, cmdErr := iw.run("dev", iface, "link")
+	if cmdErr != nil {
+		return IWLink{}, cmdErr
+	}
+	return ParseIWLink(cmdOut), nil
+}
+
+// Stations returns the peers of the interface
+func (iw *IW) Stations(iface string) ([]IWStation, error) {
+	cmdOut, cmdErr := iw.run("dev", iface, "station", "dump")
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+	return ParseIWStations(cmdOut), nil
+}
+
+// PhyInfo returns the capabilities of the PHY, such as phy0
+func (iw *IW) PhyInfo(phy string) (IWPhy, error) {
+	cmdOut, cmdErr := iw.run("phy", phy, "info")
+	if cmdErr != nil {
+		return IWPhy{}, cmdErr
+	}
+	phys := ParseIWPhys(cmdOut)
+	if len(phys) == 0 {
+		return IWPhy{}, errors.New("iw: no information on " + phy)
+	}
+	return phys[0], nil
+}
+
+// Connect joins the interface to an open network, or to a WEP one
+// when a key is provided. iw can't do the handshakes WPA needs.
+func (iw *IW) Connect(iface, ssid, wepKey string) error {
+	args := []string{"dev", iface, "connect", ssid}
+	if wepKey != "" {
+		args = append(args, "keys", "0:"+wepKey)
+	}
+	_, cmdErr := iw.run(args...)
+	return cmdErr
+}
+
+// Disconnect leaves the network of the interface
+func (iw *IW) Disconnect(iface string) error {
+	_, cmdErr := iw.run("dev", iface, "disconnect")
+	return cmdErr
+}
+
+// ParseIWScan parses the output of iw dev <iface> scan
+func ParseIWScan(output []byte) []IWScanResult {
+	results := []IWScanResult{}
+	var current *IWScanResult
+	// section is the element the indented lines that follow belong to
+	section := ""
+	secondaryChannel := false
+	finish := func() {
+		if current == nil {
+			return
+		}
+		if current.Channel == 0 {
+			current.Channel = FrequencyChannel(current.Frequency)
+		}
+		if current.ChannelWidth == 0 {
+			current.ChannelWidth = 20
+			if secondaryChannel {
+				current.ChannelWidth = 40
+			}
+		}
+		results = append(results, *current)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "BSS ") {
+			finish()
+			bssid := strings.TrimPrefix(line, "BSS ")
+			if end := strings.IndexAny(bssid, "( "); end >= 0 {
+				bssid = bssid[:end]
+			}
+			current = &IWScanResult{
+				BSSID:      bssid,
+				Associated: strings.HasSuffix(line, "-- associated"),
+			}
+			section = ""
+			secondaryChannel = false
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value := splitIWField(line)
+		if !strings.HasPrefix(line, "\t\t") && !strings.HasPrefix(key, "*") {
+			section = key
+		}
+		switch key {
+		case "freq":
+			frequency, _ := strconv.ParseFloat(value, 64)
+			current.Frequency = int(frequency)
+		case "signal":
+			signal, _ := strconv.ParseFloat(strings.TrimSuffix(value, " dBm"), 64)
+			current.Signal = int(signal)
+		case "last seen":
+			// newer iw releases also print the boottime it was seen at
+			if strings.HasSuffix(value, " ms ago") {
+				current.LastSeen, _ = strconv.Atoi(strings.TrimSuffix(value, " ms ago"))
+			}
+		case "SSID":
+			current.SSID = unescapeIWSSID(value)
+		case "capability":
+			current.Privacy = strings.Contains(value, "Privacy")
+		case "DS Parameter set":
+			current.Channel, _ = strconv.Atoi(strings.TrimPrefix(value, "channel "))
+		case "Country":
+			current.Country = strings.Fields(value + " ")[0]
+		case "RSN":
+			current.RSN = true
+		case "WPA":
+			current.WPA = true
+		case "HT capabilities":
+			current.HT = true
+		case "VHT capabilities":
+			current.VHT = true
+		case "HE capabilities":
+			current.HE = true
+		case "* Group cipher":
+			if section == "RSN" || current.GroupCipher == "" {
+				current.GroupCipher = value
+			}
+		case "* Pairwise ciphers":
+			current.PairwiseCiphers = appendMissing(current.PairwiseCiphers, strings.Fields(value)...)
+		case "* Authentication suites":
+			current.AuthSuites = appendMissing(current.AuthSuites, strings.Fields(strings.Replace(value, "IEEE 802.1X", "802.1X", -1))...)
+		case "* secondary channel offset":
+			secondaryChannel = value == "above" || value == "below"
+		case "* channel width":
+			// VHT operation: 1 is 80 MHz, 2 160 MHz and 3 80+80 MHz
+			switch strings.Fields(value + " ")[0] {
+			case "1":
+				current.ChannelWidth = 80
+			case "2", "3":
+				current.ChannelWidth = 160
+			}
+		}
+	}
+	finish()
+	return results
+}
+
+// ParseIWLink parses the output of iw dev <iface> link
+func ParseIWLink(output []byte) IWLink {
+	link := IWLink{Attributes: map[string]string{}}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Connected to ") {
+			link.Connected = true
+			link.BSSID = strings.Fields(strings.TrimPrefix(line, "Connected to "))[0]
+			continue
+		}
+		key, value := splitIWField(line)
+		if value == "" {
+			continue
+		}
+		link.Attributes[key] = value
+		switch key {
+		case "SSID":
+			link.SSID = unescapeIWSSID(value)
+		case "freq":
+			frequency, _ := strconv.ParseFloat(value, 64)
+			link.Frequency = int(frequency)
+		case "signal":
+			link.Signal = parseSignal(value)
+		case "RX":
+			link.RxBytes = parseCount(value)
+		case "TX":
+			link.TxBytes = parseCount(value)
+		case "rx bitrate":
+			link.RxBitrate = parseBitrate(value)
+		case "tx bitrate":
+			link.TxBitrate = parseBitrate(value)
+		}
+	}
+	return link
+}
+
+// ParseIWStations parses the output of iw dev <iface> station dump
+func ParseIWStations(output []byte) []IWStation {
+	stations := []IWStation{}
+	var current *IWStation
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Station ") {
+			if current != nil {
+				stations = append(stations, *current)
+			}
+			current = &IWStation{
+				MAC:        strings.Fields(strings.TrimPrefix(line, "Station "))[0],
+				Attributes: map[string]string{},
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value := splitIWField(line)
+		if value == "" {
+			continue
+		}
+		current.Attributes[key] = value
+		switch key {
+		case "inactive time":
+			current.InactiveTime = int(parseCount(value))
+		case "rx bytes":
+			current.RxBytes = parseCount(value)
+		case "rx packets":
+			current.RxPackets = parseCount(value)
+		case "tx bytes":
+			current.TxBytes = parseCount(value)
+		case "tx packets":
+			current.TxPackets = parseCount(value)
+		case "tx retries":
+			current.TxRetries = parseCount(value)
+		case "tx failed":
+			current.TxFailed = parseCount(value)
+		case "signal":
+			current.Signal = parseSignal(value)
+		case "signal avg":
+			current.SignalAverage = parseSignal(value)
+		case "rx bitrate":
+			current.RxBitrate = parseBitrate(value)
+		case "tx bitrate":
+			current.TxBitrate = parseBitrate(value)
+		case "authorized":
+			current.Authorized = value == "yes"
+		case "authenticated":
+			current.Authenticated = value == "yes"
+		case "associated":
+			current.Associated = value == "yes"
+		case "connected time":
+			current.ConnectedTime = int(parseCount(value))
+		}
+	}
+	if current != nil {
+		stations = append(stations, *current)
+	}
+	return stations
+}
+
+// ParseIWPhys parses the output of iw phy, or iw phy <phy> info
+func ParseIWPhys(output []byte) []IWPhy {
+	phys := []IWPhy{}
+	var current *IWPhy
+	var band *IWBand
+	// section is the list the indented lines that follow belong to
+	section := ""
+	finishBand := func() {
+		if current != nil && band != nil {
+			current.Bands = append(current.Bands, *band)
+		}
+		band = nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Wiphy ") {
+			finishBand()
+			if current != nil {
+				phys = append(phys, *current)
+			}
+			current = &IWPhy{Name: strings.TrimSpace(strings.TrimPrefix(line, "Wiphy "))}
+			// iw only prints the wiphy index line since 4.x, but the
+			// kernel names phys after their index until renamed
+			current.Index, _ = strconv.Atoi(strings.TrimPrefix(current.Name, "phy"))
+			section = ""
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		depth := len(line) - len(strings.TrimLeft(line, "\t"))
+		text := strings.TrimSpace(line)
+		if depth == 1 {
+			finishBand()
+			key, value := splitIWField(line)
+			section = key
+			switch {
+			case key == "wiphy index":
+				current.Index, _ = strconv.Atoi(value)
+			case strings.HasPrefix(key, "Band "):
+				band = &IWBand{}
+				band.Number, _ = strconv.Atoi(strings.TrimPrefix(key, "Band "))
+			}
+			continue
+		}
+		if band == nil {
+			if section == "Supported interface modes" && strings.HasPrefix(text, "* ") {
+				current.InterfaceModes = append(current.InterfaceModes, strings.TrimPrefix(text, "* "))
+			}
+			continue
+		}
+		if depth == 2 {
+			key, value := splitIWField(line)
+			section = key
+			switch {
+			case strings.HasPrefix(key, "HT TX/RX MCS rate indexes supported"):
+				band.HTMCS = value
+			case strings.HasPrefix(key, "HE Iftypes"):
+				band.HEIftypes = appendMissing(band.HEIftypes, strings.Split(value, ", ")...)
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(section, "Capabilities"):
+			band.HTCapabilities = append(band.HTCapabilities, text)
+		case strings.HasPrefix(section, "VHT Capabilities"):
+			band.VHTCapabilities = append(band.VHTCapabilities, text)
+		case strings.HasPrefix(section, "Bitrates"), strings.HasPrefix(section, "Frequencies"):
+			parseBandItem(band, text)
+		}
+	}
+	finishBand()
+	if current != nil {
+		phys = append(phys, *current)
+	}
+	return phys
+}
+
+// parseBandItem parses a bitrate such as "* 5.5 Mbps (short preamble
+// supported)" or a frequency such as "* 5260.0 MHz [52] (20.0 dBm)
+// (radar detection)" into the band
+func parseBandItem(band *IWBand, text string) {
+	fields := strings.Fields(strings.TrimPrefix(text, "* "))
+	if len(fields) < 2 {
+		return
+	}
+	value, parseErr := strconv.ParseFloat(fields[0], 64)
+	if parseErr != nil {
+		return
+	}
+	switch fields[1] {
+	case "Mbps":
+		band.Bitrates = append(band.Bitrates, value)
+	case "MHz":
+		frequency := IWFrequency{Frequency: int(value)}
+		if len(fields) > 2 {
+			frequency.Channel, _ = strconv.Atoi(strings.Trim(fields[2], "[]"))
+		}
+		if open := strings.Index(text, "("); open >= 0 {
+			details := text[open:]
+			frequency.Disabled = strings.Contains(details, "disabled")
+			frequency.NoIR = strings.Contains(details, "no IR") || strings.Contains(details, "passive scanning") || strings.Contains(details, "no IBSS")
+			frequency.Radar = strings.Contains(details, "radar detection")
+			power := strings.Fields(strings.TrimPrefix(details, "("))
+			if len(power) >= 2 && strings.HasPrefix(power[1], "dBm") {
+				frequency.MaxPower, _ = strconv.ParseFloat(power[0], 64)
+			}
+		}
+		band.Frequencies = append(band.Frequencies, frequency)
+	}
+}
+
+// splitIWField splits an indented "key: value" line of iw's output
+func splitIWField(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	separator := strings.Index(line, ":")
+	if separator < 0 {
+		return line, ""
+	}
+	return line[:separator], strings.TrimSpace(line[separator+1:])
+}
+
+// unescapeIWSSID decodes the \xNN escapes iw prints unprintable SSID
+// bytes as, including backslashes and spaces at either end. Hidden
+// networks that advertise their SSID as zero bytes get an empty one.
+func unescapeIWSSID(value string) string {
+	var buffer bytes.Buffer
+	hidden := true
+	for index := 0; index < len(value); index++ {
+		char := value[index]
+		if char == '\\' && index+3 < len(value) && value[index+1] == 'x' {
+			if decoded, parseErr := strconv.ParseUint(value[index+2:index+4], 16, 8); parseErr == nil {
+				char = byte(decoded)
+				index += 3
+			}
+		}
+		hidden = hidden && char == 0
+		buffer.WriteByte(char)
+	}
+	if hidden {
+		return ""
+	}
+	return buffer.String()
+}
+
+// parseSignal returns the signal in dBm of a value such as
+// "-52 [-54, -55] dBm"
+func parseSignal(value string) int {
+	signal, _ := strconv.ParseFloat(strings.Fields(value + " 0")[0], 64)
+	return int(signal)
+}
+
+// parseCount returns the number a value such as "1234 bytes (10
+// packets)" or "310 ms" starts with
+func parseCount(value string) uint64 {
+	count, _ := strconv.ParseUint(strings.Fields(value + " 0")[0], 10, 64)
+	return count
+}
+
+// parseBitrate returns the MBit/s of a value such as "866.7 MBit/s
+// VHT-MCS 9 80MHz short GI VHT-NSS 2"
+func parseBitrate(value string) float64 {
+	bitrate, _ := strconv.ParseFloat(strings.Fields(value + " 0")[0], 64)
+	return bitrate
+}
+
+// appendMissing appends the values that aren't in the list yet
+func appendMissing(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, item := range list {
+			if item == value {
+				found = true
+			}
+		}
+		if !found && value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,243 @@
+package linux
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readIWFixture reads iw output from testdata
+func readIWFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, readErr := ioutil.ReadFile(filepath.Join("testdata", "iw", name))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return data
+}
+
+func TestParseIWScan(t *testing.T) {
+	tests := []struct {
+		fixture string
+		results []IWScanResult
+	}{
+		{
+			fixture: "scan-ath9k-iw4.14.txt",
+			results: []IWScanResult{
+				{BSSID: "00:1f:33:aa:bb:01", SSID: "workshop", Frequency: 2412, Channel: 1, ChannelWidth: 20, Signal: -39, LastSeen: 460, Privacy: true, WPA: true, RSN: true, GroupCipher: "TKIP", PairwiseCiphers: []string{"CCMP", "TKIP"}, AuthSuites: []string{"PSK"}, HT: true, Associated: true},
+				{BSSID: "00:0c:41:de:ad:01", SSID: "linksys", Frequency: 2462, Channel: 11, ChannelWidth: 20, Signal: -77, LastSeen: 3120, Privacy: true},
+				{BSSID: "02:1a:11:f0:9c:44", SSID: "Free Cafe WiFi", Frequency: 2437, Channel: 6, ChannelWidth: 20, Signal: -64, LastSeen: 1080, Country: "US"},
+			},
+		},
+		{
+			fixture: "scan-brcmfmac-iw5.9.txt",
+			results: []IWScanResult{
+				{BSSID: "dc:a6:32:00:11:22", SSID: "pi-lab", Frequency: 5745, Channel: 149, ChannelWidth: 80, Signal: -57, Privacy: true, RSN: true, GroupCipher: "CCMP", PairwiseCiphers: []string{"CCMP"}, AuthSuites: []string{"SAE"}, HT: true, VHT: true, Associated: true},
+				// hidden network advertising its SSID as zero bytes
+				{BSSID: "4a:5b:6c:7d:8e:9f", Frequency: 2412, Channel: 1, ChannelWidth: 20, Signal: -88, LastSeen: 4200, Privacy: true, RSN: true, GroupCipher: "CCMP", PairwiseCiphers: []string{"CCMP"}, AuthSuites: []string{"PSK"}},
+			},
+		},
+		{
+			fixture: "scan-iwlwifi-iw5.19.txt",
+			results: []IWScanResult{
+				{BSSID: "3c:37:86:1a:2b:3c", SSID: "Ottopress", Frequency: 5180, Channel: 36, ChannelWidth: 80, Signal: -48, LastSeen: 212, Country: "DE", Privacy: true, RSN: true, GroupCipher: "CCMP", PairwiseCiphers: []string{"CCMP"}, AuthSuites: []string{"PSK", "SAE"}, HT: true, VHT: true, HE: true, Associated: true},
+				{BSSID: "3e:37:86:1a:2b:3d", SSID: "Ottopress Guest", Frequency: 2437, Channel: 6, ChannelWidth: 20, Signal: -71, LastSeen: 1520, HT: true},
+				{BSSID: "70:4f:57:0e:11:22", SSID: "CorpNet", Frequency: 5500, Channel: 100, ChannelWidth: 160, Signal: -83, LastSeen: 1520, Privacy: true, RSN: true, GroupCipher: "CCMP", PairwiseCiphers: []string{"CCMP"}, AuthSuites: []string{"802.1X", "FT/802.1X"}, HT: true, VHT: true},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			results := ParseIWScan(readIWFixture(t, test.fixture))
+			if len(results) != len(test.results) {
+				t.Fatalf("got %d results, want %d", len(results), len(test.results))
+			}
+			for index := range results {
+				if !reflect.DeepEqual(results[index], test.results[index]) {
+					t.Errorf("result %d = %+v\nwant %+v", index, results[index], test.results[index])
+				}
+			}
+		})
+	}
+}
+
+func TestParseIWLink(t *testing.T) {
+	tests := []struct {
+		fixture string
+		link    IWLink
+	}{
+		{
+			fixture: "link-ath9k.txt",
+			link:    IWLink{Connected: true, BSSID: "00:1f:33:aa:bb:01", SSID: "workshop", Frequency: 2412, Signal: -39, RxBytes: 1192834, TxBytes: 283711, TxBitrate: 65},
+		},
+		{
+			fixture: "link-iwlwifi.txt",
+			link:    IWLink{Connected: true, BSSID: "3c:37:86:1a:2b:3c", SSID: "Ottopress", Frequency: 5180, Signal: -48, RxBytes: 48213977, TxBytes: 6128833, RxBitrate: 1200.9, TxBitrate: 960.7},
+		},
+		{
+			fixture: "link-not-connected.txt",
+			link:    IWLink{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			link := ParseIWLink(readIWFixture(t, test.fixture))
+			attributes := link.Attributes
+			link.Attributes = nil
+			if !reflect.DeepEqual(link, test.link) {
+				t.Fatalf("link = %+v\nwant %+v", link, test.link)
+			}
+			if test.link.Connected && attributes["dtim period"] != "1" {
+				t.Errorf("dtim period attribute = %q", attributes["dtim period"])
+			}
+		})
+	}
+}
+
+func TestParseIWStations(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		stations []IWStation
+	}{
+		{
+			fixture: "station-dump-ath9k-ap.txt",
+			stations: []IWStation{
+				{MAC: "8c:85:90:12:34:56", InactiveTime: 310, RxBytes: 2819334, RxPackets: 18211, TxBytes: 19283774, TxPackets: 16102, TxRetries: 1203, TxFailed: 12, Signal: -52, SignalAverage: -51, RxBitrate: 117, TxBitrate: 130, Authorized: true, Authenticated: true, Associated: true, ConnectedTime: 5812},
+				{MAC: "b8:27:eb:9a:bc:de", InactiveTime: 18230, RxBytes: 82113, RxPackets: 912, TxBytes: 41233, TxPackets: 488, TxRetries: 93, Signal: -74, SignalAverage: -73, RxBitrate: 6, TxBitrate: 39, Authenticated: true, Associated: true, ConnectedTime: 14},
+			},
+		},
+		{
+			fixture: "station-dump-iwlwifi.txt",
+			stations: []IWStation{
+				{MAC: "3c:37:86:1a:2b:3c", InactiveTime: 24, RxBytes: 48213977, RxPackets: 52114, TxBytes: 6128833, TxPackets: 23401, TxRetries: 812, TxFailed: 3, Signal: -48, SignalAverage: -47, RxBitrate: 1200.9, TxBitrate: 960.7, Authorized: true, Authenticated: true, Associated: true, ConnectedTime: 1873},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			stations := ParseIWStations(readIWFixture(t, test.fixture))
+			if len(stations) != len(test.stations) {
+				t.Fatalf("got %d stations, want %d", len(stations), len(test.stations))
+			}
+			for index := range stations {
+				stations[index].Attributes = nil
+				if !reflect.DeepEqual(stations[index], test.stations[index]) {
+					t.Errorf("station %d = %+v\nwant %+v", index, stations[index], test.stations[index])
+				}
+			}
+		})
+	}
+}
+
+func TestParseIWPhys(t *testing.T) {
+	type band struct {
+		number      int
+		htMCS       string
+		vht         bool
+		heIftypes   []string
+		bitrates    int
+		frequencies int
+		first       IWFrequency
+		last        IWFrequency
+	}
+	tests := []struct {
+		fixture string
+		name    string
+		index   int
+		modes   []string
+		bands   []band
+	}{
+		{
+			fixture: "phy-info-ath9k-iw4.14.txt",
+			name:    "phy0",
+			modes:   []string{"IBSS", "managed", "AP", "AP/VLAN", "monitor", "mesh point", "P2P-client", "P2P-GO", "outside context of a BSS"},
+			bands: []band{
+				{number: 1, htMCS: "0-15", heIftypes: []string{}, bitrates: 12, frequencies: 14, first: IWFrequency{Frequency: 2412, Channel: 1, MaxPower: 20}, last: IWFrequency{Frequency: 2484, Channel: 14, Disabled: true}},
+				{number: 2, htMCS: "0-15", heIftypes: []string{}, bitrates: 8, frequencies: 13, first: IWFrequency{Frequency: 5180, Channel: 36, MaxPower: 17}, last: IWFrequency{Frequency: 5825, Channel: 165, MaxPower: 30}},
+			},
+		},
+		{
+			fixture: "phy-info-iwlwifi-ax200-iw5.19.txt",
+			name:    "phy0",
+			modes:   []string{"IBSS", "managed", "AP", "AP/VLAN", "monitor", "P2P-client", "P2P-GO", "P2P-device"},
+			bands: []band{
+				{number: 1, htMCS: "0-15", heIftypes: []string{"managed", "AP"}, bitrates: 12, frequencies: 14, first: IWFrequency{Frequency: 2412, Channel: 1, MaxPower: 22}, last: IWFrequency{Frequency: 2484, Channel: 14, Disabled: true}},
+				{number: 2, htMCS: "0-15", vht: true, heIftypes: []string{"managed"}, bitrates: 8, frequencies: 15, first: IWFrequency{Frequency: 5180, Channel: 36, MaxPower: 22}, last: IWFrequency{Frequency: 5845, Channel: 169, Disabled: true}},
+				{number: 4, heIftypes: []string{"managed"}, frequencies: 3, first: IWFrequency{Frequency: 5955, Channel: 1, Disabled: true}, last: IWFrequency{Frequency: 5995, Channel: 9, Disabled: true}},
+			},
+		},
+		{
+			// iw 3.17 has no wiphy index line, so the index comes
+			// from the name
+			fixture: "phy-info-rtl8xxxu-iw3.17.txt",
+			name:    "phy1",
+			index:   1,
+			modes:   []string{"managed", "monitor"},
+			bands: []band{
+				{number: 1, htMCS: "0-7", heIftypes: []string{}, bitrates: 12, frequencies: 14, first: IWFrequency{Frequency: 2412, Channel: 1, MaxPower: 20}, last: IWFrequency{Frequency: 2484, Channel: 14, Disabled: true}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			phys := ParseIWPhys(readIWFixture(t, test.fixture))
+			if len(phys) != 1 {
+				t.Fatalf("got %d phys, want 1", len(phys))
+			}
+			phy := phys[0]
+			if phy.Name != test.name || phy.Index != test.index {
+				t.Errorf("phy = %s index %d, want %s index %d", phy.Name, phy.Index, test.name, test.index)
+			}
+			if !reflect.DeepEqual(phy.InterfaceModes, test.modes) {
+				t.Errorf("interface modes = %q, want %q", phy.InterfaceModes, test.modes)
+			}
+			if len(phy.Bands) != len(test.bands) {
+				t.Fatalf("got %d bands, want %d", len(phy.Bands), len(test.bands))
+			}
+			for index, want := range test.bands {
+				got := phy.Bands[index]
+				if got.Number != want.number || got.HTMCS != want.htMCS || (len(got.VHTCapabilities) > 0) != want.vht {
+					t.Errorf("band %d = number %d, mcs %q, %d vht capabilities", index, got.Number, got.HTMCS, len(got.VHTCapabilities))
+				}
+				if !reflect.DeepEqual(got.HEIftypes, want.heIftypes) && len(got.HEIftypes)+len(want.heIftypes) > 0 {
+					t.Errorf("band %d HE iftypes = %q, want %q", index, got.HEIftypes, want.heIftypes)
+				}
+				if len(got.Bitrates) != want.bitrates || len(got.Frequencies) != want.frequencies {
+					t.Fatalf("band %d has %d bitrates and %d frequencies, want %d and %d", index, len(got.Bitrates), len(got.Frequencies), want.bitrates, want.frequencies)
+				}
+				if want.frequencies > 0 && (got.Frequencies[0] != want.first || got.Frequencies[len(got.Frequencies)-1] != want.last) {
+					t.Errorf("band %d frequencies run from %+v to %+v", index, got.Frequencies[0], got.Frequencies[len(got.Frequencies)-1])
+				}
+			}
+		})
+	}
+}
+
+func TestParseIWPhysIndex(t *testing.T) {
+	output := []byte("Wiphy phy3\n\twiphy index: 3\nWiphy mywifi\n\twiphy index: 7\nWiphy phy12\n")
+	phys := ParseIWPhys(output)
+	indexes := map[string]int{}
+	for _, phy := range phys {
+		indexes[phy.Name] = phy.Index
+	}
+	if want := map[string]int{"phy3": 3, "mywifi": 7, "phy12": 12}; !reflect.DeepEqual(indexes, want) {
+		t.Fatalf("indexes = %v, want %v", indexes, want)
+	}
+}
+
+func TestUnescapeIWSSID(t *testing.T) {
+	tests := map[string]string{
+		`plain`:              "plain",
+		`\x20leading`:        " leading",
+		`back\x5cslash`:      `back\slash`,
+		`caf\xc3\xa9`:        "café",
+		`\x00\x00\x00\x00`:   "",
+		`not\xzzan escape\x`: `not\xzzan escape\x`,
+	}
+	for value, want := range tests {
+		if ssid := unescapeIWSSID(value); ssid != want {
+			t.Errorf("unescapeIWSSID(%q) = %q, want %q", value, ssid, want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package linux
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrIWDNotRunning is returned when iwctl is used while the iwd
+	// daemon isn't running, as iwctl would wait for it forever
+	ErrIWDNotRunning = errors.New("linux: iwd is not running")
+
+	// ansiEscapes matches the color codes iwctl decorates its tables
+	// with
+	ansiEscapes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+// IWCtl is a wrapper for the iwctl command of the iwd daemon.
+type IWCtl struct {
+	// ProcDir is where running processes are looked up to tell
+	// whether iwd is running
+	ProcDir string
+}
+
+// IWDDevice represents a device from the iwctl device listing
+type IWDDevice struct {
+	Name    string
+	Address string
+	Powered bool
+	Adapter string
+	Mode    string
+}
+
+// IWDNetwork represents a network from the iwctl network listing.
+// Security is one of open, psk, 8021x or wep.
+type IWDNetwork struct {
+	SSID      string
+	Security  string
+	Signal    int
+	Connected bool
+}
+
+// IWDStation represents the state of a station from the iwctl station
+// listing. State is one of connected, connecting, disconnected,
+// disconnecting or roaming.
+type IWDStation struct {
+	State            string
+	Scanning         bool
+	ConnectedNetwork string
+}
+
+// NewIWCtl creates a new instance of an IWCtl command wrapper.
+func NewIWCtl() *IWCtl {
+	return &IWCtl{ProcDir: "/proc"}
+}
+
+// IsInstalled returns whether or not the iwctl executable
+// can be found in the current PATH environment variable.
+func (iwctl *IWCtl) IsInstalled() bool {
+	_, err := exec.LookPath("iwctl")
+	if err != nil {
+		return false
+	}
+	return true
+}
+
+// Running returns whether or not an iwd process is running
+func (iwctl *IWCtl) Running() bool {
+	processes, readErr := ioutil.ReadDir(iwctl.ProcDir)
+	if readErr != nil {
+		return false
+	}
+	for _, process := range processes {
+		if _, pidErr := strconv.Atoi(process.Name()); pidErr != nil {
+			continue
+		}
+		comm, commErr := ioutil.ReadFile(filepath.Join(iwctl.ProcDir, process.Name(), "comm"))
+		if commErr == nil && strings.TrimSpace(string(comm)) == "iwd" {
+			return true
+		}
+	}
+	return false
+}
+
+// Version returns the version iwctl reports, such as "2.8"
+func (iwctl *IWCtl) Version() (string, error) {
+	cmdOut, cmdErr := exec.Command("iwctl", "--version").CombinedOutput()
+	if cmdErr != nil {
+		return "", cmdErr
+	}
+	version := strings.TrimSpace(string(cmdOut))
+	if version == "" {
+		return "", ErrNoVersion
+	}
+	return version, nil
+}
+
+// run runs iwctl without ever prompting, returning its output or an
+// error holding the message iwctl printed
+func (iwctl *IWCtl) run(args ...string) ([]byte, error) {
+	if !iwctl.Running() {
+		return nil, ErrIWDNotRunning
+	}
+	cmd := exec.Command("iwctl", append([]string{"--dont-ask"}, args...)...)
+	cmdOut, cmdErr := cmd.CombinedOutput()
+	cmdOut = ansiEscapes.ReplaceAll(cmdOut, nil)
+	if cmdErr != nil {
+		message := strings.TrimSpace(string(cmdOut))
+		if message == "" {
+			return nil, cmdErr
+		}
+		return nil, errors.New("iwctl: " + message)
+	}
+	return cmdOut, nil
+}
+
+// Devices returns all devices iwd manages
+func (iwctl *IWCtl) Devices() ([]IWDDevice, error) {
+	cmdOut, cmdErr := iwctl.run("device", "list")
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+	return ParseIWDDevices(cmdOut), nil
+}
+
+// Scan asks iwd to scan and returns the networks it sees. iwd scans in
+// the background, so the results may be from before the scan ends.
+func (iwctl *IWCtl) Scan(iface string) ([]IWDNetwork, error) {
+	_, scanErr := iwctl.run("station", iface, "scan")
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	return iwctl.Networks(iface)
+}
+
+// Networks returns the networks iwd sees, with their signal in dBm
+func (iwctl *IWCtl) Networks(iface string) ([]IWDNetwork, error) {
+	cmdOut, cmdErr := iwctl.run("station", iface, "get-networks", "rssi-dbms")
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+	return ParseIWDNetworks(cmdOut), nil
+}
+
+// Station returns the state of the station
+func (iwctl *IWCtl) Station(iface string) (IWDStation, error) {
+	cmdOut, cmdErr := iwctl.run("station", iface, "show")
+	if cmdErr != nil {
+		return IWDStation{}, cmdErr
+	}
+	return ParseIWDStation(cmdOut), nil
+}
+
+// ParseIWDDevices parses the output of iwctl device list
+func ParseIWDDevices(output []byte) []IWDDevice {
+	devices := []IWDDevice{}
+	_, rows := splitIWDTable(output)
+	for _, row := range rows {
+		fields := strings.Fields(row)
+		if len(fields) < 5 {
+			continue
+		}
+		devices = append(devices, IWDDevice{
+			Name:    fields[0],
+			Address: fields[1],
+			Powered: fields[2] == "on",
+			Adapter: fields[3],
+			Mode:    fields[4],
+		})
+	}
+	return devices
+}
+
+// ParseIWDNetworks parses the output of iwctl station get-networks
+// rssi-dbms. SSIDs are cut out of the line from where the column
+// starts up to the security and signal columns, so runs of spaces in
+// them are kept.
+func ParseIWDNetworks(output []byte) []IWDNetwork {
+	networks := []IWDNetwork{}
+	header, rows := splitIWDTable(output)
+	nameStart := strings.Index(header, "Network name")
+	if nameStart < 0 {
+		return networks
+	}
+	for _, row := range rows {
+		if len(row) <= nameStart {
+			continue
+		}
+		rest := strings.TrimRight(row[nameStart:], " \t")
+		fields := strings.Fields(rest)
+		if len(fields) < 3 {
+			continue
+		}
+		signal, signalErr := strconv.Atoi(fields[len(fields)-1])
+		if signalErr != nil {
+			continue
+		}
+		// the security and signal are the last two fields, which
+		// leaves the SSID in front of them
+		security := fields[len(fields)-2]
+		rest = strings.TrimRight(strings.TrimSuffix(rest, fields[len(fields)-1]), " \t")
+		network := IWDNetwork{
+			SSID:      strings.TrimRight(strings.TrimSuffix(rest, security), " \t"),
+			Security:  security,
+			Connected: strings.TrimSpace(row[:nameStart]) == ">",
+		}
+		// older iwctl releases print hundredths of a dBm
+		if signal < -1000 {
+			signal /= 100
+		}
+		network.Signal = signal
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// ParseIWDStation parses the output of iwctl station show. The
+// connected network is cut out of the value column so runs of spaces
+// in its SSID are kept.
+func ParseIWDStation(output []byte) IWDStation {
+	station := IWDStation{}
+	header, rows := splitIWDTable(output)
+	valueStart := strings.Index(header, "Value")
+	for _, row := range rows {
+		fields := strings.Fields(row)
+		// settable properties are marked with a star
+		if len(fields) > 0 && fields[0] == "*" {
+			fields = fields[1:]
+		}
+		switch {
+		case len(fields) >= 2 && fields[0] == "State":
+			station.State = fields[1]
+		case len(fields) >= 2 && fields[0] == "Scanning":
+			station.Scanning = fields[1] == "yes"
+		case len(fields) >= 3 && fields[0] == "Connected" && fields[1] == "network":
+			station.ConnectedNetwork = strings.Join(fields[2:], " ")
+			if valueStart > 0 && len(row) > valueStart {
+				station.ConnectedNetwork = strings.TrimRight(row[valueStart:], " \t")
+			}
+		}
+	}
+	return station
+}
+
+// Connect connects the station to the network. Its credentials have to
+// be in a known network file as iwctl is never allowed to prompt.
+func (iwctl *IWCtl) Connect(iface, ssid string) error {
+	_, cmdErr := iwctl.run("station", iface, "connect", ssid)
+	return cmdErr
+}
+
+// ConnectHidden connects the station to the hidden network
+func (iwctl *IWCtl) ConnectHidden(iface, ssid string) error {
+	_, cmdErr := iwctl.run("station", iface, "connect-hidden", ssid)
+	return cmdErr
+}
+
+// Disconnect disconnects the station from its network
+func (iwctl *IWCtl) Disconnect(iface string) error {
+	_, cmdErr := iwctl.run("station", iface, "disconnect")
+	return cmdErr
+}
+
+// SetPowered turns the device on or off
+func (iwctl *IWCtl) SetPowered(iface string, powered bool) error {
+	value := "off"
+	if powered {
+		value = "on"
+	}
+	_, cmdErr := iwctl.run("device", iface, "set-property", "Powered", value)
+	return cmdErr
+}
+
+// IWDNetworkFile returns the name iwd stores the settings of the
+// network under in its state directory. SSIDs made of anything but
+// letters, digits, spaces, dashes and underscores are hex encoded.
+func IWDNetworkFile(ssid, security string) string {
+	plain := ssid != ""
+	for _, char := range ssid {
+		if !(char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' || char == ' ' || char == '-' || char == '_') {
+			plain = false
+		}
+	}
+	if plain {
+		return ssid + "." + security
+	}
+	return "=" + hex.EncodeToString([]byte(ssid)) + "." + security
+}
+
+// splitIWDTable returns the column header line and the row lines of
+// an iwctl table, skipping its title and separators. Lines keep their
+// spacing so columns can be found by their offset in the header.
+func splitIWDTable(output []byte) (string, []string) {
+	header := ""
+	rows := []string{}
+	separators := 0
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "----"):
+			separators++
+		case trimmed == "":
+		// the column headers sit between the first two separators
+		case separators == 1:
+			header = line
+		// rows follow the separator under the column headers
+		case separators >= 2:
+			rows = append(rows, line)
+		}
+	}
+	return header, rows
+}
@@ -0,0 +1,82 @@
+package linux
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readIWCtlFixture reads iwctl output from testdata with its colors
+// removed, as run does
+func readIWCtlFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, readErr := ioutil.ReadFile(filepath.Join("testdata", "iwctl", name))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return ansiEscapes.ReplaceAll(data, nil)
+}
+
+func TestParseIWDNetworks(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		networks []IWDNetwork
+	}{
+		{
+			fixture: "get-networks-iwd2.txt",
+			networks: []IWDNetwork{
+				{SSID: "Home  Network", Security: "psk", Signal: -54, Connected: true},
+				{SSID: "cafe", Security: "open", Signal: -71},
+				{SSID: "printer", Security: "8021x", Signal: -88},
+			},
+		},
+		{
+			fixture: "get-networks-iwd1.txt",
+			networks: []IWDNetwork{
+				{SSID: "Office", Security: "psk", Signal: -61, Connected: true},
+				{SSID: "two  spaces", Security: "psk", Signal: -68},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			networks := ParseIWDNetworks(readIWCtlFixture(t, test.fixture))
+			if !reflect.DeepEqual(networks, test.networks) {
+				t.Fatalf("networks = %+v, want %+v", networks, test.networks)
+			}
+		})
+	}
+}
+
+func TestParseIWDStation(t *testing.T) {
+	station := ParseIWDStation(readIWCtlFixture(t, "station-show.txt"))
+	want := IWDStation{State: "connected", ConnectedNetwork: "Home  Network"}
+	if station != want {
+		t.Fatalf("station = %+v, want %+v", station, want)
+	}
+}
+
+func TestParseIWDDevices(t *testing.T) {
+	devices := ParseIWDDevices(readIWCtlFixture(t, "device-list.txt"))
+	want := []IWDDevice{
+		{Name: "wlan0", Address: "02:00:00:00:00:01", Powered: true, Adapter: "phy0", Mode: "station"},
+		{Name: "wlan1", Address: "02:00:00:00:00:02", Powered: false, Adapter: "phy1", Mode: "ap"},
+	}
+	if !reflect.DeepEqual(devices, want) {
+		t.Fatalf("devices = %+v, want %+v", devices, want)
+	}
+}
+
+func TestIWDNetworkFile(t *testing.T) {
+	tests := map[string]string{
+		"Home Network": "Home Network.psk",
+		"café":         "=636166c3a9.psk",
+		"a/b":          "=612f62.psk",
+	}
+	for ssid, name := range tests {
+		if file := IWDNetworkFile(ssid, "psk"); file != name {
+			t.Errorf("IWDNetworkFile(%q) = %q, want %q", ssid, file, name)
+		}
+	}
+}
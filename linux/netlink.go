@@ -0,0 +1,430 @@
+package linux
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	// netlinkRoute and netlinkGeneric are the netlink protocols of
+	// rtnetlink and generic netlink
+	netlinkRoute   = 0
+	netlinkGeneric = 16
+	// netlinkBufferSize is large enough for a full datagram of a dump
+	netlinkBufferSize = 65536
+
+	netlinkHeaderLen   = 16
+	genericHeaderLen   = 4
+	attributeHeaderLen = 4
+
+	// message flags
+	netlinkFlagRequest = 0x1
+	netlinkFlagMulti   = 0x2
+	netlinkFlagAck     = 0x4
+	netlinkFlagDump    = 0x300
+
+	// control message types
+	netlinkMessageNoop  = 0x1
+	netlinkMessageError = 0x2
+	netlinkMessageDone  = 0x3
+
+	// attributeNested and attributeNetByteOrder are flags carried in
+	// the type of an attribute
+	attributeNested       = 0x8000
+	attributeNetByteOrder = 0x4000
+
+	// generic netlink controller
+	genericControllerID       = 0x10
+	controllerCmdGetFamily    = 3
+	controllerAttrFamilyID    = 1
+	controllerAttrFamilyName  = 2
+	controllerAttrMcastGroups = 7
+	controllerAttrMcastName   = 1
+	controllerAttrMcastID     = 2
+)
+
+var (
+	// ErrNetlinkMessage is returned when a netlink message or one of
+	// its attributes is truncated
+	ErrNetlinkMessage = errors.New("netlink: malformed message")
+	// ErrNetlinkUnsupported is returned on platforms without netlink
+	ErrNetlinkUnsupported = errors.New("netlink: not supported on this platform")
+	// ErrNetlinkTimeout is returned when no reply arrived in time
+	ErrNetlinkTimeout = errors.New("netlink: timed out waiting for a reply")
+	// ErrNetlinkFamily is returned when the kernel doesn't know a
+	// generic netlink family
+	ErrNetlinkFamily = errors.New("netlink: unknown generic netlink family")
+
+	// nativeEndian is the byte order of netlink headers and attributes,
+	// which is the one of the host
+	nativeEndian = hostByteOrder()
+)
+
+// NetlinkMessage is a single netlink message. Data is the payload that
+// follows the header.
+type NetlinkMessage struct {
+	Type     uint16
+	Flags    uint16
+	Sequence uint32
+	PID      uint32
+	Data     []byte
+}
+
+// NetlinkAttribute is a type-length-value attribute of a netlink
+// message. Type has the nested and byte order flags stripped.
+type NetlinkAttribute struct {
+	Type uint16
+	Data []byte
+}
+
+// GenericMessage is the payload of a generic netlink message
+type GenericMessage struct {
+	Command    uint8
+	Version    uint8
+	Attributes []NetlinkAttribute
+}
+
+// Encode returns the message in its wire format
+func (message NetlinkMessage) Encode() []byte {
+	data := make([]byte, netlinkAlign(netlinkHeaderLen+len(message.Data)))
+	nativeEndian.PutUint32(data[0:4], uint32(netlinkHeaderLen+len(message.Data)))
+	nativeEndian.PutUint16(data[4:6], message.Type)
+	nativeEndian.PutUint16(data[6:8], message.Flags)
+	nativeEndian.PutUint32(data[8:12], message.Sequence)
+	nativeEndian.PutUint32(data[12:16], message.PID)
+	copy(data[netlinkHeaderLen:], message.Data)
+	return data
+}
+
+// ParseNetlinkMessages parses the messages of a netlink datagram
+func ParseNetlinkMessages(data []byte) ([]NetlinkMessage, error) {
+	messages := []NetlinkMessage{}
+	for len(data) >= netlinkHeaderLen {
+		length := int(nativeEndian.Uint32(data[0:4]))
+		if length < netlinkHeaderLen || length > len(data) {
+			return messages, ErrNetlinkMessage
+		}
+		messages = append(messages, NetlinkMessage{
+			Type:     nativeEndian.Uint16(data[4:6]),
+			Flags:    nativeEndian.Uint16(data[6:8]),
+			Sequence: nativeEndian.Uint32(data[8:12]),
+			PID:      nativeEndian.Uint32(data[12:16]),
+			Data:     data[netlinkHeaderLen:length],
+		})
+		if netlinkAlign(length) >= len(data) {
+			return messages, nil
+		}
+		data = data[netlinkAlign(length):]
+	}
+	if len(data) != 0 {
+		return messages, ErrNetlinkMessage
+	}
+	return messages, nil
+}
+
+// Err returns the error an error message carries, or nil for an
+// acknowledgement or any other type of message
+func (message NetlinkMessage) Err() error {
+	if message.Type != netlinkMessageError {
+		return nil
+	}
+	if len(message.Data) < 4 {
+		return ErrNetlinkMessage
+	}
+	code := int32(nativeEndian.Uint32(message.Data[0:4]))
+	if code == 0 {
+		return nil
+	}
+	return syscall.Errno(-code)
+}
+
+// Encode returns the generic netlink header followed by the attributes
+func (message GenericMessage) Encode() []byte {
+	return append([]byte{message.Command, message.Version, 0, 0}, EncodeNetlinkAttributes(message.Attributes)...)
+}
+
+// ParseGenericMessage parses the payload of a generic netlink message
+func ParseGenericMessage(data []byte) (GenericMessage, error) {
+	if len(data) < genericHeaderLen {
+		return GenericMessage{}, ErrNetlinkMessage
+	}
+	attributes, parseErr := ParseNetlinkAttributes(data[genericHeaderLen:])
+	return GenericMessage{Command: data[0], Version: data[1], Attributes: attributes}, parseErr
+}
+
+// EncodeNetlinkAttributes returns the attributes in their wire format,
+// each padded to a four byte boundary
+func EncodeNetlinkAttributes(attributes []NetlinkAttribute) []byte {
+	data := []byte{}
+	for _, attribute := range attributes {
+		encoded := make([]byte, netlinkAlign(attributeHeaderLen+len(attribute.Data)))
+		nativeEndian.PutUint16(encoded[0:2], uint16(attributeHeaderLen+len(attribute.Data)))
+		nativeEndian.PutUint16(encoded[2:4], attribute.Type)
+		copy(encoded[attributeHeaderLen:], attribute.Data)
+		data = append(data, encoded...)
+	}
+	return data
+}
+
+// ParseNetlinkAttributes parses a run of attributes
+func ParseNetlinkAttributes(data []byte) ([]NetlinkAttribute, error) {
+	attributes := []NetlinkAttribute{}
+	for len(data) >= attributeHeaderLen {
+		length := int(nativeEndian.Uint16(data[0:2]))
+		if length < attributeHeaderLen || length > len(data) {
+			return attributes, ErrNetlinkMessage
+		}
+		attributes = append(attributes, NetlinkAttribute{
+			Type: nativeEndian.Uint16(data[2:4]) &^ (attributeNested | attributeNetByteOrder),
+			Data: data[attributeHeaderLen:length],
+		})
+		if netlinkAlign(length) >= len(data) {
+			return attributes, nil
+		}
+		data = data[netlinkAlign(length):]
+	}
+	return attributes, nil
+}
+
+// findAttribute returns the attribute of the provided type
+func findAttribute(attributes []NetlinkAttribute, attributeType uint16) (NetlinkAttribute, bool) {
+	for _, attribute := range attributes {
+		if attribute.Type == attributeType {
+			return attribute, true
+		}
+	}
+	return NetlinkAttribute{}, false
+}
+
+// Uint8 returns the value of a u8 attribute
+func (attribute NetlinkAttribute) Uint8() uint8 {
+	if len(attribute.Data) < 1 {
+		return 0
+	}
+	return attribute.Data[0]
+}
+
+// Uint16 returns the value of a u16 attribute
+func (attribute NetlinkAttribute) Uint16() uint16 {
+	if len(attribute.Data) < 2 {
+		return 0
+	}
+	return nativeEndian.Uint16(attribute.Data)
+}
+
+// Uint32 returns the value of a u32 attribute
+func (attribute NetlinkAttribute) Uint32() uint32 {
+	if len(attribute.Data) < 4 {
+		return 0
+	}
+	return nativeEndian.Uint32(attribute.Data)
+}
+
+// Uint64 returns the value of a u64 attribute
+func (attribute NetlinkAttribute) Uint64() uint64 {
+	if len(attribute.Data) < 8 {
+		return 0
+	}
+	return nativeEndian.Uint64(attribute.Data)
+}
+
+// String returns the value of a string attribute without its
+// terminating NUL
+func (attribute NetlinkAttribute) String() string {
+	for index, char := range attribute.Data {
+		if char == 0 {
+			return string(attribute.Data[:index])
+		}
+	}
+	return string(attribute.Data)
+}
+
+// Nested parses the attributes nested in the attribute
+func (attribute NetlinkAttribute) Nested() ([]NetlinkAttribute, error) {
+	return ParseNetlinkAttributes(attribute.Data)
+}
+
+// uint32Attribute returns a u32 attribute
+func uint32Attribute(attributeType uint16, value uint32) NetlinkAttribute {
+	data := make([]byte, 4)
+	nativeEndian.PutUint32(data, value)
+	return NetlinkAttribute{Type: attributeType, Data: data}
+}
+
+// stringAttribute returns a NUL terminated string attribute
+func stringAttribute(attributeType uint16, value string) NetlinkAttribute {
+	return NetlinkAttribute{Type: attributeType, Data: append([]byte(value), 0)}
+}
+
+// nestedAttribute returns an attribute holding the provided ones
+func nestedAttribute(attributeType uint16, attributes ...NetlinkAttribute) NetlinkAttribute {
+	return NetlinkAttribute{Type: attributeType | attributeNested, Data: EncodeNetlinkAttributes(attributes)}
+}
+
+// netlinkAlign rounds the length up to a four byte boundary
+func netlinkAlign(length int) int {
+	return (length + 3) &^ 3
+}
+
+// hostByteOrder returns the byte order of the host
+func hostByteOrder() binary.ByteOrder {
+	value := uint16(1)
+	if *(*byte)(unsafe.Pointer(&value)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// netlinkConn sends requests over a netlink socket and collects the
+// replies that carry their sequence number
+type netlinkConn struct {
+	socket   *netlinkSocket
+	sequence uint32
+}
+
+// dialNetlink opens a connection for the provided netlink protocol
+func dialNetlink(protocol int) (*netlinkConn, error) {
+	socket, openErr := openNetlinkSocket(protocol)
+	if openErr != nil {
+		return nil, openErr
+	}
+	return &netlinkConn{socket: socket}, nil
+}
+
+// execute sends the request and returns the messages it was answered
+// with. Dump requests are complete once the kernel sends DONE, other
+// requests are acknowledged.
+func (conn *netlinkConn) execute(request NetlinkMessage) ([]NetlinkMessage, error) {
+	conn.sequence++
+	request.Sequence = conn.sequence
+	request.Flags |= netlinkFlagRequest
+	dump := request.Flags&netlinkFlagDump == netlinkFlagDump
+	if !dump {
+		request.Flags |= netlinkFlagAck
+	}
+	if sendErr := conn.socket.send(request.Encode()); sendErr != nil {
+		return nil, sendErr
+	}
+	replies := []NetlinkMessage{}
+	for {
+		messages, receiveErr := conn.socket.receive()
+		if receiveErr != nil {
+			return replies, receiveErr
+		}
+		for _, message := range messages {
+			if message.Sequence != request.Sequence {
+				continue
+			}
+			switch message.Type {
+			case netlinkMessageNoop:
+				continue
+			case netlinkMessageDone:
+				return replies, nil
+			case netlinkMessageError:
+				return replies, message.Err()
+			}
+			replies = append(replies, message)
+		}
+	}
+}
+
+// watch hands every message received on the connection to the handler
+// until the handler returns false, the returned function is called or
+// the socket fails. The connection is then closed and finish called.
+func (conn *netlinkConn) watch(handle func(message NetlinkMessage, done <-chan struct{}) bool, finish func()) func() {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		defer finish()
+		defer conn.close()
+		// wake up regularly to notice that the watch was stopped
+		if timeoutErr := conn.socket.setTimeout(time.Second); timeoutErr != nil {
+			return
+		}
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			messages, receiveErr := conn.socket.receive()
+			if receiveErr == ErrNetlinkTimeout {
+				continue
+			}
+			if receiveErr != nil {
+				return
+			}
+			for _, message := range messages {
+				if !handle(message, done) {
+					return
+				}
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// close closes the socket of the connection
+func (conn *netlinkConn) close() error {
+	return conn.socket.close()
+}
+
+// genericFamily is a generic netlink family resolved by the controller
+type genericFamily struct {
+	id     uint16
+	groups map[string]uint32
+}
+
+// resolveFamily asks the generic netlink controller for the ID and
+// multicast groups of the family with the provided name
+func (conn *netlinkConn) resolveFamily(name string) (genericFamily, error) {
+	request := GenericMessage{
+		Command:    controllerCmdGetFamily,
+		Version:    1,
+		Attributes: []NetlinkAttribute{stringAttribute(controllerAttrFamilyName, name)},
+	}
+	replies, executeErr := conn.execute(NetlinkMessage{Type: genericControllerID, Data: request.Encode()})
+	if executeErr == syscall.ENOENT {
+		return genericFamily{}, ErrNetlinkFamily
+	}
+	if executeErr != nil {
+		return genericFamily{}, executeErr
+	}
+	if len(replies) == 0 {
+		return genericFamily{}, ErrNetlinkFamily
+	}
+	return parseGenericFamily(replies[0].Data)
+}
+
+// parseGenericFamily parses the reply of the controller to a
+// CTRL_CMD_GETFAMILY request
+func parseGenericFamily(data []byte) (genericFamily, error) {
+	reply, parseErr := ParseGenericMessage(data)
+	if parseErr != nil {
+		return genericFamily{}, parseErr
+	}
+	family := genericFamily{groups: map[string]uint32{}}
+	id, found := findAttribute(reply.Attributes, controllerAttrFamilyID)
+	if !found {
+		return family, ErrNetlinkFamily
+	}
+	family.id = id.Uint16()
+	groupsAttribute, found := findAttribute(reply.Attributes, controllerAttrMcastGroups)
+	if !found {
+		return family, nil
+	}
+	groups, _ := groupsAttribute.Nested()
+	for _, group := range groups {
+		groupAttributes, _ := group.Nested()
+		groupName, nameFound := findAttribute(groupAttributes, controllerAttrMcastName)
+		groupID, idFound := findAttribute(groupAttributes, controllerAttrMcastID)
+		if nameFound && idFound {
+			family.groups[groupName.String()] = groupID.Uint32()
+		}
+	}
+	return family, nil
+}
@@ -0,0 +1,70 @@
+//go:build linux
+
+package linux
+
+import (
+	"syscall"
+	"time"
+)
+
+// solNetlink is the SOL_NETLINK socket option level, which the syscall
+// package lacks
+const solNetlink = 270
+
+// netlinkSocket is a raw netlink socket bound to a port the kernel
+// picked
+type netlinkSocket struct {
+	fd int
+}
+
+// openNetlinkSocket opens and binds a socket for the netlink protocol
+func openNetlinkSocket(protocol int) (*netlinkSocket, error) {
+	fd, socketErr := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, protocol)
+	if socketErr != nil {
+		return nil, socketErr
+	}
+	if bindErr := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); bindErr != nil {
+		syscall.Close(fd)
+		return nil, bindErr
+	}
+	return &netlinkSocket{fd: fd}, nil
+}
+
+// send sends a datagram to the kernel
+func (socket *netlinkSocket) send(data []byte) error {
+	return syscall.Sendto(socket.fd, data, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+}
+
+// receive waits for a datagram and parses its messages
+func (socket *netlinkSocket) receive() ([]NetlinkMessage, error) {
+	buffer := make([]byte, netlinkBufferSize)
+	for {
+		length, _, receiveErr := syscall.Recvfrom(socket.fd, buffer, 0)
+		if receiveErr == syscall.EINTR {
+			continue
+		}
+		if receiveErr == syscall.EAGAIN {
+			return nil, ErrNetlinkTimeout
+		}
+		if receiveErr != nil {
+			return nil, receiveErr
+		}
+		return ParseNetlinkMessages(buffer[:length])
+	}
+}
+
+// joinGroup subscribes the socket to a multicast group
+func (socket *netlinkSocket) joinGroup(group uint32) error {
+	return syscall.SetsockoptInt(socket.fd, solNetlink, syscall.NETLINK_ADD_MEMBERSHIP, int(group))
+}
+
+// setTimeout bounds how long receive waits, zero waiting forever
+func (socket *netlinkSocket) setTimeout(timeout time.Duration) error {
+	timeval := syscall.NsecToTimeval(timeout.Nanoseconds())
+	return syscall.SetsockoptTimeval(socket.fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &timeval)
+}
+
+// close closes the socket
+func (socket *netlinkSocket) close() error {
+	return syscall.Close(socket.fd)
+}
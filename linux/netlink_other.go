@@ -0,0 +1,33 @@
+//go:build !linux
+
+package linux
+
+import "time"
+
+// netlinkSocket can't be opened as netlink is Linux only
+type netlinkSocket struct{}
+
+// openNetlinkSocket always fails as netlink is Linux only
+func openNetlinkSocket(protocol int) (*netlinkSocket, error) {
+	return nil, ErrNetlinkUnsupported
+}
+
+func (socket *netlinkSocket) send(data []byte) error {
+	return ErrNetlinkUnsupported
+}
+
+func (socket *netlinkSocket) receive() ([]NetlinkMessage, error) {
+	return nil, ErrNetlinkUnsupported
+}
+
+func (socket *netlinkSocket) joinGroup(group uint32) error {
+	return ErrNetlinkUnsupported
+}
+
+func (socket *netlinkSocket) setTimeout(timeout time.Duration) error {
+	return ErrNetlinkUnsupported
+}
+
+func (socket *netlinkSocket) close() error {
+	return ErrNetlinkUnsupported
+}
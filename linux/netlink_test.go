@@ -0,0 +1,202 @@
+package linux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// golden decodes hex bytes captured from a little endian host, which
+// may be spread over lines and spaced for readability. Netlink uses the
+// byte order of the host, so tests using them are skipped elsewhere.
+func golden(t *testing.T, lines ...string) []byte {
+	t.Helper()
+	if nativeEndian != binary.LittleEndian {
+		t.Skip("golden netlink bytes are little endian")
+	}
+	data, decodeErr := hex.DecodeString(strings.Join(strings.Fields(strings.Join(lines, " ")), ""))
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	return data
+}
+
+func TestEncodeNetlinkAttributes(t *testing.T) {
+	want := golden(t,
+		"08 00 03 00 07 00 00 00",
+		"0a 00 04 00 77 6c 61 6e 30 00 00 00",
+		"0c 00 15 80 08 00 01 00 36 01 00 00",
+	)
+	data := EncodeNetlinkAttributes([]NetlinkAttribute{
+		uint32Attribute(nl80211AttrIfindex, 7),
+		stringAttribute(nl80211AttrIfname, "wlan0"),
+		nestedAttribute(nl80211AttrStaInfo, uint32Attribute(nl80211StaInactiveTime, 310)),
+	})
+	if !bytes.Equal(data, want) {
+		t.Fatalf("encoded attributes = % x\nwant % x", data, want)
+	}
+
+	attributes, parseErr := ParseNetlinkAttributes(data)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	if len(attributes) != 3 {
+		t.Fatalf("got %d attributes, want 3", len(attributes))
+	}
+	if attributes[0].Type != nl80211AttrIfindex || attributes[0].Uint32() != 7 {
+		t.Errorf("ifindex attribute = %d %d", attributes[0].Type, attributes[0].Uint32())
+	}
+	// the padding is not part of the value, the terminating NUL is
+	if attributes[1].Type != nl80211AttrIfname || !bytes.Equal(attributes[1].Data, []byte("wlan0\x00")) || attributes[1].String() != "wlan0" {
+		t.Errorf("ifname attribute = %d %q", attributes[1].Type, attributes[1].Data)
+	}
+	// the nested flag is stripped from the type
+	if attributes[2].Type != nl80211AttrStaInfo {
+		t.Errorf("nested attribute type = %#x", attributes[2].Type)
+	}
+	nested, nestedErr := attributes[2].Nested()
+	if nestedErr != nil || len(nested) != 1 || nested[0].Type != nl80211StaInactiveTime || nested[0].Uint32() != 310 {
+		t.Errorf("nested attributes = %+v, %v", nested, nestedErr)
+	}
+}
+
+func TestParseNetlinkAttributesMalformed(t *testing.T) {
+	tests := map[string][]byte{
+		"length past the end":    golden(t, "0c 00 01 00 01 00 00 00"),
+		"length below header":    golden(t, "02 00 01 00"),
+		"second one is too long": golden(t, "08 00 01 00 01 00 00 00 09 00 02 00 00"),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, parseErr := ParseNetlinkAttributes(data); parseErr != ErrNetlinkMessage {
+				t.Fatalf("error = %v, want ErrNetlinkMessage", parseErr)
+			}
+		})
+	}
+}
+
+func TestNetlinkAttributeValues(t *testing.T) {
+	attribute := NetlinkAttribute{Data: golden(t, "40 ed ff ff 01 00 00 00")}
+	if attribute.Uint8() != 0x40 || attribute.Uint16() != 0xed40 || int32(attribute.Uint32()) != -4800 || attribute.Uint64() != 0x1ffffed40 {
+		t.Errorf("values = %d %d %d %d", attribute.Uint8(), attribute.Uint16(), int32(attribute.Uint32()), attribute.Uint64())
+	}
+	// short attributes read as zero rather than panicking
+	short := NetlinkAttribute{Data: []byte{1}}
+	if short.Uint16() != 0 || short.Uint32() != 0 || short.Uint64() != 0 {
+		t.Errorf("short values = %d %d %d", short.Uint16(), short.Uint32(), short.Uint64())
+	}
+	if unterminated := (NetlinkAttribute{Data: []byte("wlan0")}); unterminated.String() != "wlan0" {
+		t.Errorf("unterminated string = %q", unterminated.String())
+	}
+}
+
+func TestNetlinkMessageEncode(t *testing.T) {
+	request := GenericMessage{
+		Command:    controllerCmdGetFamily,
+		Version:    1,
+		Attributes: []NetlinkAttribute{stringAttribute(controllerAttrFamilyName, "nl80211")},
+	}
+	message := NetlinkMessage{Type: genericControllerID, Flags: netlinkFlagRequest | netlinkFlagAck, Sequence: 1, PID: 0, Data: request.Encode()}
+	want := golden(t,
+		"20 00 00 00 10 00 05 00 01 00 00 00 00 00 00 00",
+		"03 01 00 00",
+		"0c 00 02 00 6e 6c 38 30 32 31 31 00",
+	)
+	data := message.Encode()
+	if !bytes.Equal(data, want) {
+		t.Fatalf("encoded message = % x\nwant % x", data, want)
+	}
+
+	messages, parseErr := ParseNetlinkMessages(data)
+	if parseErr != nil || len(messages) != 1 {
+		t.Fatalf("parsed %d messages, %v", len(messages), parseErr)
+	}
+	if !reflect.DeepEqual(messages[0], message) {
+		t.Errorf("parsed message = %+v\nwant %+v", messages[0], message)
+	}
+	generic, genericErr := ParseGenericMessage(messages[0].Data)
+	if genericErr != nil || generic.Command != controllerCmdGetFamily || generic.Version != 1 || generic.Attributes[0].String() != "nl80211" {
+		t.Errorf("generic message = %+v, %v", generic, genericErr)
+	}
+}
+
+func TestParseNetlinkMessages(t *testing.T) {
+	// an acknowledgement followed by DONE in a single datagram
+	data := golden(t,
+		"24 00 00 00 02 00 00 00 07 00 00 00 9a 02 00 00",
+		"00 00 00 00",
+		"20 00 00 00 10 00 05 00 07 00 00 00 00 00 00 00",
+		"14 00 00 00 03 00 02 00 07 00 00 00 9a 02 00 00",
+		"00 00 00 00",
+	)
+	messages, parseErr := ParseNetlinkMessages(data)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	if messages[0].Type != netlinkMessageError || messages[0].Sequence != 7 || messages[0].PID != 666 || messages[0].Err() != nil {
+		t.Errorf("acknowledgement = %+v, %v", messages[0], messages[0].Err())
+	}
+	if messages[1].Type != netlinkMessageDone || messages[1].Flags != netlinkFlagMulti || len(messages[1].Data) != 4 {
+		t.Errorf("done = %+v", messages[1])
+	}
+
+	if _, truncatedErr := ParseNetlinkMessages(data[:30]); truncatedErr != ErrNetlinkMessage {
+		t.Errorf("truncated datagram error = %v, want ErrNetlinkMessage", truncatedErr)
+	}
+}
+
+func TestNetlinkMessageErr(t *testing.T) {
+	// -ENODEV, followed by the header of the failed request
+	message := NetlinkMessage{Type: netlinkMessageError, Data: golden(t, "ed ff ff ff 20 00 00 00 10 00 05 00 01 00 00 00 00 00 00 00")}
+	if errorErr := message.Err(); errorErr != syscall.ENODEV {
+		t.Errorf("error = %v, want ENODEV", errorErr)
+	}
+	if truncatedErr := (NetlinkMessage{Type: netlinkMessageError, Data: []byte{1}}).Err(); truncatedErr != ErrNetlinkMessage {
+		t.Errorf("truncated error = %v, want ErrNetlinkMessage", truncatedErr)
+	}
+	if otherErr := (NetlinkMessage{Type: netlinkMessageDone}).Err(); otherErr != nil {
+		t.Errorf("done error = %v", otherErr)
+	}
+}
+
+func TestParseGenericFamily(t *testing.T) {
+	// the CTRL_CMD_NEWFAMILY reply for nl80211 with its config and
+	// scan groups
+	reply := golden(t,
+		"01 02 00 00",
+		"0c 00 02 00 6e 6c 38 30 32 31 31 00",
+		"06 00 01 00 1c 00 00 00",
+		"34 00 07 80",
+		"18 00 01 80 0b 00 01 00 63 6f 6e 66 69 67 00 00 08 00 02 00 04 00 00 00",
+		"18 00 02 80 09 00 01 00 73 63 61 6e 00 00 00 00 08 00 02 00 05 00 00 00",
+	)
+	family, parseErr := parseGenericFamily(reply)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	if family.id != 0x1c {
+		t.Errorf("family id = %#x, want 0x1c", family.id)
+	}
+	if want := map[string]uint32{"config": 4, "scan": 5}; !reflect.DeepEqual(family.groups, want) {
+		t.Errorf("groups = %v, want %v", family.groups, want)
+	}
+
+	// families without multicast groups
+	family, parseErr = parseGenericFamily(reply[:24])
+	if parseErr != nil || family.id != 0x1c || len(family.groups) != 0 {
+		t.Errorf("family without groups = %+v, %v", family, parseErr)
+	}
+	if _, missingErr := parseGenericFamily(reply[:16]); missingErr != ErrNetlinkFamily {
+		t.Errorf("missing id error = %v, want ErrNetlinkFamily", missingErr)
+	}
+	if _, truncatedErr := parseGenericFamily(reply[:2]); truncatedErr != ErrNetlinkMessage {
+		t.Errorf("truncated error = %v, want ErrNetlinkMessage", truncatedErr)
+	}
+}
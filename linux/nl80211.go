@@ -0,0 +1,686 @@
+package linux
+
+import (
+	"encoding/hex"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// NL80211ScanTimeout is how long Scan waits for the kernel to
+	// report the results of the scan it triggered
+	NL80211ScanTimeout = 30 * time.Second
+
+	// nl80211 commands
+	nl80211CmdGetInterface   = 5
+	nl80211CmdGetStation     = 17
+	nl80211CmdGetScan        = 32
+	nl80211CmdTriggerScan    = 33
+	nl80211CmdNewScanResults = 34
+	nl80211CmdScanAborted    = 35
+	nl80211CmdAuthenticate   = 37
+	nl80211CmdAssociate      = 38
+	nl80211CmdDeauthenticate = 39
+	nl80211CmdDisassociate   = 40
+	nl80211CmdConnect        = 46
+	nl80211CmdRoam           = 47
+	nl80211CmdDisconnect     = 48
+
+	// nl80211 attributes
+	nl80211AttrWiphy            = 1
+	nl80211AttrIfindex          = 3
+	nl80211AttrIfname           = 4
+	nl80211AttrIftype           = 5
+	nl80211AttrMAC              = 6
+	nl80211AttrStaInfo          = 21
+	nl80211AttrScanSSIDs        = 45
+	nl80211AttrBSS              = 47
+	nl80211AttrSSID             = 52
+	nl80211AttrAuthType         = 53
+	nl80211AttrReasonCode       = 54
+	nl80211AttrTimedOut         = 65
+	nl80211AttrPrivacy          = 70
+	nl80211AttrDisconnectedByAP = 71
+	nl80211AttrStatusCode       = 72
+	nl80211AttrKeys             = 81
+
+	// nested attributes of a BSS
+	nl80211BSSBSSID       = 1
+	nl80211BSSFrequency   = 2
+	nl80211BSSCapability  = 5
+	nl80211BSSIEs         = 6
+	nl80211BSSSignalMBM   = 7
+	nl80211BSSStatus      = 9
+	nl80211BSSSeenMsAgo   = 10
+	nl80211BSSBeaconIEs   = 11
+	nl80211BSSAssociated  = 1
+	nl80211BSSIBSSJoined  = 2
+	nl80211CapabilityPriv = 0x10
+
+	// nested attributes of a station
+	nl80211StaInactiveTime  = 1
+	nl80211StaRxBytes       = 2
+	nl80211StaTxBytes       = 3
+	nl80211StaSignal        = 7
+	nl80211StaTxBitrate     = 8
+	nl80211StaRxPackets     = 9
+	nl80211StaTxPackets     = 10
+	nl80211StaTxRetries     = 11
+	nl80211StaTxFailed      = 12
+	nl80211StaSignalAvg     = 13
+	nl80211StaRxBitrate     = 14
+	nl80211StaConnectedTime = 16
+	nl80211StaFlags         = 17
+	nl80211StaRxBytes64     = 23
+	nl80211StaTxBytes64     = 24
+	nl80211RateBitrate      = 1
+	nl80211RateBitrate32    = 5
+	nl80211StaFlagAuthorize = 1 << 1
+	nl80211StaFlagAuthed    = 1 << 5
+	nl80211StaFlagAssoc     = 1 << 7
+
+	// WEP key and authentication attributes
+	nl80211KeyData        = 1
+	nl80211KeyIdx         = 2
+	nl80211KeyCipher      = 3
+	nl80211KeyDefault     = 5
+	nl80211AuthOpenSystem = 0
+	nl80211CipherWEP40    = 0x000fac01
+	nl80211CipherWEP104   = 0x000fac05
+)
+
+var (
+	// ErrNL80211NoLink is returned by Link when the interface isn't
+	// associated with any BSS
+	ErrNL80211NoLink = errors.New("nl80211: interface is not connected")
+	// ErrNL80211ScanAborted is returned when the kernel aborted a scan
+	ErrNL80211ScanAborted = errors.New("nl80211: scan aborted")
+	// ErrNL80211WEPKey is returned for WEP keys that aren't 5 or 13
+	// characters or 10 or 26 hex digits long
+	ErrNL80211WEPKey = errors.New("nl80211: invalid wep key")
+
+	// nl80211EventNames names the commands published to the scan and
+	// mlme multicast groups
+	nl80211EventNames = map[uint8]string{
+		nl80211CmdTriggerScan:    "trigger-scan",
+		nl80211CmdNewScanResults: "new-scan-results",
+		nl80211CmdScanAborted:    "scan-aborted",
+		nl80211CmdAuthenticate:   "authenticate",
+		nl80211CmdAssociate:      "associate",
+		nl80211CmdDeauthenticate: "deauthenticate",
+		nl80211CmdDisassociate:   "disassociate",
+		nl80211CmdConnect:        "connect",
+		nl80211CmdRoam:           "roam",
+		nl80211CmdDisconnect:     "disconnect",
+	}
+)
+
+// NL80211 talks to the kernel's nl80211 generic netlink family
+// directly, without the iw command. The family is resolved on first
+// use. Most operations need CAP_NET_ADMIN.
+type NL80211 struct {
+	mutex  sync.Mutex
+	conn   *netlinkConn
+	family genericFamily
+}
+
+// NL80211Interface represents a wireless interface the kernel reports.
+// Type is the nl80211 interface type, such as 2 for a station.
+type NL80211Interface struct {
+	Index int
+	Name  string
+	Wiphy int
+	Type  int
+	MAC   string
+}
+
+// NL80211BSS represents a BSS of the kernel's scan results. Signal is
+// in dBm, Frequency in MHz and LastSeen in milliseconds.
+type NL80211BSS struct {
+	BSSID      string
+	Frequency  int
+	Signal     int
+	LastSeen   int
+	Privacy    bool
+	Associated bool
+	IEs        []byte
+	Elements   InformationElements
+}
+
+// NL80211Station represents a peer of an interface, with the counters
+// of IWStation. Bitrates are in MBit/s, InactiveTime is in
+// milliseconds and ConnectedTime in seconds.
+type NL80211Station struct {
+	MAC           string
+	InactiveTime  int
+	RxBytes       uint64
+	RxPackets     uint64
+	TxBytes       uint64
+	TxPackets     uint64
+	TxRetries     uint64
+	TxFailed      uint64
+	Signal        int
+	SignalAverage int
+	RxBitrate     float64
+	TxBitrate     float64
+	Authorized    bool
+	Authenticated bool
+	Associated    bool
+	ConnectedTime int
+}
+
+// NL80211Link represents the link of a station interface
+type NL80211Link struct {
+	BSS     NL80211BSS
+	Station NL80211Station
+}
+
+// NL80211Event is a notification the kernel multicasts on the scan and
+// mlme groups. Name is the name of its command, such as "connect" or
+// "disconnect". StatusCode is set on connection results, ReasonCode on
+// disconnections.
+type NL80211Event struct {
+	Name             string
+	Command          uint8
+	Interface        string
+	Index            int
+	BSSID            string
+	StatusCode       int
+	ReasonCode       int
+	TimedOut         bool
+	DisconnectedByAP bool
+}
+
+// NewNL80211 creates a new instance of an nl80211 client
+func NewNL80211() *NL80211 {
+	return &NL80211{}
+}
+
+// IsAvailable returns whether or not the kernel provides nl80211
+func (nl *NL80211) IsAvailable() bool {
+	nl.mutex.Lock()
+	defer nl.mutex.Unlock()
+	return nl.open() == nil
+}
+
+// Close closes the netlink socket, which is opened again on next use
+func (nl *NL80211) Close() error {
+	nl.mutex.Lock()
+	defer nl.mutex.Unlock()
+	if nl.conn == nil {
+		return nil
+	}
+	closeErr := nl.conn.close()
+	nl.conn = nil
+	return closeErr
+}
+
+// open connects to generic netlink and resolves the nl80211 family
+// unless that is already done. The caller holds the mutex.
+func (nl *NL80211) open() error {
+	if nl.conn != nil {
+		return nil
+	}
+	conn, dialErr := dialNetlink(netlinkGeneric)
+	if dialErr != nil {
+		return dialErr
+	}
+	family, familyErr := conn.resolveFamily("nl80211")
+	if familyErr != nil {
+		conn.close()
+		return familyErr
+	}
+	nl.conn = conn
+	nl.family = family
+	return nil
+}
+
+// execute sends an nl80211 command and returns the generic messages it
+// was answered with
+func (nl *NL80211) execute(command uint8, flags uint16, attributes ...NetlinkAttribute) ([]GenericMessage, error) {
+	nl.mutex.Lock()
+	defer nl.mutex.Unlock()
+	if openErr := nl.open(); openErr != nil {
+		return nil, openErr
+	}
+	request := GenericMessage{Command: command, Version: 1, Attributes: attributes}
+	replies, executeErr := nl.conn.execute(NetlinkMessage{Type: nl.family.id, Flags: flags, Data: request.Encode()})
+	if executeErr != nil {
+		return nil, executeErr
+	}
+	messages := []GenericMessage{}
+	for _, reply := range replies {
+		message, parseErr := ParseGenericMessage(reply.Data)
+		if parseErr != nil {
+			return messages, parseErr
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// Interfaces returns the wireless interfaces the kernel knows of
+func (nl *NL80211) Interfaces() ([]NL80211Interface, error) {
+	messages, executeErr := nl.execute(nl80211CmdGetInterface, netlinkFlagDump)
+	if executeErr != nil {
+		return nil, executeErr
+	}
+	interfaces := []NL80211Interface{}
+	for _, message := range messages {
+		interfaces = append(interfaces, ParseNL80211Interface(message))
+	}
+	return interfaces, nil
+}
+
+// TriggerScan starts a scan on the interface without waiting for it
+func (nl *NL80211) TriggerScan(iface string) error {
+	index, indexErr := interfaceIndex(iface)
+	if indexErr != nil {
+		return indexErr
+	}
+	_, executeErr := nl.execute(nl80211CmdTriggerScan, 0,
+		uint32Attribute(nl80211AttrIfindex, index),
+		// a single wildcard SSID makes the scan active
+		nestedAttribute(nl80211AttrScanSSIDs, NetlinkAttribute{Type: 1}),
+	)
+	return executeErr
+}
+
+// Scan triggers a scan on the interface, waits for the kernel to
+// report NEW_SCAN_RESULTS and returns the BSSs found
+func (nl *NL80211) Scan(iface string) ([]NL80211BSS, error) {
+	index, indexErr := interfaceIndex(iface)
+	if indexErr != nil {
+		return nil, indexErr
+	}
+	events, subscribeErr := nl.listen("scan")
+	if subscribeErr != nil {
+		return nil, subscribeErr
+	}
+	defer events.close()
+	if timeoutErr := events.socket.setTimeout(NL80211ScanTimeout); timeoutErr != nil {
+		return nil, timeoutErr
+	}
+	if triggerErr := nl.TriggerScan(iface); triggerErr != nil {
+		return nil, triggerErr
+	}
+	deadline := time.Now().Add(NL80211ScanTimeout)
+	for time.Now().Before(deadline) {
+		messages, receiveErr := events.socket.receive()
+		if receiveErr != nil {
+			return nil, receiveErr
+		}
+		for _, message := range messages {
+			event, parseErr := ParseNL80211Event(message)
+			if parseErr != nil || event.Index != int(index) {
+				continue
+			}
+			switch event.Command {
+			case nl80211CmdNewScanResults:
+				return nl.ScanResults(iface)
+			case nl80211CmdScanAborted:
+				return nil, ErrNL80211ScanAborted
+			}
+		}
+	}
+	return nil, ErrNetlinkTimeout
+}
+
+// ScanResults returns the BSSs the kernel currently knows of, without
+// scanning
+func (nl *NL80211) ScanResults(iface string) ([]NL80211BSS, error) {
+	index, indexErr := interfaceIndex(iface)
+	if indexErr != nil {
+		return nil, indexErr
+	}
+	messages, executeErr := nl.execute(nl80211CmdGetScan, netlinkFlagDump, uint32Attribute(nl80211AttrIfindex, index))
+	if executeErr != nil {
+		return nil, executeErr
+	}
+	results := []NL80211BSS{}
+	for _, message := range messages {
+		bss, found := ParseNL80211BSS(message)
+		if found {
+			results = append(results, bss)
+		}
+	}
+	return results, nil
+}
+
+// Stations returns the peers of the interface
+func (nl *NL80211) Stations(iface string) ([]NL80211Station, error) {
+	index, indexErr := interfaceIndex(iface)
+	if indexErr != nil {
+		return nil, indexErr
+	}
+	messages, executeErr := nl.execute(nl80211CmdGetStation, netlinkFlagDump, uint32Attribute(nl80211AttrIfindex, index))
+	if executeErr != nil {
+		return nil, executeErr
+	}
+	stations := []NL80211Station{}
+	for _, message := range messages {
+		stations = append(stations, ParseNL80211Station(message))
+	}
+	return stations, nil
+}
+
+// Link returns the BSS the station interface is associated with and
+// the station info of its access point
+func (nl *NL80211) Link(iface string) (NL80211Link, error) {
+	results, resultsErr := nl.ScanResults(iface)
+	if resultsErr != nil {
+		return NL80211Link{}, resultsErr
+	}
+	for _, bss := range results {
+		if !bss.Associated {
+			continue
+		}
+		link := NL80211Link{BSS: bss}
+		stations, stationsErr := nl.Stations(iface)
+		if stationsErr != nil {
+			return link, stationsErr
+		}
+		for _, station := range stations {
+			if station.MAC == bss.BSSID {
+				link.Station = station
+			}
+		}
+		return link, nil
+	}
+	return NL80211Link{}, ErrNL80211NoLink
+}
+
+// Connect joins the interface to an open network, or to a WEP one
+// when a key is provided. Like iw, nl80211 alone can't do the
+// handshakes WPA needs.
+func (nl *NL80211) Connect(iface, ssid, wepKey string) error {
+	index, indexErr := interfaceIndex(iface)
+	if indexErr != nil {
+		return indexErr
+	}
+	attributes := []NetlinkAttribute{
+		uint32Attribute(nl80211AttrIfindex, index),
+		{Type: nl80211AttrSSID, Data: []byte(ssid)},
+		uint32Attribute(nl80211AttrAuthType, nl80211AuthOpenSystem),
+	}
+	if wepKey != "" {
+		key, cipher, keyErr := wepKeyData(wepKey)
+		if keyErr != nil {
+			return keyErr
+		}
+		attributes = append(attributes,
+			NetlinkAttribute{Type: nl80211AttrPrivacy},
+			nestedAttribute(nl80211AttrKeys, nestedAttribute(1,
+				NetlinkAttribute{Type: nl80211KeyData, Data: key},
+				NetlinkAttribute{Type: nl80211KeyIdx, Data: []byte{0}},
+				uint32Attribute(nl80211KeyCipher, cipher),
+				NetlinkAttribute{Type: nl80211KeyDefault},
+			)),
+		)
+	}
+	_, executeErr := nl.execute(nl80211CmdConnect, 0, attributes...)
+	return executeErr
+}
+
+// Disconnect leaves the network of the interface
+func (nl *NL80211) Disconnect(iface string) error {
+	index, indexErr := interfaceIndex(iface)
+	if indexErr != nil {
+		return indexErr
+	}
+	_, executeErr := nl.execute(nl80211CmdDisconnect, 0, uint32Attribute(nl80211AttrIfindex, index))
+	return executeErr
+}
+
+// Subscribe delivers the events of the provided multicast groups, the
+// scan and mlme ones when none are provided, until the returned
+// function is called
+func (nl *NL80211) Subscribe(groups ...string) (<-chan NL80211Event, func(), error) {
+	if len(groups) == 0 {
+		groups = []string{"scan", "mlme"}
+	}
+	events, listenErr := nl.listen(groups...)
+	if listenErr != nil {
+		return nil, nil, listenErr
+	}
+	delivered := make(chan NL80211Event, 16)
+	stop := events.watch(func(message NetlinkMessage, done <-chan struct{}) bool {
+		event, parseErr := ParseNL80211Event(message)
+		if parseErr != nil {
+			return true
+		}
+		select {
+		case delivered <- event:
+			return true
+		case <-done:
+			return false
+		}
+	}, func() { close(delivered) })
+	return delivered, stop, nil
+}
+
+// listen opens a socket joined to the provided multicast groups of the
+// nl80211 family
+func (nl *NL80211) listen(groups ...string) (*netlinkConn, error) {
+	nl.mutex.Lock()
+	openErr := nl.open()
+	family := nl.family
+	nl.mutex.Unlock()
+	if openErr != nil {
+		return nil, openErr
+	}
+	conn, dialErr := dialNetlink(netlinkGeneric)
+	if dialErr != nil {
+		return nil, dialErr
+	}
+	for _, group := range groups {
+		groupID, found := family.groups[group]
+		if !found {
+			conn.close()
+			return nil, errors.New("nl80211: unknown multicast group " + group)
+		}
+		if joinErr := conn.socket.joinGroup(groupID); joinErr != nil {
+			conn.close()
+			return nil, joinErr
+		}
+	}
+	return conn, nil
+}
+
+// ParseNL80211Interface parses a reply to GET_INTERFACE
+func ParseNL80211Interface(message GenericMessage) NL80211Interface {
+	iface := NL80211Interface{}
+	for _, attribute := range message.Attributes {
+		switch attribute.Type {
+		case nl80211AttrIfindex:
+			iface.Index = int(attribute.Uint32())
+		case nl80211AttrIfname:
+			iface.Name = attribute.String()
+		case nl80211AttrWiphy:
+			iface.Wiphy = int(attribute.Uint32())
+		case nl80211AttrIftype:
+			iface.Type = int(attribute.Uint32())
+		case nl80211AttrMAC:
+			iface.MAC = net.HardwareAddr(attribute.Data).String()
+		}
+	}
+	return iface
+}
+
+// ParseNL80211BSS parses a reply to GET_SCAN, returning false when it
+// holds no BSS. The IEs of the last probe response are preferred over
+// those of the beacon.
+func ParseNL80211BSS(message GenericMessage) (NL80211BSS, bool) {
+	bssAttribute, found := findAttribute(message.Attributes, nl80211AttrBSS)
+	if !found {
+		return NL80211BSS{}, false
+	}
+	attributes, _ := bssAttribute.Nested()
+	bss := NL80211BSS{}
+	var beaconIEs []byte
+	for _, attribute := range attributes {
+		switch attribute.Type {
+		case nl80211BSSBSSID:
+			bss.BSSID = net.HardwareAddr(attribute.Data).String()
+		case nl80211BSSFrequency:
+			bss.Frequency = int(attribute.Uint32())
+		case nl80211BSSCapability:
+			bss.Privacy = attribute.Uint16()&nl80211CapabilityPriv != 0
+		case nl80211BSSIEs:
+			bss.IEs = attribute.Data
+		case nl80211BSSBeaconIEs:
+			beaconIEs = attribute.Data
+		case nl80211BSSSignalMBM:
+			bss.Signal = int(int32(attribute.Uint32())) / 100
+		case nl80211BSSStatus:
+			status := attribute.Uint32()
+			bss.Associated = status == nl80211BSSAssociated || status == nl80211BSSIBSSJoined
+		case nl80211BSSSeenMsAgo:
+			bss.LastSeen = int(attribute.Uint32())
+		}
+	}
+	if len(bss.IEs) == 0 {
+		bss.IEs = beaconIEs
+	}
+	bss.Elements = ParseInformationElements(bss.IEs)
+	if bss.Elements.Channel == 0 {
+		bss.Elements.Channel = FrequencyChannel(bss.Frequency)
+	}
+	return bss, true
+}
+
+// ParseNL80211Station parses a reply to GET_STATION
+func ParseNL80211Station(message GenericMessage) NL80211Station {
+	station := NL80211Station{}
+	if mac, found := findAttribute(message.Attributes, nl80211AttrMAC); found {
+		station.MAC = net.HardwareAddr(mac.Data).String()
+	}
+	infoAttribute, found := findAttribute(message.Attributes, nl80211AttrStaInfo)
+	if !found {
+		return station
+	}
+	attributes, _ := infoAttribute.Nested()
+	for _, attribute := range attributes {
+		switch attribute.Type {
+		case nl80211StaInactiveTime:
+			station.InactiveTime = int(attribute.Uint32())
+		case nl80211StaRxBytes:
+			if station.RxBytes == 0 {
+				station.RxBytes = uint64(attribute.Uint32())
+			}
+		case nl80211StaTxBytes:
+			if station.TxBytes == 0 {
+				station.TxBytes = uint64(attribute.Uint32())
+			}
+		case nl80211StaRxBytes64:
+			station.RxBytes = attribute.Uint64()
+		case nl80211StaTxBytes64:
+			station.TxBytes = attribute.Uint64()
+		case nl80211StaRxPackets:
+			station.RxPackets = uint64(attribute.Uint32())
+		case nl80211StaTxPackets:
+			station.TxPackets = uint64(attribute.Uint32())
+		case nl80211StaTxRetries:
+			station.TxRetries = uint64(attribute.Uint32())
+		case nl80211StaTxFailed:
+			station.TxFailed = uint64(attribute.Uint32())
+		case nl80211StaSignal:
+			station.Signal = int(int8(attribute.Uint8()))
+		case nl80211StaSignalAvg:
+			station.SignalAverage = int(int8(attribute.Uint8()))
+		case nl80211StaRxBitrate:
+			station.RxBitrate = parseRateInfo(attribute)
+		case nl80211StaTxBitrate:
+			station.TxBitrate = parseRateInfo(attribute)
+		case nl80211StaConnectedTime:
+			station.ConnectedTime = int(attribute.Uint32())
+		case nl80211StaFlags:
+			// struct nl80211_sta_flag_update holds a mask and the set flags
+			if len(attribute.Data) >= 8 {
+				set := nativeEndian.Uint32(attribute.Data[4:8])
+				station.Authorized = set&nl80211StaFlagAuthorize != 0
+				station.Authenticated = set&nl80211StaFlagAuthed != 0
+				station.Associated = set&nl80211StaFlagAssoc != 0
+			}
+		}
+	}
+	return station
+}
+
+// ParseNL80211Event parses a message multicast on the scan or mlme
+// groups
+func ParseNL80211Event(message NetlinkMessage) (NL80211Event, error) {
+	generic, parseErr := ParseGenericMessage(message.Data)
+	if parseErr != nil {
+		return NL80211Event{}, parseErr
+	}
+	event := NL80211Event{Command: generic.Command, Name: nl80211EventNames[generic.Command]}
+	for _, attribute := range generic.Attributes {
+		switch attribute.Type {
+		case nl80211AttrIfindex:
+			event.Index = int(attribute.Uint32())
+		case nl80211AttrIfname:
+			event.Interface = attribute.String()
+		case nl80211AttrMAC:
+			event.BSSID = net.HardwareAddr(attribute.Data).String()
+		case nl80211AttrStatusCode:
+			event.StatusCode = int(attribute.Uint16())
+		case nl80211AttrReasonCode:
+			event.ReasonCode = int(attribute.Uint16())
+		case nl80211AttrTimedOut:
+			event.TimedOut = true
+		case nl80211AttrDisconnectedByAP:
+			event.DisconnectedByAP = true
+		}
+	}
+	if event.Interface == "" && event.Index != 0 {
+		if iface, ifaceErr := net.InterfaceByIndex(event.Index); ifaceErr == nil {
+			event.Interface = iface.Name
+		}
+	}
+	return event, nil
+}
+
+// parseRateInfo returns the bitrate in MBit/s of a nested rate info
+// attribute, which the kernel reports in units of 100 kbit/s
+func parseRateInfo(attribute NetlinkAttribute) float64 {
+	attributes, _ := attribute.Nested()
+	if bitrate, found := findAttribute(attributes, nl80211RateBitrate32); found {
+		return float64(bitrate.Uint32()) / 10
+	}
+	if bitrate, found := findAttribute(attributes, nl80211RateBitrate); found {
+		return float64(bitrate.Uint16()) / 10
+	}
+	return 0
+}
+
+// interfaceIndex returns the index of the interface with the name
+func interfaceIndex(iface string) (uint32, error) {
+	netInterface, ifaceErr := net.InterfaceByName(iface)
+	if ifaceErr != nil {
+		return 0, ifaceErr
+	}
+	return uint32(netInterface.Index), nil
+}
+
+// wepKeyData returns the bytes and cipher of a WEP key given as 5 or
+// 13 characters or as 10 or 26 hex digits
+func wepKeyData(wepKey string) ([]byte, uint32, error) {
+	key := []byte(wepKey)
+	if len(wepKey) == 10 || len(wepKey) == 26 {
+		decoded, decodeErr := hex.DecodeString(wepKey)
+		if decodeErr != nil {
+			return nil, 0, ErrNL80211WEPKey
+		}
+		key = decoded
+	}
+	switch len(key) {
+	case 5:
+		return key, nl80211CipherWEP40, nil
+	case 13:
+		return key, nl80211CipherWEP104, nil
+	}
+	return nil, 0, ErrNL80211WEPKey
+}
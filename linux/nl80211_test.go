@@ -0,0 +1,210 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+)
+
+// parseGolden parses the payload of a generic netlink message
+func parseGolden(t *testing.T, lines ...string) GenericMessage {
+	t.Helper()
+	message, parseErr := ParseGenericMessage(golden(t, lines...))
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	return message
+}
+
+func TestParseNL80211BSS(t *testing.T) {
+	// a NEW_SCAN_RESULTS reply to GET_SCAN for an associated WPA2
+	// network on channel 36
+	message := parseGolden(t,
+		"22 01 00 00",
+		"08 00 03 00 03 00 00 00",
+		"60 00 2f 80",
+		"0a 00 01 00 3c 37 86 1a 2b 3c 00 00",
+		"08 00 02 00 3c 14 00 00",
+		"06 00 05 00 11 04 00 00",
+		"08 00 07 00 40 ed ff ff",
+		"08 00 0a 00 d4 00 00 00",
+		"08 00 09 00 01 00 00 00",
+		"28 00 06 00",
+		"00 09 4f 74 74 6f 70 72 65 73 73",
+		"03 01 24",
+		"30 14 01 00 00 0f ac 04 01 00 00 0f ac 04 01 00 00 0f ac 02 00 00",
+	)
+	bss, found := ParseNL80211BSS(message)
+	if !found {
+		t.Fatal("no BSS found")
+	}
+	want := NL80211BSS{
+		BSSID:      "3c:37:86:1a:2b:3c",
+		Frequency:  5180,
+		Signal:     -48,
+		LastSeen:   212,
+		Privacy:    true,
+		Associated: true,
+		Elements: InformationElements{
+			SSID:            "Ottopress",
+			Channel:         36,
+			ChannelWidth:    20,
+			RSN:             true,
+			GroupCipher:     "CCMP",
+			PairwiseCiphers: []string{"CCMP"},
+			AuthSuites:      []string{"PSK"},
+		},
+	}
+	bss.IEs = nil
+	if !reflect.DeepEqual(bss, want) {
+		t.Fatalf("bss = %+v\nwant %+v", bss, want)
+	}
+}
+
+func TestParseNL80211BSSBeaconIEs(t *testing.T) {
+	// an open network only heard through its beacons, without a DS
+	// parameter set
+	message := parseGolden(t,
+		"22 01 00 00",
+		"30 00 2f 80",
+		"0a 00 01 00 02 1a 11 f0 9c 44 00 00",
+		"08 00 02 00 85 09 00 00",
+		"06 00 05 00 01 04 00 00",
+		"10 00 0b 00 00 0a 63 61 66 65 2d 67 75 65 73 74",
+	)
+	bss, found := ParseNL80211BSS(message)
+	if !found {
+		t.Fatal("no BSS found")
+	}
+	if bss.BSSID != "02:1a:11:f0:9c:44" || bss.Frequency != 2437 || bss.Privacy || bss.Associated {
+		t.Errorf("bss = %+v", bss)
+	}
+	if bss.Elements.SSID != "cafe-guest" || bss.Elements.Channel != 6 {
+		t.Errorf("elements = %+v, want the beacon SSID on channel 6", bss.Elements)
+	}
+
+	if _, found := ParseNL80211BSS(parseGolden(t, "22 01 00 00 08 00 03 00 03 00 00 00")); found {
+		t.Error("found a BSS in a message without one")
+	}
+}
+
+func TestParseNL80211Station(t *testing.T) {
+	// a NEW_STATION reply to GET_STATION. The 64 bit byte counter wins
+	// over the 32 bit one that wrapped.
+	message := parseGolden(t,
+		"13 01 00 00",
+		"08 00 03 00 03 00 00 00",
+		"0a 00 06 00 3c 37 86 1a 2b 3c 00 00",
+		"8c 00 15 80",
+		"08 00 01 00 18 00 00 00",
+		"08 00 02 00 d9 b3 df 02",
+		"0c 00 17 00 d9 b3 df 02 01 00 00 00",
+		"08 00 03 00 c1 84 5d 00",
+		"05 00 07 00 d0 00 00 00",
+		"14 00 08 80 08 00 05 00 87 25 00 00 06 00 01 00 87 25 00 00",
+		"08 00 09 00 92 cb 00 00",
+		"08 00 0a 00 69 5b 00 00",
+		"08 00 0b 00 2c 03 00 00",
+		"08 00 0c 00 03 00 00 00",
+		"05 00 0d 00 d1 00 00 00",
+		"0c 00 0e 80 06 00 01 00 3c 00 00 00",
+		"08 00 10 00 51 07 00 00",
+		"0c 00 11 00 a2 00 00 00 a2 00 00 00",
+	)
+	want := NL80211Station{
+		MAC:           "3c:37:86:1a:2b:3c",
+		InactiveTime:  24,
+		RxBytes:       0x102dfb3d9,
+		RxPackets:     52114,
+		TxBytes:       6128833,
+		TxPackets:     23401,
+		TxRetries:     812,
+		TxFailed:      3,
+		Signal:        -48,
+		SignalAverage: -47,
+		RxBitrate:     6,
+		TxBitrate:     960.7,
+		Authorized:    true,
+		Authenticated: true,
+		Associated:    true,
+		ConnectedTime: 1873,
+	}
+	if station := ParseNL80211Station(message); !reflect.DeepEqual(station, want) {
+		t.Fatalf("station = %+v\nwant %+v", station, want)
+	}
+}
+
+func TestParseNL80211Interface(t *testing.T) {
+	message := parseGolden(t,
+		"07 01 00 00",
+		"08 00 03 00 03 00 00 00",
+		"0a 00 04 00 77 6c 61 6e 30 00 00 00",
+		"08 00 01 00 00 00 00 00",
+		"08 00 05 00 02 00 00 00",
+		"0a 00 06 00 3c 37 86 1a 2b 3c 00 00",
+	)
+	want := NL80211Interface{Index: 3, Name: "wlan0", Type: 2, MAC: "3c:37:86:1a:2b:3c"}
+	if iface := ParseNL80211Interface(message); iface != want {
+		t.Fatalf("interface = %+v, want %+v", iface, want)
+	}
+}
+
+func TestParseNL80211Event(t *testing.T) {
+	tests := []struct {
+		name    string
+		message NetlinkMessage
+		event   NL80211Event
+	}{
+		{
+			name: "new scan results",
+			message: NetlinkMessage{Type: 0x1c, Data: golden(t,
+				"22 01 00 00",
+				"08 00 01 00 00 00 00 00",
+				"08 00 03 00 03 00 00 00",
+				"0a 00 04 00 77 6c 61 6e 30 00 00 00",
+				"0c 00 2d 80 04 00 01 00 04 00 02 00",
+			)},
+			event: NL80211Event{Name: "new-scan-results", Command: nl80211CmdNewScanResults, Interface: "wlan0", Index: 3},
+		},
+		{
+			name: "connect",
+			message: NetlinkMessage{Type: 0x1c, Data: golden(t,
+				"2e 01 00 00",
+				"0a 00 04 00 77 6c 61 6e 30 00 00 00",
+				"0a 00 06 00 3c 37 86 1a 2b 3c 00 00",
+				"06 00 48 00 11 00 00 00",
+			)},
+			event: NL80211Event{Name: "connect", Command: nl80211CmdConnect, Interface: "wlan0", BSSID: "3c:37:86:1a:2b:3c", StatusCode: 17},
+		},
+		{
+			name: "disconnect by the access point",
+			message: NetlinkMessage{Type: 0x1c, Data: golden(t,
+				"30 01 00 00",
+				"0a 00 04 00 77 6c 61 6e 30 00 00 00",
+				"06 00 36 00 03 00 00 00",
+				"04 00 47 00",
+			)},
+			event: NL80211Event{Name: "disconnect", Command: nl80211CmdDisconnect, Interface: "wlan0", ReasonCode: 3, DisconnectedByAP: true},
+		},
+		{
+			name: "connection timed out",
+			message: NetlinkMessage{Type: 0x1c, Data: golden(t,
+				"2e 01 00 00",
+				"0a 00 04 00 77 6c 61 6e 30 00 00 00",
+				"06 00 48 00 01 00 00 00",
+				"04 00 41 00",
+			)},
+			event: NL80211Event{Name: "connect", Command: nl80211CmdConnect, Interface: "wlan0", StatusCode: 1, TimedOut: true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, parseErr := ParseNL80211Event(test.message)
+			if parseErr != nil {
+				t.Fatal(parseErr)
+			}
+			if event != test.event {
+				t.Fatalf("event = %+v\nwant %+v", event, test.event)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package linux
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// NMCli is a wrapper for the NetworkManager nmcli command.
+type NMCli struct{}
+
+// NMDevice represents a device from the nmcli device listing
+type NMDevice struct {
+	Name       string
+	Type       string
+	State      string
+	Connection string
+}
+
+// NMAccessPoint represents an access point from the nmcli wifi
+// listing. Signal is a percentage.
+type NMAccessPoint struct {
+	SSID     string
+	BSSID    string
+	Channel  int
+	Signal   int
+	Security []string
+}
+
+// NMGeneral represents the general state of NetworkManager
+type NMGeneral struct {
+	Running bool
+	Version string
+}
+
+// NMConnection represents a saved connection profile
+type NMConnection struct {
+	Name   string
+	UUID   string
+	Type   string
+	Device string
+}
+
+// NewNMCli creates a new instance of an NMCli command wrapper.
+func NewNMCli() *NMCli {
+	return &NMCli{}
+}
+
+// IsInstalled returns whether or not the nmcli executable
+// can be found in the current PATH environment variable.
+func (nmcli *NMCli) IsInstalled() bool {
+	_, err := exec.LookPath("nmcli")
+	if err != nil {
+		return false
+	}
+	return true
+}
+
+// run runs nmcli in terse mode, returning its output or an error
+// holding the message nmcli printed
+func (nmcli *NMCli) run(args ...string) ([]byte, error) {
+	cmd := exec.Command("nmcli", append([]string{"--terse"}, args...)...)
+	cmdOut, cmdErr := cmd.CombinedOutput()
+	if cmdErr != nil {
+		message := strings.TrimSpace(string(cmdOut))
+		if message == "" {
+			return nil, cmdErr
+		}
+		return nil, errors.New("nmcli: " + message)
+	}
+	return cmdOut, nil
+}
+
+// Devices returns all devices NetworkManager knows about
+func (nmcli *NMCli) Devices() ([]NMDevice, error) {
+	cmdOut, cmdErr := nmcli.run("--fields", "DEVICE,TYPE,STATE,CONNECTION", "device")
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+	devices := []NMDevice{}
+	for _, fields := range splitTerse(cmdOut, 4) {
+		devices = append(devices, NMDevice{
+			Name:       fields[0],
+			Type:       fields[1],
+			State:      fields[2],
+			Connection: fields[3],
+		})
+	}
+	return devices, nil
+}
+
+// Scan rescans and returns the access points seen by the device
+func (nmcli *NMCli) Scan(iface string) ([]NMAccessPoint, error) {
+	cmdOut, cmdErr := nmcli.run("--fields", "SSID,BSSID,CHAN,SIGNAL,SECURITY", "device", "wifi", "list", "ifname", iface, "--rescan", "yes")
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+	accessPoints := []NMAccessPoint{}
+	for _, fields := range splitTerse(cmdOut, 5) {
+		accessPoint := NMAccessPoint{
+			SSID:     fields[0],
+			BSSID:    fields[1],
+			Security: strings.Fields(fields[4]),
+		}
+		accessPoint.Channel, _ = strconv.Atoi(fields[2])
+		accessPoint.Signal, _ = strconv.Atoi(fields[3])
+		accessPoints = append(accessPoints, accessPoint)
+	}
+	return accessPoints, nil
+}
+
+// Connections returns all saved connection profiles
+func (nmcli *NMCli) Connections() ([]NMConnection, error) {
+	cmdOut, cmdErr := nmcli.run("--fields", "NAME,UUID,TYPE,DEVICE", "connection", "show")
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+	connections := []NMConnection{}
+	for _, fields := range splitTerse(cmdOut, 4) {
+		connections = append(connections, NMConnection{
+			Name:   fields[0],
+			UUID:   fields[1],
+			Type:   fields[2],
+			Device: fields[3],
+		})
+	}
+	return connections, nil
+}
+
+// AddWifiConnection creates a WiFi connection profile. Settings are
+// property/value pairs such as wifi-sec.key-mgmt and must not hold
+// secrets, which are passed to Up instead.
+func (nmcli *NMCli) AddWifiConnection(name, iface, ssid string, settings [][2]string) error {
+	args := []string{"connection", "add", "type", "wifi", "con-name", name, "ifname", iface, "ssid", ssid}
+	for _, setting := range settings {
+		args = append(args, setting[0], setting[1])
+	}
+	_, cmdErr := nmcli.run(args...)
+	return cmdErr
+}
+
+// ModifyConnection changes the settings of a connection profile
+func (nmcli *NMCli) ModifyConnection(name string, settings [][2]string) error {
+	args := []string{"connection", "modify", "id", name}
+	for _, setting := range settings {
+		args = append(args, setting[0], setting[1])
+	}
+	_, cmdErr := nmcli.run(args...)
+	return cmdErr
+}
+
+// Up activates a connection profile on the device. Secrets, keyed by
+// setting name such as 802-11-wireless-security.psk, are handed over
+// in a password file only readable by its owner so they never appear
+// in the argument list. NetworkManager stores them with the profile.
+func (nmcli *NMCli) Up(name, iface string, secrets map[string]string) error {
+	args := []string{"connection", "up", "id", name, "ifname", iface}
+	if len(secrets) > 0 {
+		passwdFile, fileErr := writeSecrets(secrets)
+		if fileErr != nil {
+			return fileErr
+		}
+		defer os.Remove(passwdFile)
+		args = append(args, "passwd-file", passwdFile)
+	}
+	_, cmdErr := nmcli.run(args...)
+	return cmdErr
+}
+
+// Disconnect disconnects the device and keeps it from autoconnecting
+// until a connection is activated on it again
+func (nmcli *NMCli) Disconnect(iface string) error {
+	_, cmdErr := nmcli.run("device", "disconnect", iface)
+	return cmdErr
+}
+
+// SetRadio turns the WiFi radio on or off. NetworkManager switches all
+// WiFi devices at once.
+func (nmcli *NMCli) SetRadio(enabled bool) error {
+	state := "off"
+	if enabled {
+		state = "on"
+	}
+	_, cmdErr := nmcli.run("radio", "wifi", state)
+	return cmdErr
+}
+
+// Radio returns whether or not the WiFi radio is on
+func (nmcli *NMCli) Radio() (bool, error) {
+	cmdOut, cmdErr := nmcli.run("radio", "wifi")
+	if cmdErr != nil {
+		return false, cmdErr
+	}
+	return strings.TrimSpace(string(cmdOut)) == "enabled", nil
+}
+
+// General returns whether NetworkManager is running and its version
+func (nmcli *NMCli) General() (NMGeneral, error) {
+	cmdOut, cmdErr := nmcli.run("--fields", "RUNNING,VERSION", "general")
+	if cmdErr != nil {
+		return NMGeneral{}, cmdErr
+	}
+	rows := splitTerse(cmdOut, 2)
+	if len(rows) == 0 {
+		return NMGeneral{}, ErrNoVersion
+	}
+	return NMGeneral{Running: rows[0][0] == "running", Version: rows[0][1]}, nil
+}
+
+// Permissions returns the caller's NetworkManager permissions, mapping
+// names such as org.freedesktop.NetworkManager.network-control to
+// "yes", "no" or "auth" when polkit has to ask
+func (nmcli *NMCli) Permissions() (map[string]string, error) {
+	cmdOut, cmdErr := nmcli.run("general", "permissions")
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+	permissions := map[string]string{}
+	for _, fields := range splitTerse(cmdOut, 2) {
+		permissions[fields[0]] = fields[1]
+	}
+	return permissions, nil
+}
+
+// writeSecrets writes the secrets to a temporary password file in the
+// setting:value form nmcli reads
+func writeSecrets(secrets map[string]string) (string, error) {
+	var buffer bytes.Buffer
+	for setting, value := range secrets {
+		if strings.ContainsAny(value, "\n\r") {
+			return "", fmt.Errorf("nmcli: %s can't contain line breaks", setting)
+		}
+		buffer.WriteString(setting + ":" + value + "\n")
+	}
+	passwdFile, createErr := ioutil.TempFile("", "wifimanager-nmcli-")
+	if createErr != nil {
+		return "", createErr
+	}
+	_, writeErr := passwdFile.Write(buffer.Bytes())
+	closeErr := passwdFile.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		os.Remove(passwdFile.Name())
+		return "", writeErr
+	}
+	return passwdFile.Name(), nil
+}
+
+// splitTerse splits terse output into rows of fields, undoing the
+// escaping of colons and backslashes inside values. Rows with fewer
+// fields than expected are skipped.
+func splitTerse(output []byte, count int) [][]string {
+	rows := [][]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := []string{}
+		var current bytes.Buffer
+		for index := 0; index < len(line); index++ {
+			switch {
+			case line[index] == '\\' && index+1 < len(line):
+				index++
+				current.WriteByte(line[index])
+			case line[index] == ':':
+				fields = append(fields, current.String())
+				current.Reset()
+			default:
+				current.WriteByte(line[index])
+			}
+		}
+		fields = append(fields, current.String())
+		if len(fields) >= count {
+			rows = append(rows, fields)
+		}
+	}
+	return rows
+}
@@ -0,0 +1,203 @@
+package linux
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	// rfkillEventLen is the length of the original struct rfkill_event,
+	// which newer kernels extend but still accept
+	rfkillEventLen = 8
+
+	// rfkill event operations
+	rfkillOpAdd    = 0
+	rfkillOpDel    = 1
+	rfkillOpChange = 2
+)
+
+var (
+	// ErrNoRFKill is returned when an interface has no rfkill switch
+	ErrNoRFKill = errors.New("rfkill: no switch found for the interface")
+
+	// rfkillTypes names the rfkill switch types the way sysfs does
+	rfkillTypes = map[uint8]string{
+		0: "all",
+		1: "wlan",
+		2: "bluetooth",
+		3: "uwb",
+		4: "wimax",
+		5: "wwan",
+		6: "gps",
+		7: "fm",
+		8: "nfc",
+	}
+)
+
+// RFKill reads and sets the state of the kernel's rfkill switches.
+// Root is where sysfs is mounted and Device the rfkill character
+// device, which is used to change switches and to read them when
+// sysfs lacks the rfkill class.
+type RFKill struct {
+	Root   string
+	Device string
+}
+
+// RFKillSwitch represents an rfkill switch. A soft block is set by
+// software and can be lifted, a hard block comes from a physical
+// switch or the firmware.
+type RFKillSwitch struct {
+	Index       int
+	Name        string
+	Type        string
+	SoftBlocked bool
+	HardBlocked bool
+}
+
+// RFKillEvent is an event read from the rfkill device. Operation is
+// "add", "del" or "change".
+type RFKillEvent struct {
+	Operation string
+	Switch    RFKillSwitch
+}
+
+// NewRFKill creates a new instance of an rfkill reader for the sysfs
+// mounted on /sys and /dev/rfkill
+func NewRFKill() *RFKill {
+	return &RFKill{Root: "/sys", Device: "/dev/rfkill"}
+}
+
+// Switches returns every rfkill switch
+func (rfkill *RFKill) Switches() ([]RFKillSwitch, error) {
+	entries, readErr := ioutil.ReadDir(filepath.Join(rfkill.Root, "class", "rfkill"))
+	if readErr != nil {
+		return rfkill.deviceSwitches()
+	}
+	switches := []RFKillSwitch{}
+	for _, entry := range entries {
+		rfkillSwitch, switchErr := readRFKillSwitch(filepath.Join(rfkill.Root, "class", "rfkill", entry.Name()))
+		if switchErr != nil {
+			continue
+		}
+		switches = append(switches, rfkillSwitch)
+	}
+	return switches, nil
+}
+
+// InterfaceSwitch returns the rfkill switch of the PHY the wireless
+// interface belongs to
+func (rfkill *RFKill) InterfaceSwitch(iface string) (RFKillSwitch, error) {
+	phyDir := filepath.Join(rfkill.Root, "class", "net", iface, "phy80211")
+	entries, readErr := ioutil.ReadDir(phyDir)
+	if readErr != nil {
+		return RFKillSwitch{}, ErrNoRFKill
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "rfkill") {
+			return readRFKillSwitch(filepath.Join(phyDir, entry.Name()))
+		}
+	}
+	return RFKillSwitch{}, ErrNoRFKill
+}
+
+// SetSoftBlocked sets or lifts the soft block of the switch with the
+// provided index. The change is written to the rfkill device, or to
+// sysfs when the device can't be opened.
+func (rfkill *RFKill) SetSoftBlocked(index int, blocked bool) error {
+	event := make([]byte, rfkillEventLen)
+	nativeEndian.PutUint32(event[0:4], uint32(index))
+	event[5] = rfkillOpChange
+	if blocked {
+		event[6] = 1
+	}
+	device, openErr := os.OpenFile(rfkill.Device, os.O_WRONLY, 0)
+	if openErr != nil {
+		value := "0"
+		if blocked {
+			value = "1"
+		}
+		softPath := filepath.Join(rfkill.Root, "class", "rfkill", "rfkill"+strconv.Itoa(index), "soft")
+		if writeErr := ioutil.WriteFile(softPath, []byte(value), 0644); writeErr != nil {
+			return openErr
+		}
+		return nil
+	}
+	defer device.Close()
+	_, writeErr := device.Write(event)
+	return writeErr
+}
+
+// deviceSwitches reads the switches from the add events the rfkill
+// device sends when it is opened
+func (rfkill *RFKill) deviceSwitches() ([]RFKillSwitch, error) {
+	device, openErr := os.OpenFile(rfkill.Device, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer device.Close()
+	switches := []RFKillSwitch{}
+	buffer := make([]byte, 64)
+	for {
+		length, readErr := device.Read(buffer)
+		if readErr != nil || length < rfkillEventLen {
+			return switches, nil
+		}
+		event := ParseRFKillEvent(buffer[:length])
+		if event.Operation == "add" {
+			switches = append(switches, event.Switch)
+		}
+	}
+}
+
+// ParseRFKillEvent parses a struct rfkill_event read from the rfkill
+// device
+func ParseRFKillEvent(data []byte) RFKillEvent {
+	if len(data) < rfkillEventLen {
+		return RFKillEvent{}
+	}
+	event := RFKillEvent{Switch: RFKillSwitch{
+		Index:       int(nativeEndian.Uint32(data[0:4])),
+		Type:        rfkillTypes[data[4]],
+		SoftBlocked: data[6] != 0,
+		HardBlocked: data[7] != 0,
+	}}
+	switch data[5] {
+	case rfkillOpAdd:
+		event.Operation = "add"
+	case rfkillOpDel:
+		event.Operation = "del"
+	case rfkillOpChange:
+		event.Operation = "change"
+	}
+	return event
+}
+
+// readRFKillSwitch reads the switch in the provided sysfs directory
+func readRFKillSwitch(dir string) (RFKillSwitch, error) {
+	rfkillSwitch := RFKillSwitch{}
+	index, indexErr := ioutil.ReadFile(filepath.Join(dir, "index"))
+	if indexErr != nil {
+		return rfkillSwitch, indexErr
+	}
+	rfkillSwitch.Index, _ = strconv.Atoi(strings.TrimSpace(string(index)))
+	rfkillSwitch.Name = readSysfsValue(filepath.Join(dir, "name"))
+	rfkillSwitch.Type = readSysfsValue(filepath.Join(dir, "type"))
+	rfkillSwitch.SoftBlocked = readSysfsValue(filepath.Join(dir, "soft")) == "1"
+	rfkillSwitch.HardBlocked = readSysfsValue(filepath.Join(dir, "hard")) == "1"
+	return rfkillSwitch, nil
+}
+
+// readSysfsValue returns the trimmed contents of a sysfs attribute, or
+// an empty string when it can't be read
+func readSysfsValue(path string) string {
+	contents, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
@@ -0,0 +1,116 @@
+package linux
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestRFKill returns an rfkill reader of a fake sysfs tree where
+// wlan0 has a soft blocked switch and wlan1 none, with no rfkill
+// device so changes go through sysfs
+func newTestRFKill(t *testing.T) *RFKill {
+	t.Helper()
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"class/ieee80211/phy0/rfkill0/index": "0\n",
+		"class/ieee80211/phy0/rfkill0/name":  "phy0\n",
+		"class/ieee80211/phy0/rfkill0/type":  "wlan\n",
+		"class/ieee80211/phy0/rfkill0/soft":  "1\n",
+		"class/ieee80211/phy0/rfkill0/hard":  "0\n",
+		"class/ieee80211/phy1/":              "",
+		"class/net/wlan0/":                   "",
+		"class/net/wlan1/":                   "",
+		"class/rfkill/":                      "",
+	})
+	links := map[string]string{
+		"class/net/wlan0/phy80211": "class/ieee80211/phy0",
+		"class/net/wlan1/phy80211": "class/ieee80211/phy1",
+		"class/rfkill/rfkill0":     "class/ieee80211/phy0/rfkill0",
+	}
+	for link, target := range links {
+		if linkErr := os.Symlink(filepath.Join(root, target), filepath.Join(root, link)); linkErr != nil {
+			t.Fatal(linkErr)
+		}
+	}
+	return &RFKill{Root: root, Device: filepath.Join(root, "missing")}
+}
+
+func TestRFKillInterfaceSwitch(t *testing.T) {
+	rfkill := newTestRFKill(t)
+	rfkillSwitch, switchErr := rfkill.InterfaceSwitch("wlan0")
+	if switchErr != nil {
+		t.Fatal(switchErr)
+	}
+	want := RFKillSwitch{Index: 0, Name: "phy0", Type: "wlan", SoftBlocked: true}
+	if rfkillSwitch != want {
+		t.Errorf("switch = %+v, want %+v", rfkillSwitch, want)
+	}
+	for _, iface := range []string{"wlan1", "eth0"} {
+		if _, switchErr := rfkill.InterfaceSwitch(iface); switchErr != ErrNoRFKill {
+			t.Errorf("%s: error = %v, want ErrNoRFKill", iface, switchErr)
+		}
+	}
+	switches, switchesErr := rfkill.Switches()
+	if switchesErr != nil || !reflect.DeepEqual(switches, []RFKillSwitch{want}) {
+		t.Errorf("switches = %+v, %v", switches, switchesErr)
+	}
+}
+
+func TestReadRFKillSwitch(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"rfkill3/index": "3\n",
+		"rfkill3/type":  "bluetooth\n",
+		"rfkill3/hard":  "1\n",
+		"empty/":        "",
+	})
+	rfkillSwitch, readErr := readRFKillSwitch(filepath.Join(root, "rfkill3"))
+	if want := (RFKillSwitch{Index: 3, Type: "bluetooth", HardBlocked: true}); readErr != nil || rfkillSwitch != want {
+		t.Errorf("switch = %+v, %v, want %+v", rfkillSwitch, readErr, want)
+	}
+	if _, readErr := readRFKillSwitch(filepath.Join(root, "empty")); readErr == nil {
+		t.Error("switch without an index read")
+	}
+}
+
+func TestRFKillSetSoftBlockedSysfs(t *testing.T) {
+	rfkill := newTestRFKill(t)
+	if setErr := rfkill.SetSoftBlocked(0, false); setErr != nil {
+		t.Fatal(setErr)
+	}
+	soft, _ := ioutil.ReadFile(filepath.Join(rfkill.Root, "class/ieee80211/phy0/rfkill0/soft"))
+	if string(soft) != "0" {
+		t.Errorf("soft = %q, want 0", soft)
+	}
+	if rfkillSwitch, _ := rfkill.InterfaceSwitch("wlan0"); rfkillSwitch.SoftBlocked {
+		t.Error("switch still soft blocked")
+	}
+	// without the device or the sysfs switch the open error is
+	// returned
+	if setErr := rfkill.SetSoftBlocked(7, true); !os.IsNotExist(setErr) {
+		t.Errorf("missing switch error = %v, want the device's", setErr)
+	}
+}
+
+func TestParseRFKillEvent(t *testing.T) {
+	if nativeEndian != binary.LittleEndian {
+		t.Skip("event was captured on a little endian machine")
+	}
+	// the hardware switch of phy1 turning on, as read from /dev/rfkill
+	data := []byte{0x02, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01}
+	want := RFKillEvent{Operation: "change", Switch: RFKillSwitch{Index: 2, Type: "wlan", HardBlocked: true}}
+	if event := ParseRFKillEvent(data); event != want {
+		t.Errorf("event = %+v, want %+v", event, want)
+	}
+	// newer kernels append fields the parser ignores
+	if event := ParseRFKillEvent(append(data, 0x00)); event != want {
+		t.Errorf("extended event = %+v, want %+v", event, want)
+	}
+	if event := ParseRFKillEvent(data[:7]); event != (RFKillEvent{}) {
+		t.Errorf("short event = %+v", event)
+	}
+}
@@ -0,0 +1,167 @@
+package linux
+
+import (
+	"net"
+	"syscall"
+)
+
+const (
+	// rtnetlink message types
+	rtmNewLink = 16
+	rtmDelLink = 17
+	rtmNewAddr = 20
+	rtmDelAddr = 21
+	// ifinfoLen and ifaddrLen are the lengths of struct ifinfomsg and
+	// struct ifaddrmsg
+	ifinfoLen = 16
+	ifaddrLen = 8
+	// ifUp is the IFF_UP flag of a link
+	ifUp = 0x1
+	// link and address attributes
+	iflaIfname = 3
+	ifaAddress = 1
+	ifaLocal   = 2
+	// multicast groups of link and address changes
+	rtnlGroupLink       = 1
+	rtnlGroupIPv4Ifaddr = 5
+	rtnlGroupIPv6Ifaddr = 9
+)
+
+// RTNetlinkEvent is a link or address change rtnetlink reports. Type
+// is "newlink", "dellink", "newaddr" or "deladdr". Name is only set on
+// link changes, Address on address changes.
+type RTNetlinkEvent struct {
+	Type    string
+	Index   int
+	Name    string
+	Up      bool
+	Address net.IPNet
+}
+
+// RTNetlink brings links up and down through rtnetlink, without the
+// ip command. Changing the state of a link needs CAP_NET_ADMIN.
+type RTNetlink struct{}
+
+// NewRTNetlink creates a new instance of an rtnetlink client
+func NewRTNetlink() *RTNetlink {
+	return &RTNetlink{}
+}
+
+// Up brings the provided interface up
+func (rtnetlink *RTNetlink) Up(iface string) error {
+	return rtnetlink.setUp(iface, true)
+}
+
+// Down brings the provided interface down
+func (rtnetlink *RTNetlink) Down(iface string) error {
+	return rtnetlink.setUp(iface, false)
+}
+
+// Status returns whether or not the interface is up
+func (rtnetlink *RTNetlink) Status(iface string) (bool, error) {
+	netInterface, ifaceErr := net.InterfaceByName(iface)
+	if ifaceErr != nil {
+		return false, ifaceErr
+	}
+	return netInterface.Flags&net.FlagUp != 0, nil
+}
+
+// Subscribe delivers the link and address changes of every interface
+// until the returned function is called
+func (rtnetlink *RTNetlink) Subscribe() (<-chan RTNetlinkEvent, func(), error) {
+	conn, dialErr := dialNetlink(netlinkRoute)
+	if dialErr != nil {
+		return nil, nil, dialErr
+	}
+	for _, group := range []uint32{rtnlGroupLink, rtnlGroupIPv4Ifaddr, rtnlGroupIPv6Ifaddr} {
+		if joinErr := conn.socket.joinGroup(group); joinErr != nil {
+			conn.close()
+			return nil, nil, joinErr
+		}
+	}
+	delivered := make(chan RTNetlinkEvent, 16)
+	stop := conn.watch(func(message NetlinkMessage, done <-chan struct{}) bool {
+		event, found := ParseRTNetlinkEvent(message)
+		if !found {
+			return true
+		}
+		select {
+		case delivered <- event:
+			return true
+		case <-done:
+			return false
+		}
+	}, func() { close(delivered) })
+	return delivered, stop, nil
+}
+
+// ParseRTNetlinkEvent parses a link or address message, returning
+// false for any other message
+func ParseRTNetlinkEvent(message NetlinkMessage) (RTNetlinkEvent, bool) {
+	switch message.Type {
+	case rtmNewLink, rtmDelLink:
+		if len(message.Data) < ifinfoLen {
+			return RTNetlinkEvent{}, false
+		}
+		event := RTNetlinkEvent{
+			Type:  "newlink",
+			Index: int(int32(nativeEndian.Uint32(message.Data[4:8]))),
+			Up:    nativeEndian.Uint32(message.Data[8:12])&ifUp != 0,
+		}
+		if message.Type == rtmDelLink {
+			event.Type = "dellink"
+		}
+		attributes, _ := ParseNetlinkAttributes(message.Data[ifinfoLen:])
+		if name, found := findAttribute(attributes, iflaIfname); found {
+			event.Name = name.String()
+		}
+		return event, true
+	case rtmNewAddr, rtmDelAddr:
+		if len(message.Data) < ifaddrLen {
+			return RTNetlinkEvent{}, false
+		}
+		event := RTNetlinkEvent{Type: "newaddr", Index: int(nativeEndian.Uint32(message.Data[4:8]))}
+		if message.Type == rtmDelAddr {
+			event.Type = "deladdr"
+		}
+		// struct ifaddrmsg: family, prefix length, flags, scope, index
+		family, prefixLength := message.Data[0], int(message.Data[1])
+		attributes, _ := ParseNetlinkAttributes(message.Data[ifaddrLen:])
+		address, found := findAttribute(attributes, ifaLocal)
+		if !found {
+			address, found = findAttribute(attributes, ifaAddress)
+		}
+		if found {
+			bits := 32
+			if family == syscall.AF_INET6 {
+				bits = 128
+			}
+			event.Address = net.IPNet{IP: net.IP(address.Data), Mask: net.CIDRMask(prefixLength, bits)}
+		}
+		return event, true
+	}
+	return RTNetlinkEvent{}, false
+}
+
+// setUp sets or clears the IFF_UP flag of the interface
+func (rtnetlink *RTNetlink) setUp(iface string, up bool) error {
+	index, indexErr := interfaceIndex(iface)
+	if indexErr != nil {
+		return indexErr
+	}
+	conn, dialErr := dialNetlink(netlinkRoute)
+	if dialErr != nil {
+		return dialErr
+	}
+	defer conn.close()
+	// struct ifinfomsg: family, padding, type, index, flags and the
+	// mask of the flags to change
+	ifinfo := make([]byte, ifinfoLen)
+	nativeEndian.PutUint32(ifinfo[4:8], index)
+	if up {
+		nativeEndian.PutUint32(ifinfo[8:12], ifUp)
+	}
+	nativeEndian.PutUint32(ifinfo[12:16], ifUp)
+	_, executeErr := conn.execute(NetlinkMessage{Type: rtmNewLink, Data: ifinfo})
+	return executeErr
+}
@@ -0,0 +1,100 @@
+package linux
+
+import (
+	"testing"
+)
+
+func TestParseRTNetlinkEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		datagram []byte
+		event    RTNetlinkEvent
+		address  string
+	}{
+		{
+			name: "link up",
+			datagram: golden(t,
+				"34 00 00 00 10 00 00 00 00 00 00 00 00 00 00 00",
+				"00 00 01 00 03 00 00 00 43 10 01 00 00 00 00 00",
+				"0a 00 03 00 77 6c 61 6e 30 00 00 00",
+				"08 00 04 00 dc 05 00 00",
+			),
+			event: RTNetlinkEvent{Type: "newlink", Index: 3, Name: "wlan0", Up: true},
+		},
+		{
+			name: "link down",
+			datagram: golden(t,
+				"2c 00 00 00 10 00 00 00 00 00 00 00 00 00 00 00",
+				"00 00 01 00 03 00 00 00 02 10 00 00 01 00 00 00",
+				"0a 00 03 00 77 6c 61 6e 30 00 00 00",
+			),
+			event: RTNetlinkEvent{Type: "newlink", Index: 3, Name: "wlan0"},
+		},
+		{
+			name: "link removed",
+			datagram: golden(t,
+				"2c 00 00 00 11 00 00 00 00 00 00 00 00 00 00 00",
+				"00 00 01 00 04 00 00 00 02 10 00 00 ff ff ff ff",
+				"0a 00 03 00 77 6c 61 6e 31 00 00 00",
+			),
+			event: RTNetlinkEvent{Type: "dellink", Index: 4, Name: "wlan1"},
+		},
+		{
+			name: "ipv4 address added",
+			datagram: golden(t,
+				"28 00 00 00 14 00 00 00 00 00 00 00 00 00 00 00",
+				"02 18 80 00 03 00 00 00",
+				"08 00 01 00 c0 a8 01 0a",
+				"08 00 02 00 c0 a8 01 0a",
+			),
+			event:   RTNetlinkEvent{Type: "newaddr", Index: 3},
+			address: "192.168.1.10/24",
+		},
+		{
+			name: "ipv6 address removed",
+			datagram: golden(t,
+				"2c 00 00 00 15 00 00 00 00 00 00 00 00 00 00 00",
+				"0a 40 80 fd 03 00 00 00",
+				"14 00 01 00 fe 80 00 00 00 00 00 00 3e 37 86 ff fe 1a 2b 3c",
+			),
+			event:   RTNetlinkEvent{Type: "deladdr", Index: 3},
+			address: "fe80::3e37:86ff:fe1a:2b3c/64",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			messages, parseErr := ParseNetlinkMessages(test.datagram)
+			if parseErr != nil || len(messages) != 1 {
+				t.Fatalf("parsed %d messages, %v", len(messages), parseErr)
+			}
+			event, found := ParseRTNetlinkEvent(messages[0])
+			if !found {
+				t.Fatal("no event found")
+			}
+			address := ""
+			if event.Address.IP != nil {
+				address = event.Address.String()
+			}
+			if address != test.address {
+				t.Errorf("address = %q, want %q", address, test.address)
+			}
+			event.Address = test.event.Address
+			if event.Type != test.event.Type || event.Index != test.event.Index || event.Name != test.event.Name || event.Up != test.event.Up {
+				t.Errorf("event = %+v\nwant %+v", event, test.event)
+			}
+		})
+	}
+}
+
+func TestParseRTNetlinkEventIgnored(t *testing.T) {
+	tests := map[string]NetlinkMessage{
+		"route":             {Type: 24, Data: make([]byte, 12)},
+		"truncated link":    {Type: rtmNewLink, Data: make([]byte, ifinfoLen-1)},
+		"truncated address": {Type: rtmNewAddr, Data: make([]byte, ifaddrLen-1)},
+	}
+	for name, message := range tests {
+		if _, found := ParseRTNetlinkEvent(message); found {
+			t.Errorf("%s: found an event", name)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package linux
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Sysfs discovers wireless interfaces through sysfs. Root is where
+// sysfs is mounted, which can point to a copy of the tree, and
+// IDDatabases lists the pci.ids and usb.ids formatted databases vendor
+// and device names are looked up in, by bus.
+type Sysfs struct {
+	Root        string
+	IDDatabases map[string][]string
+}
+
+// WirelessInterface represents a wireless network interface found in
+// sysfs. Bus is pci, usb or sdio, VendorID and DeviceID are the hex
+// IDs of the device on it, and Vendor and Model their names when they
+// can be found in the hardware ID databases.
+type WirelessInterface struct {
+	Name     string
+	PHY      string
+	MAC      string
+	Driver   string
+	Bus      string
+	VendorID string
+	DeviceID string
+	Vendor   string
+	Model    string
+}
+
+// NewSysfs creates a new instance of a sysfs reader for the sysfs
+// mounted on /sys
+func NewSysfs() *Sysfs {
+	return &Sysfs{
+		Root: "/sys",
+		IDDatabases: map[string][]string{
+			"pci": {"/usr/share/hwdata/pci.ids", "/usr/share/misc/pci.ids"},
+			"usb": {"/usr/share/hwdata/usb.ids", "/usr/share/misc/usb.ids"},
+		},
+	}
+}
+
+// IsWireless returns whether or not the interface is wireless
+func (sysfs *Sysfs) IsWireless(iface string) bool {
+	netDir := filepath.Join(sysfs.Root, "class", "net", iface)
+	for _, name := range []string{"wireless", "phy80211"} {
+		if _, statErr := os.Stat(filepath.Join(netDir, name)); statErr == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// PHYs returns the names of the wireless PHYs, such as phy0
+func (sysfs *Sysfs) PHYs() ([]string, error) {
+	entries, readErr := ioutil.ReadDir(filepath.Join(sysfs.Root, "class", "ieee80211"))
+	if readErr != nil {
+		return nil, readErr
+	}
+	phys := []string{}
+	for _, entry := range entries {
+		phys = append(phys, entry.Name())
+	}
+	return phys, nil
+}
+
+// WirelessInterfaces returns every wireless interface in sysfs
+func (sysfs *Sysfs) WirelessInterfaces() ([]WirelessInterface, error) {
+	entries, readErr := ioutil.ReadDir(filepath.Join(sysfs.Root, "class", "net"))
+	if readErr != nil {
+		return nil, readErr
+	}
+	wirelessInterfaces := []WirelessInterface{}
+	for _, entry := range entries {
+		if !sysfs.IsWireless(entry.Name()) {
+			continue
+		}
+		// interfaces can go away while the tree is read
+		wirelessInterface, ifaceErr := sysfs.WirelessInterface(entry.Name())
+		if ifaceErr != nil {
+			continue
+		}
+		wirelessInterfaces = append(wirelessInterfaces, wirelessInterface)
+	}
+	return wirelessInterfaces, nil
+}
+
+// WirelessInterface reads the details of the wireless interface
+func (sysfs *Sysfs) WirelessInterface(iface string) (WirelessInterface, error) {
+	netDir := filepath.Join(sysfs.Root, "class", "net", iface)
+	wirelessInterface := WirelessInterface{Name: iface}
+	address, addressErr := ioutil.ReadFile(filepath.Join(netDir, "address"))
+	if addressErr != nil {
+		return wirelessInterface, addressErr
+	}
+	wirelessInterface.MAC = strings.TrimSpace(string(address))
+	if phy, phyErr := ioutil.ReadFile(filepath.Join(netDir, "phy80211", "name")); phyErr == nil {
+		wirelessInterface.PHY = strings.TrimSpace(string(phy))
+	}
+
+	uevent := readUevent(filepath.Join(netDir, "device", "uevent"))
+	wirelessInterface.Driver = uevent["DRIVER"]
+	if wirelessInterface.Driver == "" {
+		if driver, linkErr := os.Readlink(filepath.Join(netDir, "device", "driver")); linkErr == nil {
+			wirelessInterface.Driver = filepath.Base(driver)
+		}
+	}
+	modalias := uevent["MODALIAS"]
+	if modalias == "" {
+		if contents, aliasErr := ioutil.ReadFile(filepath.Join(netDir, "device", "modalias")); aliasErr == nil {
+			modalias = strings.TrimSpace(string(contents))
+		}
+	}
+	wirelessInterface.Bus, wirelessInterface.VendorID, wirelessInterface.DeviceID = parseModalias(modalias)
+	if wirelessInterface.VendorID == "" {
+		wirelessInterface.Bus, wirelessInterface.VendorID, wirelessInterface.DeviceID = ueventIDs(uevent)
+	}
+	wirelessInterface.Vendor, wirelessInterface.Model = sysfs.lookupIDs(wirelessInterface.Bus, wirelessInterface.VendorID, wirelessInterface.DeviceID)
+	return wirelessInterface, nil
+}
+
+// readUevent reads the KEY=value pairs of a uevent file, returning an
+// empty map when it can't be read
+func readUevent(path string) map[string]string {
+	uevent := map[string]string{}
+	contents, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return uevent
+	}
+	for _, line := range strings.Split(string(contents), "\n") {
+		separator := strings.Index(line, "=")
+		if separator > 0 {
+			uevent[line[:separator]] = line[separator+1:]
+		}
+	}
+	return uevent
+}
+
+// parseModalias returns the bus and the lower case vendor and device
+// IDs of a modalias such as pci:v00008086d00002723sv..., usb:v0BDAp8179d...
+// or sdio:c00v02D0d4329
+func parseModalias(modalias string) (string, string, string) {
+	separator := strings.Index(modalias, ":")
+	if separator < 0 {
+		return "", "", ""
+	}
+	bus, fields := modalias[:separator], modalias[separator+1:]
+	deviceKey := byte('d')
+	if bus == "usb" {
+		deviceKey = 'p'
+	}
+	vendorID, deviceID := "", ""
+	for index := 0; index < len(fields); index++ {
+		switch fields[index] {
+		case 'v':
+			if vendorID == "" {
+				vendorID = modaliasField(fields[index+1:])
+			}
+		case deviceKey:
+			if deviceID == "" && vendorID != "" {
+				deviceID = modaliasField(fields[index+1:])
+			}
+		}
+	}
+	return bus, shortID(vendorID), shortID(deviceID)
+}
+
+// modaliasField returns the upper case hex digits a modalias field
+// starts with
+func modaliasField(fields string) string {
+	end := 0
+	for end < len(fields) && (fields[end] >= '0' && fields[end] <= '9' || fields[end] >= 'A' && fields[end] <= 'F') {
+		end++
+	}
+	return fields[:end]
+}
+
+// ueventIDs returns the bus and the vendor and device IDs of the
+// PCI_ID, PRODUCT or SDIO_ID key of a uevent
+func ueventIDs(uevent map[string]string) (string, string, string) {
+	if pciID, found := uevent["PCI_ID"]; found {
+		ids := strings.SplitN(pciID, ":", 2)
+		if len(ids) == 2 {
+			return "pci", shortID(ids[0]), shortID(ids[1])
+		}
+	}
+	if sdioID, found := uevent["SDIO_ID"]; found {
+		ids := strings.SplitN(sdioID, ":", 2)
+		if len(ids) == 2 {
+			return "sdio", shortID(ids[0]), shortID(ids[1])
+		}
+	}
+	if product, found := uevent["PRODUCT"]; found {
+		ids := strings.Split(product, "/")
+		if len(ids) >= 2 {
+			return "usb", shortID(ids[0]), shortID(ids[1])
+		}
+	}
+	return "", "", ""
+}
+
+// shortID returns the ID as 4 lower case hex digits, the way the
+// hardware ID databases list them
+func shortID(id string) string {
+	id = strings.ToLower(id)
+	if len(id) > 4 {
+		id = id[len(id)-4:]
+	}
+	for len(id) > 0 && len(id) < 4 {
+		id = "0" + id
+	}
+	return id
+}
+
+// lookupIDs returns the vendor and device names of the IDs from the
+// first hardware ID database of the bus that lists the vendor
+func (sysfs *Sysfs) lookupIDs(bus, vendorID, deviceID string) (string, string) {
+	if vendorID == "" {
+		return "", ""
+	}
+	for _, path := range sysfs.IDDatabases[bus] {
+		file, openErr := os.Open(path)
+		if openErr != nil {
+			continue
+		}
+		vendor, model := scanIDs(file, vendorID, deviceID)
+		file.Close()
+		if vendor != "" {
+			return vendor, model
+		}
+	}
+	return "", ""
+}
+
+// scanIDs looks the IDs up in a pci.ids or usb.ids formatted database,
+// where vendors start a line and their devices follow indented by a tab
+func scanIDs(file *os.File, vendorID, deviceID string) (string, string) {
+	vendor := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "#") || line == "":
+		case !strings.HasPrefix(line, "\t"):
+			if vendor != "" {
+				return vendor, ""
+			}
+			if strings.HasPrefix(line, vendorID+"  ") {
+				vendor = strings.TrimSpace(line[len(vendorID):])
+			}
+		case vendor != "" && strings.HasPrefix(line, "\t"+deviceID+"  "):
+			return vendor, strings.TrimSpace(line[len(deviceID)+1:])
+		}
+	}
+	return vendor, ""
+}
@@ -0,0 +1,116 @@
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTree creates the files of a fake sysfs tree, creating
+// directories for paths ending in a slash
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(root, name)
+		if name[len(name)-1] == '/' {
+			if mkdirErr := os.MkdirAll(path, 0755); mkdirErr != nil {
+				t.Fatal(mkdirErr)
+			}
+			continue
+		}
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0755); mkdirErr != nil {
+			t.Fatal(mkdirErr)
+		}
+		if writeErr := ioutil.WriteFile(path, []byte(contents), 0644); writeErr != nil {
+			t.Fatal(writeErr)
+		}
+	}
+}
+
+func TestSysfsWirelessInterfaces(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		// PCI card found through the modalias in its uevent
+		"class/net/wlan0/address":       "02:00:00:00:00:01\n",
+		"class/net/wlan0/phy80211/name": "phy0\n",
+		"class/net/wlan0/wireless/":     "",
+		"class/net/wlan0/device/uevent": "DRIVER=iwlwifi\nPCI_CLASS=28000\nPCI_ID=8086:2723\nMODALIAS=pci:v00008086d00002723sv00008086sd00000084bc02sc80i00\n",
+		"class/ieee80211/phy0/index":    "0\n",
+		// USB dongle with only a modalias file and a driver link
+		"class/net/wlan1/address":         "02:00:00:00:00:02\n",
+		"class/net/wlan1/phy80211/name":   "phy1\n",
+		"class/net/wlan1/device/modalias": "usb:v0BDAp8179d0000dc00dsc00dp00icFFiscFFipFFin00\n",
+		"drivers/rtl8xxxu/":               "",
+		"class/ieee80211/phy1/index":      "1\n",
+		// SDIO chip known from the SDIO_ID of its uevent only
+		"class/net/wlan2/address":       "02:00:00:00:00:03\n",
+		"class/net/wlan2/wireless/":     "",
+		"class/net/wlan2/device/uevent": "DRIVER=brcmfmac\nSDIO_CLASS=00\nSDIO_ID=02D0:4329\n",
+		// wired interfaces are skipped
+		"class/net/eth0/address":       "02:00:00:00:00:04\n",
+		"class/net/eth0/device/uevent": "DRIVER=e1000e\nPCI_ID=8086:15D7\n",
+	})
+	if linkErr := os.Symlink(filepath.Join(root, "drivers", "rtl8xxxu"), filepath.Join(root, "class/net/wlan1/device/driver")); linkErr != nil {
+		t.Fatal(linkErr)
+	}
+	pciIDs := filepath.Join(root, "pci.ids")
+	writeTree(t, root, map[string]string{
+		"pci.ids": "# pci.ids\n8086  Intel Corporation\n\t2723  Wi-Fi 6 AX200\n\t\t8086 0084  Wi-Fi 6 AX200NGW\n8087  Intel Corp.\n",
+	})
+	sysfs := &Sysfs{Root: root, IDDatabases: map[string][]string{"pci": {filepath.Join(root, "missing.ids"), pciIDs}}}
+
+	wirelessInterfaces, ifaceErr := sysfs.WirelessInterfaces()
+	if ifaceErr != nil {
+		t.Fatal(ifaceErr)
+	}
+	want := []WirelessInterface{
+		{Name: "wlan0", PHY: "phy0", MAC: "02:00:00:00:00:01", Driver: "iwlwifi", Bus: "pci", VendorID: "8086", DeviceID: "2723", Vendor: "Intel Corporation", Model: "Wi-Fi 6 AX200"},
+		{Name: "wlan1", PHY: "phy1", MAC: "02:00:00:00:00:02", Driver: "rtl8xxxu", Bus: "usb", VendorID: "0bda", DeviceID: "8179"},
+		{Name: "wlan2", MAC: "02:00:00:00:00:03", Driver: "brcmfmac", Bus: "sdio", VendorID: "02d0", DeviceID: "4329"},
+	}
+	if !reflect.DeepEqual(wirelessInterfaces, want) {
+		t.Fatalf("interfaces = %+v\nwant %+v", wirelessInterfaces, want)
+	}
+
+	phys, phyErr := sysfs.PHYs()
+	if phyErr != nil || !reflect.DeepEqual(phys, []string{"phy0", "phy1"}) {
+		t.Errorf("PHYs = %v, %v", phys, phyErr)
+	}
+	if sysfs.IsWireless("eth0") {
+		t.Error("eth0 is reported as wireless")
+	}
+}
+
+func TestParseModalias(t *testing.T) {
+	tests := []struct {
+		modalias string
+		ids      [3]string
+	}{
+		{"pci:v000010ECd0000C822sv000017AAsd00005124bc02sc80i00", [3]string{"pci", "10ec", "c822"}},
+		{"usb:v0BDAp8179d0000dc00dsc00dp00icFFiscFFipFFin00", [3]string{"usb", "0bda", "8179"}},
+		{"sdio:c00v02D0dA9A6", [3]string{"sdio", "02d0", "a9a6"}},
+		{"platform:wlcore", [3]string{"platform", "", ""}},
+		{"", [3]string{"", "", ""}},
+	}
+	for _, test := range tests {
+		bus, vendorID, deviceID := parseModalias(test.modalias)
+		if ids := [3]string{bus, vendorID, deviceID}; ids != test.ids {
+			t.Errorf("parseModalias(%q) = %v, want %v", test.modalias, ids, test.ids)
+		}
+	}
+}
+
+func TestReadUevent(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"uevent": "DRIVER=ath9k\nPCI_SLOT_NAME=0000:03:00.0\nMODALIAS=pci:v0000168Cd0000002Asv\n\n"})
+	uevent := readUevent(filepath.Join(root, "uevent"))
+	want := map[string]string{"DRIVER": "ath9k", "PCI_SLOT_NAME": "0000:03:00.0", "MODALIAS": "pci:v0000168Cd0000002Asv"}
+	if !reflect.DeepEqual(uevent, want) {
+		t.Fatalf("uevent = %v, want %v", uevent, want)
+	}
+	if missing := readUevent(filepath.Join(root, "missing")); len(missing) != 0 {
+		t.Errorf("missing uevent = %v", missing)
+	}
+}
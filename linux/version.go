@@ -0,0 +1,28 @@
+package linux
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrNoVersion is returned when a command's output doesn't hold a
+	// version
+	ErrNoVersion = errors.New("linux: no version found in output")
+)
+
+// toolVersion returns the word following the name on the first line of
+// the output that starts with it, such as "v2.10" from
+// "wpa_supplicant v2.10"
+func toolVersion(output []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == name {
+			return fields[1], nil
+		}
+	}
+	return "", ErrNoVersion
+}
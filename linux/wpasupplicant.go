@@ -0,0 +1,541 @@
+package linux
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// WPAScanTimeout is how long Scan waits for wpa_supplicant to
+	// report that a scan has finished
+	WPAScanTimeout = 30 * time.Second
+	// WPAConnectTimeout is how long Connect waits for wpa_supplicant
+	// to either complete or give up on a connection
+	WPAConnectTimeout = 30 * time.Second
+)
+
+var (
+	// ErrWPAReply is returned when wpa_supplicant answers a request
+	// with something other than what the command is documented to return
+	ErrWPAReply = errors.New("wpa_supplicant: unexpected reply")
+	// ErrWPAAuth is returned when wpa_supplicant temporarily disables a
+	// network after failing to authenticate with it
+	ErrWPAAuth = errors.New("wpa_supplicant: authentication failed")
+	// ErrWPAArgument is returned when a request argument holds spaces
+	// or control characters, which would split it into several
+	// arguments or requests
+	ErrWPAArgument = errors.New("wpa_supplicant: invalid request argument")
+)
+
+// WPASupplicant is a client for the control interface of a running
+// wpa_supplicant daemon managing a single interface.
+type WPASupplicant struct {
+	Interface  string
+	Executable string
+	CtrlDir    string
+}
+
+// WPAScanResult represents a BSS from the SCAN_RESULTS reply
+type WPAScanResult struct {
+	BSSID     string
+	Frequency int
+	Signal    int
+	Flags     []string
+	SSID      string
+}
+
+// WPANetwork represents a configured network from the LIST_NETWORKS
+// reply
+type WPANetwork struct {
+	ID    int
+	SSID  string
+	BSSID string
+	Flags []string
+}
+
+// DPPAuth represents the parameters of a DPP_AUTH_INIT request. Own
+// and Configurator are left out of the request when zero, as
+// wpa_supplicant numbers them from one. PSK is a raw PSK of 64 hex
+// digits handed out in place of a passphrase.
+type DPPAuth struct {
+	Peer         int
+	Own          int
+	Role         string
+	Configurator int
+	Conf         string
+	SSID         string
+	Passphrase   string
+	PSK          string
+}
+
+// NewWPASupplicant creates a new instance of the wpa_supplicant
+// client for the provided interface.
+func NewWPASupplicant(iface string) *WPASupplicant {
+	return &WPASupplicant{
+		Interface:  iface,
+		Executable: "wpa_supplicant",
+		CtrlDir:    "/var/run/wpa_supplicant",
+	}
+}
+
+// IsInstalled returns whether or not the wpa_supplicant executable
+// can be found in the current PATH environment variable.
+func (wpaSupplicant *WPASupplicant) IsInstalled() bool {
+	_, err := exec.LookPath(wpaSupplicant.Executable)
+	if err != nil {
+		return false
+	}
+	return true
+}
+
+// Version returns the version wpa_supplicant reports, such as "v2.10"
+func (wpaSupplicant *WPASupplicant) Version() (string, error) {
+	cmdOut, cmdErr := exec.Command(wpaSupplicant.Executable, "-v").CombinedOutput()
+	version, versionErr := toolVersion(cmdOut, "wpa_supplicant")
+	if versionErr != nil && cmdErr != nil {
+		return "", cmdErr
+	}
+	return version, versionErr
+}
+
+// WPASupplicantInterfaces returns the names of the interfaces that
+// have a control socket in the provided directory
+func WPASupplicantInterfaces(ctrlDir string) ([]string, error) {
+	files, readErr := ioutil.ReadDir(ctrlDir)
+	if readErr != nil {
+		return nil, readErr
+	}
+	ifaces := []string{}
+	for _, file := range files {
+		if file.Mode()&os.ModeSocket != 0 && !strings.HasPrefix(file.Name(), "p2p-dev-") {
+			ifaces = append(ifaces, file.Name())
+		}
+	}
+	return ifaces, nil
+}
+
+// ctrlPath returns the location of the control socket for the interface
+func (wpaSupplicant *WPASupplicant) ctrlPath() string {
+	return filepath.Join(wpaSupplicant.CtrlDir, wpaSupplicant.Interface)
+}
+
+// Request sends a raw command to the wpa_supplicant control interface
+func (wpaSupplicant *WPASupplicant) Request(command string) (string, error) {
+	ctrl, dialErr := dialCtrl(wpaSupplicant.ctrlPath())
+	if dialErr != nil {
+		return "", dialErr
+	}
+	defer ctrl.Close()
+	return ctrl.Request(command)
+}
+
+// requestOK sends a command that is answered with OK
+func (wpaSupplicant *WPASupplicant) requestOK(command string) error {
+	reply, requestErr := wpaSupplicant.Request(command)
+	if requestErr != nil {
+		return requestErr
+	}
+	if strings.TrimSpace(reply) != "OK" {
+		return ErrWPAReply
+	}
+	return nil
+}
+
+// requestID sends a command that is answered with the numeric id of
+// whatever it created
+func (wpaSupplicant *WPASupplicant) requestID(command string) (int, error) {
+	reply, requestErr := wpaSupplicant.Request(command)
+	if requestErr != nil {
+		return 0, requestErr
+	}
+	id, convErr := strconv.Atoi(strings.TrimSpace(reply))
+	if convErr != nil {
+		return 0, ErrWPAReply
+	}
+	return id, nil
+}
+
+// Scan triggers a scan, waits for it to finish and returns the results
+func (wpaSupplicant *WPASupplicant) Scan() ([]WPAScanResult, error) {
+	ctrl, dialErr := dialCtrl(wpaSupplicant.ctrlPath())
+	if dialErr != nil {
+		return nil, dialErr
+	}
+	defer ctrl.Close()
+	_, attachErr := ctrl.Request("ATTACH")
+	if attachErr != nil {
+		return nil, attachErr
+	}
+	defer ctrl.Request("DETACH")
+	// a scan that is already running reports its results just the same
+	reply, scanErr := ctrl.Request("SCAN")
+	if scanErr != nil && !strings.HasPrefix(reply, "FAIL-BUSY") {
+		return nil, scanErr
+	}
+	_, waitErr := ctrl.WaitEvent(WPAScanTimeout, "CTRL-EVENT-SCAN-RESULTS")
+	if waitErr != nil {
+		return nil, waitErr
+	}
+	return wpaSupplicant.ScanResults()
+}
+
+// Events delivers the event messages of wpa_supplicant, such as
+// "CTRL-EVENT-DISCONNECTED bssid=... reason=3", until the returned
+// function is called or the daemon goes away
+func (wpaSupplicant *WPASupplicant) Events() (<-chan string, func(), error) {
+	ctrl, dialErr := dialCtrl(wpaSupplicant.ctrlPath())
+	if dialErr != nil {
+		return nil, nil, dialErr
+	}
+	if _, attachErr := ctrl.Request("ATTACH"); attachErr != nil {
+		ctrl.Close()
+		return nil, nil, attachErr
+	}
+	events := make(chan string, 16)
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		defer close(events)
+		defer ctrl.Close()
+		for {
+			select {
+			case <-done:
+				ctrl.Request("DETACH")
+				return
+			default:
+			}
+			// wake up regularly to notice that the caller is done
+			message, readErr := ctrl.ReadEvent(time.Second)
+			if netErr, ok := readErr.(net.Error); ok && netErr.Timeout() {
+				continue
+			}
+			if readErr != nil {
+				return
+			}
+			select {
+			case events <- message:
+			case <-done:
+			}
+		}
+	}()
+	return events, func() { once.Do(func() { close(done) }) }, nil
+}
+
+// ScanResults returns the results of the last scan
+func (wpaSupplicant *WPASupplicant) ScanResults() ([]WPAScanResult, error) {
+	reply, requestErr := wpaSupplicant.Request("SCAN_RESULTS")
+	if requestErr != nil {
+		return nil, requestErr
+	}
+	results := []WPAScanResult{}
+	for _, fields := range splitTable(reply) {
+		if len(fields) < 5 {
+			continue
+		}
+		result := WPAScanResult{
+			BSSID: fields[0],
+			Flags: splitFlags(fields[3]),
+			SSID:  unescapeText(fields[4]),
+		}
+		result.Frequency, _ = strconv.Atoi(fields[1])
+		result.Signal, _ = strconv.Atoi(fields[2])
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Networks returns the configured networks
+func (wpaSupplicant *WPASupplicant) Networks() ([]WPANetwork, error) {
+	reply, requestErr := wpaSupplicant.Request("LIST_NETWORKS")
+	if requestErr != nil {
+		return nil, requestErr
+	}
+	networks := []WPANetwork{}
+	for _, fields := range splitTable(reply) {
+		if len(fields) < 4 {
+			continue
+		}
+		id, convErr := strconv.Atoi(fields[0])
+		if convErr != nil {
+			continue
+		}
+		networks = append(networks, WPANetwork{
+			ID:    id,
+			SSID:  unescapeText(fields[1]),
+			BSSID: fields[2],
+			Flags: splitFlags(fields[3]),
+		})
+	}
+	return networks, nil
+}
+
+// AddNetwork creates an empty, disabled network and returns its id
+func (wpaSupplicant *WPASupplicant) AddNetwork() (int, error) {
+	return wpaSupplicant.requestID("ADD_NETWORK")
+}
+
+// SetNetwork sets a network variable. The value is passed on as is,
+// so strings have to be quoted or hex encoded the way they would be
+// in wpa_supplicant.conf.
+func (wpaSupplicant *WPASupplicant) SetNetwork(id int, key, value string) error {
+	return wpaSupplicant.requestOK(fmt.Sprintf("SET_NETWORK %d %s %s", id, key, value))
+}
+
+// SelectNetwork enables the network, disables all others and
+// connects to it
+func (wpaSupplicant *WPASupplicant) SelectNetwork(id int) error {
+	return wpaSupplicant.requestOK(fmt.Sprintf("SELECT_NETWORK %d", id))
+}
+
+// Connect selects the network and waits until wpa_supplicant has
+// either connected to it or disabled it after failing to authenticate
+func (wpaSupplicant *WPASupplicant) Connect(id int) error {
+	ctrl, dialErr := dialCtrl(wpaSupplicant.ctrlPath())
+	if dialErr != nil {
+		return dialErr
+	}
+	defer ctrl.Close()
+	_, attachErr := ctrl.Request("ATTACH")
+	if attachErr != nil {
+		return attachErr
+	}
+	defer ctrl.Request("DETACH")
+	_, selectErr := ctrl.Request(fmt.Sprintf("SELECT_NETWORK %d", id))
+	if selectErr != nil {
+		return selectErr
+	}
+	event, waitErr := ctrl.WaitEvent(WPAConnectTimeout, "CTRL-EVENT-CONNECTED", "CTRL-EVENT-SSID-TEMP-DISABLED")
+	if waitErr != nil {
+		return waitErr
+	}
+	if strings.HasPrefix(event, "CTRL-EVENT-SSID-TEMP-DISABLED") {
+		return ErrWPAAuth
+	}
+	return nil
+}
+
+// RemoveNetwork removes a configured network
+func (wpaSupplicant *WPASupplicant) RemoveNetwork(id int) error {
+	return wpaSupplicant.requestOK(fmt.Sprintf("REMOVE_NETWORK %d", id))
+}
+
+// Disconnect disconnects from the current network and stops
+// wpa_supplicant from reconnecting until a network is selected
+func (wpaSupplicant *WPASupplicant) Disconnect() error {
+	return wpaSupplicant.requestOK("DISCONNECT")
+}
+
+// Set changes a global wpa_supplicant setting at runtime
+func (wpaSupplicant *WPASupplicant) Set(name, value string) error {
+	return wpaSupplicant.requestOK("SET " + name + " " + value)
+}
+
+// Status returns the key=value pairs of the STATUS reply, such as
+// wpa_state, ssid and bssid
+func (wpaSupplicant *WPASupplicant) Status() (map[string]string, error) {
+	reply, requestErr := wpaSupplicant.Request("STATUS")
+	if requestErr != nil {
+		return nil, requestErr
+	}
+	status := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(reply))
+	for scanner.Scan() {
+		pair := strings.SplitN(scanner.Text(), "=", 2)
+		if len(pair) == 2 {
+			status[pair[0]] = pair[1]
+		}
+	}
+	return status, nil
+}
+
+// DPPBootstrapGen registers QR code bootstrapping information for
+// this device and returns its id. The key is the DER encoded EC
+// private key; wpa_supplicant generates one if it's empty. Channels
+// are in the class/channel form of the DPP URI C: field, the MAC is in
+// colon separated form and the info can't contain spaces or semicolons,
+// as it ends up in the I: field of the URI.
+func (wpaSupplicant *WPASupplicant) DPPBootstrapGen(channels []string, mac, info string, key []byte) (int, error) {
+	if !isArgument(mac) || !isArgument(info) || strings.Contains(info, ";") {
+		return 0, ErrWPAArgument
+	}
+	for _, channel := range channels {
+		if !isArgument(channel) || strings.Contains(channel, ",") {
+			return 0, ErrWPAArgument
+		}
+	}
+	command := "DPP_BOOTSTRAP_GEN type=qrcode"
+	if len(channels) > 0 {
+		command += " chan=" + strings.Join(channels, ",")
+	}
+	if mac != "" {
+		command += " mac=" + mac
+	}
+	if info != "" {
+		command += " info=" + info
+	}
+	if len(key) > 0 {
+		command += " key=" + hex.EncodeToString(key)
+	}
+	return wpaSupplicant.requestID(command)
+}
+
+// DPPBootstrapGetURI returns the DPP URI of registered bootstrapping
+// information
+func (wpaSupplicant *WPASupplicant) DPPBootstrapGetURI(id int) (string, error) {
+	reply, requestErr := wpaSupplicant.Request(fmt.Sprintf("DPP_BOOTSTRAP_GET_URI %d", id))
+	if requestErr != nil {
+		return "", requestErr
+	}
+	return strings.TrimSpace(reply), nil
+}
+
+// DPPBootstrapRemove removes registered bootstrapping information
+func (wpaSupplicant *WPASupplicant) DPPBootstrapRemove(id int) error {
+	return wpaSupplicant.requestOK(fmt.Sprintf("DPP_BOOTSTRAP_REMOVE %d", id))
+}
+
+// DPPQRCode registers the DPP URI of a peer, as scanned from its QR
+// code, and returns the id to authenticate it with
+func (wpaSupplicant *WPASupplicant) DPPQRCode(uri string) (int, error) {
+	if !isArgument(uri) {
+		return 0, ErrWPAArgument
+	}
+	return wpaSupplicant.requestID("DPP_QR_CODE " + uri)
+}
+
+// DPPConfiguratorAdd creates a configurator with a fresh signing key
+// and returns its id
+func (wpaSupplicant *WPASupplicant) DPPConfiguratorAdd() (int, error) {
+	return wpaSupplicant.requestID("DPP_CONFIGURATOR_ADD")
+}
+
+// DPPAuthInit starts DPP authentication with a peer
+func (wpaSupplicant *WPASupplicant) DPPAuthInit(auth DPPAuth) error {
+	if !isArgument(auth.Role) || !isArgument(auth.Conf) || !isArgument(auth.PSK) {
+		return ErrWPAArgument
+	}
+	return wpaSupplicant.requestOK(auth.command())
+}
+
+// DPPListen waits for DPP authentication requests on the provided
+// frequency in MHz. The role is "enrollee", "configurator" or empty
+// for either.
+func (wpaSupplicant *WPASupplicant) DPPListen(frequency int, role string) error {
+	if !isArgument(role) {
+		return ErrWPAArgument
+	}
+	command := fmt.Sprintf("DPP_LISTEN %d", frequency)
+	if role != "" {
+		command += " role=" + role
+	}
+	return wpaSupplicant.requestOK(command)
+}
+
+// DPPStopListen stops waiting for DPP authentication requests
+func (wpaSupplicant *WPASupplicant) DPPStopListen() error {
+	return wpaSupplicant.requestOK("DPP_STOP_LISTEN")
+}
+
+// command returns the DPP_AUTH_INIT request. The SSID and passphrase
+// are hex encoded as wpa_supplicant expects.
+func (auth DPPAuth) command() string {
+	command := fmt.Sprintf("DPP_AUTH_INIT peer=%d", auth.Peer)
+	if auth.Own != 0 {
+		command += fmt.Sprintf(" own=%d", auth.Own)
+	}
+	if auth.Role != "" {
+		command += " role=" + auth.Role
+	}
+	if auth.Configurator != 0 {
+		command += fmt.Sprintf(" configurator=%d", auth.Configurator)
+	}
+	if auth.Conf != "" {
+		command += " conf=" + auth.Conf
+	}
+	if auth.SSID != "" {
+		command += " ssid=" + hex.EncodeToString([]byte(auth.SSID))
+	}
+	if auth.Passphrase != "" {
+		command += " pass=" + hex.EncodeToString([]byte(auth.Passphrase))
+	}
+	if auth.PSK != "" {
+		command += " psk=" + auth.PSK
+	}
+	return command
+}
+
+// isArgument returns whether the value can be passed as a single
+// argument of a request
+func isArgument(value string) bool {
+	return !strings.Contains(value, " ") && !hasControlCharacters(value)
+}
+
+// splitTable splits a tab separated reply into its rows, skipping the
+// header line
+func splitTable(reply string) [][]string {
+	rows := [][]string{}
+	scanner := bufio.NewScanner(strings.NewReader(reply))
+	scanner.Scan()
+	for scanner.Scan() {
+		if scanner.Text() != "" {
+			rows = append(rows, strings.Split(scanner.Text(), "\t"))
+		}
+	}
+	return rows
+}
+
+// splitFlags splits a [FLAG][FLAG] list
+func splitFlags(flags string) []string {
+	flags = strings.Trim(flags, "[]")
+	if flags == "" {
+		return []string{}
+	}
+	return strings.Split(flags, "][")
+}
+
+// unescapeText reverses the escaping wpa_supplicant applies to SSIDs
+// in its replies, which leaves printable ASCII as is
+func unescapeText(text string) string {
+	var buffer bytes.Buffer
+	for index := 0; index < len(text); index++ {
+		if text[index] != '\\' || index+1 >= len(text) {
+			buffer.WriteByte(text[index])
+			continue
+		}
+		index++
+		switch text[index] {
+		case 'n':
+			buffer.WriteByte('\n')
+		case 'r':
+			buffer.WriteByte('\r')
+		case 't':
+			buffer.WriteByte('\t')
+		case 'e':
+			buffer.WriteByte(0x1b)
+		case 'x':
+			if index+2 < len(text) {
+				if char, decodeErr := hex.DecodeString(text[index+1 : index+3]); decodeErr == nil {
+					buffer.Write(char)
+					index += 2
+					continue
+				}
+			}
+			buffer.WriteByte('x')
+		default:
+			buffer.WriteByte(text[index])
+		}
+	}
+	return buffer.String()
+}
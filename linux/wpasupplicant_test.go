@@ -0,0 +1,138 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newTestWPASupplicant returns a client for a fake wpa_supplicant
+// control socket answering with the provided replies
+func newTestWPASupplicant(t *testing.T, replies map[string]string) (*WPASupplicant, *fakeCtrl) {
+	t.Helper()
+	dir := t.TempDir()
+	ctrl := newFakeCtrl(t, dir, "wlan0", replies)
+	wpaSupplicant := NewWPASupplicant("wlan0")
+	wpaSupplicant.CtrlDir = dir
+	return wpaSupplicant, ctrl
+}
+
+func TestDPPBootstrapGen(t *testing.T) {
+	wpaSupplicant, ctrl := newTestWPASupplicant(t, map[string]string{
+		"DPP_BOOTSTRAP_GEN type=qrcode chan=81/1,115/36 mac=02:00:00:00:00:01 info=kitchen key=0a0b": "3\n",
+		"DPP_BOOTSTRAP_GET_URI 3": "DPP:C:81/1,115/36;M:020000000001;I:kitchen;K:MDkw;;\n",
+	})
+	id, genErr := wpaSupplicant.DPPBootstrapGen([]string{"81/1", "115/36"}, "02:00:00:00:00:01", "kitchen", []byte{0x0a, 0x0b})
+	if genErr != nil || id != 3 {
+		t.Fatalf("DPPBootstrapGen = %d, %v", id, genErr)
+	}
+	uri, uriErr := wpaSupplicant.DPPBootstrapGetURI(id)
+	if uriErr != nil || uri != "DPP:C:81/1,115/36;M:020000000001;I:kitchen;K:MDkw;;" {
+		t.Fatalf("DPPBootstrapGetURI = %q, %v", uri, uriErr)
+	}
+	if received := ctrl.received(); len(received) != 2 {
+		t.Errorf("sent %q", received)
+	}
+}
+
+func TestDPPBootstrapGenRejectsArguments(t *testing.T) {
+	wpaSupplicant, ctrl := newTestWPASupplicant(t, nil)
+	tests := []struct {
+		name     string
+		channels []string
+		mac      string
+		info     string
+	}{
+		{name: "space in info", info: "living room"},
+		{name: "semicolon in info", info: "kitchen;K:forged"},
+		{name: "newline in info", info: "kitchen\nDPP_CONFIGURATOR_ADD"},
+		{name: "space in mac", mac: "02:00:00:00:00:01 key=00"},
+		{name: "comma in channel", channels: []string{"81/1,81/6"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, genErr := wpaSupplicant.DPPBootstrapGen(test.channels, test.mac, test.info, nil); genErr != ErrWPAArgument {
+				t.Fatalf("got %v, want ErrWPAArgument", genErr)
+			}
+		})
+	}
+	if received := ctrl.received(); len(received) != 0 {
+		t.Errorf("sent %q", received)
+	}
+}
+
+func TestDPPAuthInit(t *testing.T) {
+	wpaSupplicant, ctrl := newTestWPASupplicant(t, map[string]string{
+		"DPP_QR_CODE DPP:K:MDkw;;": "1\n",
+		"DPP_CONFIGURATOR_ADD":     "1\n",
+		"DPP_AUTH_INIT peer=1 role=configurator configurator=1 conf=sta-psk ssid=686f6d65 pass=636f727265637420686f727365": "OK\n",
+	})
+	peer, qrErr := wpaSupplicant.DPPQRCode("DPP:K:MDkw;;")
+	if qrErr != nil {
+		t.Fatal(qrErr)
+	}
+	configurator, addErr := wpaSupplicant.DPPConfiguratorAdd()
+	if addErr != nil {
+		t.Fatal(addErr)
+	}
+	authErr := wpaSupplicant.DPPAuthInit(DPPAuth{
+		Peer:         peer,
+		Role:         "configurator",
+		Configurator: configurator,
+		Conf:         "sta-psk",
+		SSID:         "home",
+		Passphrase:   "correct horse",
+	})
+	if authErr != nil {
+		t.Fatalf("DPPAuthInit: %v, sent %q", authErr, ctrl.received())
+	}
+	if _, qrErr := wpaSupplicant.DPPQRCode("DPP:K:MDkw;; DPP_STOP_LISTEN"); qrErr != ErrWPAArgument {
+		t.Errorf("DPPQRCode with a space got %v, want ErrWPAArgument", qrErr)
+	}
+	if listenErr := wpaSupplicant.DPPListen(2412, "enrollee\n"); listenErr != ErrWPAArgument {
+		t.Errorf("DPPListen with a newline got %v, want ErrWPAArgument", listenErr)
+	}
+}
+
+func TestDPPAuthInitPSK(t *testing.T) {
+	psk := "f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e"
+	wpaSupplicant, ctrl := newTestWPASupplicant(t, map[string]string{
+		"DPP_AUTH_INIT peer=1 role=configurator conf=sta-psk ssid=49454545 psk=" + psk: "OK\n",
+	})
+	authErr := wpaSupplicant.DPPAuthInit(DPPAuth{Peer: 1, Role: "configurator", Conf: "sta-psk", SSID: "IEEE", PSK: psk})
+	if authErr != nil {
+		t.Fatalf("DPPAuthInit: %v, sent %q", authErr, ctrl.received())
+	}
+	if authErr := wpaSupplicant.DPPAuthInit(DPPAuth{Peer: 1, Role: "configurator", Conf: "sta-psk", PSK: psk + " pass=00"}); authErr != ErrWPAArgument {
+		t.Errorf("DPPAuthInit with a space in the PSK got %v, want ErrWPAArgument", authErr)
+	}
+}
+
+func TestDPPListen(t *testing.T) {
+	wpaSupplicant, _ := newTestWPASupplicant(t, map[string]string{
+		"DPP_LISTEN 2437 role=enrollee": "OK\n",
+		"DPP_STOP_LISTEN":               "OK\n",
+	})
+	if listenErr := wpaSupplicant.DPPListen(2437, "enrollee"); listenErr != nil {
+		t.Fatal(listenErr)
+	}
+	if stopErr := wpaSupplicant.DPPStopListen(); stopErr != nil {
+		t.Fatal(stopErr)
+	}
+	if listenErr := wpaSupplicant.DPPListen(2412, ""); listenErr != ErrCtrlFail {
+		t.Fatalf("unexpected request got %v, want ErrCtrlFail", listenErr)
+	}
+}
+
+func TestWPASupplicantStatus(t *testing.T) {
+	wpaSupplicant, _ := newTestWPASupplicant(t, map[string]string{
+		"STATUS": "bssid=02:00:00:00:00:01\nfreq=2412\nssid=home\nwpa_state=COMPLETED\n",
+	})
+	status, statusErr := wpaSupplicant.Status()
+	if statusErr != nil {
+		t.Fatal(statusErr)
+	}
+	want := map[string]string{"bssid": "02:00:00:00:00:01", "freq": "2412", "ssid": "home", "wpa_state": "COMPLETED"}
+	if !reflect.DeepEqual(status, want) {
+		t.Fatalf("status = %v, want %v", status, want)
+	}
+}
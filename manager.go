@@ -0,0 +1,295 @@
+package wifimanager
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// EventScan is published when an interface finished scanning
+	EventScan int = iota
+	// EventConnect is published when an interface joined a network
+	EventConnect
+	// EventDisconnect is published when an interface left its network
+	EventDisconnect
+	// EventPower is published when an interface was turned on or off
+	EventPower
+	// EventError is published when an operation on an interface failed
+	EventError
+	// EventInterfaceAdded is published when a watching manager found a
+	// new interface
+	EventInterfaceAdded
+	// EventInterfaceRemoved is published when an interface a watching
+	// manager knew went away
+	EventInterfaceRemoved
+
+	// eventBuffer is the number of events a subscriber can fall behind
+	// by before further events are dropped for it
+	eventBuffer = 32
+)
+
+var (
+	// ErrUnknownIface is returned when the manager is asked about an
+	// interface its backend doesn't have
+	ErrUnknownIface = errors.New("wifi: no interface found with provided name")
+
+	// eventNames are the names of the event types used by EventName
+	eventNames = map[int]string{
+		EventScan:       "scan",
+		EventConnect:    "connect",
+		EventDisconnect: "disconnect",
+		EventPower:      "power",
+		EventError:      "error",
+
+		EventInterfaceAdded:   "added",
+		EventInterfaceRemoved: "removed",
+	}
+)
+
+// Manager drives the interfaces of a backend by name on behalf of
+// long running services, remembering the network each interface was
+// connected to and publishing every change as an Event. Once watching,
+// it keeps a registry of the interfaces of the backend as they come
+// and go.
+type Manager struct {
+	Profiles    *ProfileStore
+	backend     Backend
+	mutex       sync.Mutex
+	connections map[string]WifiNetwork
+	subscribers map[chan Event]bool
+	// registry holds the interfaces of the backend by name while the
+	// manager is watching, and is nil otherwise
+	registry map[string]WifiInterface
+}
+
+// Event describes a change made through a Manager. Networks never
+// carry their security key.
+type Event struct {
+	Type      int
+	Interface string
+	Time      time.Time
+	Network   *WifiNetwork
+	Networks  []WifiNetwork
+	Powered   bool
+	Err       error
+}
+
+// NewManager creates a manager for the backend. Profiles may be nil,
+// in which case Connect never looks up saved keys.
+func NewManager(backend Backend, profiles *ProfileStore) *Manager {
+	return &Manager{
+		Profiles:    profiles,
+		backend:     backend,
+		connections: map[string]WifiNetwork{},
+		subscribers: map[chan Event]bool{},
+	}
+}
+
+// EventName returns the name of the event type
+func EventName(eventType int) string {
+	return eventNames[eventType]
+}
+
+// Backend returns the backend the manager drives
+func (manager *Manager) Backend() Backend {
+	return manager.backend
+}
+
+// Interfaces returns the interfaces of the backend, with the network
+// each one was last connected to through the manager. A watching
+// manager answers from its registry.
+func (manager *Manager) Interfaces() ([]WifiInterface, error) {
+	wifiInterfaces, watching := manager.registered()
+	if !watching {
+		var ifaceErr error
+		wifiInterfaces, ifaceErr = manager.backend.Interfaces()
+		if ifaceErr != nil {
+			return nil, ifaceErr
+		}
+	}
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for index := range wifiInterfaces {
+		wifiInterfaces[index].Connection = manager.connections[wifiInterfaces[index].Name]
+	}
+	return wifiInterfaces, nil
+}
+
+// Interface returns the interface with the provided name
+func (manager *Manager) Interface(name string) (*WifiInterface, error) {
+	wifiInterfaces, ifaceErr := manager.Interfaces()
+	if ifaceErr != nil {
+		return nil, ifaceErr
+	}
+	for index := range wifiInterfaces {
+		if wifiInterfaces[index].Name == name {
+			return &wifiInterfaces[index], nil
+		}
+	}
+	return nil, ErrUnknownIface
+}
+
+// Scan returns the networks reachable from the interface
+func (manager *Manager) Scan(name string) ([]WifiNetwork, error) {
+	wifiInterface, ifaceErr := manager.Interface(name)
+	if ifaceErr != nil {
+		return nil, ifaceErr
+	}
+	networks, scanErr := wifiInterface.Scan()
+	if scanErr != nil {
+		manager.publish(Event{Type: EventError, Interface: name, Err: scanErr})
+		return nil, scanErr
+	}
+	manager.publish(Event{Type: EventScan, Interface: name, Networks: networks})
+	return networks, nil
+}
+
+// ScanAll scans on every interface at once and returns the networks
+// found merged by BSSID like the ScanAll function does, publishing the
+// scan of each interface
+func (manager *Manager) ScanAll() ([]ScannedNetwork, error) {
+	wifiInterfaces, ifaceErr := manager.Interfaces()
+	if ifaceErr != nil {
+		return nil, ifaceErr
+	}
+	if len(wifiInterfaces) < 1 {
+		return nil, ErrMissingIface
+	}
+	results, scanErrors := scanEach(wifiInterfaces)
+	for _, wifiInterface := range wifiInterfaces {
+		if scanErr, failed := scanErrors[wifiInterface.Name]; failed {
+			manager.publish(Event{Type: EventError, Interface: wifiInterface.Name, Err: scanErr})
+			continue
+		}
+		manager.publish(Event{Type: EventScan, Interface: wifiInterface.Name, Networks: results[wifiInterface.Name]})
+	}
+	return mergeScans(wifiInterfaces, results, scanErrors)
+}
+
+// Connect joins the interface to the network. A network without a
+// security key uses the key of the profile saved for its SSID, if any.
+func (manager *Manager) Connect(name string, network WifiNetwork) error {
+	wifiInterface, ifaceErr := manager.Interface(name)
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	if network.SecurityKey == "" && manager.Profiles != nil {
+		profile, profileErr := manager.Profiles.Get(network.SSID)
+		if profileErr == nil {
+			network.SecurityKey = profile.SecurityKey
+			if len(network.Security) == 0 {
+				network.Security = profile.Network().Security
+			}
+			network.Hidden = network.Hidden || profile.Hidden
+		}
+	}
+	wifiInterface.UpdateNetwork(network)
+	connectErr := wifiInterface.Connect()
+	if connectErr != nil {
+		manager.publish(Event{Type: EventError, Interface: name, Err: connectErr})
+		return connectErr
+	}
+	network.SecurityKey = ""
+	manager.mutex.Lock()
+	manager.connections[name] = network
+	manager.mutex.Unlock()
+	manager.publish(Event{Type: EventConnect, Interface: name, Network: &network})
+	return nil
+}
+
+// Disconnect leaves the network the interface is connected to
+func (manager *Manager) Disconnect(name string) error {
+	wifiInterface, ifaceErr := manager.Interface(name)
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	disconnectErr := wifiInterface.Disconnect()
+	if disconnectErr != nil {
+		manager.publish(Event{Type: EventError, Interface: name, Err: disconnectErr})
+		return disconnectErr
+	}
+	manager.mutex.Lock()
+	delete(manager.connections, name)
+	manager.mutex.Unlock()
+	manager.publish(Event{Type: EventDisconnect, Interface: name})
+	return nil
+}
+
+// SetPower turns the interface on or off
+func (manager *Manager) SetPower(name string, powered bool) error {
+	wifiInterface, ifaceErr := manager.Interface(name)
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	var powerErr error
+	if powered {
+		powerErr = wifiInterface.Up()
+	} else {
+		powerErr = wifiInterface.Down()
+	}
+	if powerErr != nil {
+		manager.publish(Event{Type: EventError, Interface: name, Err: powerErr})
+		return powerErr
+	}
+	if !powered {
+		manager.mutex.Lock()
+		delete(manager.connections, name)
+		manager.mutex.Unlock()
+	}
+	manager.publish(Event{Type: EventPower, Interface: name, Powered: powered})
+	return nil
+}
+
+// Status returns the power state of the interface
+func (manager *Manager) Status(name string) (bool, error) {
+	wifiInterface, ifaceErr := manager.Interface(name)
+	if ifaceErr != nil {
+		return false, ifaceErr
+	}
+	return wifiInterface.Status()
+}
+
+// State returns the connection state of the interface
+func (manager *Manager) State(name string) (InterfaceState, error) {
+	wifiInterface, ifaceErr := manager.Interface(name)
+	if ifaceErr != nil {
+		return InterfaceState{}, ifaceErr
+	}
+	return wifiInterface.State()
+}
+
+// Subscribe returns a channel receiving every event published after
+// the call, and a function that stops the subscription and closes the
+// channel. Events are dropped for subscribers that fall behind.
+func (manager *Manager) Subscribe() (<-chan Event, func()) {
+	events := make(chan Event, eventBuffer)
+	manager.mutex.Lock()
+	manager.subscribers[events] = true
+	manager.mutex.Unlock()
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			manager.mutex.Lock()
+			delete(manager.subscribers, events)
+			manager.mutex.Unlock()
+			close(events)
+		})
+	}
+}
+
+// publish sends the event to every subscriber
+func (manager *Manager) publish(event Event) {
+	event.Time = time.Now()
+	if event.Err != nil {
+		event.Err = RedactError(event.Err)
+	}
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for subscriber := range manager.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
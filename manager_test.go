@@ -0,0 +1,91 @@
+package wifimanager
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// recordingBackend records the calls made to it
+type recordingBackend struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (backend *recordingBackend) record(call string) {
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+	backend.calls = append(backend.calls, call)
+}
+
+func (backend *recordingBackend) recorded() []string {
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+	return append([]string{}, backend.calls...)
+}
+
+func (backend *recordingBackend) Name() string      { return "recording" }
+func (backend *recordingBackend) IsInstalled() bool { return true }
+
+func (backend *recordingBackend) Interfaces() ([]WifiInterface, error) {
+	return []WifiInterface{{Interface: net.Interface{Index: 3, Name: "wlan0"}, backend: backend}}, nil
+}
+
+func (backend *recordingBackend) Scan(iface string) ([]WifiNetwork, error) {
+	backend.record("scan " + iface)
+	return nil, nil
+}
+
+func (backend *recordingBackend) Connect(iface string, network WifiNetwork) error {
+	backend.record("connect " + iface + " " + network.SSID)
+	return nil
+}
+
+func (backend *recordingBackend) Disconnect(iface string) error {
+	backend.record("disconnect " + iface)
+	return nil
+}
+
+func (backend *recordingBackend) Up(iface string) error {
+	backend.record("up " + iface)
+	return nil
+}
+
+func (backend *recordingBackend) Down(iface string) error {
+	backend.record("down " + iface)
+	return nil
+}
+
+func (backend *recordingBackend) Status(iface string) (bool, error) {
+	return true, nil
+}
+
+func TestManagerSetPower(t *testing.T) {
+	tests := []struct {
+		powered bool
+		calls   []string
+	}{
+		{powered: true, calls: []string{"up wlan0"}},
+		{powered: false, calls: []string{"down wlan0"}},
+	}
+	for _, test := range tests {
+		backend := &recordingBackend{}
+		manager := NewManager(backend, nil)
+		events, unsubscribe := manager.Subscribe()
+		if powerErr := manager.SetPower("wlan0", test.powered); powerErr != nil {
+			t.Fatal(powerErr)
+		}
+		unsubscribe()
+		if calls := backend.recorded(); !reflect.DeepEqual(calls, test.calls) {
+			t.Errorf("SetPower(wlan0, %v) called %q, want %q", test.powered, calls, test.calls)
+		}
+		event := <-events
+		if event.Type != EventPower || event.Interface != "wlan0" || event.Powered != test.powered {
+			t.Errorf("SetPower(wlan0, %v) published %+v", test.powered, event)
+		}
+	}
+	if powerErr := NewManager(&recordingBackend{}, nil).SetPower("wlan9", true); powerErr != ErrUnknownIface {
+		t.Errorf("unknown interface error = %v, want ErrUnknownIface", powerErr)
+	}
+}
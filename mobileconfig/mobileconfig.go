@@ -0,0 +1,326 @@
+package mobileconfig
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/DHowett/go-plist"
+	"github.com/ottopress/WifiManager"
+)
+
+const (
+	// WiFiPayloadType is the payload type of WiFi network settings
+	WiFiPayloadType = "com.apple.wifi.managed"
+	// RootCertPayloadType is the payload type of a trusted CA certificate
+	RootCertPayloadType = "com.apple.security.root"
+	// ConfigurationType is the payload type of the profile itself
+	ConfigurationType = "Configuration"
+	// DefaultIdentifier is the reverse DNS prefix used for payload
+	// identifiers when the options don't provide one
+	DefaultIdentifier = "com.ottopress.wifimanager"
+	// Extension is the file extension macOS and iOS install profiles from
+	Extension = ".mobileconfig"
+)
+
+var (
+	// ErrNotProfile is returned when parsing a plist that isn't a
+	// configuration profile
+	ErrNotProfile = errors.New("mobileconfig: not a configuration profile")
+
+	// encryptionTypes maps security protocols to the EncryptionType
+	// values of the WiFi payload
+	encryptionTypes = map[int]string{
+		wifimanager.SecurityNone: "None",
+		wifimanager.SecurityWEP:  "WEP",
+		wifimanager.SecurityWPA:  "WPA",
+		wifimanager.SecurityWPA2: "WPA2",
+		wifimanager.SecurityWPA3: "WPA3",
+	}
+	// eapTypes maps EAP method names to their IANA assigned numbers,
+	// which AcceptEAPTypes lists
+	eapTypes = map[string]int{
+		"TLS":  13,
+		"LEAP": 17,
+		"SIM":  18,
+		"TTLS": 21,
+		"AKA":  23,
+		"PEAP": 25,
+		"FAST": 43,
+	}
+	// innerAuthentications holds the spelling Apple uses for the TTLS
+	// inner authentication methods
+	innerAuthentications = []string{"PAP", "CHAP", "MSCHAP", "MSCHAPv2", "EAP"}
+)
+
+// Options holds the profile level settings of a generated profile
+type Options struct {
+	Identifier   string
+	DisplayName  string
+	Description  string
+	Organization string
+}
+
+// Config represents a configuration profile. Payloads other than WiFi
+// networks and CA certificates are kept but only their common keys
+// survive a round trip.
+type Config struct {
+	PayloadContent      []Payload
+	PayloadType         string
+	PayloadVersion      int
+	PayloadIdentifier   string
+	PayloadUUID         string
+	PayloadDisplayName  string
+	PayloadDescription  string `plist:",omitempty"`
+	PayloadOrganization string `plist:",omitempty"`
+}
+
+// Payload represents an entry of the profile's PayloadContent, holding
+// the keys of both the WiFi and the certificate payloads. AutoJoin
+// defaults to true when it is left out.
+type Payload struct {
+	PayloadType                        string
+	PayloadVersion                     int
+	PayloadIdentifier                  string
+	PayloadUUID                        string
+	PayloadDisplayName                 string                  `plist:",omitempty"`
+	PayloadContent                     interface{}             `plist:",omitempty"`
+	PayloadCertificateFileName         string                  `plist:",omitempty"`
+	SSID                               string                  `plist:"SSID_STR,omitempty"`
+	HiddenNetwork                      bool                    `plist:"HIDDEN_NETWORK,omitempty"`
+	AutoJoin                           *bool                   `plist:",omitempty"`
+	EncryptionType                     string                  `plist:",omitempty"`
+	Password                           string                  `plist:",omitempty"`
+	DisableAssociationMACRandomization bool                    `plist:",omitempty"`
+	EAPClientConfiguration             *EAPClientConfiguration `plist:",omitempty"`
+}
+
+// EAPClientConfiguration represents the enterprise settings of a WiFi
+// payload
+type EAPClientConfiguration struct {
+	AcceptEAPTypes               []int    `plist:",omitempty"`
+	UserName                     string   `plist:",omitempty"`
+	UserPassword                 string   `plist:",omitempty"`
+	OuterIdentity                string   `plist:",omitempty"`
+	TTLSInnerAuthentication      string   `plist:",omitempty"`
+	TLSTrustedServerNames        []string `plist:",omitempty"`
+	PayloadCertificateAnchorUUID []string `plist:",omitempty"`
+}
+
+// NewConfig creates a configuration profile with a WiFi payload for
+// every profile. CA certificates referenced by EAP settings are read
+// and embedded; client certificates aren't, as macOS expects them as
+// a PKCS#12 identity that has to be installed separately.
+func NewConfig(options Options, profiles ...wifimanager.Profile) (*Config, error) {
+	if options.Identifier == "" {
+		options.Identifier = DefaultIdentifier
+	}
+	uuid, uuidErr := newUUID()
+	if uuidErr != nil {
+		return nil, uuidErr
+	}
+	config := &Config{
+		PayloadContent:      []Payload{},
+		PayloadType:         ConfigurationType,
+		PayloadVersion:      1,
+		PayloadIdentifier:   options.Identifier + "." + uuid,
+		PayloadUUID:         uuid,
+		PayloadDisplayName:  options.DisplayName,
+		PayloadDescription:  options.Description,
+		PayloadOrganization: options.Organization,
+	}
+	if config.PayloadDisplayName == "" {
+		config.PayloadDisplayName = "Wi-Fi"
+	}
+	for _, profile := range profiles {
+		payload, payloadErr := newPayload(options.Identifier, profile)
+		if payloadErr != nil {
+			return nil, payloadErr
+		}
+		if profile.EAP != nil && profile.EAP.CACert != "" {
+			certPayload, certErr := newCertPayload(options.Identifier, profile.EAP.CACert)
+			if certErr != nil {
+				return nil, certErr
+			}
+			payload.EAPClientConfiguration.PayloadCertificateAnchorUUID = []string{certPayload.PayloadUUID}
+			config.PayloadContent = append(config.PayloadContent, certPayload)
+		}
+		config.PayloadContent = append(config.PayloadContent, payload)
+	}
+	return config, nil
+}
+
+// Parse parses a configuration profile in XML or binary plist form
+func Parse(data []byte) (*Config, error) {
+	config := &Config{}
+	_, parseErr := plist.Unmarshal(data, config)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if config.PayloadType != ConfigurationType {
+		return nil, ErrNotProfile
+	}
+	return config, nil
+}
+
+// Marshal returns the XML plist representation of the profile
+func (config *Config) Marshal() ([]byte, error) {
+	return plist.MarshalIndent(config, plist.XMLFormat, "\t")
+}
+
+// Profiles converts the WiFi payloads into profiles. Embedded CA
+// certificates have no path to refer to, so CACert is left empty.
+func (config *Config) Profiles() ([]wifimanager.Profile, error) {
+	profiles := []wifimanager.Profile{}
+	for _, payload := range config.PayloadContent {
+		if payload.PayloadType != WiFiPayloadType {
+			continue
+		}
+		profile, profileErr := payload.Profile()
+		if profileErr != nil {
+			return profiles, profileErr
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// Profile converts a WiFi payload into a profile. Apple uses "WPA" for
+// WPA/WPA2 personal networks, which is read as WPA2.
+func (payload Payload) Profile() (wifimanager.Profile, error) {
+	profile := wifimanager.Profile{
+		SSID:        payload.SSID,
+		SecurityKey: payload.Password,
+		Hidden:      payload.HiddenNetwork,
+		AutoJoin:    payload.AutoJoin == nil || *payload.AutoJoin,
+	}
+	if payload.DisableAssociationMACRandomization {
+		profile.MACRandomization = wifimanager.MACRandomNever
+	}
+	switch payload.EncryptionType {
+	case "None", "":
+		profile.Security = wifimanager.SecurityNone
+	case "WEP":
+		profile.Security = wifimanager.SecurityWEP
+	case "WPA", "WPA2":
+		profile.Security = wifimanager.SecurityWPA2
+	case "WPA3":
+		profile.Security = wifimanager.SecurityWPA3
+	case "Any":
+		profile.Security = wifimanager.SecurityWPA2
+		if payload.Password == "" && payload.EAPClientConfiguration == nil {
+			profile.Security = wifimanager.SecurityNone
+		}
+	default:
+		return profile, fmt.Errorf("mobileconfig: unsupported encryption type %q", payload.EncryptionType)
+	}
+	if eap := payload.EAPClientConfiguration; eap != nil {
+		profile.SecurityKey = ""
+		profile.EAP = &wifimanager.EAPConfig{
+			Identity:          eap.UserName,
+			Password:          eap.UserPassword,
+			AnonymousIdentity: eap.OuterIdentity,
+			Phase2:            strings.ToUpper(eap.TTLSInnerAuthentication),
+		}
+		for name, number := range eapTypes {
+			if len(eap.AcceptEAPTypes) > 0 && eap.AcceptEAPTypes[0] == number {
+				profile.EAP.Method = name
+			}
+		}
+		if len(eap.TLSTrustedServerNames) > 0 {
+			profile.EAP.ServerDomain = eap.TLSTrustedServerNames[0]
+		}
+	}
+	return profile, nil
+}
+
+// newPayload creates the WiFi payload of a profile
+func newPayload(identifier string, profile wifimanager.Profile) (Payload, error) {
+	uuid, uuidErr := newUUID()
+	if uuidErr != nil {
+		return Payload{}, uuidErr
+	}
+	encryptionType, known := encryptionTypes[profile.Security]
+	if !known {
+		return Payload{}, errors.New("mobileconfig: unsupported security protocol")
+	}
+	autoJoin := profile.AutoJoin
+	payload := Payload{
+		PayloadType:                        WiFiPayloadType,
+		PayloadVersion:                     1,
+		PayloadIdentifier:                  identifier + ".wifi." + uuid,
+		PayloadUUID:                        uuid,
+		PayloadDisplayName:                 "Wi-Fi (" + profile.SSID + ")",
+		SSID:                               profile.SSID,
+		HiddenNetwork:                      profile.Hidden,
+		AutoJoin:                           &autoJoin,
+		EncryptionType:                     encryptionType,
+		DisableAssociationMACRandomization: profile.MACRandomization == wifimanager.MACRandomNever,
+	}
+	if profile.EAP == nil {
+		if profile.Security != wifimanager.SecurityNone {
+			payload.Password = profile.SecurityKey
+		}
+		return payload, nil
+	}
+	eapType, known := eapTypes[strings.ToUpper(profile.EAP.Method)]
+	if !known {
+		return Payload{}, fmt.Errorf("mobileconfig: unsupported eap method %q", profile.EAP.Method)
+	}
+	if payload.EncryptionType == "None" {
+		payload.EncryptionType = "WPA2"
+	}
+	eap := &EAPClientConfiguration{
+		AcceptEAPTypes: []int{eapType},
+		UserName:       profile.EAP.Identity,
+		UserPassword:   profile.EAP.Password,
+		OuterIdentity:  profile.EAP.AnonymousIdentity,
+	}
+	if eapType == eapTypes["TTLS"] {
+		for _, method := range innerAuthentications {
+			if strings.EqualFold(method, profile.EAP.Phase2) {
+				eap.TTLSInnerAuthentication = method
+			}
+		}
+	}
+	if profile.EAP.ServerDomain != "" {
+		eap.TLSTrustedServerNames = []string{profile.EAP.ServerDomain}
+	}
+	payload.EAPClientConfiguration = eap
+	return payload, nil
+}
+
+// newCertPayload creates a trusted certificate payload from a PEM or
+// DER encoded certificate file
+func newCertPayload(identifier, path string) (Payload, error) {
+	data, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return Payload{}, readErr
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	uuid, uuidErr := newUUID()
+	if uuidErr != nil {
+		return Payload{}, uuidErr
+	}
+	return Payload{
+		PayloadType:                RootCertPayloadType,
+		PayloadVersion:             1,
+		PayloadIdentifier:          identifier + ".cert." + uuid,
+		PayloadUUID:                uuid,
+		PayloadDisplayName:         filepath.Base(path),
+		PayloadContent:             data,
+		PayloadCertificateFileName: filepath.Base(path),
+	}, nil
+}
+
+// newUUID generates a random version 4 UUID in the upper case form
+// profiles use
+func newUUID() (string, error) {
+	uuid, uuidErr := wifimanager.NewUUID()
+	return strings.ToUpper(uuid), uuidErr
+}
@@ -0,0 +1,143 @@
+package mobileconfig
+
+import (
+	"bytes"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ottopress/WifiManager"
+)
+
+// roundTrip generates a profile, marshals it and parses it back
+func roundTrip(t *testing.T, profiles ...wifimanager.Profile) (*Config, []wifimanager.Profile) {
+	t.Helper()
+	config, newErr := NewConfig(Options{DisplayName: "Office"}, profiles...)
+	if newErr != nil {
+		t.Fatal(newErr)
+	}
+	data, marshalErr := config.Marshal()
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	parsed, parseErr := Parse(data)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	parsedProfiles, profilesErr := parsed.Profiles()
+	if profilesErr != nil {
+		t.Fatal(profilesErr)
+	}
+	return parsed, parsedProfiles
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile wifimanager.Profile
+	}{
+		{
+			name:    "psk",
+			profile: wifimanager.Profile{SSID: "home", Security: wifimanager.SecurityWPA2, SecurityKey: "correct horse", AutoJoin: true},
+		},
+		{
+			name:    "open",
+			profile: wifimanager.Profile{SSID: "cafe", Security: wifimanager.SecurityNone, AutoJoin: true},
+		},
+		{
+			name:    "wep",
+			profile: wifimanager.Profile{SSID: "legacy", Security: wifimanager.SecurityWEP, SecurityKey: "0123456789", AutoJoin: true},
+		},
+		{
+			name:    "hidden",
+			profile: wifimanager.Profile{SSID: "attic", Security: wifimanager.SecurityWPA3, SecurityKey: "correct horse", Hidden: true, AutoJoin: true},
+		},
+		{
+			name:    "no auto join",
+			profile: wifimanager.Profile{SSID: "guest", Security: wifimanager.SecurityWPA2, SecurityKey: "correct horse"},
+		},
+		{
+			name:    "mac randomization",
+			profile: wifimanager.Profile{SSID: "home", Security: wifimanager.SecurityWPA2, SecurityKey: "correct horse", AutoJoin: true, MACRandomization: wifimanager.MACRandomNever},
+		},
+		{
+			name: "eap ttls",
+			profile: wifimanager.Profile{SSID: "corp", Security: wifimanager.SecurityWPA2, AutoJoin: true, EAP: &wifimanager.EAPConfig{
+				Method:            "TTLS",
+				Identity:          "jdoe",
+				AnonymousIdentity: "anonymous",
+				Password:          "hunter2",
+				Phase2:            "MSCHAPV2",
+				ServerDomain:      "radius.example.com",
+			}},
+		},
+	}
+	for _, test := range tests {
+		_, profiles := roundTrip(t, test.profile)
+		if len(profiles) != 1 || !reflect.DeepEqual(profiles[0], test.profile) {
+			t.Errorf("%s: profiles = %+v, want %+v", test.name, profiles, test.profile)
+		}
+	}
+}
+
+func TestRoundTripCACert(t *testing.T) {
+	der := []byte{0x30, 0x82, 0x01, 0x0a, 0x02, 0x01, 0x01}
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if writeErr := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	profile := wifimanager.Profile{SSID: "corp", Security: wifimanager.SecurityWPA2, AutoJoin: true, EAP: &wifimanager.EAPConfig{Method: "PEAP", Identity: "jdoe", CACert: path}}
+	config, profiles := roundTrip(t, profile)
+	if len(config.PayloadContent) != 2 {
+		t.Fatalf("%d payloads, want the certificate and the network", len(config.PayloadContent))
+	}
+	cert, wifi := config.PayloadContent[0], config.PayloadContent[1]
+	if cert.PayloadType != RootCertPayloadType || cert.PayloadCertificateFileName != "ca.pem" {
+		t.Errorf("certificate payload = %+v", cert)
+	}
+	if content, _ := cert.PayloadContent.([]byte); !bytes.Equal(content, der) {
+		t.Errorf("certificate content = %x, want the DER bytes %x", cert.PayloadContent, der)
+	}
+	anchors := wifi.EAPClientConfiguration.PayloadCertificateAnchorUUID
+	if len(anchors) != 1 || anchors[0] != cert.PayloadUUID {
+		t.Errorf("anchors = %v, want %s", anchors, cert.PayloadUUID)
+	}
+	// the certificate is embedded, there is no path to refer to
+	profile.EAP.CACert = ""
+	if len(profiles) != 1 || !reflect.DeepEqual(profiles[0], profile) {
+		t.Errorf("profiles = %+v, want %+v", profiles, profile)
+	}
+}
+
+func TestPayloadEncryptionTypes(t *testing.T) {
+	tests := []struct {
+		payload  Payload
+		security int
+	}{
+		{payload: Payload{EncryptionType: "WPA", Password: "correct horse"}, security: wifimanager.SecurityWPA2},
+		{payload: Payload{EncryptionType: "Any", Password: "correct horse"}, security: wifimanager.SecurityWPA2},
+		{payload: Payload{EncryptionType: "Any", EAPClientConfiguration: &EAPClientConfiguration{AcceptEAPTypes: []int{25}}}, security: wifimanager.SecurityWPA2},
+		{payload: Payload{EncryptionType: "Any"}, security: wifimanager.SecurityNone},
+		{payload: Payload{}, security: wifimanager.SecurityNone},
+	}
+	for _, test := range tests {
+		profile, profileErr := test.payload.Profile()
+		if profileErr != nil || profile.Security != test.security {
+			t.Errorf("%+v: security = %d, %v, want %d", test.payload, profile.Security, profileErr, test.security)
+		}
+	}
+	if _, profileErr := (Payload{EncryptionType: "WPA4"}).Profile(); profileErr == nil {
+		t.Error("unknown encryption type parsed")
+	}
+}
+
+func TestParseNotProfile(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0"><dict><key>PayloadType</key><string>com.apple.wifi.managed</string></dict></plist>`)
+	if _, parseErr := Parse(data); parseErr != ErrNotProfile {
+		t.Fatalf("Parse error = %v, want ErrNotProfile", parseErr)
+	}
+}
@@ -0,0 +1,164 @@
+package wifimanager
+
+import (
+	"net"
+	"os"
+
+	"github.com/ottopress/WifiManager/linux"
+)
+
+// NL80211Backend drives WiFi interfaces by talking nl80211 and
+// rtnetlink to the kernel directly, for systems without a supplicant
+// or the iw and ip commands. Like the iw backend it can only join open
+// and WEP networks and needs root.
+type NL80211Backend struct {
+	nl80211   *linux.NL80211
+	rtnetlink *linux.RTNetlink
+}
+
+// NewNL80211Backend creates a new instance of the nl80211 backend
+func NewNL80211Backend() *NL80211Backend {
+	return &NL80211Backend{nl80211: linux.NewNL80211(), rtnetlink: linux.NewRTNetlink()}
+}
+
+// Name returns the name of the backend
+func (nlBackend *NL80211Backend) Name() string {
+	return "nl80211"
+}
+
+// IsInstalled returns whether or not the kernel provides nl80211
+func (nlBackend *NL80211Backend) IsInstalled() bool {
+	return nlBackend.nl80211.IsAvailable()
+}
+
+// Interfaces returns all wireless interfaces in sysfs
+func (nlBackend *NL80211Backend) Interfaces() ([]WifiInterface, error) {
+	wifiInterfaces := []WifiInterface{}
+	wirelessInterfaces, sysfsErr := sysfs.WirelessInterfaces()
+	if sysfsErr != nil {
+		return wifiInterfaces, sysfsErr
+	}
+	for _, wirelessInterface := range wirelessInterfaces {
+		iface, ifaceErr := net.InterfaceByName(wirelessInterface.Name)
+		if ifaceErr != nil {
+			continue
+		}
+		wifiInterfaces = append(wifiInterfaces, newSysfsInterface(*iface, nlBackend))
+	}
+	return wifiInterfaces, nil
+}
+
+// Scan triggers a scan and returns the networks the kernel found
+func (nlBackend *NL80211Backend) Scan(iface string) ([]WifiNetwork, error) {
+	results, scanErr := nlBackend.nl80211.Scan(iface)
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	wifiNetworks := []WifiNetwork{}
+	for _, result := range results {
+		elements := result.Elements
+		wifiNetworks = append(wifiNetworks, WifiNetwork{
+			SSID:     elements.SSID,
+			BSSID:    result.BSSID,
+			RSSI:     result.Signal,
+			HT:       elements.HT,
+			Channel:  elements.Channel,
+			Security: bssSecurity(result.Privacy, elements.RSN, elements.WPA, elements.GroupCipher, elements.PairwiseCiphers, elements.AuthSuites),
+		})
+	}
+	return wifiNetworks, nil
+}
+
+// Connect joins the interface to an open or WEP network. The kernel
+// probes for the SSID it is given, so hidden networks need nothing
+// more.
+func (nlBackend *NL80211Backend) Connect(iface string, network WifiNetwork) error {
+	RegisterSecret(network.SecurityKey)
+	defer ForgetSecret(network.SecurityKey)
+	protocol := SecurityNone
+	if len(network.Security) > 0 {
+		protocol = network.Security[0].Protocol
+	}
+	switch protocol {
+	case SecurityNone:
+		return nlBackend.nl80211.Connect(iface, network.SSID, "")
+	case SecurityWEP:
+		return RedactError(nlBackend.nl80211.Connect(iface, network.SSID, network.SecurityKey))
+	}
+	return ErrUnsupportedSecurity
+}
+
+// Disconnect disconnects from the current network without shutting
+// down the interface
+func (nlBackend *NL80211Backend) Disconnect(iface string) error {
+	return nlBackend.nl80211.Disconnect(iface)
+}
+
+// Up turns on the interface
+func (nlBackend *NL80211Backend) Up(iface string) error {
+	if unblockErr := unblockRFKill(iface); unblockErr != nil {
+		return unblockErr
+	}
+	return nlBackend.rtnetlink.Up(iface)
+}
+
+// Down turns off the interface
+func (nlBackend *NL80211Backend) Down(iface string) error {
+	return nlBackend.rtnetlink.Down(iface)
+}
+
+// Status returns the power state of the interface
+func (nlBackend *NL80211Backend) Status(iface string) (bool, error) {
+	return nlBackend.rtnetlink.Status(iface)
+}
+
+// State returns whether the interface is off, connected or not
+func (nlBackend *NL80211Backend) State(iface string) (int, error) {
+	powered, statusErr := nlBackend.rtnetlink.Status(iface)
+	if statusErr != nil || !powered {
+		return StateOff, statusErr
+	}
+	_, linkErr := nlBackend.nl80211.Link(iface)
+	if linkErr == linux.ErrNL80211NoLink {
+		return StateDisconnected, nil
+	}
+	if linkErr != nil {
+		return StateOff, linkErr
+	}
+	return StateConnected, nil
+}
+
+// Subscribe delivers the power, association, address and hotplug
+// events the kernel reports for the interface
+func (nlBackend *NL80211Backend) Subscribe(iface string) (<-chan InterfaceEvent, func(), error) {
+	stream, streamErr := netlinkEvents(iface, true)
+	if streamErr != nil {
+		return nil, nil, streamErr
+	}
+	return stream.subscription()
+}
+
+// Diagnose reports on the nl80211 netlink family. Scanning and
+// connecting need CAP_NET_ADMIN and connecting is limited to open and
+// WEP networks.
+func (nlBackend *NL80211Backend) Diagnose() BackendReport {
+	familyCheck := Check{Name: "nl80211", Kind: CheckKernel, Present: nlBackend.nl80211.IsAvailable(), Required: true}
+	if !familyCheck.Present {
+		familyCheck.Detail = "netlink family not found"
+	}
+	report := BackendReport{Checks: []Check{familyCheck}}
+	report.Problems, report.Usable = missingRequired(report.Checks)
+	root := os.Geteuid() == 0
+	if report.Usable && !root {
+		report.Problems = append(report.Problems, "scanning and connecting over nl80211 needs root")
+	}
+	if report.Usable {
+		report.Problems = append(report.Problems, "only open and WEP networks can be joined without a supplicant")
+	}
+	report.Privileged = report.Usable && root
+	report.Features = Features{
+		Scan:    report.Privileged,
+		Connect: report.Privileged,
+	}
+	return report
+}
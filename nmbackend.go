@@ -0,0 +1,338 @@
+package wifimanager
+
+import (
+	"net"
+	"os"
+	"strings"
+
+	"github.com/ottopress/WifiManager/darwin"
+	"github.com/ottopress/WifiManager/linux"
+)
+
+const (
+	// nmNetworkControl is the permission to connect and disconnect
+	nmNetworkControl = "org.freedesktop.NetworkManager.network-control"
+	// nmEnableWifi is the permission to turn the WiFi radio on and off
+	nmEnableWifi = "org.freedesktop.NetworkManager.enable-disable-wifi"
+)
+
+var (
+	// nmPermissions are the permissions every operation of the
+	// backend needs between them
+	nmPermissions = []string{nmNetworkControl, nmEnableWifi}
+
+	// nmProtocols maps the security names nmcli lists for access
+	// points to their respective WifiNetworkSecurity protocol values
+	nmProtocols = map[string]int{
+		"WPA1": SecurityWPA,
+		"WPA2": SecurityWPA2,
+		"WPA3": SecurityWPA3,
+		"WEP":  SecurityWEP,
+	}
+)
+
+// NetworkManagerBackend drives WiFi interfaces through NetworkManager
+// using the nmcli command. Connections are saved as NetworkManager
+// profiles named after the SSID.
+type NetworkManagerBackend struct {
+	nmcli *linux.NMCli
+}
+
+// NewNetworkManagerBackend creates a new instance of the
+// NetworkManager backend
+func NewNetworkManagerBackend() *NetworkManagerBackend {
+	return &NetworkManagerBackend{nmcli: linux.NewNMCli()}
+}
+
+// Name returns the name of the backend
+func (nmBackend *NetworkManagerBackend) Name() string {
+	return "networkmanager"
+}
+
+// IsInstalled returns whether or not nmcli is installed
+func (nmBackend *NetworkManagerBackend) IsInstalled() bool {
+	return nmBackend.nmcli.IsInstalled()
+}
+
+// Interfaces returns all WiFi devices NetworkManager knows about
+func (nmBackend *NetworkManagerBackend) Interfaces() ([]WifiInterface, error) {
+	wifiInterfaces := []WifiInterface{}
+	devices, devicesErr := nmBackend.nmcli.Devices()
+	if devicesErr != nil {
+		return wifiInterfaces, devicesErr
+	}
+	for _, device := range devices {
+		if device.Type != "wifi" {
+			continue
+		}
+		iface, ifaceErr := net.InterfaceByName(device.Name)
+		if ifaceErr != nil {
+			continue
+		}
+		wifiInterfaces = append(wifiInterfaces, newSysfsInterface(*iface, nmBackend))
+	}
+	return wifiInterfaces, nil
+}
+
+// Scan returns a list of all reachable WiFi networks. NetworkManager
+// reports signal strength as a percentage, which is converted back to
+// dBm the way NetworkManager derives it.
+func (nmBackend *NetworkManagerBackend) Scan(iface string) ([]WifiNetwork, error) {
+	accessPoints, scanErr := nmBackend.nmcli.Scan(iface)
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	wifiNetworks := []WifiNetwork{}
+	for _, accessPoint := range accessPoints {
+		security := []WifiNetworkSecurity{}
+		method := darwin.PSK
+		for _, name := range accessPoint.Security {
+			if name == "802.1X" {
+				method = darwin.EAP
+			}
+		}
+		for _, name := range accessPoint.Security {
+			if protocol, known := nmProtocols[name]; known {
+				security = append(security, WifiNetworkSecurity{Protocol: protocol, Method: method})
+			}
+		}
+		if len(security) == 0 {
+			security = append(security, WifiNetworkSecurity{Protocol: SecurityNone})
+		}
+		wifiNetworks = append(wifiNetworks, WifiNetwork{
+			SSID:     accessPoint.SSID,
+			BSSID:    accessPoint.BSSID,
+			RSSI:     accessPoint.Signal*60/100 - 100,
+			Channel:  accessPoint.Channel,
+			Security: security,
+		})
+	}
+	return wifiNetworks, nil
+}
+
+// Connect connects the interface to the provided network, creating or
+// updating the profile for its SSID
+func (nmBackend *NetworkManagerBackend) Connect(iface string, network WifiNetwork) error {
+	RegisterSecret(network.SecurityKey)
+	defer ForgetSecret(network.SecurityKey)
+	return RedactError(nmBackend.connect(iface, network))
+}
+
+// connect does the work of Connect
+func (nmBackend *NetworkManagerBackend) connect(iface string, network WifiNetwork) error {
+	settings, secrets, settingsErr := nmSettings(network)
+	if settingsErr != nil {
+		return settingsErr
+	}
+	connections, listErr := nmBackend.nmcli.Connections()
+	if listErr != nil {
+		return listErr
+	}
+	exists := false
+	for _, connection := range connections {
+		if connection.Name == network.SSID && connection.Type == "802-11-wireless" {
+			exists = true
+		}
+	}
+	if exists {
+		modifyErr := nmBackend.nmcli.ModifyConnection(network.SSID, settings)
+		if modifyErr != nil {
+			return modifyErr
+		}
+	} else {
+		addErr := nmBackend.nmcli.AddWifiConnection(network.SSID, iface, network.SSID, settings)
+		if addErr != nil {
+			return addErr
+		}
+	}
+	return nmBackend.nmcli.Up(network.SSID, iface, secrets)
+}
+
+// SupportsRawPSK returns true as NetworkManager takes a PSK of 64 hex
+// digits in place of the passphrase
+func (nmBackend *NetworkManagerBackend) SupportsRawPSK() bool {
+	return true
+}
+
+// Disconnect disconnects from the current network without shutting
+// down the interface
+func (nmBackend *NetworkManagerBackend) Disconnect(iface string) error {
+	return nmBackend.nmcli.Disconnect(iface)
+}
+
+// Up turns on the device. NetworkManager only switches all WiFi
+// devices at once, so the device is turned on by lifting the rfkill
+// soft block of its radio and the WiFi radio of NetworkManager is
+// only enabled when it is off.
+func (nmBackend *NetworkManagerBackend) Up(iface string) error {
+	if unblockErr := unblockRFKill(iface); unblockErr != nil {
+		return unblockErr
+	}
+	enabled, radioErr := nmBackend.nmcli.Radio()
+	if radioErr != nil || enabled {
+		return radioErr
+	}
+	return nmBackend.nmcli.SetRadio(true)
+}
+
+// Down turns off the device by soft blocking its radio, leaving the
+// other WiFi devices alone. Devices without an rfkill switch, and
+// callers that may not write to /dev/rfkill, can only turn it off
+// along with every other one through NetworkManager.
+func (nmBackend *NetworkManagerBackend) Down(iface string) error {
+	rfkillSwitch, switchErr := rfkill.InterfaceSwitch(iface)
+	if switchErr != nil {
+		return nmBackend.nmcli.SetRadio(false)
+	}
+	blockErr := rfkill.SetSoftBlocked(rfkillSwitch.Index, true)
+	if os.IsPermission(blockErr) {
+		return nmBackend.nmcli.SetRadio(false)
+	}
+	return blockErr
+}
+
+// Status returns whether the WiFi radio of NetworkManager is on and
+// the radio of the device isn't blocked
+func (nmBackend *NetworkManagerBackend) Status(iface string) (bool, error) {
+	enabled, radioErr := nmBackend.nmcli.Radio()
+	if radioErr != nil || !enabled {
+		return false, radioErr
+	}
+	if rfkillSwitch, switchErr := rfkill.InterfaceSwitch(iface); switchErr == nil {
+		return !rfkillSwitch.SoftBlocked && !rfkillSwitch.HardBlocked, nil
+	}
+	return true, nil
+}
+
+// State returns the connection state of the device. NetworkManager
+// reports devices it can't use, such as while the radio is off, as
+// unavailable.
+func (nmBackend *NetworkManagerBackend) State(iface string) (int, error) {
+	devices, devicesErr := nmBackend.nmcli.Devices()
+	if devicesErr != nil {
+		return StateOff, devicesErr
+	}
+	for _, device := range devices {
+		if device.Name != iface {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(device.State, "connected"):
+			return StateConnected, nil
+		case device.State == "connecting (need authentication)":
+			return StateAuthenticating, nil
+		case strings.HasPrefix(device.State, "connecting"):
+			return StateAssociating, nil
+		case device.State == "failed":
+			return StateFailed, nil
+		case device.State == "unavailable" || device.State == "unmanaged":
+			return StateOff, nil
+		}
+		return StateDisconnected, nil
+	}
+	return StateOff, ErrUnknownIface
+}
+
+// Subscribe delivers the power, association, address and hotplug
+// events the kernel reports for the interface
+func (nmBackend *NetworkManagerBackend) Subscribe(iface string) (<-chan InterfaceEvent, func(), error) {
+	stream, streamErr := netlinkEvents(iface, true)
+	if streamErr != nil {
+		return nil, nil, streamErr
+	}
+	return stream.subscription()
+}
+
+// Diagnose reports on nmcli, the NetworkManager daemon it talks to over
+// D-Bus and the polkit permissions NetworkManager grants the caller.
+// Hotspots go through hostapd, which needs root.
+func (nmBackend *NetworkManagerBackend) Diagnose() BackendReport {
+	hostapd := linux.NewHostapd("")
+	nmcliCheck := commandCheck("nmcli", nmBackend.nmcli.IsInstalled(), true, nil)
+	serviceCheck := Check{Name: "org.freedesktop.NetworkManager", Kind: CheckService, Required: true}
+	if nmcliCheck.Present {
+		general, generalErr := nmBackend.nmcli.General()
+		if generalErr != nil {
+			serviceCheck.Detail = generalErr.Error()
+		} else if !general.Running {
+			serviceCheck.Detail = "NetworkManager is not running"
+		} else {
+			serviceCheck.Present = true
+			serviceCheck.Version = general.Version
+			nmcliCheck.Version = general.Version
+		}
+	}
+	report := BackendReport{Checks: []Check{
+		nmcliCheck,
+		serviceCheck,
+		commandCheck("hostapd", hostapd.IsInstalled(), false, hostapd.Version),
+	}}
+	report.Problems, report.Usable = missingRequired(report.Checks)
+
+	allowed := map[string]bool{}
+	if report.Usable {
+		permissions, permissionsErr := nmBackend.nmcli.Permissions()
+		if permissionsErr != nil {
+			report.Problems = append(report.Problems, "reading permissions failed: "+permissionsErr.Error())
+		}
+		for _, permission := range nmPermissions {
+			allowed[permission] = permissions[permission] == "yes"
+			if !allowed[permission] && permissionsErr == nil {
+				report.Problems = append(report.Problems, permission+" is "+permissions[permission])
+			}
+		}
+	}
+	report.Privileged = report.Usable
+	for _, permission := range nmPermissions {
+		report.Privileged = report.Privileged && allowed[permission]
+	}
+	report.Features = Features{
+		Scan:               report.Usable,
+		Connect:            allowed[nmNetworkControl],
+		Hotspot:            hostapd.IsInstalled() && os.Geteuid() == 0,
+		EnterpriseProfiles: allowed[nmNetworkControl],
+	}
+	return report
+}
+
+// nmSettings returns the profile settings and the secrets for the
+// network, based on the protocol of its first security configuration.
+// Hidden networks are marked so NetworkManager probes for them.
+func nmSettings(network WifiNetwork) ([][2]string, map[string]string, error) {
+	settings, secrets, securityErr := nmSecuritySettings(network)
+	if securityErr != nil {
+		return nil, nil, securityErr
+	}
+	if network.Hidden {
+		settings = append(settings, [2]string{"802-11-wireless.hidden", "yes"})
+	}
+	return settings, secrets, nil
+}
+
+// nmSecuritySettings returns the security settings and the secrets of
+// nmSettings
+func nmSecuritySettings(network WifiNetwork) ([][2]string, map[string]string, error) {
+	protocol := SecurityNone
+	if len(network.Security) > 0 {
+		protocol = network.Security[0].Protocol
+	}
+	switch protocol {
+	case SecurityWPA, SecurityWPA2:
+		proto := "rsn"
+		if protocol == SecurityWPA {
+			proto = "wpa"
+		}
+		settings := [][2]string{{"wifi-sec.key-mgmt", "wpa-psk"}, {"wifi-sec.proto", proto}, {"wifi-sec.psk-flags", "0"}}
+		return settings, map[string]string{"802-11-wireless-security.psk": network.SecurityKey}, nil
+	case SecurityWPA3:
+		if IsRawPSK(network.SecurityKey) {
+			return nil, nil, ErrRawPSK
+		}
+		settings := [][2]string{{"wifi-sec.key-mgmt", "sae"}, {"wifi-sec.psk-flags", "0"}}
+		return settings, map[string]string{"802-11-wireless-security.psk": network.SecurityKey}, nil
+	case SecurityWEP:
+		settings := [][2]string{{"wifi-sec.key-mgmt", "none"}, {"wifi-sec.wep-key-type", "1"}}
+		return settings, map[string]string{"802-11-wireless-security.wep-key0": network.SecurityKey}, nil
+	}
+	return [][2]string{}, nil, nil
+}
@@ -0,0 +1,169 @@
+package wifimanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeNMCli is an nmcli that records its arguments and keeps the state
+// of the WiFi radio in a file next to it
+const fakeNMCli = `#!/bin/sh
+dir=$(dirname "$0")
+echo "$*" >> "$dir/calls"
+case "$*" in
+"--terse radio wifi") cat "$dir/radio" ;;
+"--terse radio wifi on") echo enabled > "$dir/radio" ;;
+"--terse radio wifi off") echo disabled > "$dir/radio" ;;
+esac
+`
+
+// setTestNMCli puts a fake nmcli first in PATH with the WiFi radio on,
+// returning a function that lists the calls made to it
+func setTestNMCli(t *testing.T) func() []string {
+	t.Helper()
+	dir := t.TempDir()
+	if writeErr := ioutil.WriteFile(filepath.Join(dir, "nmcli"), []byte(fakeNMCli), 0755); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	if writeErr := ioutil.WriteFile(filepath.Join(dir, "radio"), []byte("enabled\n"), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return func() []string {
+		calls, _ := ioutil.ReadFile(filepath.Join(dir, "calls"))
+		return strings.Split(strings.TrimSpace(string(calls)), "\n")
+	}
+}
+
+func TestNMSettings(t *testing.T) {
+	wpa2 := []WifiNetworkSecurity{{Protocol: SecurityWPA2}}
+	tests := []struct {
+		name     string
+		network  WifiNetwork
+		settings [][2]string
+		secrets  map[string]string
+	}{
+		{
+			name:     "open",
+			network:  WifiNetwork{SSID: "cafe"},
+			settings: [][2]string{},
+		},
+		{
+			name:     "hidden open",
+			network:  WifiNetwork{SSID: "cafe", Hidden: true},
+			settings: [][2]string{{"802-11-wireless.hidden", "yes"}},
+		},
+		{
+			name:     "hidden passphrase",
+			network:  WifiNetwork{SSID: "home", SecurityKey: "correct horse", Security: wpa2, Hidden: true},
+			settings: [][2]string{{"wifi-sec.key-mgmt", "wpa-psk"}, {"wifi-sec.proto", "rsn"}, {"wifi-sec.psk-flags", "0"}, {"802-11-wireless.hidden", "yes"}},
+			secrets:  map[string]string{"802-11-wireless-security.psk": "correct horse"},
+		},
+	}
+	for _, test := range tests {
+		settings, secrets, settingsErr := nmSettings(test.network)
+		if settingsErr != nil {
+			t.Fatalf("%s: %v", test.name, settingsErr)
+		}
+		if !reflect.DeepEqual(settings, test.settings) || !reflect.DeepEqual(secrets, test.secrets) {
+			t.Errorf("%s: settings = %q, %v, want %q, %v", test.name, settings, secrets, test.settings, test.secrets)
+		}
+	}
+	hiddenSAE := WifiNetwork{SSID: "home", SecurityKey: "f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e", Security: []WifiNetworkSecurity{{Protocol: SecurityWPA3}}, Hidden: true}
+	if _, _, settingsErr := nmSettings(hiddenSAE); settingsErr != ErrRawPSK {
+		t.Errorf("raw psk over sae error = %v, want ErrRawPSK", settingsErr)
+	}
+}
+
+func TestNMDown(t *testing.T) {
+	tests := []struct {
+		name     string
+		iface    string
+		readOnly bool
+		calls    []string
+		blocked  bool
+	}{
+		{
+			name:    "rfkill switch",
+			iface:   "wlan0",
+			calls:   []string{"--terse radio wifi"},
+			blocked: true,
+		},
+		{
+			name:  "no rfkill switch",
+			iface: "wlan1",
+			calls: []string{"--terse radio wifi off", "--terse radio wifi"},
+		},
+		{
+			name:     "rfkill not writable",
+			iface:    "wlan0",
+			readOnly: true,
+			calls:    []string{"--terse radio wifi off", "--terse radio wifi"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := setTestRFKill(t, false, false)
+			if test.readOnly {
+				if os.Geteuid() == 0 {
+					t.Skip("root may write to read-only files")
+				}
+				device := filepath.Join(t.TempDir(), "rfkill")
+				if writeErr := ioutil.WriteFile(device, nil, 0400); writeErr != nil {
+					t.Fatal(writeErr)
+				}
+				if chmodErr := os.Chmod(filepath.Join(dir, "soft"), 0444); chmodErr != nil {
+					t.Fatal(chmodErr)
+				}
+				rfkill.Device = device
+			}
+			calls := setTestNMCli(t)
+			nmBackend := NewNetworkManagerBackend()
+			if downErr := nmBackend.Down(test.iface); downErr != nil {
+				t.Fatal(downErr)
+			}
+			powered, statusErr := nmBackend.Status(test.iface)
+			if statusErr != nil {
+				t.Fatal(statusErr)
+			}
+			if powered {
+				t.Error("Status = true after Down")
+			}
+			if recorded := calls(); !reflect.DeepEqual(recorded, test.calls) {
+				t.Errorf("nmcli calls = %q, want %q", recorded, test.calls)
+			}
+			soft, _ := ioutil.ReadFile(filepath.Join(dir, "soft"))
+			if blocked := strings.TrimSpace(string(soft)) == "1"; blocked != test.blocked {
+				t.Errorf("soft blocked = %v, want %v", blocked, test.blocked)
+			}
+		})
+	}
+}
+
+func TestNMUp(t *testing.T) {
+	dir := setTestRFKill(t, true, false)
+	calls := setTestNMCli(t)
+	nmBackend := NewNetworkManagerBackend()
+	if powered, _ := nmBackend.Status("wlan0"); powered {
+		t.Error("Status = true while soft blocked")
+	}
+	if upErr := nmBackend.Up("wlan0"); upErr != nil {
+		t.Fatal(upErr)
+	}
+	if powered, _ := nmBackend.Status("wlan0"); !powered {
+		t.Error("Status = false after Up")
+	}
+	soft, _ := ioutil.ReadFile(filepath.Join(dir, "soft"))
+	if strings.TrimSpace(string(soft)) != "0" {
+		t.Errorf("soft = %q after Up, want 0", soft)
+	}
+	for _, call := range calls() {
+		if call == "--terse radio wifi on" {
+			t.Error("Up turned on the radio of NetworkManager while it was on")
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package nmkeyfile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Extension is the file extension NetworkManager looks for in its
+	// system-connections directory
+	Extension = ".nmconnection"
+)
+
+var (
+	// ErrMissingID is returned when writing a keyfile without a
+	// connection id to name it after
+	ErrMissingID = errors.New("nmkeyfile: connection id is missing")
+)
+
+// Line represents a single line of a keyfile section: either a
+// key=value entry, or a comment or blank line when Key is empty.
+// Raw holds the line as it was read and is written back unchanged
+// until the entry is modified.
+type Line struct {
+	Key   string
+	Value string
+	Raw   string
+}
+
+// Section represents a [name] group of a keyfile
+type Section struct {
+	Name  string
+	Lines []Line
+}
+
+// KeyFile represents a NetworkManager .nmconnection keyfile. Sections,
+// entries and comments keep their original order so an unmodified
+// keyfile is written back byte for byte.
+type KeyFile struct {
+	// header holds the comments and blank lines before the first
+	// section
+	header   []Line
+	sections []*Section
+}
+
+// Parse parses the contents of a keyfile
+func Parse(data []byte) (*KeyFile, error) {
+	keyFile := &KeyFile{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var section *Section
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		raw := scanner.Text()
+		trimmed := strings.TrimSpace(raw)
+		var line Line
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			line = Line{Raw: raw}
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			section = &Section{Name: trimmed[1 : len(trimmed)-1]}
+			keyFile.sections = append(keyFile.sections, section)
+			continue
+		default:
+			pair := strings.SplitN(trimmed, "=", 2)
+			if len(pair) != 2 {
+				return nil, fmt.Errorf("nmkeyfile: line %d: invalid entry", lineNumber)
+			}
+			if section == nil {
+				return nil, fmt.Errorf("nmkeyfile: line %d: entry outside of a section", lineNumber)
+			}
+			line = Line{Key: strings.TrimSpace(pair[0]), Value: pair[1], Raw: raw}
+		}
+		if section == nil {
+			keyFile.header = append(keyFile.header, line)
+		} else {
+			section.Lines = append(section.Lines, line)
+		}
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, scanErr
+	}
+	return keyFile, nil
+}
+
+// ReadFile parses the keyfile at the provided path
+func ReadFile(path string) (*KeyFile, error) {
+	data, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return nil, readErr
+	}
+	return Parse(data)
+}
+
+// WriteFile writes the keyfile into the provided directory, named
+// after the connection id. NetworkManager ignores keyfiles that are
+// readable by anyone but their owner, so the keyfile is written to a
+// new 0600 file that then replaces any existing one, whatever mode
+// that had.
+func (keyFile *KeyFile) WriteFile(dir string) (string, error) {
+	id, _ := keyFile.Get("connection", "id")
+	if id == "" {
+		return "", ErrMissingID
+	}
+	name := strings.Map(func(char rune) rune {
+		if char == '/' || char < 0x20 {
+			return '_'
+		}
+		return char
+	}, id)
+	path := filepath.Join(dir, name+Extension)
+	tempFile, tempErr := ioutil.TempFile(dir, "."+name+"-")
+	if tempErr != nil {
+		return path, tempErr
+	}
+	_, writeErr := tempFile.Write(keyFile.Marshal())
+	closeErr := tempFile.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr == nil {
+		writeErr = os.Chmod(tempFile.Name(), 0600)
+	}
+	if writeErr == nil {
+		writeErr = os.Rename(tempFile.Name(), path)
+	}
+	if writeErr != nil {
+		os.Remove(tempFile.Name())
+	}
+	return path, writeErr
+}
+
+// Marshal returns the keyfile representation
+func (keyFile *KeyFile) Marshal() []byte {
+	var buffer bytes.Buffer
+	for _, line := range keyFile.header {
+		buffer.WriteString(line.String())
+		buffer.WriteByte('\n')
+	}
+	for _, section := range keyFile.sections {
+		buffer.WriteString("[" + section.Name + "]\n")
+		for _, line := range section.Lines {
+			buffer.WriteString(line.String())
+			buffer.WriteByte('\n')
+		}
+	}
+	return buffer.Bytes()
+}
+
+// Section returns the section with the provided name, or nil if the
+// keyfile doesn't have it
+func (keyFile *KeyFile) Section(name string) *Section {
+	for _, section := range keyFile.sections {
+		if section.Name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// RemoveSection removes the section with the provided name
+func (keyFile *KeyFile) RemoveSection(name string) {
+	sections := []*Section{}
+	for _, section := range keyFile.sections {
+		if section.Name != name {
+			sections = append(sections, section)
+		}
+	}
+	keyFile.sections = sections
+}
+
+// addSection creates a new section. Settings sections go ahead of the
+// IP and proxy sections the way NetworkManager orders them, and a
+// blank line is kept between sections.
+func (keyFile *KeyFile) addSection(name string) *Section {
+	trailing := map[string]bool{"ipv4": true, "ipv6": true, "proxy": true}
+	section := &Section{Name: name}
+	index := len(keyFile.sections)
+	for sectionIndex, existing := range keyFile.sections {
+		if trailing[existing.Name] && !trailing[name] {
+			index = sectionIndex
+			break
+		}
+	}
+	if index > 0 {
+		previous := keyFile.sections[index-1]
+		if len(previous.Lines) == 0 || previous.Lines[len(previous.Lines)-1].Key != "" {
+			previous.Lines = append(previous.Lines, Line{})
+		}
+	}
+	if index < len(keyFile.sections) {
+		section.Lines = append(section.Lines, Line{})
+	}
+	keyFile.sections = append(keyFile.sections, nil)
+	copy(keyFile.sections[index+1:], keyFile.sections[index:])
+	keyFile.sections[index] = section
+	return section
+}
+
+// Get returns the unescaped value of an entry
+func (keyFile *KeyFile) Get(section, key string) (string, bool) {
+	keySection := keyFile.Section(section)
+	if keySection == nil {
+		return "", false
+	}
+	for _, line := range keySection.Lines {
+		if line.Key == key {
+			return Unescape(line.Value), true
+		}
+	}
+	return "", false
+}
+
+// Set escapes and stores the value of an entry, creating the section
+// if it doesn't exist yet
+func (keyFile *KeyFile) Set(section, key, value string) {
+	keyFile.SetRaw(section, key, Escape(value))
+}
+
+// SetRaw stores the value of an entry as is, for values such as lists
+// and byte arrays that are already in their keyfile form
+func (keyFile *KeyFile) SetRaw(section, key, value string) {
+	keySection := keyFile.Section(section)
+	if keySection == nil {
+		keySection = keyFile.addSection(section)
+	}
+	for index := range keySection.Lines {
+		if keySection.Lines[index].Key == key {
+			keySection.Lines[index].Value = value
+			keySection.Lines[index].Raw = ""
+			return
+		}
+	}
+	// keep new entries ahead of any trailing blank lines so sections
+	// stay separated
+	index := len(keySection.Lines)
+	for index > 0 && keySection.Lines[index-1].Key == "" {
+		index--
+	}
+	keySection.Lines = append(keySection.Lines, Line{})
+	copy(keySection.Lines[index+1:], keySection.Lines[index:])
+	keySection.Lines[index] = Line{Key: key, Value: value}
+}
+
+// Delete removes an entry
+func (keyFile *KeyFile) Delete(section, key string) {
+	keySection := keyFile.Section(section)
+	if keySection == nil {
+		return
+	}
+	lines := []Line{}
+	for _, line := range keySection.Lines {
+		if line.Key != key {
+			lines = append(lines, line)
+		}
+	}
+	keySection.Lines = lines
+}
+
+// String returns the keyfile text of the line
+func (line Line) String() string {
+	if line.Raw != "" || line.Key == "" {
+		return line.Raw
+	}
+	return line.Key + "=" + line.Value
+}
+
+// Escape escapes a string value the way GLib key files expect
+func Escape(value string) string {
+	var buffer bytes.Buffer
+	for index, char := range value {
+		switch char {
+		case '\\':
+			buffer.WriteString("\\\\")
+		case '\n':
+			buffer.WriteString("\\n")
+		case '\t':
+			buffer.WriteString("\\t")
+		case '\r':
+			buffer.WriteString("\\r")
+		case ' ':
+			if index == 0 {
+				buffer.WriteString("\\s")
+			} else {
+				buffer.WriteRune(char)
+			}
+		default:
+			buffer.WriteRune(char)
+		}
+	}
+	return buffer.String()
+}
+
+// Unescape reverses Escape
+func Unescape(value string) string {
+	var buffer bytes.Buffer
+	for index := 0; index < len(value); index++ {
+		if value[index] != '\\' || index+1 >= len(value) {
+			buffer.WriteByte(value[index])
+			continue
+		}
+		index++
+		switch value[index] {
+		case 's':
+			buffer.WriteByte(' ')
+		case 'n':
+			buffer.WriteByte('\n')
+		case 't':
+			buffer.WriteByte('\t')
+		case 'r':
+			buffer.WriteByte('\r')
+		default:
+			buffer.WriteByte(value[index])
+		}
+	}
+	return buffer.String()
+}
+
+// splitList splits a semicolon separated list value
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ";") {
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
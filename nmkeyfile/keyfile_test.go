@@ -0,0 +1,110 @@
+package nmkeyfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/ottopress/WifiManager"
+)
+
+const testKeyFile = `# written by hand
+[connection]
+id=home
+uuid=0b1e8f2a-6c44-4d7e-9a51-3f1c2d7e8b90
+type=wifi
+
+[wifi]
+mode=infrastructure
+ssid=home
+
+[wifi-security]
+key-mgmt=wpa-psk
+psk=correct horse
+
+[ipv4]
+method=auto
+`
+
+func TestRoundTrip(t *testing.T) {
+	keyFile, parseErr := Parse([]byte(testKeyFile))
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	if marshaled := string(keyFile.Marshal()); marshaled != testKeyFile {
+		t.Fatalf("round trip changed the keyfile:\n%s", marshaled)
+	}
+	profile, profileErr := keyFile.Profile()
+	if profileErr != nil {
+		t.Fatal(profileErr)
+	}
+	if profile.SSID != "home" || profile.Security != wifimanager.SecurityWPA2 || profile.SecurityKey != "correct horse" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+}
+
+func TestNewKeyFile(t *testing.T) {
+	keyFile, newErr := NewKeyFile(wifimanager.Profile{SSID: "cafe", Security: wifimanager.SecurityNone, AutoJoin: true})
+	if newErr != nil {
+		t.Fatal(newErr)
+	}
+	uuid, _ := keyFile.Get("connection", "uuid")
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid) {
+		t.Errorf("uuid %q is not a version 4 uuid", uuid)
+	}
+	if id, _ := keyFile.Get("connection", "id"); id != "cafe" {
+		t.Errorf("id = %q, want cafe", id)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "home"+Extension)
+	if writeErr := ioutil.WriteFile(path, []byte("stale"), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	keyFile, parseErr := Parse([]byte(testKeyFile))
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	written, writeErr := keyFile.WriteFile(dir)
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	if written != path {
+		t.Errorf("wrote %s, want %s", written, path)
+	}
+	info, statErr := os.Stat(path)
+	if statErr != nil {
+		t.Fatal(statErr)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %o, want 600", info.Mode().Perm())
+	}
+	data, _ := ioutil.ReadFile(path)
+	if string(data) != testKeyFile {
+		t.Errorf("wrote %q", data)
+	}
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("left %d files behind, want 1", len(entries))
+	}
+}
+
+func TestWriteFileMissingID(t *testing.T) {
+	keyFile := &KeyFile{}
+	keyFile.Set("connection", "type", "wifi")
+	if _, writeErr := keyFile.WriteFile(t.TempDir()); writeErr != ErrMissingID {
+		t.Fatalf("got %v, want ErrMissingID", writeErr)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for _, value := range []string{" leading space", "tab\tand\nnewline", `back\slash`, "plain"} {
+		if unescaped := Unescape(Escape(value)); unescaped != value {
+			t.Errorf("Unescape(Escape(%q)) = %q", value, unescaped)
+		}
+	}
+}
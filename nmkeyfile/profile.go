@@ -0,0 +1,277 @@
+package nmkeyfile
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ottopress/WifiManager"
+)
+
+const (
+	// SecretFlagNone means the secret is stored in the keyfile
+	SecretFlagNone int = 0
+	// SecretFlagAgentOwned means a secret agent provides the secret
+	SecretFlagAgentOwned int = 1
+	// SecretFlagNotSaved means the secret is asked for every time
+	SecretFlagNotSaved int = 2
+	// SecretFlagNotRequired means the secret isn't needed at all
+	SecretFlagNotRequired int = 4
+)
+
+var (
+	// byteListRE matches SSIDs written as a list of byte values
+	byteListRE = regexp.MustCompile(`^(\d{1,3};)+\d{0,3}$`)
+
+	// macRandomization maps the profile MAC randomization values to
+	// the wifi.mac-address-randomization setting
+	macRandomization = map[int]string{
+		wifimanager.MACRandomDefault: "default",
+		wifimanager.MACRandomNever:   "never",
+		wifimanager.MACRandomAlways:  "always",
+	}
+)
+
+// NewKeyFile creates a keyfile for the profile with a fresh UUID
+func NewKeyFile(profile wifimanager.Profile) (*KeyFile, error) {
+	uuid, uuidErr := wifimanager.NewUUID()
+	if uuidErr != nil {
+		return nil, uuidErr
+	}
+	keyFile := &KeyFile{}
+	keyFile.Set("connection", "id", profile.SSID)
+	keyFile.Set("connection", "uuid", uuid)
+	keyFile.Set("connection", "type", "wifi")
+	keyFile.Set("wifi", "mode", "infrastructure")
+	keyFile.ApplyProfile(profile)
+	keyFile.Set("ipv4", "method", "auto")
+	keyFile.Set("ipv6", "addr-gen-mode", "stable-privacy")
+	keyFile.Set("ipv6", "method", "auto")
+	return keyFile, nil
+}
+
+// Profile converts the keyfile into a profile. Secrets that are owned
+// by an agent or not saved are left empty.
+func (keyFile *KeyFile) Profile() (wifimanager.Profile, error) {
+	profile := wifimanager.Profile{AutoJoin: true, Security: wifimanager.SecurityNone}
+	if connectionType, _ := keyFile.Get("connection", "type"); connectionType != "wifi" && connectionType != "802-11-wireless" {
+		return profile, fmt.Errorf("nmkeyfile: unsupported connection type %q", connectionType)
+	}
+	ssid, _ := keyFile.Get("wifi", "ssid")
+	profile.SSID = decodeSSID(ssid)
+	if hidden, _ := keyFile.Get("wifi", "hidden"); hidden == "true" {
+		profile.Hidden = true
+	}
+	if randomization, ok := keyFile.Get("wifi", "mac-address-randomization"); ok {
+		for value, setting := range macRandomization {
+			if randomization == setting || randomization == strconv.Itoa(value) {
+				profile.MACRandomization = value
+			}
+		}
+	}
+	if autoconnect, _ := keyFile.Get("connection", "autoconnect"); autoconnect == "false" {
+		profile.AutoJoin = false
+	}
+	if priority, ok := keyFile.Get("connection", "autoconnect-priority"); ok {
+		profile.Priority, _ = strconv.Atoi(priority)
+	}
+
+	keyMgmt, _ := keyFile.Get("wifi-security", "key-mgmt")
+	proto, _ := keyFile.Get("wifi-security", "proto")
+	wpaOnly := proto != "" && !strings.Contains(proto, "rsn")
+	switch keyMgmt {
+	case "wpa-psk":
+		profile.Security = wifimanager.SecurityWPA2
+		if wpaOnly {
+			profile.Security = wifimanager.SecurityWPA
+		}
+		profile.SecurityKey, _ = keyFile.Get("wifi-security", "psk")
+	case "sae":
+		profile.Security = wifimanager.SecurityWPA3
+		profile.SecurityKey, _ = keyFile.Get("wifi-security", "psk")
+	case "wpa-eap", "wpa-eap-suite-b-192", "ieee8021x":
+		profile.Security = wifimanager.SecurityWPA2
+		if wpaOnly {
+			profile.Security = wifimanager.SecurityWPA
+		}
+		profile.EAP = keyFile.eapConfig()
+	case "none":
+		profile.Security = wifimanager.SecurityWEP
+		profile.SecurityKey, _ = keyFile.Get("wifi-security", "wep-key0")
+	case "owe", "":
+		profile.Security = wifimanager.SecurityNone
+	default:
+		return profile, fmt.Errorf("nmkeyfile: unsupported key-mgmt %q", keyMgmt)
+	}
+	return profile, nil
+}
+
+// ApplyProfile updates the wifi, wifi-security and 802-1x settings to
+// match the profile, leaving the connection's identity, IP settings
+// and anything else the profile doesn't cover untouched. Secrets whose
+// flags say they aren't saved are not written.
+func (keyFile *KeyFile) ApplyProfile(profile wifimanager.Profile) {
+	keyFile.SetRaw("wifi", "ssid", encodeSSID(profile.SSID))
+	if profile.Hidden {
+		keyFile.Set("wifi", "hidden", "true")
+	} else {
+		keyFile.Delete("wifi", "hidden")
+	}
+	if profile.MACRandomization != wifimanager.MACRandomDefault {
+		keyFile.Set("wifi", "mac-address-randomization", macRandomization[profile.MACRandomization])
+	} else {
+		keyFile.Delete("wifi", "mac-address-randomization")
+	}
+	if profile.AutoJoin {
+		keyFile.Delete("connection", "autoconnect")
+	} else {
+		keyFile.Set("connection", "autoconnect", "false")
+	}
+	if profile.Priority != 0 {
+		keyFile.Set("connection", "autoconnect-priority", strconv.Itoa(profile.Priority))
+	} else {
+		keyFile.Delete("connection", "autoconnect-priority")
+	}
+
+	for _, key := range []string{"key-mgmt", "proto", "psk", "wep-key0", "wep-key-type", "auth-alg"} {
+		keyFile.Delete("wifi-security", key)
+	}
+	if profile.EAP == nil {
+		keyFile.RemoveSection("802-1x")
+	}
+	saveSecret := keyFile.SecretFlags("wifi-security", "psk-flags")&(SecretFlagAgentOwned|SecretFlagNotSaved) == 0
+	switch {
+	case profile.EAP != nil:
+		keyFile.Set("wifi-security", "key-mgmt", "wpa-eap")
+		if profile.Security == wifimanager.SecurityWPA {
+			keyFile.SetRaw("wifi-security", "proto", "wpa;")
+		}
+		keyFile.applyEAP(profile.EAP)
+	case profile.Security == wifimanager.SecurityWPA || profile.Security == wifimanager.SecurityWPA2:
+		keyFile.Set("wifi-security", "key-mgmt", "wpa-psk")
+		if profile.Security == wifimanager.SecurityWPA {
+			keyFile.SetRaw("wifi-security", "proto", "wpa;")
+		}
+		if saveSecret {
+			keyFile.Set("wifi-security", "psk", profile.SecurityKey)
+		}
+	case profile.Security == wifimanager.SecurityWPA3:
+		keyFile.Set("wifi-security", "key-mgmt", "sae")
+		if saveSecret {
+			keyFile.Set("wifi-security", "psk", profile.SecurityKey)
+		}
+	case profile.Security == wifimanager.SecurityWEP:
+		keyFile.Set("wifi-security", "key-mgmt", "none")
+		keyFile.Set("wifi-security", "auth-alg", "open")
+		keyFile.Set("wifi-security", "wep-key-type", "1")
+		if saveSecret {
+			keyFile.Set("wifi-security", "wep-key0", profile.SecurityKey)
+		}
+	default:
+		keyFile.RemoveSection("wifi-security")
+	}
+}
+
+// SecretFlags returns the value of a *-flags entry
+func (keyFile *KeyFile) SecretFlags(section, key string) int {
+	value, _ := keyFile.Get(section, key)
+	flags, _ := strconv.Atoi(value)
+	return flags
+}
+
+// SetSecretFlags sets a *-flags entry, removing the secret it guards
+// from the keyfile if the flags say it mustn't be stored there
+func (keyFile *KeyFile) SetSecretFlags(section, key string, flags int) {
+	if flags == SecretFlagNone {
+		keyFile.Delete(section, key)
+		return
+	}
+	keyFile.Set(section, key, strconv.Itoa(flags))
+	if flags&(SecretFlagAgentOwned|SecretFlagNotSaved) != 0 {
+		keyFile.Delete(section, strings.TrimSuffix(key, "-flags"))
+	}
+}
+
+// eapConfig reads the 802-1x section
+func (keyFile *KeyFile) eapConfig() *wifimanager.EAPConfig {
+	eap := &wifimanager.EAPConfig{}
+	methods, _ := keyFile.Get("802-1x", "eap")
+	if list := splitList(methods); len(list) > 0 {
+		eap.Method = strings.ToUpper(list[0])
+	}
+	eap.Identity, _ = keyFile.Get("802-1x", "identity")
+	eap.AnonymousIdentity, _ = keyFile.Get("802-1x", "anonymous-identity")
+	eap.Password, _ = keyFile.Get("802-1x", "password")
+	phase2, _ := keyFile.Get("802-1x", "phase2-auth")
+	if phase2 == "" {
+		phase2, _ = keyFile.Get("802-1x", "phase2-autheap")
+	}
+	eap.Phase2 = strings.ToUpper(phase2)
+	eap.CACert = certPath(keyFile, "ca-cert")
+	eap.ClientCert = certPath(keyFile, "client-cert")
+	eap.PrivateKey = certPath(keyFile, "private-key")
+	eap.ServerDomain, _ = keyFile.Get("802-1x", "domain-suffix-match")
+	return eap
+}
+
+// applyEAP writes the 802-1x section
+func (keyFile *KeyFile) applyEAP(eap *wifimanager.EAPConfig) {
+	keyFile.SetRaw("802-1x", "eap", strings.ToLower(eap.Method)+";")
+	for _, key := range []string{"identity", "anonymous-identity", "password", "phase2-auth", "phase2-autheap", "ca-cert", "client-cert", "private-key", "domain-suffix-match"} {
+		keyFile.Delete("802-1x", key)
+	}
+	for _, entry := range [][2]string{
+		{"identity", eap.Identity},
+		{"anonymous-identity", eap.AnonymousIdentity},
+		{"password", eap.Password},
+		{"phase2-auth", strings.ToLower(eap.Phase2)},
+		{"ca-cert", eap.CACert},
+		{"client-cert", eap.ClientCert},
+		{"private-key", eap.PrivateKey},
+		{"domain-suffix-match", eap.ServerDomain},
+	} {
+		if entry[1] != "" {
+			keyFile.Set("802-1x", entry[0], entry[1])
+		}
+	}
+}
+
+// certPath reads a certificate entry, which may be a plain path or a
+// file:// URI
+func certPath(keyFile *KeyFile, key string) string {
+	value, _ := keyFile.Get("802-1x", key)
+	return strings.TrimPrefix(value, "file://")
+}
+
+// decodeSSID reads an SSID that is either a plain string or a list of
+// byte values, the form NetworkManager uses for SSIDs that aren't
+// valid UTF-8
+func decodeSSID(value string) string {
+	if !byteListRE.MatchString(value) {
+		return value
+	}
+	ssid := []byte{}
+	for _, item := range splitList(value) {
+		char, parseErr := strconv.Atoi(item)
+		if parseErr != nil || char > 255 {
+			return value
+		}
+		ssid = append(ssid, byte(char))
+	}
+	return string(ssid)
+}
+
+// encodeSSID writes an SSID, falling back to a byte list for SSIDs
+// that aren't valid UTF-8 or would be mistaken for one
+func encodeSSID(ssid string) string {
+	if utf8.ValidString(ssid) && !byteListRE.MatchString(ssid) && !strings.ContainsAny(ssid, ";\n\r") {
+		return Escape(ssid)
+	}
+	items := []string{}
+	for _, char := range []byte(ssid) {
+		items = append(items, strconv.Itoa(int(char)))
+	}
+	return strings.Join(items, ";") + ";"
+}
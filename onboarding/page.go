@@ -0,0 +1,53 @@
+package onboarding
+
+import "html/template"
+
+// pageTemplate is the single page of the portal. It refreshes itself
+// while a connection attempt is in progress.
+var pageTemplate = template.Must(template.New("portal").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{if eq .Status.State "connecting"}}<meta http-equiv="refresh" content="2">{{end}}
+<title>WiFi Setup</title>
+<style>
+body { font-family: sans-serif; max-width: 28em; margin: 2em auto; padding: 0 1em; }
+label, select, input, button { display: block; width: 100%; margin-bottom: 1em; }
+.status { padding: 0.5em; border-radius: 4px; background: #eee; }
+.failed { background: #fdd; }
+.connected { background: #dfd; }
+</style>
+</head>
+<body>
+<h1>WiFi Setup</h1>
+{{with .Status}}
+{{if eq .State "connecting"}}<p class="status">Connecting to {{.SSID}}&hellip;</p>{{end}}
+{{if eq .State "connected"}}<p class="status connected">Connected to {{.SSID}}. You can close this page.</p>{{end}}
+{{if eq .State "failed"}}<p class="status failed">Could not connect to {{.SSID}}: {{.Error}}</p>{{end}}
+{{end}}
+{{if ne .Status.State "connected"}}
+<form method="post" action="/connect">
+<label for="ssid">Network</label>
+<input id="ssid" name="ssid" list="networks" autocomplete="off" required>
+<datalist id="networks">
+{{range .Networks}}<option value="{{.SSID}}">{{.SSID}} ({{.RSSI}} dBm)</option>
+{{end}}
+</datalist>
+<label for="password">Password</label>
+<input id="password" name="password" type="password" autocomplete="off">
+<label for="security">Security, for networks not listed</label>
+<select id="security" name="security">
+<option value="">As listed</option>
+<option value="open">Open</option>
+<option value="wpa2">WPA2</option>
+<option value="wpa3">WPA3</option>
+<option value="wpa">WPA</option>
+<option value="wep">WEP</option>
+</select>
+<button type="submit">Connect</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
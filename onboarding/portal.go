@@ -0,0 +1,371 @@
+package onboarding
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ottopress/WifiManager"
+)
+
+const (
+	// StateWaiting means no credentials have been submitted yet
+	StateWaiting = "waiting"
+	// StateConnecting means a connection attempt is in progress
+	StateConnecting = "connecting"
+	// StateConnected means the submitted credentials worked and the
+	// profile has been saved
+	StateConnected = "connected"
+	// StateFailed means the last connection attempt failed
+	StateFailed = "failed"
+)
+
+var (
+	// ErrPortalRunning is returned when starting a portal twice
+	ErrPortalRunning = errors.New("onboarding: portal is already running")
+	// ErrPortalNotRunning is returned when stopping a portal that
+	// hasn't been started
+	ErrPortalNotRunning = errors.New("onboarding: portal is not running")
+	// ErrMissingSSID is returned when credentials are submitted
+	// without a network name
+	ErrMissingSSID = errors.New("onboarding: no network name provided")
+	// ErrMissingSecurity is returned when a security key is submitted
+	// for a network the scan didn't find without naming its security
+	ErrMissingSecurity = errors.New("onboarding: network not found, its security must be provided")
+
+	// ResultPeriod is how long the access point is brought back after
+	// a successful attempt on a single radio, so the phone can rejoin
+	// it and read the result, before the device joins the network for
+	// good
+	ResultPeriod = 30 * time.Second
+
+	// probePaths are the URLs operating systems fetch to detect
+	// captive portals. Redirecting them makes phones open the portal
+	// on their own.
+	probePaths = map[string]bool{
+		"/generate_204":              true,
+		"/gen_204":                   true,
+		"/hotspot-detect.html":       true,
+		"/library/test/success.html": true,
+		"/ncsi.txt":                  true,
+		"/connecttest.txt":           true,
+		"/success.txt":               true,
+	}
+)
+
+// Radio is the set of interface operations the portal relies on.
+// *wifimanager.WifiInterface satisfies it for every backend,
+// including the simulated one.
+type Radio interface {
+	Scan() ([]wifimanager.WifiNetwork, error)
+	StartAccessPoint(accessPoint wifimanager.AccessPoint) error
+	StopAccessPoint() error
+	UpdateNetwork(network wifimanager.WifiNetwork)
+	Connect() error
+}
+
+// Status reports the progress of onboarding back to the portal
+type Status struct {
+	State string `json:"state"`
+	SSID  string `json:"ssid,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Portal brings up a SoftAP and serves a page where the user picks
+// the network the device should join. When the access point and the
+// station radio are the same interface, the access point is stopped
+// for the duration of each connection attempt and brought back up
+// afterwards so the result can be read: for good if the attempt
+// failed, and for ResultPeriod if it worked.
+type Portal struct {
+	AccessPoint  wifimanager.AccessPoint
+	APRadio      Radio
+	StationRadio Radio
+	Profiles     *wifimanager.ProfileStore
+
+	mutex    sync.Mutex
+	networks []wifimanager.WifiNetwork
+	status   Status
+	server   *http.Server
+	stopped  bool
+	done     chan struct{}
+}
+
+// NewPortal creates a portal that hosts the access point and joins
+// the selected network on the same radio
+func NewPortal(radio Radio, accessPoint wifimanager.AccessPoint, profiles *wifimanager.ProfileStore) *Portal {
+	return &Portal{
+		AccessPoint:  accessPoint,
+		APRadio:      radio,
+		StationRadio: radio,
+		Profiles:     profiles,
+		status:       Status{State: StateWaiting},
+		done:         make(chan struct{}),
+	}
+}
+
+// Start scans for networks, brings up the access point and serves
+// the portal on the provided address
+func (portal *Portal) Start(addr string) error {
+	portal.mutex.Lock()
+	defer portal.mutex.Unlock()
+	if portal.server != nil {
+		return ErrPortalRunning
+	}
+	// scan before the access point is up, single radios often can't
+	// scan while hosting one
+	networks, scanErr := portal.StationRadio.Scan()
+	if scanErr == nil {
+		portal.networks = networks
+	}
+	apErr := portal.APRadio.StartAccessPoint(portal.AccessPoint)
+	if apErr != nil {
+		return apErr
+	}
+	listener, listenErr := net.Listen("tcp", addr)
+	if listenErr != nil {
+		portal.APRadio.StopAccessPoint()
+		return listenErr
+	}
+	portal.server = &http.Server{Handler: portal}
+	portal.stopped = false
+	go portal.server.Serve(listener)
+	return nil
+}
+
+// Stop shuts down the portal and its access point. A connection
+// attempt in progress carries on but leaves the access point down.
+func (portal *Portal) Stop() error {
+	portal.mutex.Lock()
+	defer portal.mutex.Unlock()
+	if portal.server == nil {
+		return ErrPortalNotRunning
+	}
+	closeErr := portal.server.Close()
+	portal.server = nil
+	portal.stopped = true
+	apErr := portal.APRadio.StopAccessPoint()
+	if closeErr != nil {
+		return closeErr
+	}
+	if apErr != nil && apErr != wifimanager.ErrAPNotRunning {
+		return apErr
+	}
+	return nil
+}
+
+// Done is closed once the device has joined a network and saved its
+// profile
+func (portal *Portal) Done() <-chan struct{} {
+	return portal.done
+}
+
+// Status returns the progress of onboarding
+func (portal *Portal) Status() Status {
+	portal.mutex.Lock()
+	defer portal.mutex.Unlock()
+	return portal.status
+}
+
+// Networks returns the networks offered on the portal, one entry per
+// SSID using its strongest access point, strongest first. A single
+// radio can't scan while it hosts the access point, so it offers the
+// networks found before the access point came up, while a separate
+// station radio scans again.
+func (portal *Portal) Networks() []wifimanager.WifiNetwork {
+	if portal.APRadio != portal.StationRadio {
+		networks, scanErr := portal.StationRadio.Scan()
+		if scanErr == nil {
+			portal.mutex.Lock()
+			portal.networks = networks
+			portal.mutex.Unlock()
+		}
+	}
+	portal.mutex.Lock()
+	defer portal.mutex.Unlock()
+	bestBySSID := map[string]wifimanager.WifiNetwork{}
+	for _, network := range portal.networks {
+		if network.SSID == "" {
+			continue
+		}
+		best, seen := bestBySSID[network.SSID]
+		if !seen || network.RSSI > best.RSSI {
+			bestBySSID[network.SSID] = network
+		}
+	}
+	unique := []wifimanager.WifiNetwork{}
+	for _, network := range bestBySSID {
+		unique = append(unique, network)
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].RSSI > unique[j].RSSI
+	})
+	return unique
+}
+
+// Submit attempts to join the network with the provided credentials
+// in the background. Progress is reported through Status. The
+// security protocol, such as "wpa2", is taken from the scan and only
+// needs to be named for networks it didn't find, such as hidden ones,
+// that take a security key.
+func (portal *Portal) Submit(ssid, securityKey, security string) error {
+	if ssid == "" {
+		return ErrMissingSSID
+	}
+	network := wifimanager.WifiNetwork{SSID: ssid, SecurityKey: securityKey}
+	if security != "" {
+		protocol, parseErr := wifimanager.ParseSecurity(security)
+		if parseErr != nil {
+			return parseErr
+		}
+		network.Security = []wifimanager.WifiNetworkSecurity{{Protocol: protocol}}
+	}
+	portal.mutex.Lock()
+	defer portal.mutex.Unlock()
+	if portal.status.State == StateConnecting || portal.status.State == StateConnected {
+		return nil
+	}
+	if security == "" {
+		found := false
+		for _, scanned := range portal.networks {
+			if scanned.SSID == ssid {
+				network.Security = scanned.Security
+				found = true
+				break
+			}
+		}
+		if !found && securityKey != "" {
+			return ErrMissingSecurity
+		}
+	}
+	portal.status = Status{State: StateConnecting, SSID: ssid}
+	go portal.attempt(network)
+	return nil
+}
+
+// attempt joins the network and records the outcome. On a single
+// radio the access point is brought back for ResultPeriod after a
+// successful attempt, as the phone lost it along with the portal, and
+// the network is joined again once it is gone. The access point is
+// restarted whenever joining fails, so the portal stays reachable.
+func (portal *Portal) attempt(network wifimanager.WifiNetwork) {
+	sameRadio := portal.APRadio == portal.StationRadio
+	if sameRadio {
+		portal.APRadio.StopAccessPoint()
+	}
+	portal.StationRadio.UpdateNetwork(network)
+	connectErr := portal.StationRadio.Connect()
+	if connectErr == nil && portal.Profiles != nil {
+		connectErr = portal.saveProfile(network)
+	}
+	if connectErr != nil {
+		if sameRadio {
+			portal.restartAccessPoint()
+		}
+		portal.setStatus(Status{State: StateFailed, SSID: network.SSID, Error: connectErr.Error()})
+		return
+	}
+	portal.setStatus(Status{State: StateConnected, SSID: network.SSID})
+	if sameRadio && ResultPeriod > 0 && portal.restartAccessPoint() == nil {
+		time.Sleep(ResultPeriod)
+		portal.APRadio.StopAccessPoint()
+		if rejoinErr := portal.StationRadio.Connect(); rejoinErr != nil {
+			// bring the portal back so the user can try again
+			portal.restartAccessPoint()
+			portal.setStatus(Status{State: StateFailed, SSID: network.SSID, Error: rejoinErr.Error()})
+			return
+		}
+	}
+	close(portal.done)
+}
+
+// saveProfile saves the joined network as a profile, with its PSK in
+// place of the passphrase when the backend of the station radio can
+// join with it
+func (portal *Portal) saveProfile(network wifimanager.WifiNetwork) error {
+	profile := wifimanager.NewProfile(network)
+	if radio, ok := portal.StationRadio.(interface{ Backend() wifimanager.Backend }); ok {
+		if deriveErr := profile.DerivePSKFor(radio.Backend()); deriveErr != nil {
+			return deriveErr
+		}
+	}
+	return portal.Profiles.Save(profile)
+}
+
+// restartAccessPoint brings the access point back up after a
+// connection attempt, unless the portal has been stopped since
+func (portal *Portal) restartAccessPoint() error {
+	portal.mutex.Lock()
+	defer portal.mutex.Unlock()
+	if portal.stopped {
+		return ErrPortalNotRunning
+	}
+	return portal.APRadio.StartAccessPoint(portal.AccessPoint)
+}
+
+// setStatus updates the reported progress
+func (portal *Portal) setStatus(status Status) {
+	portal.mutex.Lock()
+	defer portal.mutex.Unlock()
+	portal.status = status
+}
+
+// ServeHTTP serves the portal page, the JSON endpoints and the
+// captive portal probe redirects
+func (portal *Portal) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	switch {
+	case request.URL.Path == "/" && request.Method == http.MethodGet:
+		portal.servePage(writer)
+	case request.URL.Path == "/networks" && request.Method == http.MethodGet:
+		writeJSON(writer, http.StatusOK, portal.Networks())
+	case request.URL.Path == "/status" && request.Method == http.MethodGet:
+		writeJSON(writer, http.StatusOK, portal.Status())
+	case request.URL.Path == "/connect" && request.Method == http.MethodPost:
+		submitErr := portal.Submit(request.FormValue("ssid"), request.FormValue("password"), request.FormValue("security"))
+		if submitErr != nil {
+			http.Error(writer, submitErr.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(request.Header.Get("Accept"), "application/json") {
+			writeJSON(writer, http.StatusAccepted, portal.Status())
+			return
+		}
+		http.Redirect(writer, request, "/", http.StatusSeeOther)
+	case probePaths[request.URL.Path]:
+		http.Redirect(writer, request, "http://"+localHost(request)+"/", http.StatusFound)
+	default:
+		http.NotFound(writer, request)
+	}
+}
+
+// servePage renders the portal page
+func (portal *Portal) servePage(writer http.ResponseWriter) {
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Header().Set("Cache-Control", "no-store")
+	pageTemplate.Execute(writer, struct {
+		Status   Status
+		Networks []wifimanager.WifiNetwork
+	}{portal.Status(), portal.Networks()})
+}
+
+// writeJSON writes the value as a JSON response
+func writeJSON(writer http.ResponseWriter, code int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(code)
+	json.NewEncoder(writer).Encode(value)
+}
+
+// localHost returns the address the request was received on so probe
+// redirects point at the portal rather than the probed host
+func localHost(request *http.Request) string {
+	if localAddr, ok := request.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		return localAddr.String()
+	}
+	return request.Host
+}
@@ -0,0 +1,194 @@
+package onboarding
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ottopress/WifiManager"
+)
+
+// newTestPortal starts a portal on a single simulated radio in range
+// of two access points of the same network
+func newTestPortal(t *testing.T) (*Portal, *wifimanager.SimulatedBackend) {
+	t.Helper()
+	ResultPeriod = 0
+	backend := wifimanager.NewSimulatedBackend("sim0")
+	wpa2 := []wifimanager.WifiNetworkSecurity{{Protocol: wifimanager.SecurityWPA2}}
+	backend.AddNetwork(wifimanager.WifiNetwork{SSID: "Home", BSSID: "02:11:22:33:44:02", RSSI: -61, Security: wpa2, SecurityKey: "password1"})
+	backend.AddNetwork(wifimanager.WifiNetwork{SSID: "Home", BSSID: "02:11:22:33:44:01", RSSI: -48, Security: wpa2, SecurityKey: "password1"})
+	wifiInterfaces, ifaceErr := backend.Interfaces()
+	if ifaceErr != nil {
+		t.Fatal(ifaceErr)
+	}
+	accessPoint := wifimanager.AccessPoint{SSID: "Setup", Security: wifimanager.SecurityWPA2, SecurityKey: "onboarding"}
+	profiles := wifimanager.NewProfileStore(filepath.Join(t.TempDir(), "profiles.json"))
+	portal := NewPortal(&wifiInterfaces[0], accessPoint, profiles)
+	if startErr := portal.Start("127.0.0.1:0"); startErr != nil {
+		t.Fatal(startErr)
+	}
+	t.Cleanup(func() { portal.Stop() })
+	return portal, backend
+}
+
+// waitState waits for the portal to reach the state
+func waitState(t *testing.T, portal *Portal, state string) Status {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := portal.Status(); status.State == state {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("state = %+v, want %s", portal.Status(), state)
+	return Status{}
+}
+
+func TestPortalNetworksUsesScanFromBeforeAccessPoint(t *testing.T) {
+	portal, backend := newTestPortal(t)
+	backend.AddNetwork(wifimanager.WifiNetwork{SSID: "Later", BSSID: "02:11:22:33:44:09", RSSI: -30})
+	networks := portal.Networks()
+	if len(networks) != 1 || networks[0].SSID != "Home" || networks[0].RSSI != -48 {
+		t.Fatalf("networks = %+v, want the strongest Home only", networks)
+	}
+}
+
+func TestPortalConnect(t *testing.T) {
+	portal, backend := newTestPortal(t)
+	form := url.Values{"ssid": {"Home"}, "password": {"password1"}}
+	request := httptest.NewRequest(http.MethodPost, "/connect", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	recorder := httptest.NewRecorder()
+	portal.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("POST /connect = %d %s", recorder.Code, recorder.Body)
+	}
+	select {
+	case <-portal.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("portal not done, status %+v", portal.Status())
+	}
+	connection, _ := backend.Connection("sim0")
+	if connection == nil || connection.BSSID != "02:11:22:33:44:01" {
+		t.Fatalf("connection = %+v, want the strongest access point", connection)
+	}
+	profile, profileErr := portal.Profiles.Get("Home")
+	if profileErr != nil || profile.Security != wifimanager.SecurityWPA2 {
+		t.Fatalf("profile = %+v, %v", profile, profileErr)
+	}
+	psk, _ := wifimanager.DerivePSK("password1", "Home")
+	if profile.SecurityKey != hex.EncodeToString(psk) {
+		t.Errorf("saved key = %q, want the derived PSK", profile.SecurityKey)
+	}
+}
+
+func TestPortalConnectFailureRestartsAccessPoint(t *testing.T) {
+	portal, backend := newTestPortal(t)
+	if submitErr := portal.Submit("Home", "wrong-password", ""); submitErr != nil {
+		t.Fatal(submitErr)
+	}
+	status := waitState(t, portal, StateFailed)
+	if status.Error == "" {
+		t.Errorf("failed status has no error")
+	}
+	if addErr := backend.AddStation("sim0", wifimanager.Station{MAC: "02:00:00:00:00:10"}); addErr != nil {
+		t.Fatalf("access point not back up: %v", addErr)
+	}
+}
+
+func TestPortalSubmitUnknownNetworkNeedsSecurity(t *testing.T) {
+	portal, _ := newTestPortal(t)
+	if submitErr := portal.Submit("Hidden", "password1", ""); submitErr != ErrMissingSecurity {
+		t.Fatalf("Submit error = %v, want ErrMissingSecurity", submitErr)
+	}
+	if submitErr := portal.Submit("Hidden", "password1", "wpa2"); submitErr != nil {
+		t.Fatalf("Submit with security: %v", submitErr)
+	}
+	waitState(t, portal, StateFailed)
+}
+
+func TestPortalRejoinFailureRestartsAccessPoint(t *testing.T) {
+	portal, backend := newTestPortal(t)
+	ResultPeriod = 200 * time.Millisecond
+	defer func() { ResultPeriod = 0 }()
+	if submitErr := portal.Submit("Home", "password1", ""); submitErr != nil {
+		t.Fatal(submitErr)
+	}
+	waitState(t, portal, StateConnected)
+	// the network goes out of range while the results are shown, so
+	// joining it again fails
+	backend.RemoveNetwork("02:11:22:33:44:01")
+	backend.RemoveNetwork("02:11:22:33:44:02")
+	status := waitState(t, portal, StateFailed)
+	if status.Error == "" {
+		t.Errorf("failed status has no error")
+	}
+	if addErr := backend.AddStation("sim0", wifimanager.Station{MAC: "02:00:00:00:00:10"}); addErr != nil {
+		t.Fatalf("access point not back up: %v", addErr)
+	}
+	select {
+	case <-portal.Done():
+		t.Fatal("portal is done after failing to rejoin")
+	default:
+	}
+}
+
+// blockingRadio holds connection attempts until released
+type blockingRadio struct {
+	Radio
+	connecting chan struct{}
+	release    chan struct{}
+}
+
+// Connect signals the attempt and waits for its release
+func (radio *blockingRadio) Connect() error {
+	radio.connecting <- struct{}{}
+	<-radio.release
+	return radio.Radio.Connect()
+}
+
+func TestPortalStopDuringAttemptKeepsAccessPointDown(t *testing.T) {
+	portal, backend := newTestPortal(t)
+	radio := &blockingRadio{Radio: portal.APRadio, connecting: make(chan struct{}), release: make(chan struct{})}
+	portal.APRadio, portal.StationRadio = radio, radio
+	if submitErr := portal.Submit("Home", "wrong-password", ""); submitErr != nil {
+		t.Fatal(submitErr)
+	}
+	<-radio.connecting
+	if stopErr := portal.Stop(); stopErr != nil {
+		t.Fatal(stopErr)
+	}
+	close(radio.release)
+	waitState(t, portal, StateFailed)
+	if addErr := backend.AddStation("sim0", wifimanager.Station{MAC: "02:00:00:00:00:10"}); addErr != wifimanager.ErrAPNotRunning {
+		t.Fatalf("AddStation error = %v, want the access point down", addErr)
+	}
+}
+
+func TestPortalStopDuringResultPeriodKeepsAccessPointDown(t *testing.T) {
+	portal, backend := newTestPortal(t)
+	ResultPeriod = 200 * time.Millisecond
+	defer func() { ResultPeriod = 0 }()
+	if submitErr := portal.Submit("Home", "password1", ""); submitErr != nil {
+		t.Fatal(submitErr)
+	}
+	waitState(t, portal, StateConnected)
+	if stopErr := portal.Stop(); stopErr != nil {
+		t.Fatal(stopErr)
+	}
+	// joining again after the results fails, which would otherwise
+	// bring the access point back
+	backend.RemoveNetwork("02:11:22:33:44:01")
+	backend.RemoveNetwork("02:11:22:33:44:02")
+	waitState(t, portal, StateFailed)
+	if addErr := backend.AddStation("sim0", wifimanager.Station{MAC: "02:00:00:00:00:10"}); addErr != wifimanager.ErrAPNotRunning {
+		t.Fatalf("AddStation error = %v, want the access point down", addErr)
+	}
+}
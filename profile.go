@@ -0,0 +1,210 @@
+package wifimanager
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// MACRandomDefault leaves MAC address randomization up to the
+	// system
+	MACRandomDefault int = iota
+	// MACRandomNever always uses the permanent MAC address
+	MACRandomNever
+	// MACRandomAlways uses a random MAC address for the network
+	MACRandomAlways
+)
+
+var (
+	// ErrMissingProfile is returned if no saved profile exists for
+	// the requested SSID
+	ErrMissingProfile = errors.New("wifi: no profile found with provided name")
+)
+
+// Profile represents a saved WiFi network
+type Profile struct {
+	SSID             string     `json:"ssid"`
+	Security         int        `json:"security"`
+	SecurityKey      string     `json:"security_key,omitempty"`
+	Hidden           bool       `json:"hidden,omitempty"`
+	AutoJoin         bool       `json:"auto_join"`
+	Priority         int        `json:"priority,omitempty"`
+	EAP              *EAPConfig `json:"eap,omitempty"`
+	MACRandomization int        `json:"mac_randomization,omitempty"`
+}
+
+// EAPConfig holds the 802.1x settings of a WPA/WPA2 Enterprise
+// network
+type EAPConfig struct {
+	Method            string `json:"method"`
+	Identity          string `json:"identity,omitempty"`
+	AnonymousIdentity string `json:"anonymous_identity,omitempty"`
+	Password          string `json:"password,omitempty"`
+	Phase2            string `json:"phase2,omitempty"`
+	CACert            string `json:"ca_cert,omitempty"`
+	ClientCert        string `json:"client_cert,omitempty"`
+	PrivateKey        string `json:"private_key,omitempty"`
+	ServerDomain      string `json:"server_domain,omitempty"`
+}
+
+// ProfileStore persists profiles as a JSON file
+type ProfileStore struct {
+	Path  string
+	mutex sync.Mutex
+}
+
+// NewProfile builds a profile from a network, taking the security
+// protocol of its first security configuration
+func NewProfile(network WifiNetwork) Profile {
+	profile := Profile{
+		SSID:        network.SSID,
+		Security:    SecurityNone,
+		SecurityKey: network.SecurityKey,
+		AutoJoin:    true,
+	}
+	if len(network.Security) > 0 {
+		profile.Security = network.Security[0].Protocol
+	}
+	return profile
+}
+
+// Network returns the WifiNetwork the profile describes, ready to be
+// passed to WifiInterface.UpdateNetwork
+func (profile Profile) Network() WifiNetwork {
+	return WifiNetwork{
+		SSID:        profile.SSID,
+		Security:    []WifiNetworkSecurity{{Protocol: profile.Security}},
+		SecurityKey: profile.SecurityKey,
+		Hidden:      profile.Hidden,
+	}
+}
+
+// NewProfileStore creates a profile store backed by the file at the
+// provided path
+func NewProfileStore(path string) *ProfileStore {
+	return &ProfileStore{Path: path}
+}
+
+// List returns all saved profiles. A missing file holds no profiles.
+func (store *ProfileStore) List() ([]Profile, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.load()
+}
+
+// Get returns the saved profile for the provided SSID
+func (store *ProfileStore) Get(ssid string) (Profile, error) {
+	profiles, loadErr := store.List()
+	if loadErr != nil {
+		return Profile{}, loadErr
+	}
+	for _, profile := range profiles {
+		if profile.SSID == ssid {
+			return profile, nil
+		}
+	}
+	return Profile{}, ErrMissingProfile
+}
+
+// Save adds the profile, replacing any existing profile for the SSID
+func (store *ProfileStore) Save(profile Profile) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	profiles, loadErr := store.load()
+	if loadErr != nil {
+		return loadErr
+	}
+	replaced := false
+	for index := range profiles {
+		if profiles[index].SSID == profile.SSID {
+			profiles[index] = profile
+			replaced = true
+		}
+	}
+	if !replaced {
+		profiles = append(profiles, profile)
+	}
+	return store.write(profiles)
+}
+
+// Remove deletes the saved profile for the provided SSID
+func (store *ProfileStore) Remove(ssid string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	profiles, loadErr := store.load()
+	if loadErr != nil {
+		return loadErr
+	}
+	remaining := []Profile{}
+	for _, profile := range profiles {
+		if profile.SSID != ssid {
+			remaining = append(remaining, profile)
+		}
+	}
+	if len(remaining) == len(profiles) {
+		return ErrMissingProfile
+	}
+	return store.write(remaining)
+}
+
+// load reads the profiles from disk. The caller must hold the mutex.
+func (store *ProfileStore) load() ([]Profile, error) {
+	profiles := []Profile{}
+	data, readErr := ioutil.ReadFile(store.Path)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return profiles, nil
+		}
+		return nil, readErr
+	}
+	unmarshalErr := json.Unmarshal(data, &profiles)
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+	return profiles, nil
+}
+
+// write replaces the profiles on disk. The file holds security keys so
+// it is only readable by its owner, and it is written to a fresh
+// temporary file first so a crash can't leave it truncated. The caller
+// must hold the mutex.
+func (store *ProfileStore) write(profiles []Profile) error {
+	data, marshalErr := json.MarshalIndent(profiles, "", "\t")
+	if marshalErr != nil {
+		return marshalErr
+	}
+	tempFile, createErr := ioutil.TempFile(filepath.Dir(store.Path), "."+filepath.Base(store.Path)+".")
+	if createErr != nil {
+		return createErr
+	}
+	_, writeErr := tempFile.Write(data)
+	closeErr := tempFile.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		os.Remove(tempFile.Name())
+		return writeErr
+	}
+	return os.Rename(tempFile.Name(), store.Path)
+}
+
+// NewUUID generates a random version 4 UUID, as used to identify
+// profiles in the NetworkManager keyfiles and configuration profiles
+// they are exported to
+func NewUUID() (string, error) {
+	uuid := make([]byte, 16)
+	_, randErr := rand.Read(uuid)
+	if randErr != nil {
+		return "", randErr
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]), nil
+}
@@ -0,0 +1,157 @@
+package wifimanager
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+)
+
+const (
+	// PassphraseMinLength is the shortest WPA passphrase allowed
+	PassphraseMinLength = 8
+	// PassphraseMaxLength is the longest WPA passphrase allowed. Keys
+	// of 64 hex digits are raw PSKs rather than passphrases.
+	PassphraseMaxLength = 63
+	// PSKLength is the length in bytes of a WPA pre-shared key
+	PSKLength = 32
+	// pskIterations is the PBKDF2 iteration count IEEE 802.11i uses
+	pskIterations = 4096
+)
+
+var (
+	// ErrInvalidPassphrase is returned when a WPA security key is
+	// neither a valid passphrase nor a raw PSK
+	ErrInvalidPassphrase = errors.New("wifi: passphrase must be 8 to 63 printable ASCII characters or 64 hex digits")
+	// ErrRawPSK is returned when connecting to a network that can't be
+	// joined with a raw PSK, such as a WPA3 network, which needs the
+	// passphrase itself
+	ErrRawPSK = errors.New("wifi: network can't be joined with a raw psk")
+)
+
+// RawPSKBackend is implemented by backends that can join WPA/WPA2
+// personal networks with a raw PSK in place of the passphrase
+type RawPSKBackend interface {
+	SupportsRawPSK() bool
+}
+
+// SupportsRawPSK returns whether the backend can join WPA/WPA2
+// personal networks with a raw PSK
+func SupportsRawPSK(backend Backend) bool {
+	rawBackend, ok := backend.(RawPSKBackend)
+	return ok && rawBackend.SupportsRawPSK()
+}
+
+// ValidatePassphrase returns whether the security key is usable for a
+// WPA/WPA2 personal network: 8 to 63 printable ASCII characters, or a
+// raw PSK of 64 hex digits
+func ValidatePassphrase(key string) error {
+	if IsRawPSK(key) {
+		return nil
+	}
+	if len(key) < PassphraseMinLength || len(key) > PassphraseMaxLength {
+		return ErrInvalidPassphrase
+	}
+	for index := 0; index < len(key); index++ {
+		if key[index] < 0x20 || key[index] > 0x7e {
+			return ErrInvalidPassphrase
+		}
+	}
+	return nil
+}
+
+// IsRawPSK returns whether the security key is a raw PSK of 64 hex
+// digits rather than a passphrase
+func IsRawPSK(key string) bool {
+	if len(key) != PSKLength*2 {
+		return false
+	}
+	_, decodeErr := hex.DecodeString(key)
+	return decodeErr == nil
+}
+
+// DerivePSK derives the 256-bit pre-shared key of a WPA/WPA2 personal
+// network from its passphrase and SSID using PBKDF2-SHA1, as defined by
+// IEEE 802.11i. A key that already is a raw PSK is decoded as is.
+func DerivePSK(passphrase, ssid string) ([]byte, error) {
+	validateErr := ValidatePassphrase(passphrase)
+	if validateErr != nil {
+		return nil, validateErr
+	}
+	if IsRawPSK(passphrase) {
+		return hex.DecodeString(passphrase)
+	}
+	return pbkdf2SHA1([]byte(passphrase), []byte(ssid), pskIterations, PSKLength), nil
+}
+
+// DerivePSK replaces the network's passphrase with the hex encoded PSK
+// derived from it, so only the derived key is handed to the backend.
+// WPA3 networks keep their passphrase as SAE needs it.
+func (wifiNetwork *WifiNetwork) DerivePSK() error {
+	if len(wifiNetwork.Security) == 0 {
+		return nil
+	}
+	protocol := wifiNetwork.Security[0].Protocol
+	if protocol != SecurityWPA && protocol != SecurityWPA2 {
+		return nil
+	}
+	psk, deriveErr := DerivePSK(wifiNetwork.SecurityKey, wifiNetwork.SSID)
+	if deriveErr != nil {
+		return deriveErr
+	}
+	wifiNetwork.SecurityKey = hex.EncodeToString(psk)
+	return nil
+}
+
+// DerivePSK replaces the profile's passphrase with the hex encoded PSK
+// derived from it so the passphrase itself doesn't have to be stored.
+// WPA3 and enterprise profiles are left unchanged.
+func (profile *Profile) DerivePSK() error {
+	if profile.EAP != nil || (profile.Security != SecurityWPA && profile.Security != SecurityWPA2) {
+		return nil
+	}
+	psk, deriveErr := DerivePSK(profile.SecurityKey, profile.SSID)
+	if deriveErr != nil {
+		return deriveErr
+	}
+	profile.SecurityKey = hex.EncodeToString(psk)
+	return nil
+}
+
+// DerivePSKFor derives the PSK of the profile when the backend can
+// join with it, so that profiles saved for the backend don't hold the
+// passphrase. Other backends need the passphrase itself.
+func (profile *Profile) DerivePSKFor(backend Backend) error {
+	if !SupportsRawPSK(backend) {
+		return nil
+	}
+	return profile.DerivePSK()
+}
+
+// pbkdf2SHA1 implements PBKDF2 from RFC 2898 with HMAC-SHA1 as the
+// pseudorandom function
+func pbkdf2SHA1(password, salt []byte, iterations, keyLength int) []byte {
+	prf := hmac.New(sha1.New, password)
+	key := []byte{}
+	counter := make([]byte, 4)
+	for block := uint32(1); len(key) < keyLength; block++ {
+		binary.BigEndian.PutUint32(counter, block)
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter)
+		sum := prf.Sum(nil)
+		result := make([]byte, len(sum))
+		copy(result, sum)
+		for iteration := 1; iteration < iterations; iteration++ {
+			prf.Reset()
+			prf.Write(sum)
+			sum = prf.Sum(sum[:0])
+			for index := range result {
+				result[index] ^= sum[index]
+			}
+		}
+		key = append(key, result...)
+	}
+	return key[:keyLength]
+}
@@ -0,0 +1,97 @@
+package wifimanager
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestDerivePSK(t *testing.T) {
+	// test vectors from IEEE 802.11i-2004 annex H.4
+	tests := []struct {
+		passphrase string
+		ssid       string
+		psk        string
+	}{
+		{"password", "IEEE", "f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e"},
+		{"ThisIsAPassword", "ThisIsASSID", "0dc0d6eb90555ed6419756b9a15ec3e3209b63df707dd508d14581f8982721af"},
+		{strings.Repeat("a", 32), strings.Repeat("Z", 32), "becb93866bb8c3832cb777c2f559807c8c59afcb6eae734885001300a981cc62"},
+	}
+	for _, test := range tests {
+		psk, deriveErr := DerivePSK(test.passphrase, test.ssid)
+		if deriveErr != nil {
+			t.Fatalf("DerivePSK(%q, %q): %v", test.passphrase, test.ssid, deriveErr)
+		}
+		if encoded := hex.EncodeToString(psk); encoded != test.psk {
+			t.Errorf("DerivePSK(%q, %q) = %s, want %s", test.passphrase, test.ssid, encoded, test.psk)
+		}
+	}
+}
+
+func TestDerivePSKRawKey(t *testing.T) {
+	raw := "F42C6FC52DF0EBEF9EBB4B90B38A5F902E83FE1B135A70E23AED762E9710A12E"
+	psk, deriveErr := DerivePSK(raw, "ignored")
+	if deriveErr != nil {
+		t.Fatal(deriveErr)
+	}
+	if encoded := hex.EncodeToString(psk); encoded != strings.ToLower(raw) {
+		t.Fatalf("raw psk decoded to %s", encoded)
+	}
+}
+
+func TestValidatePassphrase(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"12345678", true},
+		{strings.Repeat("x", 63), true},
+		{strings.Repeat("ab", 32), true},
+		{"1234567", false},
+		{strings.Repeat("x", 64), false},
+		{"tab\tpassword", false},
+		{"pässword", false},
+	}
+	for _, test := range tests {
+		validateErr := ValidatePassphrase(test.key)
+		if (validateErr == nil) != test.valid {
+			t.Errorf("ValidatePassphrase(%q) = %v, want valid %v", test.key, validateErr, test.valid)
+		}
+		if !test.valid && validateErr != ErrInvalidPassphrase {
+			t.Errorf("ValidatePassphrase(%q) = %v, want ErrInvalidPassphrase", test.key, validateErr)
+		}
+	}
+}
+
+func TestNetworkDerivePSK(t *testing.T) {
+	network := WifiNetwork{SSID: "IEEE", SecurityKey: "password", Security: []WifiNetworkSecurity{{Protocol: SecurityWPA2}}}
+	if deriveErr := network.DerivePSK(); deriveErr != nil {
+		t.Fatal(deriveErr)
+	}
+	if network.SecurityKey != "f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e" {
+		t.Errorf("security key = %s", network.SecurityKey)
+	}
+	sae := WifiNetwork{SSID: "IEEE", SecurityKey: "password", Security: []WifiNetworkSecurity{{Protocol: SecurityWPA3}}}
+	if deriveErr := sae.DerivePSK(); deriveErr != nil || sae.SecurityKey != "password" {
+		t.Errorf("WPA3 security key = %q, %v", sae.SecurityKey, deriveErr)
+	}
+}
+
+func TestProfileDerivePSKFor(t *testing.T) {
+	tests := []struct {
+		backend Backend
+		key     string
+	}{
+		{backend: NewSimulatedBackend("sim0"), key: "f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e"},
+		{backend: &recordingBackend{}, key: "password"},
+	}
+	for _, test := range tests {
+		profile := Profile{SSID: "IEEE", Security: SecurityWPA2, SecurityKey: "password"}
+		if deriveErr := profile.DerivePSKFor(test.backend); deriveErr != nil {
+			t.Fatal(deriveErr)
+		}
+		if profile.SecurityKey != test.key {
+			t.Errorf("%s: security key = %s, want %s", test.backend.Name(), profile.SecurityKey, test.key)
+		}
+	}
+}
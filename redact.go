@@ -0,0 +1,140 @@
+package wifimanager
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// RedactedText replaces secrets in redacted text
+	RedactedText = "[REDACTED]"
+	// secretMinLength is the length below which values aren't treated
+	// as secrets, as redacting them would mangle unrelated text
+	secretMinLength = 4
+)
+
+var (
+	// secrets counts the registrations of every value registered as a
+	// secret
+	secrets      = map[string]int{}
+	secretsMutex sync.RWMutex
+)
+
+// redactedError is an error whose message has had secrets removed.
+// The original error is still available to errors.Is and errors.As.
+type redactedError struct {
+	message string
+	err     error
+}
+
+// redactWriter writes to the underlying writer with secrets removed
+type redactWriter struct {
+	writer io.Writer
+}
+
+// RegisterSecret marks the value as a secret so it is removed from any
+// error or log output the library produces, until it is forgotten
+// with ForgetSecret. Every registration should be paired with a
+// deferred ForgetSecret so secrets are only held while a request
+// handles them. Connect registers the security key of the network it
+// is given for as long as it runs.
+func RegisterSecret(secret string) {
+	if len(secret) < secretMinLength {
+		return
+	}
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	for _, variant := range secretVariants(secret) {
+		secrets[variant]++
+	}
+}
+
+// ForgetSecret undoes a RegisterSecret of the value. The value is
+// still treated as a secret while other registrations of it remain,
+// so concurrent requests handling the same secret don't expose it.
+func ForgetSecret(secret string) {
+	if len(secret) < secretMinLength {
+		return
+	}
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	for _, variant := range secretVariants(secret) {
+		secrets[variant]--
+		if secrets[variant] <= 0 {
+			delete(secrets, variant)
+		}
+	}
+}
+
+// secretVariants returns the forms of the secret to redact. Errors
+// often quote values, which escapes unusual characters.
+func secretVariants(secret string) []string {
+	variants := []string{secret}
+	if quoted := strconv.Quote(secret); quoted[1:len(quoted)-1] != secret {
+		variants = append(variants, quoted[1:len(quoted)-1])
+	}
+	return variants
+}
+
+// Redact replaces every registered secret in the text
+func Redact(text string) string {
+	secretsMutex.RLock()
+	defer secretsMutex.RUnlock()
+	if len(secrets) == 0 {
+		return text
+	}
+	// longer secrets go first so one containing another is removed whole
+	ordered := []string{}
+	for secret := range secrets {
+		ordered = append(ordered, secret)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return len(ordered[i]) > len(ordered[j])
+	})
+	for _, secret := range ordered {
+		text = strings.Replace(text, secret, RedactedText, -1)
+	}
+	return text
+}
+
+// RedactError returns the error with every registered secret removed
+// from its message
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := Redact(err.Error())
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{message: message, err: err}
+}
+
+// RedactWriter wraps the writer so every registered secret is removed
+// from what is written to it, for use with log.SetOutput
+func RedactWriter(writer io.Writer) io.Writer {
+	return &redactWriter{writer: writer}
+}
+
+// Error returns the redacted message
+func (redacted *redactedError) Error() string {
+	return redacted.message
+}
+
+// Unwrap returns the original error
+func (redacted *redactedError) Unwrap() error {
+	return redacted.err
+}
+
+// Write writes the data with secrets removed. The length of the
+// original data is reported so callers don't treat it as a short write.
+func (redacted *redactWriter) Write(data []byte) (int, error) {
+	_, writeErr := io.WriteString(redacted.writer, Redact(string(data)))
+	if writeErr != nil {
+		return 0, writeErr
+	}
+	return len(data), nil
+}
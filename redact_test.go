@@ -0,0 +1,85 @@
+package wifimanager
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	RegisterSecret("correct horse")
+	defer ForgetSecret("correct horse")
+	RegisterSecret("tab\tkey")
+	defer ForgetSecret("tab\tkey")
+	RegisterSecret("abc")
+	defer ForgetSecret("abc")
+
+	text := Redact(`psk "correct horse" rejected, tried "tab\tkey" over abc`)
+	if want := `psk "[REDACTED]" rejected, tried "[REDACTED]" over abc`; text != want {
+		t.Fatalf("Redact = %q, want %q", text, want)
+	}
+}
+
+func TestForgetSecret(t *testing.T) {
+	RegisterSecret("correct horse")
+	RegisterSecret("correct horse")
+	ForgetSecret("correct horse")
+	if text := Redact("correct horse"); text != RedactedText {
+		t.Fatalf("secret still registered once was exposed: %q", text)
+	}
+	ForgetSecret("correct horse")
+	if text := Redact("correct horse"); text != "correct horse" {
+		t.Fatalf("forgotten secret still redacted: %q", text)
+	}
+	secretsMutex.RLock()
+	defer secretsMutex.RUnlock()
+	if len(secrets) != 0 {
+		t.Fatalf("secrets left behind: %d", len(secrets))
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	RegisterSecret("correct horse")
+	defer ForgetSecret("correct horse")
+	cause := errors.New("wrong key correct horse")
+	redacted := RedactError(cause)
+	if redacted.Error() != "wrong key "+RedactedText {
+		t.Errorf("message = %q", redacted.Error())
+	}
+	if !errors.Is(redacted, cause) {
+		t.Error("redacted error doesn't unwrap to its cause")
+	}
+	plain := errors.New("nothing secret")
+	if RedactError(plain) != plain {
+		t.Error("error without secrets was wrapped")
+	}
+}
+
+func TestRedactWriter(t *testing.T) {
+	RegisterSecret("correct horse")
+	defer ForgetSecret("correct horse")
+	var buffer bytes.Buffer
+	data := []byte("joining with correct horse\n")
+	written, writeErr := RedactWriter(&buffer).Write(data)
+	if writeErr != nil || written != len(data) {
+		t.Fatalf("Write = %d, %v", written, writeErr)
+	}
+	if buffer.String() != "joining with "+RedactedText+"\n" {
+		t.Fatalf("wrote %q", buffer.String())
+	}
+}
+
+func TestConnectForgetsSecret(t *testing.T) {
+	wifiInterfaces, ifaceErr := NewSimulatedBackend("wlan0").Interfaces()
+	if ifaceErr != nil {
+		t.Fatal(ifaceErr)
+	}
+	wifiInterface := wifiInterfaces[0]
+	wifiInterface.Connection = WifiNetwork{SSID: "missing", SecurityKey: "correct horse"}
+	if connectErr := wifiInterface.Connect(); connectErr == nil {
+		t.Fatal("connected to a network that doesn't exist")
+	}
+	if text := Redact("correct horse"); text != "correct horse" {
+		t.Fatalf("secret still registered after Connect returned: %q", text)
+	}
+}
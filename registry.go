@@ -0,0 +1,178 @@
+package wifimanager
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ottopress/WifiManager/linux"
+)
+
+var (
+	// RegistryRefreshInterval is how often a watching manager lists the
+	// interfaces of a backend the kernel can't report hotplug for
+	RegistryRefreshInterval = 5 * time.Second
+
+	// ErrWatching is returned when a manager is asked to watch while
+	// it already is
+	ErrWatching = errors.New("wifi: manager is already watching the interfaces")
+)
+
+// Watch fills the registry of the manager with the interfaces of its
+// backend and keeps it up to date until the returned function is
+// called, publishing EventInterfaceAdded and EventInterfaceRemoved as
+// interfaces come and go. The Linux backends are refreshed when
+// rtnetlink reports a link was added or deleted, other backends every
+// RegistryRefreshInterval, as are the Linux ones once rtnetlink stops
+// reporting. The connection and state remembered for an interface are
+// dropped when it goes away.
+func (manager *Manager) Watch() (func(), error) {
+	manager.mutex.Lock()
+	if manager.registry != nil {
+		manager.mutex.Unlock()
+		return nil, ErrWatching
+	}
+	manager.registry = map[string]WifiInterface{}
+	manager.mutex.Unlock()
+	if refreshErr := manager.refresh(); refreshErr != nil {
+		manager.mutex.Lock()
+		manager.registry = nil
+		manager.mutex.Unlock()
+		return nil, refreshErr
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		if _, ok := manager.backend.(EventBackend); ok {
+			if links, stopLinks, linkErr := kernelLink.Subscribe(); linkErr == nil {
+				defer stopLinks()
+				manager.watchLinks(links, done)
+				return
+			}
+		}
+		manager.pollInterfaces(done)
+	}()
+	return func() {
+		manager.mutex.Lock()
+		if manager.registry == nil {
+			manager.mutex.Unlock()
+			return
+		}
+		manager.registry = nil
+		manager.mutex.Unlock()
+		close(done)
+		<-stopped
+	}, nil
+}
+
+// watchLinks refreshes the registry whenever a wireless link it
+// doesn't know of shows up, a link it knows is renamed or deleted.
+// Changes of the flags of a known link and links that sysfs doesn't
+// show as wireless, such as veths and bridges, are ignored. When the
+// subscription ends the registry is polled instead.
+func (manager *Manager) watchLinks(links <-chan linux.RTNetlinkEvent, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case link, open := <-links:
+			if !open {
+				manager.refresh()
+				manager.pollInterfaces(done)
+				return
+			}
+			name, known := manager.knownName(link.Index)
+			switch {
+			case link.Type == "dellink" && known,
+				link.Type == "newlink" && known && name != link.Name,
+				link.Type == "newlink" && !known && sysfs.IsWireless(link.Name):
+				manager.refresh()
+			}
+		}
+	}
+}
+
+// pollInterfaces refreshes the registry every RegistryRefreshInterval
+// until done is closed
+func (manager *Manager) pollInterfaces(done <-chan struct{}) {
+	ticker := time.NewTicker(RegistryRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			manager.refresh()
+		}
+	}
+}
+
+// refresh lists the interfaces of the backend and publishes the
+// differences with the registry. An interface that came back under
+// the same name with another index is reported as removed and added.
+func (manager *Manager) refresh() error {
+	wifiInterfaces, ifaceErr := manager.backend.Interfaces()
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	current := map[string]WifiInterface{}
+	for _, wifiInterface := range wifiInterfaces {
+		current[wifiInterface.Name] = wifiInterface
+	}
+	events := []Event{}
+	manager.mutex.Lock()
+	if manager.registry == nil {
+		manager.mutex.Unlock()
+		return nil
+	}
+	for name, known := range manager.registry {
+		if found, present := current[name]; !present || found.Index != known.Index {
+			delete(manager.connections, name)
+			states.forget(manager.backend.Name(), name)
+			events = append(events, Event{Type: EventInterfaceRemoved, Interface: name})
+		}
+	}
+	for name, found := range current {
+		if known, present := manager.registry[name]; !present || known.Index != found.Index {
+			events = append(events, Event{Type: EventInterfaceAdded, Interface: name})
+		}
+	}
+	manager.registry = current
+	manager.mutex.Unlock()
+	for _, event := range events {
+		manager.publish(event)
+	}
+	return nil
+}
+
+// registered returns the interfaces in the registry ordered by index,
+// and false when the manager isn't watching
+func (manager *Manager) registered() ([]WifiInterface, bool) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if manager.registry == nil {
+		return nil, false
+	}
+	wifiInterfaces := []WifiInterface{}
+	for _, wifiInterface := range manager.registry {
+		wifiInterfaces = append(wifiInterfaces, wifiInterface)
+	}
+	sort.Slice(wifiInterfaces, func(first, second int) bool {
+		return wifiInterfaces[first].Index < wifiInterfaces[second].Index
+	})
+	return wifiInterfaces, true
+}
+
+// knownName returns the name the interface with the index is in the
+// registry under, and false when it isn't in the registry
+func (manager *Manager) knownName(index int) (string, bool) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for name, wifiInterface := range manager.registry {
+		if wifiInterface.Index == index {
+			return name, true
+		}
+	}
+	return "", false
+}
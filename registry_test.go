@@ -0,0 +1,134 @@
+package wifimanager
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ottopress/WifiManager/linux"
+)
+
+func TestWatchLinks(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{"class/net/wlan1/phy80211", "class/net/veth0"} {
+		if mkdirErr := os.MkdirAll(filepath.Join(root, path), 0755); mkdirErr != nil {
+			t.Fatal(mkdirErr)
+		}
+	}
+	previousRoot := sysfs.Root
+	SetSysfsRoot(root)
+	defer SetSysfsRoot(previousRoot)
+
+	backend := &polledBackend{iface: net.Interface{Index: 3, Name: "wlan0"}}
+	manager := NewManager(backend, nil)
+	manager.registry = map[string]WifiInterface{}
+	if refreshErr := manager.refresh(); refreshErr != nil {
+		t.Fatal(refreshErr)
+	}
+	links := make(chan linux.RTNetlinkEvent)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		manager.watchLinks(links, done)
+	}()
+
+	tests := []struct {
+		name    string
+		link    linux.RTNetlinkEvent
+		rename  string
+		refresh bool
+	}{
+		{name: "known link went down", link: linux.RTNetlinkEvent{Type: "newlink", Index: 3, Name: "wlan0"}},
+		{name: "veth added", link: linux.RTNetlinkEvent{Type: "newlink", Index: 9, Name: "veth0", Up: true}},
+		{name: "veth removed", link: linux.RTNetlinkEvent{Type: "dellink", Index: 9, Name: "veth0"}},
+		{name: "wireless link added", link: linux.RTNetlinkEvent{Type: "newlink", Index: 4, Name: "wlan1"}, refresh: true},
+		{name: "known link renamed", link: linux.RTNetlinkEvent{Type: "newlink", Index: 3, Name: "wlp2s0"}, rename: "wlp2s0", refresh: true},
+		{name: "renamed link changed", link: linux.RTNetlinkEvent{Type: "newlink", Index: 3, Name: "wlp2s0", Up: true}},
+		{name: "known link removed", link: linux.RTNetlinkEvent{Type: "dellink", Index: 3, Name: "wlp2s0"}, refresh: true},
+	}
+	listed := 1
+	for _, test := range tests {
+		backend.mutex.Lock()
+		if test.rename != "" {
+			backend.iface.Name = test.rename
+		}
+		backend.mutex.Unlock()
+		links <- test.link
+		// a second event makes sure the first one was handled
+		links <- linux.RTNetlinkEvent{Type: "newlink", Index: 3, Name: backend.iface.Name}
+		if test.refresh {
+			listed++
+		}
+		backend.mutex.Lock()
+		if backend.listed != listed {
+			t.Errorf("%s: interfaces listed %d times, want %d", test.name, backend.listed, listed)
+			listed = backend.listed
+		}
+		backend.mutex.Unlock()
+	}
+	close(done)
+	<-stopped
+
+	if _, known := manager.knownName(3); !known {
+		t.Fatal("interface 3 isn't registered")
+	}
+	if name, _ := manager.knownName(3); name != "wlp2s0" {
+		t.Errorf("interface 3 is registered as %q, want wlp2s0", name)
+	}
+}
+
+func TestWatchLinksClosed(t *testing.T) {
+	previousInterval := RegistryRefreshInterval
+	RegistryRefreshInterval = 10 * time.Millisecond
+	defer func() { RegistryRefreshInterval = previousInterval }()
+
+	backend := &polledBackend{iface: net.Interface{Index: 3, Name: "wlan0"}}
+	manager := NewManager(backend, nil)
+	manager.registry = map[string]WifiInterface{}
+	if refreshErr := manager.refresh(); refreshErr != nil {
+		t.Fatal(refreshErr)
+	}
+	events, unsubscribe := manager.Subscribe()
+	defer unsubscribe()
+	links := make(chan linux.RTNetlinkEvent)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		manager.watchLinks(links, done)
+	}()
+	defer func() {
+		close(done)
+		<-stopped
+	}()
+
+	close(links)
+	timeout := time.After(5 * time.Second)
+	// the hotplug comes after the refresh made when the subscription
+	// ended, so only polling can report it
+	for {
+		backend.mutex.Lock()
+		listed := backend.listed
+		backend.mutex.Unlock()
+		if listed > 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	backend.mutex.Lock()
+	backend.iface = net.Interface{Index: 4, Name: "wlan1"}
+	backend.mutex.Unlock()
+	for {
+		select {
+		case event := <-events:
+			if event.Type == EventInterfaceAdded && event.Interface == "wlan1" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("no event for wlan1 after the link subscription ended")
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package wifimanager
+
+import (
+	"errors"
+
+	"github.com/ottopress/WifiManager/linux"
+)
+
+var (
+	// rfkill reads and changes the rfkill switches of the interfaces
+	// the Linux backends drive
+	rfkill = linux.NewRFKill()
+
+	// ErrHardBlocked is returned when turning on an interface whose
+	// radio is blocked by a hardware switch or the firmware, which no
+	// software can lift
+	ErrHardBlocked = errors.New("wifi: radio is hard blocked by rfkill, check the wireless switch")
+)
+
+// PowerStatus is the power state of an interface along with the rfkill
+// blocks of its radio
+type PowerStatus struct {
+	Powered     bool
+	SoftBlocked bool
+	HardBlocked bool
+}
+
+// PowerStatus returns the power state of the WiFi interface and the
+// rfkill blocks of its radio. Interfaces without an rfkill switch,
+// such as the ones of Mac OS X, are never blocked.
+func (wifiInterface *WifiInterface) PowerStatus() (PowerStatus, error) {
+	powered, statusErr := wifiInterface.Status()
+	if statusErr != nil {
+		return PowerStatus{}, statusErr
+	}
+	status := PowerStatus{Powered: powered}
+	if rfkillSwitch, switchErr := rfkill.InterfaceSwitch(wifiInterface.Name); switchErr == nil {
+		status.SoftBlocked = rfkillSwitch.SoftBlocked
+		status.HardBlocked = rfkillSwitch.HardBlocked
+	}
+	return status, nil
+}
+
+// SetSoftBlocked sets or lifts the rfkill soft block of the radio of
+// the WiFi interface
+func (wifiInterface *WifiInterface) SetSoftBlocked(blocked bool) error {
+	rfkillSwitch, switchErr := rfkill.InterfaceSwitch(wifiInterface.Name)
+	if switchErr != nil {
+		return switchErr
+	}
+	return rfkill.SetSoftBlocked(rfkillSwitch.Index, blocked)
+}
+
+// unblockRFKill lifts the soft block of the radio of the interface so
+// that it can be turned on, failing with ErrHardBlocked when a hard
+// block keeps it off. Interfaces without an rfkill switch are left
+// alone.
+func unblockRFKill(iface string) error {
+	rfkillSwitch, switchErr := rfkill.InterfaceSwitch(iface)
+	if switchErr != nil {
+		return nil
+	}
+	if rfkillSwitch.HardBlocked {
+		return ErrHardBlocked
+	}
+	if !rfkillSwitch.SoftBlocked {
+		return nil
+	}
+	return rfkill.SetSoftBlocked(rfkillSwitch.Index, false)
+}
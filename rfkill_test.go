@@ -0,0 +1,63 @@
+package wifimanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ottopress/WifiManager/linux"
+)
+
+// setTestRFKill points the rfkill reader at a fake sysfs tree where
+// wlan0 has a switch with the provided blocks, restoring it when the
+// test ends
+func setTestRFKill(t *testing.T, soft, hard bool) string {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, "class/net/wlan0/phy80211/rfkill0")
+	if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
+		t.Fatal(mkdirErr)
+	}
+	values := map[string]string{"index": "0", "type": "wlan", "soft": "0", "hard": "0"}
+	if soft {
+		values["soft"] = "1"
+	}
+	if hard {
+		values["hard"] = "1"
+	}
+	for name, value := range values {
+		if writeErr := ioutil.WriteFile(filepath.Join(dir, name), []byte(value+"\n"), 0644); writeErr != nil {
+			t.Fatal(writeErr)
+		}
+	}
+	if mkdirErr := os.MkdirAll(filepath.Join(root, "class/rfkill"), 0755); mkdirErr != nil {
+		t.Fatal(mkdirErr)
+	}
+	if linkErr := os.Symlink(dir, filepath.Join(root, "class/rfkill/rfkill0")); linkErr != nil {
+		t.Fatal(linkErr)
+	}
+	previous := rfkill
+	rfkill = &linux.RFKill{Root: root, Device: filepath.Join(root, "missing")}
+	t.Cleanup(func() { rfkill = previous })
+	return dir
+}
+
+func TestUnblockRFKill(t *testing.T) {
+	setTestRFKill(t, true, true)
+	if unblockErr := unblockRFKill("wlan0"); unblockErr != ErrHardBlocked {
+		t.Errorf("hard blocked error = %v, want ErrHardBlocked", unblockErr)
+	}
+
+	dir := setTestRFKill(t, true, false)
+	if unblockErr := unblockRFKill("wlan0"); unblockErr != nil {
+		t.Fatal(unblockErr)
+	}
+	if soft, _ := ioutil.ReadFile(filepath.Join(dir, "soft")); string(soft) != "0" {
+		t.Errorf("soft = %q, want the block lifted", soft)
+	}
+	// interfaces without a switch are left alone
+	if unblockErr := unblockRFKill("wlan1"); unblockErr != nil {
+		t.Errorf("wlan1: %v", unblockErr)
+	}
+}
@@ -0,0 +1,110 @@
+package wifimanager
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ScannedNetwork is a network found by scanning on several interfaces
+// at once. The network is the one seen by the interface with the
+// strongest signal, and Interfaces lists every interface that saw it.
+type ScannedNetwork struct {
+	WifiNetwork
+	Interfaces []string
+}
+
+// ScanErrors holds the scans of ScanAll that failed by interface name
+type ScanErrors map[string]error
+
+// Error lists the failed scans by interface name
+func (scanErrors ScanErrors) Error() string {
+	names := []string{}
+	for name := range scanErrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	messages := []string{}
+	for _, name := range names {
+		messages = append(messages, name+": "+scanErrors[name].Error())
+	}
+	return "wifi: scan failed on " + strings.Join(messages, ", ")
+}
+
+// ScanAll scans on every provided interface at once and merges the
+// networks they found by BSSID. When some scans fail the networks of
+// the others are still returned along with ScanErrors, which is
+// returned alone when every scan failed.
+func ScanAll(wifiInterfaces []WifiInterface) ([]ScannedNetwork, error) {
+	results, scanErrors := scanEach(wifiInterfaces)
+	return mergeScans(wifiInterfaces, results, scanErrors)
+}
+
+// scanEach scans on every interface concurrently, returning the
+// networks found and the errors by interface name
+func scanEach(wifiInterfaces []WifiInterface) (map[string][]WifiNetwork, ScanErrors) {
+	results := map[string][]WifiNetwork{}
+	scanErrors := ScanErrors{}
+	var mutex sync.Mutex
+	var group sync.WaitGroup
+	for index := range wifiInterfaces {
+		group.Add(1)
+		go func(wifiInterface *WifiInterface) {
+			defer group.Done()
+			networks, scanErr := wifiInterface.Scan()
+			mutex.Lock()
+			defer mutex.Unlock()
+			if scanErr != nil {
+				scanErrors[wifiInterface.Name] = scanErr
+				return
+			}
+			results[wifiInterface.Name] = networks
+		}(&wifiInterfaces[index])
+	}
+	group.Wait()
+	return results, scanErrors
+}
+
+// mergeScans merges the networks found on every interface, in the
+// order of the interfaces. Networks are told apart by BSSID, or by
+// SSID when the backend doesn't report BSSIDs.
+func mergeScans(wifiInterfaces []WifiInterface, results map[string][]WifiNetwork, scanErrors ScanErrors) ([]ScannedNetwork, error) {
+	if len(scanErrors) > 0 && len(results) == 0 {
+		return nil, scanErrors
+	}
+	merged := []ScannedNetwork{}
+	positions := map[string]int{}
+	for _, wifiInterface := range wifiInterfaces {
+		for _, network := range results[wifiInterface.Name] {
+			key := strings.ToLower(network.BSSID)
+			if key == "" {
+				key = "ssid:" + network.SSID
+			}
+			position, seen := positions[key]
+			if !seen {
+				positions[key] = len(merged)
+				merged = append(merged, ScannedNetwork{WifiNetwork: network, Interfaces: []string{wifiInterface.Name}})
+				continue
+			}
+			scanned := &merged[position]
+			scanned.Interfaces = appendMissing(scanned.Interfaces, wifiInterface.Name)
+			if network.RSSI > scanned.RSSI {
+				scanned.WifiNetwork = network
+			}
+		}
+	}
+	if len(scanErrors) > 0 {
+		return merged, scanErrors
+	}
+	return merged, nil
+}
+
+// appendMissing appends the value unless the list already holds it
+func appendMissing(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
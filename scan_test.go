@@ -0,0 +1,102 @@
+package wifimanager
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+// testInterfaces returns interfaces with the provided names and no backend
+func testInterfaces(names ...string) []WifiInterface {
+	wifiInterfaces := []WifiInterface{}
+	for _, name := range names {
+		wifiInterfaces = append(wifiInterfaces, WifiInterface{Interface: net.Interface{Name: name}})
+	}
+	return wifiInterfaces
+}
+
+func TestMergeScans(t *testing.T) {
+	results := map[string][]WifiNetwork{
+		"wlan0": {
+			{SSID: "home", BSSID: "02:aa:bb:cc:dd:01", RSSI: -70},
+			{SSID: "cafe", RSSI: -60},
+			{SSID: "work", BSSID: "02:aa:bb:cc:dd:02", RSSI: -50},
+		},
+		"wlan1": {
+			{SSID: "home", BSSID: "02:AA:BB:CC:DD:01", RSSI: -40, Channel: 36},
+			{SSID: "cafe", RSSI: -80},
+			{SSID: "cafe", BSSID: "02:aa:bb:cc:dd:03", RSSI: -55},
+			{SSID: "work", BSSID: "02:aa:bb:cc:dd:02", RSSI: -65},
+		},
+	}
+	merged, mergeErr := mergeScans(testInterfaces("wlan0", "wlan1"), results, ScanErrors{})
+	if mergeErr != nil {
+		t.Fatal(mergeErr)
+	}
+	expected := []ScannedNetwork{
+		{WifiNetwork: WifiNetwork{SSID: "home", BSSID: "02:AA:BB:CC:DD:01", RSSI: -40, Channel: 36}, Interfaces: []string{"wlan0", "wlan1"}},
+		{WifiNetwork: WifiNetwork{SSID: "cafe", RSSI: -60}, Interfaces: []string{"wlan0", "wlan1"}},
+		{WifiNetwork: WifiNetwork{SSID: "work", BSSID: "02:aa:bb:cc:dd:02", RSSI: -50}, Interfaces: []string{"wlan0", "wlan1"}},
+		{WifiNetwork: WifiNetwork{SSID: "cafe", BSSID: "02:aa:bb:cc:dd:03", RSSI: -55}, Interfaces: []string{"wlan1"}},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("mergeScans =\n%+v\nwant\n%+v", merged, expected)
+	}
+}
+
+func TestMergeScansDuplicates(t *testing.T) {
+	results := map[string][]WifiNetwork{
+		"wlan0": {
+			{SSID: "home", BSSID: "02:aa:bb:cc:dd:01", RSSI: -70},
+			{SSID: "home", BSSID: "02:aa:bb:cc:dd:01", RSSI: -60},
+		},
+	}
+	merged, _ := mergeScans(testInterfaces("wlan0"), results, ScanErrors{})
+	if len(merged) != 1 {
+		t.Fatalf("mergeScans returned %d networks, want 1", len(merged))
+	}
+	if merged[0].RSSI != -60 || !reflect.DeepEqual(merged[0].Interfaces, []string{"wlan0"}) {
+		t.Errorf("mergeScans = %+v, want RSSI -60 seen by wlan0 once", merged[0])
+	}
+}
+
+func TestScanAllErrors(t *testing.T) {
+	backend := NewSimulatedBackend("sim0", "sim1")
+	backend.AddNetwork(WifiNetwork{SSID: "home", BSSID: "02:11:22:33:44:01", RSSI: -48})
+	wifiInterfaces, ifaceErr := backend.Interfaces()
+	if ifaceErr != nil {
+		t.Fatal(ifaceErr)
+	}
+
+	merged, scanErr := ScanAll(wifiInterfaces)
+	if scanErr != nil {
+		t.Fatalf("ScanAll = %v, want no error", scanErr)
+	}
+	if len(merged) != 1 || !reflect.DeepEqual(merged[0].Interfaces, []string{"sim0", "sim1"}) {
+		t.Fatalf("ScanAll = %+v, want home seen by sim0 and sim1", merged)
+	}
+
+	backend.Down("sim1")
+	merged, scanErr = ScanAll(wifiInterfaces)
+	scanErrors, ok := scanErr.(ScanErrors)
+	if !ok || len(scanErrors) != 1 || scanErrors["sim1"] != ErrSimPowerOff {
+		t.Fatalf("ScanAll with sim1 off error = %v, want ScanErrors for sim1", scanErr)
+	}
+	if len(merged) != 1 || !reflect.DeepEqual(merged[0].Interfaces, []string{"sim0"}) {
+		t.Errorf("ScanAll with sim1 off = %+v, want home seen by sim0", merged)
+	}
+
+	backend.Down("sim0")
+	merged, scanErr = ScanAll(wifiInterfaces)
+	scanErrors, ok = scanErr.(ScanErrors)
+	if !ok || len(scanErrors) != 2 {
+		t.Fatalf("ScanAll with both off error = %v, want ScanErrors for both", scanErr)
+	}
+	if merged != nil {
+		t.Errorf("ScanAll with both off = %+v, want nil", merged)
+	}
+	expected := "wifi: scan failed on sim0: " + ErrSimPowerOff.Error() + ", sim1: " + ErrSimPowerOff.Error()
+	if scanErr.Error() != expected {
+		t.Errorf("error = %q, want %q", scanErr.Error(), expected)
+	}
+}
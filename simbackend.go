@@ -0,0 +1,346 @@
+package wifimanager
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrSimMissingIface is returned when the simulated backend is
+	// asked about an interface it doesn't have
+	ErrSimMissingIface = errors.New("simulated: no interface found with provided name")
+	// ErrSimPowerOff is returned when an operation needs the simulated
+	// interface to be powered on
+	ErrSimPowerOff = errors.New("simulated: interface is powered off")
+	// ErrSimAuth is returned when the security key provided while
+	// connecting doesn't match the simulated network's key
+	ErrSimAuth = errors.New("simulated: authentication failed")
+)
+
+// SimulatedBackend is an in-memory Backend that can be developed and
+// tested against without any WiFi hardware. The SecurityKey of each
+// network in range is the key required to join it.
+type SimulatedBackend struct {
+	mutex      sync.Mutex
+	networks   []WifiNetwork
+	interfaces []*simulatedInterface
+}
+
+// simulatedInterface holds the state of a single simulated radio
+type simulatedInterface struct {
+	iface       net.Interface
+	powered     bool
+	connection  *WifiNetwork
+	accessPoint *AccessPoint
+	stations    []Station
+}
+
+// NewSimulatedBackend creates a simulated backend with a powered on
+// interface for each of the provided names
+func NewSimulatedBackend(names ...string) *SimulatedBackend {
+	simBackend := &SimulatedBackend{}
+	for index, name := range names {
+		simBackend.interfaces = append(simBackend.interfaces, &simulatedInterface{
+			iface: net.Interface{
+				Index:        index + 1,
+				MTU:          1500,
+				Name:         name,
+				HardwareAddr: net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, byte(index + 1)},
+				Flags:        net.FlagUp | net.FlagBroadcast | net.FlagMulticast,
+			},
+			powered: true,
+		})
+	}
+	return simBackend
+}
+
+// AddNetwork puts a network in range of every simulated interface
+func (simBackend *SimulatedBackend) AddNetwork(network WifiNetwork) {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simBackend.networks = append(simBackend.networks, network)
+}
+
+// RemoveNetwork takes the access point with the provided BSSID out of
+// range, disconnecting any interface associated with it
+func (simBackend *SimulatedBackend) RemoveNetwork(bssid string) {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	networks := []WifiNetwork{}
+	for _, network := range simBackend.networks {
+		if network.BSSID != bssid {
+			networks = append(networks, network)
+		}
+	}
+	simBackend.networks = networks
+	for _, simIface := range simBackend.interfaces {
+		if simIface.connection != nil && simIface.connection.BSSID == bssid {
+			simIface.connection = nil
+		}
+	}
+}
+
+// AddStation associates a simulated client with the access point
+// hosted on the provided interface
+func (simBackend *SimulatedBackend) AddStation(iface string, station Station) error {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	if simIface.accessPoint == nil {
+		return ErrAPNotRunning
+	}
+	simIface.stations = append(simIface.stations, station)
+	return nil
+}
+
+// Connection returns the network the interface is connected to, or
+// nil if it isn't connected
+func (simBackend *SimulatedBackend) Connection(iface string) (*WifiNetwork, error) {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return nil, ifaceErr
+	}
+	if simIface.connection == nil {
+		return nil, nil
+	}
+	connection := *simIface.connection
+	return &connection, nil
+}
+
+// get returns the simulated interface with the provided name. The
+// caller must hold the mutex.
+func (simBackend *SimulatedBackend) get(iface string) (*simulatedInterface, error) {
+	for _, simIface := range simBackend.interfaces {
+		if simIface.iface.Name == iface {
+			return simIface, nil
+		}
+	}
+	return nil, ErrSimMissingIface
+}
+
+// Name returns the name of the backend
+func (simBackend *SimulatedBackend) Name() string {
+	return "simulated"
+}
+
+// IsInstalled always returns true as the backend has no requirements
+func (simBackend *SimulatedBackend) IsInstalled() bool {
+	return true
+}
+
+// Diagnose reports scanning, connecting and access point mode as
+// available as the backend has no requirements
+func (simBackend *SimulatedBackend) Diagnose() BackendReport {
+	return BackendReport{
+		Usable:     true,
+		Privileged: true,
+		Features:   Features{Scan: true, Connect: true, Hotspot: true},
+	}
+}
+
+// Interfaces returns all simulated interfaces
+func (simBackend *SimulatedBackend) Interfaces() ([]WifiInterface, error) {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	wifiInterfaces := []WifiInterface{}
+	for _, simIface := range simBackend.interfaces {
+		wifiInterfaces = append(wifiInterfaces, WifiInterface{
+			Interface: simIface.iface,
+			Model:     "Simulated",
+			Vendor:    "WifiManager",
+			backend:   simBackend,
+		})
+	}
+	return wifiInterfaces, nil
+}
+
+// Scan returns every network in range
+func (simBackend *SimulatedBackend) Scan(iface string) ([]WifiNetwork, error) {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return nil, ifaceErr
+	}
+	if !simIface.powered {
+		return nil, ErrSimPowerOff
+	}
+	wifiNetworks := []WifiNetwork{}
+	for _, network := range simBackend.networks {
+		network.SecurityKey = ""
+		wifiNetworks = append(wifiNetworks, network)
+	}
+	return wifiNetworks, nil
+}
+
+// Connect connects the interface to the strongest access point with
+// the network's SSID if the security key matches
+func (simBackend *SimulatedBackend) Connect(iface string, network WifiNetwork) error {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	if !simIface.powered {
+		return ErrSimPowerOff
+	}
+	accessPoints, apErr := GetAPs(network.SSID, simBackend.networks)
+	if apErr != nil {
+		return apErr
+	}
+	bestAP, _ := GetBestAP(accessPoints)
+	if bestAP.SecurityKey != network.SecurityKey && !simBackend.matchesPSK(bestAP, network.SecurityKey) {
+		return ErrSimAuth
+	}
+	simIface.connection = &bestAP
+	return nil
+}
+
+// SupportsRawPSK returns true as WPA/WPA2 networks accept the PSK
+// derived from their security key
+func (simBackend *SimulatedBackend) SupportsRawPSK() bool {
+	return true
+}
+
+// matchesPSK returns whether the key is the raw PSK derived from the
+// security key of a WPA/WPA2 network
+func (simBackend *SimulatedBackend) matchesPSK(network WifiNetwork, key string) bool {
+	if !IsRawPSK(key) || network.DerivePSK() != nil {
+		return false
+	}
+	return strings.EqualFold(network.SecurityKey, key)
+}
+
+// Disconnect disconnects the interface from its current network
+func (simBackend *SimulatedBackend) Disconnect(iface string) error {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	simIface.connection = nil
+	return nil
+}
+
+// Up powers on the interface
+func (simBackend *SimulatedBackend) Up(iface string) error {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	simIface.powered = true
+	return nil
+}
+
+// Down powers off the interface, dropping its connection
+func (simBackend *SimulatedBackend) Down(iface string) error {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	simIface.powered = false
+	simIface.connection = nil
+	simIface.accessPoint = nil
+	simIface.stations = nil
+	return nil
+}
+
+// Status returns the power state of the interface
+func (simBackend *SimulatedBackend) Status(iface string) (bool, error) {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return false, ifaceErr
+	}
+	return simIface.powered, nil
+}
+
+// BSSID returns the BSSID of the network the interface is connected
+// to, or an empty string if it isn't connected
+func (simBackend *SimulatedBackend) BSSID(iface string) (string, error) {
+	connection, connectionErr := simBackend.Connection(iface)
+	if connection == nil {
+		return "", connectionErr
+	}
+	return connection.BSSID, nil
+}
+
+// State returns the connection state of the interface
+func (simBackend *SimulatedBackend) State(iface string) (int, error) {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return StateOff, ifaceErr
+	}
+	switch {
+	case !simIface.powered:
+		return StateOff, nil
+	case simIface.connection != nil:
+		return StateConnected, nil
+	}
+	return StateDisconnected, nil
+}
+
+// StartAccessPoint puts the interface in access point mode
+func (simBackend *SimulatedBackend) StartAccessPoint(iface string, accessPoint AccessPoint) error {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	if !simIface.powered {
+		return ErrSimPowerOff
+	}
+	if simIface.accessPoint != nil {
+		return ErrAPRunning
+	}
+	simIface.connection = nil
+	simIface.accessPoint = &accessPoint
+	return nil
+}
+
+// StopAccessPoint takes the interface out of access point mode
+func (simBackend *SimulatedBackend) StopAccessPoint(iface string) error {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return ifaceErr
+	}
+	if simIface.accessPoint == nil {
+		return ErrAPNotRunning
+	}
+	simIface.accessPoint = nil
+	simIface.stations = nil
+	return nil
+}
+
+// Stations returns the simulated clients of the access point
+func (simBackend *SimulatedBackend) Stations(iface string) ([]Station, error) {
+	simBackend.mutex.Lock()
+	defer simBackend.mutex.Unlock()
+	simIface, ifaceErr := simBackend.get(iface)
+	if ifaceErr != nil {
+		return nil, ifaceErr
+	}
+	if simIface.accessPoint == nil {
+		return nil, ErrAPNotRunning
+	}
+	return append([]Station{}, simIface.stations...), nil
+}
@@ -0,0 +1,160 @@
+package wifimanager
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// StateOff indicates the interface is turned off
+	StateOff int = iota
+	// StateDisconnected indicates the interface is on but has no
+	// connection
+	StateDisconnected
+	// StateScanning indicates the interface is looking for networks
+	StateScanning
+	// StateAssociating indicates the interface is joining a network
+	StateAssociating
+	// StateAuthenticating indicates the interface is going through the
+	// handshakes of a secured network
+	StateAuthenticating
+	// StateConnected indicates the interface is on and connected
+	StateConnected
+	// StateFailed indicates the last connection attempt failed. It is
+	// reported once even if the backend already says disconnected.
+	StateFailed
+	// StateRFKilled indicates the radio of the interface is blocked by
+	// rfkill
+	StateRFKilled
+)
+
+var (
+	// stateNames are the names of the states used by StateName
+	stateNames = map[int]string{
+		StateOff:            "off",
+		StateDisconnected:   "disconnected",
+		StateScanning:       "scanning",
+		StateAssociating:    "associating",
+		StateAuthenticating: "authenticating",
+		StateConnected:      "connected",
+		StateFailed:         "failed",
+		StateRFKilled:       "rfkilled",
+	}
+
+	// states remembers the last state seen of every interface so that
+	// transitions can be timed
+	states = &stateTracker{states: map[string]trackedState{}}
+)
+
+// InterfaceState is the connection state of an interface. Changed is
+// when this process saw the interface enter State, and is zero when the
+// interface already was in State the first time it was looked at, as
+// the backends don't tell when their state last changed. Previous is
+// the state seen before, or State itself when none was.
+type InterfaceState struct {
+	State    int
+	Previous int
+	Changed  time.Time
+}
+
+// StateBackend is implemented by backends that can tell the connection
+// state of an interface rather than only its power state
+type StateBackend interface {
+	State(iface string) (int, error)
+}
+
+// stateTracker holds the last state seen of interfaces, keyed by
+// backend and interface name
+type stateTracker struct {
+	mutex  sync.Mutex
+	states map[string]trackedState
+}
+
+// trackedState is the last state seen of an interface, along with
+// whether State returned a failure it is in yet
+type trackedState struct {
+	InterfaceState
+	failureReported bool
+}
+
+// StateName returns the name of the state, such as "connected"
+func StateName(state int) string {
+	return stateNames[state]
+}
+
+// State returns the connection state of the WiFi interface. A radio
+// blocked by rfkill is reported as such whatever the backend says, and
+// a failed connection attempt is reported once before the interface is
+// taken to be disconnected. Backends that only report power are taken to
+// be disconnected while the interface is on.
+func (wifiInterface *WifiInterface) State() (InterfaceState, error) {
+	backend := wifiInterface.Backend()
+	if rfkillSwitch, switchErr := rfkill.InterfaceSwitch(wifiInterface.Name); switchErr == nil {
+		if rfkillSwitch.SoftBlocked || rfkillSwitch.HardBlocked {
+			return states.observe(backend.Name(), wifiInterface.Name, StateRFKilled), nil
+		}
+	}
+	state := StateOff
+	if stateBackend, ok := backend.(StateBackend); ok {
+		backendState, stateErr := stateBackend.State(wifiInterface.Name)
+		if stateErr != nil {
+			return InterfaceState{}, stateErr
+		}
+		state = backendState
+	} else {
+		powered, statusErr := backend.Status(wifiInterface.Name)
+		if statusErr != nil {
+			return InterfaceState{}, statusErr
+		}
+		if powered {
+			state = StateDisconnected
+		}
+	}
+	return states.observe(backend.Name(), wifiInterface.Name, state), nil
+}
+
+// observe records the state of the interface, returning it along with
+// the time it was entered. A disconnected interface stays failed until
+// the failure was returned once.
+func (tracker *stateTracker) observe(backend, iface string, state int) InterfaceState {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	key := backend + "/" + iface
+	last, seen := tracker.states[key]
+	if seen && last.State == StateFailed && state == StateDisconnected && !last.failureReported {
+		state = StateFailed
+	}
+	if seen && last.State == state {
+		last.failureReported = state == StateFailed
+		tracker.states[key] = last
+		return last.InterfaceState
+	}
+	current := InterfaceState{State: state, Previous: last.State, Changed: time.Now()}
+	if !seen {
+		current.Previous = state
+		current.Changed = time.Time{}
+	}
+	tracker.states[key] = trackedState{InterfaceState: current, failureReported: state == StateFailed}
+	return current
+}
+
+// fail records that a connection attempt of the interface just failed
+func (tracker *stateTracker) fail(backend, iface string) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	key := backend + "/" + iface
+	last, seen := tracker.states[key]
+	current := InterfaceState{State: StateFailed, Previous: last.State, Changed: time.Now()}
+	if !seen {
+		current.Previous = StateFailed
+	}
+	tracker.states[key] = trackedState{InterfaceState: current}
+}
+
+// forget drops the state of an interface that went away, so that an
+// interface showing up under the same name starts afresh
+func (tracker *stateTracker) forget(backend, iface string) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	delete(tracker.states, backend+"/"+iface)
+}
@@ -0,0 +1,100 @@
+package wifimanager
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// stateBackend reports a settable connection state
+type stateBackend struct {
+	recordingBackend
+	state int
+}
+
+func (backend *stateBackend) State(iface string) (int, error) {
+	return backend.state, nil
+}
+
+func TestStateTrackerFailureReportedOnce(t *testing.T) {
+	tracker := &stateTracker{states: map[string]trackedState{}}
+	tracker.observe("test", "wlan0", StateAssociating)
+	tracker.fail("test", "wlan0")
+	failed := tracker.observe("test", "wlan0", StateDisconnected)
+	if failed.State != StateFailed || failed.Previous != StateAssociating || failed.Changed.IsZero() {
+		t.Errorf("disconnected after failing = %+v, want failed after associating", failed)
+	}
+	disconnected := tracker.observe("test", "wlan0", StateDisconnected)
+	if disconnected.State != StateDisconnected || disconnected.Previous != StateFailed {
+		t.Errorf("disconnected after the failure was reported = %+v", disconnected)
+	}
+
+	// a failure the backend reports is reported as it is seen
+	tracker.observe("test", "wlan0", StateFailed)
+	if observed := tracker.observe("test", "wlan0", StateDisconnected); observed.State != StateDisconnected {
+		t.Errorf("disconnected after a reported failure = %+v", observed)
+	}
+
+	// failing again after a retry is a new failure
+	tracker.observe("test", "wlan0", StateAssociating)
+	tracker.fail("test", "wlan0")
+	if observed := tracker.observe("test", "wlan0", StateDisconnected); observed.State != StateFailed {
+		t.Errorf("disconnected after failing again = %+v", observed)
+	}
+
+	// a failure of an interface never looked at before is still timed
+	tracker.fail("test", "wlan1")
+	if observed := tracker.observe("test", "wlan1", StateDisconnected); observed.State != StateFailed || observed.Changed.IsZero() {
+		t.Errorf("first state after failing = %+v", observed)
+	}
+}
+
+func TestStateTrackerTransitions(t *testing.T) {
+	tracker := &stateTracker{states: map[string]trackedState{}}
+	first := tracker.observe("test", "wlan0", StateDisconnected)
+	if first.State != StateDisconnected || first.Previous != StateDisconnected || !first.Changed.IsZero() {
+		t.Errorf("first state = %+v, want disconnected at an unknown time", first)
+	}
+	time.Sleep(time.Millisecond)
+	if same := tracker.observe("test", "wlan0", StateDisconnected); same != first {
+		t.Errorf("unchanged state = %+v, want %+v", same, first)
+	}
+	before := time.Now()
+	connected := tracker.observe("test", "wlan0", StateConnected)
+	if connected.Previous != StateDisconnected || connected.Changed.Before(before) {
+		t.Errorf("connected = %+v after %+v", connected, first)
+	}
+	if same := tracker.observe("test", "wlan0", StateConnected); same != connected {
+		t.Errorf("unchanged state = %+v, want %+v", same, connected)
+	}
+	// interfaces and backends are tracked apart
+	if other := tracker.observe("other", "wlan0", StateOff); other.Previous != StateOff {
+		t.Errorf("other backend = %+v", other)
+	}
+	tracker.forget("test", "wlan0")
+	if fresh := tracker.observe("test", "wlan0", StateOff); fresh.Previous != StateOff || !fresh.Changed.IsZero() {
+		t.Errorf("state after forgetting = %+v", fresh)
+	}
+}
+
+func TestWifiInterfaceStateRFKilled(t *testing.T) {
+	backend := &stateBackend{state: StateConnected}
+	wifiInterface := NewBackendInterface(net.Interface{Index: 3, Name: "wlan0"}, backend)
+	t.Cleanup(func() { states.forget(backend.Name(), "wlan0") })
+	tests := []struct {
+		soft  bool
+		hard  bool
+		state int
+	}{
+		{soft: false, hard: false, state: StateConnected},
+		{soft: true, hard: false, state: StateRFKilled},
+		{soft: false, hard: true, state: StateRFKilled},
+	}
+	for _, test := range tests {
+		setTestRFKill(t, test.soft, test.hard)
+		state, stateErr := wifiInterface.State()
+		if stateErr != nil || state.State != test.state {
+			t.Errorf("soft %v hard %v: state = %s, %v, want %s", test.soft, test.hard, StateName(state.State), stateErr, StateName(test.state))
+		}
+	}
+}
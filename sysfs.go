@@ -0,0 +1,40 @@
+package wifimanager
+
+import (
+	"net"
+
+	"github.com/ottopress/WifiManager/linux"
+)
+
+var (
+	// sysfs is where the Linux backends discover wireless interfaces
+	// and their hardware
+	sysfs = linux.NewSysfs()
+)
+
+// SetSysfsRoot changes where sysfs is read from, such as a copy of
+// the tree
+func SetSysfsRoot(root string) {
+	sysfs.Root = root
+	rfkill.Root = root
+}
+
+// newSysfsInterface returns the interface driven by the backend, with
+// its model and vendor read from sysfs. The device and vendor IDs
+// stand in for the names that aren't in the hardware ID databases.
+func newSysfsInterface(iface net.Interface, backend Backend) WifiInterface {
+	wifiInterface := WifiInterface{Interface: iface, backend: backend}
+	wirelessInterface, sysfsErr := sysfs.WirelessInterface(iface.Name)
+	if sysfsErr != nil {
+		return wifiInterface
+	}
+	wifiInterface.Vendor = wirelessInterface.Vendor
+	if wifiInterface.Vendor == "" {
+		wifiInterface.Vendor = wirelessInterface.VendorID
+	}
+	wifiInterface.Model = wirelessInterface.Model
+	if wifiInterface.Model == "" {
+		wifiInterface.Model = wirelessInterface.DeviceID
+	}
+	return wifiInterface
+}
@@ -3,6 +3,7 @@ package wifimanager
 import (
 	"errors"
 	"net"
+	"strings"
 
 	"github.com/ottopress/WifiManager/darwin"
 )
@@ -24,12 +25,15 @@ const (
 	SecurityWPA2
 	// SecurityNone represents the lack of any WiFi security protocol
 	SecurityNone
+	// SecurityWPA3 represents the WPA3/SAE WiFi security protocol
+	SecurityWPA3
 )
 
 var (
 	airport        = darwin.NewAirPort()
 	networkSetup   = darwin.NewNetworkSetup()
 	systemProfiler = darwin.NewSystemProfiler()
+	coreWLAN       = darwin.NewCoreWLAN()
 
 	// ErrMissingIface should be returned if no interfaces could be found
 	// while getting available interfaces
@@ -37,14 +41,29 @@ var (
 	// ErrMissingAP should be returned if scanning for an access point with
 	// a specific SSID yielded no results
 	ErrMissingAP = errors.New("wifi: no access point found with provided name")
+	// ErrUnknownSecurity is returned when parsing the name of a
+	// security protocol that doesn't exist
+	ErrUnknownSecurity = errors.New("wifi: unknown security protocol")
+
+	// securityNames are the names of the security protocols used by
+	// SecurityName and ParseSecurity
+	securityNames = map[int]string{
+		SecurityNone: "open",
+		SecurityWEP:  "wep",
+		SecurityWPA:  "wpa",
+		SecurityWPA2: "wpa2",
+		SecurityWPA3: "wpa3",
+	}
 )
 
 // WifiInterface represents a physical WiFi interface
 type WifiInterface struct {
 	net.Interface
-	Model      string
-	Vendor     string
-	Connection WifiNetwork
+	Model        string
+	Vendor       string
+	Connection   WifiNetwork
+	Connectivity Connectivity
+	backend      Backend
 }
 
 // WifiNetwork represents a discovered WiFi network
@@ -56,6 +75,9 @@ type WifiNetwork struct {
 	Channel     int
 	Security    []WifiNetworkSecurity
 	SecurityKey string
+	// Hidden is set when the network doesn't broadcast its SSID, so
+	// it has to be probed for by name when connecting
+	Hidden bool
 }
 
 // WifiNetworkSecurity represents the security configuration of
@@ -67,27 +89,17 @@ type WifiNetworkSecurity struct {
 	Group    int
 }
 
-// GetWifiInterfaces returns a list of all active Wifi interfaces
+// GetWifiInterfaces returns a list of all active Wifi interfaces of
+// the default backend, which is detected on first use
 func GetWifiInterfaces() ([]WifiInterface, error) {
-	wifiInterfaces := []WifiInterface{}
-
-	netInterfaces, netErr := net.Interfaces()
-	if netErr != nil {
-		return wifiInterfaces, netErr
-	}
-
-	_, runErr := systemProfiler.Run(networkSetup)
-	if runErr != nil {
-		return wifiInterfaces, runErr
+	backend, detectErr := detectDefaultBackend()
+	if detectErr != nil {
+		return nil, detectErr
 	}
-	for _, iface := range netInterfaces {
-		_, spErr := systemProfiler.Get(iface.Name)
-		if spErr == nil {
-			wifiInterface, _ := NewWifiInterface(iface)
-			wifiInterfaces = append(wifiInterfaces, wifiInterface)
-		}
+	wifiInterfaces, ifaceErr := backend.Interfaces()
+	if ifaceErr != nil {
+		return wifiInterfaces, ifaceErr
 	}
-
 	if len(wifiInterfaces) < 1 {
 		return wifiInterfaces, ErrMissingIface
 	}
@@ -95,8 +107,12 @@ func GetWifiInterfaces() ([]WifiInterface, error) {
 }
 
 // NewWifiInterface builds a WifiInterface instance off of the
-// "net" package's interface.
+// "net" package's interface. Its hardware is read from sysfs on Linux
+// and from system_profiler otherwise.
 func NewWifiInterface(iface net.Interface) (WifiInterface, error) {
+	if sysfs.IsWireless(iface.Name) {
+		return newSysfsInterface(iface, nil), nil
+	}
 	wifiInterface := WifiInterface{Interface: iface}
 
 	spInfo, spErr := systemProfiler.Get(iface.Name)
@@ -111,29 +127,9 @@ func NewWifiInterface(iface net.Interface) (WifiInterface, error) {
 
 // Scan returns a list of all reachable WiFi networks
 func (wifiInterface *WifiInterface) Scan() ([]WifiNetwork, error) {
-	airportNetworks, airportErr := airport.Scan()
-	if airportErr != nil {
-		return nil, airportErr
-	}
-	wifiNetworks := []WifiNetwork{}
-	for _, network := range airportNetworks {
-		security := []WifiNetworkSecurity{}
-		for _, airSecurity := range network.Security {
-			security = append(security, WifiNetworkSecurity{
-				Protocol: airSecurity.Protocol,
-				Method:   airSecurity.Method,
-				Unicasts: airSecurity.Unicasts,
-				Group:    airSecurity.Group,
-			})
-		}
-		wifiNetworks = append(wifiNetworks, WifiNetwork{
-			SSID:     network.SSID,
-			BSSID:    network.BSSID,
-			RSSI:     network.RSSI,
-			Channel:  network.Channel,
-			Security: security,
-			HT:       network.HT,
-		})
+	wifiNetworks, scanErr := wifiInterface.Backend().Scan(wifiInterface.Name)
+	if scanErr != nil {
+		return nil, scanErr
 	}
 	return wifiNetworks, nil
 }
@@ -153,14 +149,14 @@ func GetAPs(ssid string, networks []WifiNetwork) ([]WifiNetwork, error) {
 }
 
 // GetBestAP returns the access point with the provided SSID that
-// has the best quality connection
+// has the strongest signal
 func GetBestAP(accessPoints []WifiNetwork) (WifiNetwork, error) {
 	if len(accessPoints) == 1 {
 		return accessPoints[0], nil
 	}
 	bestAP := accessPoints[0]
 	for _, accessPoint := range accessPoints {
-		if accessPoint.RSSI < bestAP.RSSI {
+		if accessPoint.RSSI > bestAP.RSSI {
 			bestAP = accessPoint
 		}
 	}
@@ -172,9 +168,11 @@ func (wifiInterface *WifiInterface) UpdateNetwork(network WifiNetwork) {
 	wifiInterface.Connection = network
 }
 
-// Up turns on the WiFi interface
+// Up turns on the WiFi interface. The Linux backends lift an rfkill
+// soft block first and return ErrHardBlocked when a hard block keeps
+// the radio off.
 func (wifiInterface *WifiInterface) Up() error {
-	upErr := networkSetup.Up(wifiInterface.Name)
+	upErr := wifiInterface.Backend().Up(wifiInterface.Name)
 	if upErr != nil {
 		return upErr
 	}
@@ -183,25 +181,31 @@ func (wifiInterface *WifiInterface) Up() error {
 
 // Down turns off the WiFi interface
 func (wifiInterface *WifiInterface) Down() error {
-	downErr := networkSetup.Down(wifiInterface.Name)
+	downErr := wifiInterface.Backend().Down(wifiInterface.Name)
 	if downErr != nil {
 		return downErr
 	}
 	return nil
 }
 
-// Connect the interface to the current WiFi connection
+// Connect the interface to the current WiFi connection. The security
+// key is registered as a secret so it never shows up in errors, and a
+// failure leaves the interface in StateFailed.
 func (wifiInterface *WifiInterface) Connect() error {
-	connectErr := networkSetup.Connect(wifiInterface.Name, wifiInterface.Connection.SSID, wifiInterface.Connection.SecurityKey)
+	RegisterSecret(wifiInterface.Connection.SecurityKey)
+	defer ForgetSecret(wifiInterface.Connection.SecurityKey)
+	backend := wifiInterface.Backend()
+	connectErr := backend.Connect(wifiInterface.Name, wifiInterface.Connection)
 	if connectErr != nil {
-		return connectErr
+		states.fail(backend.Name(), wifiInterface.Name)
+		return RedactError(connectErr)
 	}
 	return nil
 }
 
 // Status returns the power state of the WiFi interface
 func (wifiInterface *WifiInterface) Status() (bool, error) {
-	status, statusErr := networkSetup.Status(wifiInterface.Name)
+	status, statusErr := wifiInterface.Backend().Status(wifiInterface.Name)
 	if statusErr != nil {
 		return false, statusErr
 	}
@@ -211,37 +215,38 @@ func (wifiInterface *WifiInterface) Status() (bool, error) {
 // Disconnect disconnects from the current network without shutting
 // down the interface
 func (wifiInterface *WifiInterface) Disconnect() error {
-	disconnectErr := airport.Disconnect()
+	disconnectErr := wifiInterface.Backend().Disconnect(wifiInterface.Name)
 	if disconnectErr != nil {
 		return disconnectErr
 	}
 	return nil
 }
 
+// SecurityName returns the short name of the security protocol, such
+// as "wpa2" or "open"
+func SecurityName(protocol int) string {
+	return securityNames[protocol]
+}
+
+// ParseSecurity returns the security protocol with the provided short
+// name, ignoring case
+func ParseSecurity(name string) (int, error) {
+	for protocol, protocolName := range securityNames {
+		if strings.EqualFold(name, protocolName) {
+			return protocol, nil
+		}
+	}
+	return 0, ErrUnknownSecurity
+}
+
 // UpdateSecurityKey updates the security key of the network
 func (wifiNetwork *WifiNetwork) UpdateSecurityKey(key string) {
 	wifiNetwork.SecurityKey = key
 }
 
-// Prerequisites returns whether or not all the required
-// commands are installed
+// Prerequisites returns whether or not all the commands the Mac OS X
+// backend relies on are installed. Diagnose reports which ones are
+// missing.
 func Prerequisites() bool {
-	commandList := map[string]bool{
-		"airport":        airport.IsInstalled(),
-		"networkSetup":   networkSetup.IsInstalled(),
-		"systemProfiler": systemProfiler.IsInstalled(),
-	}
-
-	needList := []string{}
-
-	for command, installed := range commandList {
-		if !installed {
-			needList = append(needList, command)
-		}
-	}
-
-	if len(needList) > 0 {
-		return false
-	}
-	return true
+	return NewDarwinBackend().Diagnose().Usable
 }
@@ -0,0 +1,55 @@
+package wifimanager
+
+import (
+	"testing"
+)
+
+func TestGetBestAP(t *testing.T) {
+	tests := []struct {
+		name         string
+		accessPoints []WifiNetwork
+		bssid        string
+	}{
+		{
+			name:         "single access point",
+			accessPoints: []WifiNetwork{{BSSID: "02:11:22:33:44:01", RSSI: -80}},
+			bssid:        "02:11:22:33:44:01",
+		},
+		{
+			name: "strongest signal",
+			accessPoints: []WifiNetwork{
+				{BSSID: "02:11:22:33:44:01", RSSI: -61},
+				{BSSID: "02:11:22:33:44:02", RSSI: -48},
+				{BSSID: "02:11:22:33:44:03", RSSI: -83},
+			},
+			bssid: "02:11:22:33:44:02",
+		},
+		{
+			name: "first of equally strong",
+			accessPoints: []WifiNetwork{
+				{BSSID: "02:11:22:33:44:01", RSSI: -52},
+				{BSSID: "02:11:22:33:44:02", RSSI: -52},
+			},
+			bssid: "02:11:22:33:44:01",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bestAP, bestErr := GetBestAP(test.accessPoints)
+			if bestErr != nil || bestAP.BSSID != test.bssid {
+				t.Fatalf("best access point = %s, %v, want %s", bestAP.BSSID, bestErr, test.bssid)
+			}
+		})
+	}
+}
+
+func TestGetAPs(t *testing.T) {
+	networks := []WifiNetwork{{SSID: "Home", BSSID: "02:11:22:33:44:01"}, {SSID: "Cafe"}, {SSID: "Home", BSSID: "02:11:22:33:44:02"}}
+	accessPoints, apErr := GetAPs("Home", networks)
+	if apErr != nil || len(accessPoints) != 2 {
+		t.Fatalf("access points = %+v, %v", accessPoints, apErr)
+	}
+	if _, missingErr := GetAPs("Office", networks); missingErr != ErrMissingAP {
+		t.Fatalf("missing network error = %v, want ErrMissingAP", missingErr)
+	}
+}
@@ -0,0 +1,540 @@
+package wifiqr
+
+import (
+	"errors"
+)
+
+const (
+	// LevelL recovers from roughly 7% of the code being damaged
+	LevelL int = iota
+	// LevelM recovers from roughly 15% of the code being damaged
+	LevelM
+	// LevelQ recovers from roughly 25% of the code being damaged
+	LevelQ
+	// LevelH recovers from roughly 30% of the code being damaged
+	LevelH
+	// MinVersion is the smallest QR code version, 21x21 modules
+	MinVersion = 1
+	// MaxVersion is the largest QR code version, 177x177 modules
+	MaxVersion = 40
+)
+
+var (
+	// ErrDataTooLong is returned when the data doesn't fit in even
+	// the largest QR code at the requested error correction level
+	ErrDataTooLong = errors.New("wifiqr: data too long for a qr code")
+	// ErrInvalidLevel is returned for unknown error correction levels
+	ErrInvalidLevel = errors.New("wifiqr: invalid error correction level")
+
+	// levelFormatBits are the format information bits of each level
+	levelFormatBits = [4]int{1, 0, 3, 2}
+	// eccCodewordsPerBlock is indexed by level and version
+	eccCodewordsPerBlock = [4][41]int{
+		{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+		{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	}
+	// eccBlocks is the number of error correction blocks, indexed by
+	// level and version
+	eccBlocks = [4][41]int{
+		{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+		{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+		{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+	}
+)
+
+// QRCode is an encoded QR code symbol
+type QRCode struct {
+	Version  int
+	Size     int
+	Level    int
+	Mask     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Encode encodes the data in byte mode into the smallest QR code that
+// holds it at the provided error correction level
+func Encode(data []byte, level int) (*QRCode, error) {
+	if level < LevelL || level > LevelH {
+		return nil, ErrInvalidLevel
+	}
+	version := MinVersion
+	for ; version <= MaxVersion; version++ {
+		if byteModeBits(version, len(data)) <= dataCodewords(version, level)*8 {
+			break
+		}
+	}
+	if version > MaxVersion {
+		return nil, ErrDataTooLong
+	}
+
+	// mode indicator, character count, data, terminator, then padding
+	bits := &bitBuffer{}
+	bits.append(0x4, 4)
+	bits.append(len(data), countBits(version))
+	for _, char := range data {
+		bits.append(int(char), 8)
+	}
+	capacity := dataCodewords(version, level) * 8
+	terminator := capacity - bits.length
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-bits.length%8)%8)
+	for pad := 0xEC; bits.length < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	qrCode := newQRCode(version, level)
+	qrCode.drawFunctionPatterns()
+	qrCode.drawCodewords(qrCode.addECC(bits.bytes()))
+	qrCode.chooseMask()
+	return qrCode, nil
+}
+
+// Dark returns whether the module at the provided column and row is
+// dark. Coordinates outside the symbol are light.
+func (qrCode *QRCode) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= qrCode.Size || y >= qrCode.Size {
+		return false
+	}
+	return qrCode.modules[y][x]
+}
+
+// newQRCode creates an empty symbol of the provided version
+func newQRCode(version, level int) *QRCode {
+	size := version*4 + 17
+	qrCode := &QRCode{Version: version, Size: size, Level: level}
+	qrCode.modules = make([][]bool, size)
+	qrCode.function = make([][]bool, size)
+	for row := range qrCode.modules {
+		qrCode.modules[row] = make([]bool, size)
+		qrCode.function[row] = make([]bool, size)
+	}
+	return qrCode
+}
+
+// setFunction sets a module that is part of a function pattern
+func (qrCode *QRCode) setFunction(x, y int, dark bool) {
+	qrCode.modules[y][x] = dark
+	qrCode.function[y][x] = true
+}
+
+// drawFunctionPatterns draws the timing, finder and alignment patterns
+// plus placeholder format and version information
+func (qrCode *QRCode) drawFunctionPatterns() {
+	size := qrCode.Size
+	for index := 0; index < size; index++ {
+		qrCode.setFunction(6, index, index%2 == 0)
+		qrCode.setFunction(index, 6, index%2 == 0)
+	}
+	qrCode.drawFinder(3, 3)
+	qrCode.drawFinder(size-4, 3)
+	qrCode.drawFinder(3, size-4)
+
+	positions := alignmentPositions(qrCode.Version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					qrCode.setFunction(x+dx, y+dy, maxInt(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	qrCode.drawFormat(0)
+	qrCode.drawVersion()
+}
+
+// drawFinder draws a finder pattern and its separator centered on the
+// provided module
+func (qrCode *QRCode) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= qrCode.Size || yy >= qrCode.Size {
+				continue
+			}
+			distance := maxInt(abs(dx), abs(dy))
+			qrCode.setFunction(xx, yy, distance != 2 && distance != 4)
+		}
+	}
+}
+
+// drawFormat draws both copies of the format information for the mask
+func (qrCode *QRCode) drawFormat(mask int) {
+	data := levelFormatBits[qrCode.Level]<<3 | mask
+	remainder := data
+	for index := 0; index < 10; index++ {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+	bits := (data<<10 | remainder) ^ 0x5412
+	bit := func(index int) bool {
+		return (bits>>uint(index))&1 != 0
+	}
+
+	for index := 0; index <= 5; index++ {
+		qrCode.setFunction(8, index, bit(index))
+	}
+	qrCode.setFunction(8, 7, bit(6))
+	qrCode.setFunction(8, 8, bit(7))
+	qrCode.setFunction(7, 8, bit(8))
+	for index := 9; index < 15; index++ {
+		qrCode.setFunction(14-index, 8, bit(index))
+	}
+
+	size := qrCode.Size
+	for index := 0; index < 8; index++ {
+		qrCode.setFunction(size-1-index, 8, bit(index))
+	}
+	for index := 8; index < 15; index++ {
+		qrCode.setFunction(8, size-15+index, bit(index))
+	}
+	qrCode.setFunction(8, size-8, true)
+}
+
+// drawVersion draws both copies of the version information, which
+// only symbols of version 7 and up carry
+func (qrCode *QRCode) drawVersion() {
+	if qrCode.Version < 7 {
+		return
+	}
+	remainder := qrCode.Version
+	for index := 0; index < 12; index++ {
+		remainder = (remainder << 1) ^ ((remainder >> 11) * 0x1F25)
+	}
+	bits := qrCode.Version<<12 | remainder
+	for index := 0; index < 18; index++ {
+		dark := (bits>>uint(index))&1 != 0
+		a := qrCode.Size - 11 + index%3
+		b := index / 3
+		qrCode.setFunction(a, b, dark)
+		qrCode.setFunction(b, a, dark)
+	}
+}
+
+// addECC splits the data into blocks, appends the Reed-Solomon error
+// correction codewords of each and interleaves the result
+func (qrCode *QRCode) addECC(data []byte) []byte {
+	numBlocks := eccBlocks[qrCode.Level][qrCode.Version]
+	blockECCLength := eccCodewordsPerBlock[qrCode.Level][qrCode.Version]
+	rawCodewords := rawDataModules(qrCode.Version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLength := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLength)
+	blocks := [][]byte{}
+	offset := 0
+	for index := 0; index < numBlocks; index++ {
+		dataLength := shortBlockLength - blockECCLength
+		if index >= numShortBlocks {
+			dataLength++
+		}
+		blockData := data[offset : offset+dataLength]
+		offset += dataLength
+		block := append([]byte{}, blockData...)
+		if index < numShortBlocks {
+			// placeholder so every block has the same length
+			block = append(block, 0)
+		}
+		block = append(block, reedSolomonRemainder(blockData, divisor)...)
+		blocks = append(blocks, block)
+	}
+
+	result := []byte{}
+	for column := range blocks[0] {
+		for index, block := range blocks {
+			if column != shortBlockLength-blockECCLength || index >= numShortBlocks {
+				result = append(result, block[column])
+			}
+		}
+	}
+	return result
+}
+
+// drawCodewords places the codewords in the zigzag order, skipping
+// function modules
+func (qrCode *QRCode) drawCodewords(codewords []byte) {
+	bitIndex := 0
+	size := qrCode.Size
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vertical := 0; vertical < size; vertical++ {
+			for column := 0; column < 2; column++ {
+				x := right - column
+				upward := (right+1)&2 == 0
+				y := vertical
+				if upward {
+					y = size - 1 - vertical
+				}
+				if !qrCode.function[y][x] && bitIndex < len(codewords)*8 {
+					qrCode.modules[y][x] = (codewords[bitIndex>>3]>>uint(7-bitIndex&7))&1 != 0
+					bitIndex++
+				}
+			}
+		}
+	}
+}
+
+// chooseMask applies the mask pattern with the lowest penalty score
+func (qrCode *QRCode) chooseMask() {
+	bestMask := 0
+	bestPenalty := -1
+	for mask := 0; mask < 8; mask++ {
+		qrCode.applyMask(mask)
+		qrCode.drawFormat(mask)
+		penalty := qrCode.penalty()
+		if bestPenalty < 0 || penalty < bestPenalty {
+			bestMask = mask
+			bestPenalty = penalty
+		}
+		qrCode.applyMask(mask)
+	}
+	qrCode.Mask = bestMask
+	qrCode.applyMask(bestMask)
+	qrCode.drawFormat(bestMask)
+}
+
+// applyMask inverts the data modules selected by the mask pattern.
+// Applying the same mask twice undoes it.
+func (qrCode *QRCode) applyMask(mask int) {
+	for y := 0; y < qrCode.Size; y++ {
+		for x := 0; x < qrCode.Size; x++ {
+			if qrCode.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				qrCode.modules[y][x] = !qrCode.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four rules of the specification.
+// Lower scores are easier to read.
+func (qrCode *QRCode) penalty() int {
+	size := qrCode.Size
+	penalty := 0
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for _, vertical := range []bool{false, true} {
+		for line := 0; line < size; line++ {
+			module := func(position int) bool {
+				if vertical {
+					return qrCode.modules[position][line]
+				}
+				return qrCode.modules[line][position]
+			}
+			run := 1
+			for position := 1; position <= size; position++ {
+				if position < size && module(position) == module(position-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+			for position := 0; position+11 <= size; position++ {
+				for _, pattern := range finderLike {
+					matches := true
+					for offset, dark := range pattern {
+						if module(position+offset) != dark {
+							matches = false
+							break
+						}
+					}
+					if matches {
+						penalty += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if qrCode.modules[y][x] {
+				dark++
+			}
+			if x+1 < size && y+1 < size {
+				color := qrCode.modules[y][x]
+				if color == qrCode.modules[y][x+1] && color == qrCode.modules[y+1][x] && color == qrCode.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := size * size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	penalty += k * 10
+	return penalty
+}
+
+// alignmentPositions returns the centers of the alignment patterns
+// along each axis
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+	positions := make([]int, count)
+	positions[0] = 6
+	for index, position := count-1, version*4+17-7; index >= 1; index, position = index-1, position-step {
+		positions[index] = position
+	}
+	return positions
+}
+
+// rawDataModules returns the number of modules available for data and
+// error correction in a symbol of the provided version
+func rawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		count := version/7 + 2
+		result -= (25*count-10)*count - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// dataCodewords returns the number of data codewords a symbol holds
+func dataCodewords(version, level int) int {
+	return rawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*eccBlocks[level][version]
+}
+
+// countBits returns the width of the byte mode character count
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// byteModeBits returns the number of bits a byte mode segment of the
+// provided length takes up
+func byteModeBits(version, length int) int {
+	if length >= 1<<uint(countBits(version)) {
+		return 1 << 30
+	}
+	return 4 + countBits(version) + length*8
+}
+
+// reedSolomonDivisor returns the generator polynomial of the provided
+// degree, highest coefficient omitted
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for index := 0; index < degree; index++ {
+		for coefficient := range result {
+			result[coefficient] = gfMultiply(result[coefficient], root)
+			if coefficient+1 < degree {
+				result[coefficient] ^= result[coefficient+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder returns the error correction codewords of the
+// data for the divisor
+func reedSolomonRemainder(data []byte, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, char := range data {
+		factor := char ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for index, coefficient := range divisor {
+			result[index] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies two elements of GF(2^8) modulo 0x11D
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for index := 7; index >= 0; index-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(index))&1) * int(x)
+	}
+	return byte(z)
+}
+
+// bitBuffer accumulates bits most significant first
+type bitBuffer struct {
+	data   []byte
+	length int
+}
+
+// append adds the lowest count bits of the value
+func (buffer *bitBuffer) append(value, count int) {
+	for index := count - 1; index >= 0; index-- {
+		if buffer.length%8 == 0 {
+			buffer.data = append(buffer.data, 0)
+		}
+		if (value>>uint(index))&1 != 0 {
+			buffer.data[buffer.length/8] |= 0x80 >> uint(buffer.length%8)
+		}
+		buffer.length++
+	}
+}
+
+// bytes returns the accumulated bits
+func (buffer *bitBuffer) bytes() []byte {
+	return buffer.data
+}
+
+// abs returns the absolute value of an int
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// maxInt returns the larger of two ints
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,417 @@
+package wifiqr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	// formatStrings are the format information bit strings of ISO/IEC
+	// 18004 table C.1, indexed by level and mask
+	formatStrings = [4][8]string{
+		{"111011111000100", "111001011110011", "111110110101010", "111100010011101", "110011000101111", "110001100011000", "110110001000001", "110100101110110"},
+		{"101010000010010", "101000100100101", "101111001111100", "101101101001011", "100010111111001", "100000011001110", "100111110010111", "100101010100000"},
+		{"011010101011111", "011000001101000", "011111100110001", "011101000000110", "010010010110100", "010000110000011", "010111011011010", "010101111101101"},
+		{"001011010001001", "001001110111110", "001110011100111", "001100111010000", "000011101100010", "000001001010101", "000110100001100", "000100000111011"},
+	}
+
+	// byteCapacities are the byte mode capacities of ISO/IEC 18004
+	// table 7 for some versions, indexed by level
+	byteCapacities = map[int][4]int{
+		1:  {17, 14, 11, 7},
+		2:  {32, 26, 20, 14},
+		5:  {106, 84, 60, 44},
+		7:  {154, 122, 86, 64},
+		8:  {192, 152, 108, 84},
+		9:  {230, 180, 130, 98},
+		10: {271, 213, 151, 119},
+		20: {858, 666, 482, 382},
+		40: {2953, 2331, 1663, 1273},
+	}
+
+	// gfExp and gfLog are the exponent and logarithm tables of
+	// GF(2^8) modulo 0x11D, built independently of gfMultiply
+	gfExp, gfLog = galoisTables()
+)
+
+// galoisTables builds the exponent and logarithm tables of GF(2^8)
+func galoisTables() ([512]byte, [256]int) {
+	var exp [512]byte
+	var log [256]int
+	value := 1
+	for power := 0; power < 255; power++ {
+		exp[power] = byte(value)
+		log[value] = power
+		value <<= 1
+		if value&0x100 != 0 {
+			value ^= 0x11D
+		}
+	}
+	for power := 255; power < 512; power++ {
+		exp[power] = exp[power-255]
+	}
+	return exp, log
+}
+
+// readFormat reads the format information next to the top left finder
+// pattern and looks it up in the table of the specification
+func readFormat(t *testing.T, qrCode *QRCode) (int, int) {
+	t.Helper()
+	positions := [][2]int{{0, 8}, {1, 8}, {2, 8}, {3, 8}, {4, 8}, {5, 8}, {7, 8}, {8, 8}, {8, 7}, {8, 5}, {8, 4}, {8, 3}, {8, 2}, {8, 1}, {8, 0}}
+	read := ""
+	for _, position := range positions {
+		if qrCode.Dark(position[0], position[1]) {
+			read += "1"
+		} else {
+			read += "0"
+		}
+	}
+	for level := range formatStrings {
+		for mask, format := range formatStrings[level] {
+			if read == format {
+				return level, mask
+			}
+		}
+	}
+	t.Fatalf("format information %s is not in the specification", read)
+	return 0, 0
+}
+
+// masked returns whether the mask pattern of the specification
+// inverts the module
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (y+x)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (y+x)%3 == 0
+	case 4:
+		return (y/2+x/3)%2 == 0
+	case 5:
+		return (y*x)%2+(y*x)%3 == 0
+	case 6:
+		return ((y*x)%2+(y*x)%3)%2 == 0
+	}
+	return ((y+x)%2+(y*x)%3)%2 == 0
+}
+
+// decodeSymbol reads the data back out of a symbol the way a scanner
+// does once it found the modules: it reads the format, unmasks the
+// codewords in their zigzag order, checks the Reed-Solomon blocks and
+// parses the byte mode segment
+func decodeSymbol(t *testing.T, qrCode *QRCode) []byte {
+	t.Helper()
+	level, mask := readFormat(t, qrCode)
+	if level != qrCode.Level || mask != qrCode.Mask {
+		t.Fatalf("format says level %d mask %d, symbol has level %d mask %d", level, mask, qrCode.Level, qrCode.Mask)
+	}
+
+	// two module wide columns from the right, alternating between
+	// upwards and downwards and stepping over the vertical timing
+	// pattern
+	bits := []bool{}
+	upward := true
+	for right := qrCode.Size - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for step := 0; step < qrCode.Size; step++ {
+			y := step
+			if upward {
+				y = qrCode.Size - 1 - step
+			}
+			for _, x := range []int{right, right - 1} {
+				if !qrCode.function[y][x] {
+					bits = append(bits, qrCode.Dark(x, y) != masked(mask, x, y))
+				}
+			}
+		}
+		upward = !upward
+	}
+	codewords := make([]byte, len(bits)/8)
+	for index := range codewords {
+		for bit := 0; bit < 8; bit++ {
+			if bits[index*8+bit] {
+				codewords[index] |= 0x80 >> uint(bit)
+			}
+		}
+	}
+
+	// undo the interleaving: data codewords of every block in turn,
+	// the longer blocks last, then the error correction codewords
+	blockCount := eccBlocks[level][qrCode.Version]
+	eccLength := eccCodewordsPerBlock[level][qrCode.Version]
+	longBlocks := len(codewords) % blockCount
+	shortData := len(codewords)/blockCount - eccLength
+	blocks := make([][]byte, blockCount)
+	offset := 0
+	for column := 0; column <= shortData; column++ {
+		for index := range blocks {
+			if column < shortData || index >= blockCount-longBlocks {
+				blocks[index] = append(blocks[index], codewords[offset])
+				offset++
+			}
+		}
+	}
+	for column := 0; column < eccLength; column++ {
+		for index := range blocks {
+			blocks[index] = append(blocks[index], codewords[offset])
+			offset++
+		}
+	}
+
+	data := []byte{}
+	for index, block := range blocks {
+		// a codeword is valid when the block polynomial has the roots
+		// of the generator, alpha^0 to alpha^(eccLength-1)
+		for root := 0; root < eccLength; root++ {
+			syndrome := byte(0)
+			for _, codeword := range block {
+				if syndrome != 0 {
+					syndrome = gfExp[gfLog[syndrome]+root]
+				}
+				syndrome ^= codeword
+			}
+			if syndrome != 0 {
+				t.Fatalf("block %d has syndrome %d at root %d", index, syndrome, root)
+			}
+		}
+		data = append(data, block[:len(block)-eccLength]...)
+	}
+
+	reader := &bitReader{data: data}
+	if mode := reader.read(4); mode != 0x4 {
+		t.Fatalf("mode indicator = %#x, want byte mode", mode)
+	}
+	countBits := 8
+	if qrCode.Version >= 10 {
+		countBits = 16
+	}
+	decoded := make([]byte, reader.read(countBits))
+	for index := range decoded {
+		decoded[index] = byte(reader.read(8))
+	}
+	return decoded
+}
+
+// bitReader reads bits most significant first
+type bitReader struct {
+	data     []byte
+	position int
+}
+
+// read returns the next count bits
+func (reader *bitReader) read(count int) int {
+	value := 0
+	for index := 0; index < count; index++ {
+		value <<= 1
+		if reader.data[reader.position/8]&(0x80>>uint(reader.position%8)) != 0 {
+			value |= 1
+		}
+		reader.position++
+	}
+	return value
+}
+
+// fingerprint hashes the modules of a symbol
+func fingerprint(qrCode *QRCode) string {
+	var buffer bytes.Buffer
+	for y := 0; y < qrCode.Size; y++ {
+		for x := 0; x < qrCode.Size; x++ {
+			if qrCode.Dark(x, y) {
+				buffer.WriteByte('1')
+			} else {
+				buffer.WriteByte('0')
+			}
+		}
+	}
+	sum := sha256.Sum256(buffer.Bytes())
+	return hex.EncodeToString(sum[:8])
+}
+
+func TestEncodeDecodes(t *testing.T) {
+	for version, capacities := range byteCapacities {
+		for level, capacity := range capacities {
+			data := bytes.Repeat([]byte("WIFI:T:WPA;S:Ottopress;P:\\;secret\\,;;"), capacity/36+1)[:capacity]
+			qrCode, encodeErr := Encode(data, level)
+			if encodeErr != nil {
+				t.Fatalf("version %d level %d: %v", version, level, encodeErr)
+			}
+			if qrCode.Version != version || qrCode.Size != version*4+17 {
+				t.Errorf("%d bytes at level %d took version %d, want %d", capacity, level, qrCode.Version, version)
+				continue
+			}
+			if decoded := decodeSymbol(t, qrCode); !bytes.Equal(decoded, data) {
+				t.Errorf("version %d level %d decoded %q", version, level, decoded)
+			}
+		}
+	}
+}
+
+func TestEncodeVersionCapacity(t *testing.T) {
+	for version, capacities := range byteCapacities {
+		for level, capacity := range capacities {
+			if version == 40 {
+				if _, encodeErr := Encode(make([]byte, capacity+1), level); encodeErr != ErrDataTooLong {
+					t.Errorf("level %d: %d bytes error = %v, want ErrDataTooLong", level, capacity+1, encodeErr)
+				}
+				continue
+			}
+			qrCode, encodeErr := Encode(make([]byte, capacity+1), level)
+			if encodeErr != nil || qrCode.Version != version+1 {
+				t.Errorf("level %d: %d bytes took version %d, %v, want %d", level, capacity+1, qrCode.Version, encodeErr, version+1)
+			}
+		}
+	}
+	if _, levelErr := Encode([]byte("x"), LevelH+1); levelErr != ErrInvalidLevel {
+		t.Errorf("invalid level error = %v, want ErrInvalidLevel", levelErr)
+	}
+}
+
+func TestEncodeFunctionPatterns(t *testing.T) {
+	// version information of ISO/IEC 18004 table D.1
+	versionStrings := map[int]string{
+		7:  "000111110010010100",
+		8:  "001000010110111100",
+		9:  "001001101010011001",
+		10: "001010010011010011",
+	}
+	for version, want := range versionStrings {
+		qrCode, encodeErr := Encode(make([]byte, byteCapacities[version][LevelL]), LevelL)
+		if encodeErr != nil || qrCode.Version != version {
+			t.Fatalf("version %d: got version %d, %v", version, qrCode.Version, encodeErr)
+		}
+		// bit 17 sits in the last row of the block above the bottom
+		// left finder, bit 0 in its first
+		read := ""
+		for index := 17; index >= 0; index-- {
+			x, y := index/3, qrCode.Size-11+index%3
+			if qrCode.Dark(x, y) {
+				read += "1"
+			} else {
+				read += "0"
+			}
+			if qrCode.Dark(x, y) != qrCode.Dark(y, x) {
+				t.Errorf("version %d: copies of version bit %d differ", version, index)
+			}
+		}
+		if read != want {
+			t.Errorf("version %d information = %s, want %s", version, read, want)
+		}
+	}
+
+	qrCode, _ := Encode([]byte("Ottopress"), LevelM)
+	finder := []string{"1111111", "1000001", "1011101", "1011101", "1011101", "1000001", "1111111"}
+	for _, corner := range [][2]int{{0, 0}, {qrCode.Size - 7, 0}, {0, qrCode.Size - 7}} {
+		for row, line := range finder {
+			for column, module := range line {
+				if qrCode.Dark(corner[0]+column, corner[1]+row) != (module == '1') {
+					t.Fatalf("finder at %v differs at %d,%d", corner, column, row)
+				}
+			}
+		}
+	}
+	for index := 8; index < qrCode.Size-8; index++ {
+		if qrCode.Dark(index, 6) != (index%2 == 0) || qrCode.Dark(6, index) != (index%2 == 0) {
+			t.Fatalf("timing pattern differs at %d", index)
+		}
+	}
+	// the dark module above the bottom left format information
+	if !qrCode.Dark(8, qrCode.Size-8) {
+		t.Error("dark module is light")
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	// ISO/IEC 18004 table E.1
+	tests := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		14: {6, 26, 46, 66},
+		22: {6, 26, 50, 74, 98},
+		32: {6, 34, 60, 86, 112, 138},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	}
+	for version, want := range tests {
+		if positions := alignmentPositions(version); !reflect.DeepEqual(positions, want) {
+			t.Errorf("version %d alignment positions = %v, want %v", version, positions, want)
+		}
+	}
+}
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// the data codewords of HELLO WORLD as a 1-M symbol in
+	// alphanumeric mode and their error correction codewords
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if ecc := reedSolomonRemainder(data, reedSolomonDivisor(len(want))); !bytes.Equal(ecc, want) {
+		t.Fatalf("error correction codewords = %v, want %v", ecc, want)
+	}
+}
+
+func TestEncodeGolden(t *testing.T) {
+	tests := []struct {
+		data        string
+		level       int
+		version     int
+		fingerprint string
+	}{
+		{data: "WIFI:T:nopass;S:Cafe;;", level: LevelL, version: 2, fingerprint: "b7b000b010b8b4fb"},
+		{data: "WIFI:T:WPA;S:Ottopress;P:correct horse;;", level: LevelM, version: 3, fingerprint: "26211fd21bcd2145"},
+		{data: "WIFI:T:SAE;S:Ottopress\\;Lab;P:battery\\:staple;H:true;;", level: LevelQ, version: 5, fingerprint: "1c3ae309ffe743ce"},
+		{data: "WIFI:T:WPA2-EAP;S:CorpNet;E:PEAP;A:anonymous;I:jdoe@example.com;P:hunter2;PH2:MSCHAPV2;;", level: LevelH, version: 9, fingerprint: "2e1c6aef216021aa"},
+	}
+	for _, test := range tests {
+		qrCode, encodeErr := Encode([]byte(test.data), test.level)
+		if encodeErr != nil {
+			t.Fatal(encodeErr)
+		}
+		if qrCode.Version != test.version || fingerprint(qrCode) != test.fingerprint {
+			t.Errorf("%q: version %d fingerprint %s, want version %d fingerprint %s", test.data, qrCode.Version, fingerprint(qrCode), test.version, test.fingerprint)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	qrCode, encodeErr := Encode([]byte("WIFI:T:nopass;S:Cafe;;"), LevelM)
+	if encodeErr != nil {
+		t.Fatal(encodeErr)
+	}
+	width := qrCode.Size + QuietZone*2
+	img := qrCode.Image(3)
+	if bounds := img.Bounds(); bounds.Dx() != width*3 || bounds.Dy() != width*3 {
+		t.Errorf("image bounds = %v, want %d pixels square", bounds, width*3)
+	}
+	// the top left pixel of the top left finder is dark
+	if r, _, _, _ := img.At(QuietZone*3, QuietZone*3).RGBA(); r != 0 {
+		t.Error("finder pixel is light")
+	}
+	var png bytes.Buffer
+	if writeErr := qrCode.WritePNG(&png, 2); writeErr != nil || !bytes.HasPrefix(png.Bytes(), []byte("\x89PNG")) {
+		t.Errorf("WritePNG = %d bytes, %v", png.Len(), writeErr)
+	}
+	dark := 0
+	for y := 0; y < qrCode.Size; y++ {
+		for x := 0; x < qrCode.Size; x++ {
+			if qrCode.Dark(x, y) {
+				dark++
+			}
+		}
+	}
+	if paths := strings.Count(qrCode.SVG(), "h1v1h-1z"); paths != dark {
+		t.Errorf("SVG draws %d modules, want %d", paths, dark)
+	}
+	lines := strings.Split(strings.TrimSuffix(qrCode.Terminal(), "\n"), "\n")
+	if len(lines) != (width+1)/2 || len([]rune(lines[0])) != width {
+		t.Errorf("terminal rendering is %d lines of %d, want %d of %d", len(lines), len([]rune(lines[0])), (width+1)/2, width)
+	}
+}
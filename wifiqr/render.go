@@ -0,0 +1,87 @@
+package wifiqr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+const (
+	// QuietZone is the number of light modules the specification
+	// requires around the symbol
+	QuietZone = 4
+)
+
+// Image returns the symbol as an image with each module scale pixels
+// wide, surrounded by the quiet zone
+func (qrCode *QRCode) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	width := (qrCode.Size + QuietZone*2) * scale
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, width, width), palette)
+	for y := 0; y < width; y++ {
+		for x := 0; x < width; x++ {
+			if qrCode.Dark(x/scale-QuietZone, y/scale-QuietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG writes the symbol as a PNG image
+func (qrCode *QRCode) WritePNG(writer io.Writer, scale int) error {
+	return png.Encode(writer, qrCode.Image(scale))
+}
+
+// SVG returns the symbol as an SVG document that scales to any size.
+// Each module is one user unit.
+func (qrCode *QRCode) SVG() string {
+	var buffer bytes.Buffer
+	width := qrCode.Size + QuietZone*2
+	fmt.Fprintf(&buffer, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buffer, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n", width, width)
+	fmt.Fprintf(&buffer, `<rect width="100%%" height="100%%" fill="#FFFFFF"/>`+"\n")
+	buffer.WriteString(`<path fill="#000000" d="`)
+	for y := 0; y < qrCode.Size; y++ {
+		for x := 0; x < qrCode.Size; x++ {
+			if qrCode.Dark(x, y) {
+				fmt.Fprintf(&buffer, "M%d,%dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	buffer.WriteString("\"/>\n</svg>\n")
+	return buffer.String()
+}
+
+// Terminal returns the symbol drawn with Unicode half blocks, two
+// module rows per line. Terminals usually draw light text on a dark
+// background so dark modules are left blank and light ones are drawn.
+func (qrCode *QRCode) Terminal() string {
+	var buffer bytes.Buffer
+	start := -QuietZone
+	end := qrCode.Size + QuietZone
+	for y := start; y < end; y += 2 {
+		for x := start; x < end; x++ {
+			top := qrCode.Dark(x, y)
+			bottom := qrCode.Dark(x, y+1) || y+1 >= end
+			switch {
+			case !top && !bottom:
+				buffer.WriteString("█")
+			case !top && bottom:
+				buffer.WriteString("▀")
+			case top && !bottom:
+				buffer.WriteString("▄")
+			default:
+				buffer.WriteString(" ")
+			}
+		}
+		buffer.WriteByte('\n')
+	}
+	return buffer.String()
+}
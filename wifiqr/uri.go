@@ -0,0 +1,178 @@
+package wifiqr
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/ottopress/WifiManager"
+)
+
+const (
+	// URIScheme is the prefix of every WiFi network URI
+	URIScheme = "WIFI:"
+)
+
+var (
+	// ErrInvalidURI is returned when a URI isn't a WiFi network URI
+	ErrInvalidURI = errors.New("wifiqr: invalid WIFI: uri")
+	// ErrMissingURISSID is returned when a URI has no S: field
+	ErrMissingURISSID = errors.New("wifiqr: uri has no ssid")
+
+	// uriTypes maps the upper case T: field values to security
+	// protocols
+	uriTypes = map[string]int{
+		"":         wifimanager.SecurityNone,
+		"NOPASS":   wifimanager.SecurityNone,
+		"WEP":      wifimanager.SecurityWEP,
+		"WPA":      wifimanager.SecurityWPA2,
+		"WPA2":     wifimanager.SecurityWPA2,
+		"SAE":      wifimanager.SecurityWPA3,
+		"WPA2-EAP": wifimanager.SecurityWPA2,
+	}
+)
+
+// ParseURI parses a WIFI:T:WPA;S:name;P:key;H:true;; URI, as read from
+// a QR code, into a profile. The E:, A:, I: and PH2: fields used for
+// enterprise networks are read into the profile's EAP settings.
+func ParseURI(uri string) (wifimanager.Profile, error) {
+	profile := wifimanager.Profile{AutoJoin: true}
+	if !strings.HasPrefix(strings.ToUpper(uri), URIScheme) {
+		return profile, ErrInvalidURI
+	}
+	fields, splitErr := splitFields(uri[len(URIScheme):])
+	if splitErr != nil {
+		return profile, splitErr
+	}
+	ssid, hasSSID := fields["S"]
+	if !hasSSID || ssid == "" {
+		return profile, ErrMissingURISSID
+	}
+	profile.SSID = ssid
+	// generators disagree on the case of the type, such as wpa or
+	// WPA
+	networkType := strings.ToUpper(fields["T"])
+	security, knownType := uriTypes[networkType]
+	if !knownType {
+		return profile, errors.New("wifiqr: unknown network type " + fields["T"])
+	}
+	profile.Security = security
+	profile.SecurityKey = fields["P"]
+	profile.Hidden = strings.EqualFold(fields["H"], "true")
+	if networkType == "WPA2-EAP" || fields["E"] != "" {
+		profile.SecurityKey = ""
+		profile.EAP = &wifimanager.EAPConfig{
+			Method:            fields["E"],
+			AnonymousIdentity: fields["A"],
+			Identity:          fields["I"],
+			Password:          fields["P"],
+			Phase2:            fields["PH2"],
+		}
+	}
+	return profile, nil
+}
+
+// FormatURI returns the WIFI: URI for the profile
+func FormatURI(profile wifimanager.Profile) string {
+	var buffer bytes.Buffer
+	buffer.WriteString(URIScheme)
+	switch {
+	case profile.EAP != nil:
+		buffer.WriteString("T:WPA2-EAP;")
+	case profile.Security == wifimanager.SecurityWEP:
+		buffer.WriteString("T:WEP;")
+	case profile.Security == wifimanager.SecurityWPA || profile.Security == wifimanager.SecurityWPA2:
+		buffer.WriteString("T:WPA;")
+	case profile.Security == wifimanager.SecurityWPA3:
+		buffer.WriteString("T:SAE;")
+	default:
+		buffer.WriteString("T:nopass;")
+	}
+	buffer.WriteString("S:" + escape(profile.SSID) + ";")
+	if profile.EAP != nil {
+		writeField(&buffer, "E", profile.EAP.Method)
+		writeField(&buffer, "A", profile.EAP.AnonymousIdentity)
+		writeField(&buffer, "I", profile.EAP.Identity)
+		writeField(&buffer, "P", profile.EAP.Password)
+		writeField(&buffer, "PH2", profile.EAP.Phase2)
+	} else if profile.Security != wifimanager.SecurityNone {
+		writeField(&buffer, "P", profile.SecurityKey)
+	}
+	if profile.Hidden {
+		buffer.WriteString("H:true;")
+	}
+	buffer.WriteString(";")
+	return buffer.String()
+}
+
+// FormatNetworkURI returns the WIFI: URI for a network, using its
+// SecurityKey and the protocol of its first security configuration
+func FormatNetworkURI(network wifimanager.WifiNetwork) string {
+	return FormatURI(wifimanager.NewProfile(network))
+}
+
+// writeField writes a field if its value isn't empty
+func writeField(buffer *bytes.Buffer, name, value string) {
+	if value != "" {
+		buffer.WriteString(name + ":" + escape(value) + ";")
+	}
+}
+
+// escape backslash escapes the characters that are special in a URI
+// field value
+func escape(value string) string {
+	var buffer bytes.Buffer
+	for _, char := range value {
+		if strings.ContainsRune(`\;,":`, char) {
+			buffer.WriteByte('\\')
+		}
+		buffer.WriteRune(char)
+	}
+	return buffer.String()
+}
+
+// splitFields splits the NAME:value; fields of a URI body, undoing
+// backslash escapes
+func splitFields(body string) (map[string]string, error) {
+	fields := map[string]string{}
+	var current bytes.Buffer
+	name := ""
+	inValue := false
+	for index := 0; index < len(body); index++ {
+		char := body[index]
+		switch {
+		case char == '\\' && inValue:
+			index++
+			if index >= len(body) {
+				return nil, ErrInvalidURI
+			}
+			current.WriteByte(body[index])
+		case char == ':' && !inValue:
+			name = strings.ToUpper(current.String())
+			current.Reset()
+			inValue = true
+		case char == ';':
+			if inValue {
+				fields[name] = current.String()
+			} else if current.Len() > 0 {
+				return nil, ErrInvalidURI
+			}
+			current.Reset()
+			inValue = false
+		default:
+			current.WriteByte(char)
+		}
+	}
+	// be lenient with generators that leave off the final separator
+	if inValue {
+		fields[name] = current.String()
+	} else if current.Len() > 0 {
+		return nil, ErrInvalidURI
+	}
+	return fields, nil
+}
+
+// EncodeProfile encodes the WIFI: URI of the profile as a QR code
+func EncodeProfile(profile wifimanager.Profile, level int) (*QRCode, error) {
+	return Encode([]byte(FormatURI(profile)), level)
+}
@@ -0,0 +1,86 @@
+package wifiqr
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ottopress/WifiManager"
+)
+
+func TestURIRoundTrip(t *testing.T) {
+	tests := []struct {
+		profile wifimanager.Profile
+		uri     string
+	}{
+		{
+			profile: wifimanager.Profile{SSID: "Cafe", Security: wifimanager.SecurityNone, AutoJoin: true},
+			uri:     "WIFI:T:nopass;S:Cafe;;",
+		},
+		{
+			profile: wifimanager.Profile{SSID: "home", Security: wifimanager.SecurityWPA2, SecurityKey: "correct horse", AutoJoin: true},
+			uri:     "WIFI:T:WPA;S:home;P:correct horse;;",
+		},
+		{
+			profile: wifimanager.Profile{SSID: "legacy", Security: wifimanager.SecurityWEP, SecurityKey: "0123456789", Hidden: true, AutoJoin: true},
+			uri:     "WIFI:T:WEP;S:legacy;P:0123456789;H:true;;",
+		},
+		{
+			profile: wifimanager.Profile{SSID: `a;b,c:d"e\f`, Security: wifimanager.SecurityWPA3, SecurityKey: `p\a;s,s:w"d`, AutoJoin: true},
+			uri:     `WIFI:T:SAE;S:a\;b\,c\:d\"e\\f;P:p\\a\;s\,s\:w\"d;;`,
+		},
+		{
+			profile: wifimanager.Profile{SSID: "CorpNet", Security: wifimanager.SecurityWPA2, AutoJoin: true, EAP: &wifimanager.EAPConfig{
+				Method:            "PEAP",
+				AnonymousIdentity: "anonymous",
+				Identity:          "jdoe@example.com",
+				Password:          "hunter;2",
+				Phase2:            "MSCHAPV2",
+			}},
+			uri: `WIFI:T:WPA2-EAP;S:CorpNet;E:PEAP;A:anonymous;I:jdoe@example.com;P:hunter\;2;PH2:MSCHAPV2;;`,
+		},
+	}
+	for _, test := range tests {
+		if uri := FormatURI(test.profile); uri != test.uri {
+			t.Errorf("FormatURI(%+v) = %s, want %s", test.profile, uri, test.uri)
+		}
+		profile, parseErr := ParseURI(test.uri)
+		if parseErr != nil {
+			t.Fatalf("%s: %v", test.uri, parseErr)
+		}
+		if !reflect.DeepEqual(profile, test.profile) {
+			t.Errorf("ParseURI(%s) = %+v, want %+v", test.uri, profile, test.profile)
+		}
+	}
+}
+
+func TestParseURILenient(t *testing.T) {
+	tests := map[string]wifimanager.Profile{
+		"WIFI:T:wpa;S:home;P:correct horse;;": {SSID: "home", Security: wifimanager.SecurityWPA2, SecurityKey: "correct horse", AutoJoin: true},
+		"wifi:s:home;t:Sae;p:secret;h:TRUE":   {SSID: "home", Security: wifimanager.SecurityWPA3, SecurityKey: "secret", Hidden: true, AutoJoin: true},
+		"WIFI:S:open;;":                       {SSID: "open", Security: wifimanager.SecurityNone, AutoJoin: true},
+	}
+	for uri, want := range tests {
+		profile, parseErr := ParseURI(uri)
+		if parseErr != nil || !reflect.DeepEqual(profile, want) {
+			t.Errorf("ParseURI(%s) = %+v, %v, want %+v", uri, profile, parseErr, want)
+		}
+	}
+}
+
+func TestParseURIInvalid(t *testing.T) {
+	tests := map[string]error{
+		"MECARD:N:home;;":          ErrInvalidURI,
+		"WIFI:T:WPA;P:secret;;":    ErrMissingURISSID,
+		"WIFI:T:WPA;S:;;":          ErrMissingURISSID,
+		`WIFI:T:WPA;S:home\`:       ErrInvalidURI,
+		"WIFI:T:WPA;garbage;S:x;;": ErrInvalidURI,
+	}
+	for uri, want := range tests {
+		if _, parseErr := ParseURI(uri); parseErr != want {
+			t.Errorf("ParseURI(%s) error = %v, want %v", uri, parseErr, want)
+		}
+	}
+	if _, parseErr := ParseURI("WIFI:T:WPA4;S:home;;"); parseErr == nil {
+		t.Error("unknown network type parsed")
+	}
+}
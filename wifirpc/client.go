@@ -0,0 +1,212 @@
+package wifirpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ottopress/WifiManager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultTimeout is the time a call may take before it is given
+	// up on, long enough for a remote scan or connection attempt
+	DefaultTimeout = 60 * time.Second
+)
+
+// Client drives the interfaces of a remote Manager. It satisfies the
+// Backend interface, so the interfaces it returns can be used like
+// local ones.
+type Client struct {
+	Timeout time.Duration
+	client  WifiManagerClient
+}
+
+// NewClient creates a client using the connection. Pass
+// NewTokenCredentials with grpc.WithPerRPCCredentials when the server
+// requires a token.
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{Timeout: DefaultTimeout, client: NewWifiManagerClient(conn)}
+}
+
+// context returns the context calls are made with
+func (client *Client) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), client.Timeout)
+}
+
+// Name returns the name of the backend
+func (client *Client) Name() string {
+	return "grpc"
+}
+
+// IsInstalled returns whether or not the server can be reached
+func (client *Client) IsInstalled() bool {
+	ctx, cancel := client.context()
+	defer cancel()
+	_, callErr := client.client.ListInterfaces(ctx, &ListInterfacesRequest{})
+	return callErr == nil
+}
+
+// Interfaces returns the interfaces of the remote manager, driven by
+// this client
+func (client *Client) Interfaces() ([]wifimanager.WifiInterface, error) {
+	ctx, cancel := client.context()
+	defer cancel()
+	response, callErr := client.client.ListInterfaces(ctx, &ListInterfacesRequest{})
+	if callErr != nil {
+		return nil, clientError(callErr)
+	}
+	wifiInterfaces := []wifimanager.WifiInterface{}
+	for _, iface := range response.GetInterfaces() {
+		wifiInterfaces = append(wifiInterfaces, iface.wifiInterface(client))
+	}
+	return wifiInterfaces, nil
+}
+
+// Scan returns the networks reachable from the remote interface
+func (client *Client) Scan(iface string) ([]wifimanager.WifiNetwork, error) {
+	ctx, cancel := client.context()
+	defer cancel()
+	response, callErr := client.client.Scan(ctx, &ScanRequest{Interface: iface})
+	if callErr != nil {
+		return nil, clientError(callErr)
+	}
+	return wifiNetworks(response.GetNetworks()), nil
+}
+
+// Connect joins the remote interface to the network
+func (client *Client) Connect(iface string, network wifimanager.WifiNetwork) error {
+	ctx, cancel := client.context()
+	defer cancel()
+	_, callErr := client.client.Connect(ctx, &ConnectRequest{Interface: iface, Network: newNetwork(network)})
+	return clientError(callErr)
+}
+
+// Disconnect leaves the network the remote interface is connected to
+func (client *Client) Disconnect(iface string) error {
+	ctx, cancel := client.context()
+	defer cancel()
+	_, callErr := client.client.Disconnect(ctx, &DisconnectRequest{Interface: iface})
+	return clientError(callErr)
+}
+
+// Up turns on the remote interface
+func (client *Client) Up(iface string) error {
+	ctx, cancel := client.context()
+	defer cancel()
+	_, callErr := client.client.SetPower(ctx, &SetPowerRequest{Interface: iface, Powered: true})
+	return clientError(callErr)
+}
+
+// Down turns off the remote interface
+func (client *Client) Down(iface string) error {
+	ctx, cancel := client.context()
+	defer cancel()
+	_, callErr := client.client.SetPower(ctx, &SetPowerRequest{Interface: iface, Powered: false})
+	return clientError(callErr)
+}
+
+// Status returns the power state of the remote interface
+func (client *Client) Status(iface string) (bool, error) {
+	ctx, cancel := client.context()
+	defer cancel()
+	response, callErr := client.client.GetPower(ctx, &GetPowerRequest{Interface: iface})
+	if callErr != nil {
+		return false, clientError(callErr)
+	}
+	return response.GetPowered(), nil
+}
+
+// State returns whether the remote interface is off, connected or
+// not, from its power state and the connection the remote manager
+// remembers for it
+func (client *Client) State(iface string) (int, error) {
+	powered, statusErr := client.Status(iface)
+	if statusErr != nil || !powered {
+		return wifimanager.StateOff, statusErr
+	}
+	ctx, cancel := client.context()
+	defer cancel()
+	response, callErr := client.client.ListInterfaces(ctx, &ListInterfacesRequest{})
+	if callErr != nil {
+		return wifimanager.StateOff, clientError(callErr)
+	}
+	for _, remote := range response.GetInterfaces() {
+		if remote.GetName() == iface && remote.GetConnection().GetSsid() != "" {
+			return wifimanager.StateConnected, nil
+		}
+	}
+	return wifimanager.StateDisconnected, nil
+}
+
+// Events streams the events of the remote manager until the context
+// is done or the connection fails, at which point the channel is
+// closed
+func (client *Client) Events(ctx context.Context) (<-chan wifimanager.Event, error) {
+	stream, callErr := client.client.WatchEvents(ctx, &WatchEventsRequest{})
+	if callErr != nil {
+		return nil, clientError(callErr)
+	}
+	events := make(chan wifimanager.Event)
+	go func() {
+		defer close(events)
+		for {
+			event, recvErr := stream.Recv()
+			if recvErr != nil {
+				return
+			}
+			select {
+			case events <- event.event():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// event converts a protobuf event
+func (event *Event) event() wifimanager.Event {
+	converted := wifimanager.Event{
+		Type:      wifimanager.EventError,
+		Interface: event.GetInterface(),
+		Time:      event.GetTime().AsTime(),
+		Powered:   event.GetPowered(),
+	}
+	for libraryType, protoType := range eventTypes {
+		if protoType == event.GetType() {
+			converted.Type = libraryType
+		}
+	}
+	if event.GetNetwork() != nil {
+		network := event.GetNetwork().wifiNetwork()
+		converted.Network = &network
+	}
+	if event.GetNetworks() != nil {
+		converted.Networks = wifiNetworks(event.GetNetworks())
+	}
+	if event.GetError() != "" {
+		converted.Err = errors.New(event.GetError())
+	}
+	return converted
+}
+
+// clientError converts a gRPC status back to the well known error it
+// was created from, or to a plain error holding its message
+func clientError(err error) error {
+	if err == nil {
+		return err
+	}
+	callStatus, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for knownErr, code := range errorCodes {
+		if code == callStatus.Code() && knownErr.Error() == callStatus.Message() {
+			return knownErr
+		}
+	}
+	return errors.New(callStatus.Message())
+}
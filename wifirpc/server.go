@@ -0,0 +1,162 @@
+package wifirpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/ottopress/WifiManager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// errorCodes holds the status code each well known error is
+	// reported with. Errors not listed are reported as Unknown.
+	errorCodes = map[error]codes.Code{
+		wifimanager.ErrUnknownIface:        codes.NotFound,
+		wifimanager.ErrMissingIface:        codes.NotFound,
+		wifimanager.ErrMissingAP:           codes.NotFound,
+		wifimanager.ErrMissingProfile:      codes.NotFound,
+		wifimanager.ErrInvalidPassphrase:   codes.InvalidArgument,
+		wifimanager.ErrRawPSK:              codes.InvalidArgument,
+		wifimanager.ErrUnknownSecurity:     codes.InvalidArgument,
+		wifimanager.ErrUnsupportedSecurity: codes.InvalidArgument,
+	}
+)
+
+// Server implements the WifiManager service on top of a Manager
+type Server struct {
+	UnimplementedWifiManagerServer
+	manager *wifimanager.Manager
+}
+
+// NewServer creates a server for the manager. Register it with
+// RegisterWifiManagerServer.
+func NewServer(manager *wifimanager.Manager) *Server {
+	return &Server{manager: manager}
+}
+
+// TokenAuth returns the server options that reject calls not carrying
+// the bearer token, as sent by TokenCredentials. An empty token lets
+// every call through.
+func TokenAuth(token string) []grpc.ServerOption {
+	if token == "" {
+		return nil
+	}
+	authorize := func(ctx context.Context) error {
+		values := metadata.ValueFromIncomingContext(ctx, "authorization")
+		if len(values) == 1 && subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(values[0], "Bearer ")), []byte(token)) == 1 {
+			return nil
+		}
+		return status.Error(codes.Unauthenticated, "missing or invalid token")
+	}
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if authErr := authorize(ctx); authErr != nil {
+				return nil, authErr
+			}
+			return handler(ctx, request)
+		}),
+		grpc.StreamInterceptor(func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if authErr := authorize(stream.Context()); authErr != nil {
+				return authErr
+			}
+			return handler(server, stream)
+		}),
+	}
+}
+
+// ListInterfaces returns the interfaces of the manager
+func (server *Server) ListInterfaces(ctx context.Context, request *ListInterfacesRequest) (*ListInterfacesResponse, error) {
+	wifiInterfaces, ifaceErr := server.manager.Interfaces()
+	if ifaceErr != nil {
+		return nil, statusError(ifaceErr)
+	}
+	response := &ListInterfacesResponse{}
+	for _, wifiInterface := range wifiInterfaces {
+		response.Interfaces = append(response.Interfaces, newInterface(wifiInterface))
+	}
+	return response, nil
+}
+
+// Scan returns the networks reachable from the interface
+func (server *Server) Scan(ctx context.Context, request *ScanRequest) (*ScanResponse, error) {
+	networks, scanErr := server.manager.Scan(request.GetInterface())
+	if scanErr != nil {
+		return nil, statusError(scanErr)
+	}
+	return &ScanResponse{Networks: newNetworks(networks)}, nil
+}
+
+// Connect joins the interface to the network
+func (server *Server) Connect(ctx context.Context, request *ConnectRequest) (*ConnectResponse, error) {
+	if request.GetNetwork() == nil {
+		return nil, status.Error(codes.InvalidArgument, "network is required")
+	}
+	network := request.GetNetwork().wifiNetwork()
+	wifimanager.RegisterSecret(network.SecurityKey)
+	defer wifimanager.ForgetSecret(network.SecurityKey)
+	connectErr := server.manager.Connect(request.GetInterface(), network)
+	if connectErr != nil {
+		return nil, statusError(connectErr)
+	}
+	return &ConnectResponse{}, nil
+}
+
+// Disconnect leaves the network the interface is connected to
+func (server *Server) Disconnect(ctx context.Context, request *DisconnectRequest) (*DisconnectResponse, error) {
+	disconnectErr := server.manager.Disconnect(request.GetInterface())
+	if disconnectErr != nil {
+		return nil, statusError(disconnectErr)
+	}
+	return &DisconnectResponse{}, nil
+}
+
+// SetPower turns the interface on or off
+func (server *Server) SetPower(ctx context.Context, request *SetPowerRequest) (*SetPowerResponse, error) {
+	powerErr := server.manager.SetPower(request.GetInterface(), request.GetPowered())
+	if powerErr != nil {
+		return nil, statusError(powerErr)
+	}
+	return &SetPowerResponse{}, nil
+}
+
+// GetPower returns the power state of the interface
+func (server *Server) GetPower(ctx context.Context, request *GetPowerRequest) (*GetPowerResponse, error) {
+	powered, statusErr := server.manager.Status(request.GetInterface())
+	if statusErr != nil {
+		return nil, statusError(statusErr)
+	}
+	return &GetPowerResponse{Powered: powered}, nil
+}
+
+// WatchEvents streams the events of the manager until the client
+// goes away
+func (server *Server) WatchEvents(request *WatchEventsRequest, stream WifiManager_WatchEventsServer) error {
+	events, unsubscribe := server.manager.Subscribe()
+	defer unsubscribe()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-events:
+			sendErr := stream.Send(newEvent(event))
+			if sendErr != nil {
+				return sendErr
+			}
+		}
+	}
+}
+
+// statusError converts the error to a gRPC status with any secret
+// removed from its message
+func statusError(err error) error {
+	code, known := errorCodes[err]
+	if !known {
+		code = codes.Unknown
+	}
+	return status.Error(code, wifimanager.Redact(err.Error()))
+}
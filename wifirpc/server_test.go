@@ -0,0 +1,163 @@
+package wifirpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ottopress/WifiManager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves a manager of a simulated backend over an in
+// memory connection and returns a client of it. The target names
+// localhost so token credentials may go without TLS.
+func newTestClient(t *testing.T, token string, options ...grpc.DialOption) (*Client, *wifimanager.SimulatedBackend) {
+	t.Helper()
+	backend := wifimanager.NewSimulatedBackend("sim0")
+	backend.AddNetwork(wifimanager.WifiNetwork{
+		SSID:        "home",
+		BSSID:       "02:11:22:33:44:01",
+		RSSI:        -48,
+		Security:    []wifimanager.WifiNetworkSecurity{{Protocol: wifimanager.SecurityWPA2}},
+		SecurityKey: "correct horse",
+	})
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(TokenAuth(token)...)
+	RegisterWifiManagerServer(server, NewServer(wifimanager.NewManager(backend, nil)))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	dialer := func(ctx context.Context, address string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}
+	options = append(options, grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, dialErr := grpc.NewClient("passthrough:///localhost:50051", options...)
+	if dialErr != nil {
+		t.Fatal(dialErr)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := NewClient(conn)
+	client.Timeout = 5 * time.Second
+	return client, backend
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	client, backend := newTestClient(t, "")
+	wifiInterfaces, ifaceErr := client.Interfaces()
+	if ifaceErr != nil || len(wifiInterfaces) != 1 || wifiInterfaces[0].Name != "sim0" {
+		t.Fatalf("interfaces = %+v, %v", wifiInterfaces, ifaceErr)
+	}
+	if wifiInterfaces[0].Backend() != client {
+		t.Error("remote interface isn't driven by the client")
+	}
+
+	networks, scanErr := client.Scan("sim0")
+	if scanErr != nil || len(networks) != 1 || networks[0].SSID != "home" || networks[0].RSSI != -48 {
+		t.Fatalf("networks = %+v, %v", networks, scanErr)
+	}
+	if networks[0].SecurityKey != "" {
+		t.Error("scan results carry the security key")
+	}
+
+	// the key only reaches the backend through the request, so a
+	// wrong one fails
+	network := wifimanager.WifiNetwork{SSID: "home", Security: networks[0].Security, SecurityKey: "wrong horse"}
+	if connectErr := client.Connect("sim0", network); connectErr == nil {
+		t.Fatal("connected with the wrong key")
+	}
+	network.SecurityKey = "correct horse"
+	if connectErr := client.Connect("sim0", network); connectErr != nil {
+		t.Fatal(connectErr)
+	}
+	if connection, _ := backend.Connection("sim0"); connection == nil || connection.SSID != "home" {
+		t.Fatalf("connection = %+v", connection)
+	}
+	if state, stateErr := client.State("sim0"); stateErr != nil || state != wifimanager.StateConnected {
+		t.Errorf("state = %s, %v", wifimanager.StateName(state), stateErr)
+	}
+
+	if downErr := client.Down("sim0"); downErr != nil {
+		t.Fatal(downErr)
+	}
+	if powered, statusErr := client.Status("sim0"); statusErr != nil || powered {
+		t.Errorf("powered = %v, %v after Down", powered, statusErr)
+	}
+	if upErr := client.Up("sim0"); upErr != nil {
+		t.Fatal(upErr)
+	}
+	if powered, statusErr := client.Status("sim0"); statusErr != nil || !powered {
+		t.Errorf("powered = %v, %v after Up", powered, statusErr)
+	}
+}
+
+func TestClientWatchEvents(t *testing.T) {
+	client, _ := newTestClient(t, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, eventsErr := client.Events(ctx)
+	if eventsErr != nil {
+		t.Fatal(eventsErr)
+	}
+	// the server subscribes once the stream reaches it, so keep
+	// producing events until one arrives
+	deadline := time.After(5 * time.Second)
+	for {
+		if powerErr := client.Up("sim0"); powerErr != nil {
+			t.Fatal(powerErr)
+		}
+		select {
+		case event := <-events:
+			if event.Type != wifimanager.EventPower || event.Interface != "sim0" || !event.Powered {
+				t.Fatalf("event = %+v, want sim0 powered on", event)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no event received")
+		}
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	client, _ := newTestClient(t, "")
+	if _, scanErr := client.Scan("wlan9"); scanErr != wifimanager.ErrUnknownIface {
+		t.Errorf("Scan error = %v, want ErrUnknownIface", scanErr)
+	}
+	if connectErr := client.Connect("wlan9", wifimanager.WifiNetwork{SSID: "home"}); connectErr != wifimanager.ErrUnknownIface {
+		t.Errorf("Connect error = %v, want ErrUnknownIface", connectErr)
+	}
+	if _, statusErr := client.Status("wlan9"); statusErr != wifimanager.ErrUnknownIface {
+		t.Errorf("Status error = %v, want ErrUnknownIface", statusErr)
+	}
+}
+
+func TestTokenAuth(t *testing.T) {
+	client, _ := newTestClient(t, "secret-token")
+	if _, ifaceErr := client.client.ListInterfaces(context.Background(), &ListInterfacesRequest{}); status.Code(ifaceErr) != codes.Unauthenticated {
+		t.Errorf("call without token = %v, want Unauthenticated", ifaceErr)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, streamErr := client.client.WatchEvents(ctx, &WatchEventsRequest{})
+	if streamErr == nil {
+		_, streamErr = stream.Recv()
+	}
+	if status.Code(streamErr) != codes.Unauthenticated {
+		t.Errorf("stream without token = %v, want Unauthenticated", streamErr)
+	}
+
+	wrong, _ := newTestClient(t, "secret-token", grpc.WithPerRPCCredentials(NewTokenCredentials("wrong-token", "passthrough:///localhost:50051")))
+	if _, ifaceErr := wrong.Interfaces(); ifaceErr == nil {
+		t.Error("call with the wrong token succeeded")
+	}
+	right, _ := newTestClient(t, "secret-token", grpc.WithPerRPCCredentials(NewTokenCredentials("secret-token", "passthrough:///localhost:50051")))
+	if _, ifaceErr := right.Interfaces(); ifaceErr != nil {
+		t.Errorf("call with the token = %v", ifaceErr)
+	}
+}
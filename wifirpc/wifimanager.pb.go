@@ -0,0 +1,1251 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        v5.29.3
+// source: wifimanager.proto
+
+package wifirpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SecurityProtocol mirrors the Security constants of the library
+type SecurityProtocol int32
+
+const (
+	SecurityProtocol_SECURITY_PROTOCOL_UNSPECIFIED SecurityProtocol = 0
+	SecurityProtocol_SECURITY_PROTOCOL_NONE        SecurityProtocol = 1
+	SecurityProtocol_SECURITY_PROTOCOL_WEP         SecurityProtocol = 2
+	SecurityProtocol_SECURITY_PROTOCOL_WPA         SecurityProtocol = 3
+	SecurityProtocol_SECURITY_PROTOCOL_WPA2        SecurityProtocol = 4
+	SecurityProtocol_SECURITY_PROTOCOL_WPA3        SecurityProtocol = 5
+)
+
+// Enum value maps for SecurityProtocol.
+var (
+	SecurityProtocol_name = map[int32]string{
+		0: "SECURITY_PROTOCOL_UNSPECIFIED",
+		1: "SECURITY_PROTOCOL_NONE",
+		2: "SECURITY_PROTOCOL_WEP",
+		3: "SECURITY_PROTOCOL_WPA",
+		4: "SECURITY_PROTOCOL_WPA2",
+		5: "SECURITY_PROTOCOL_WPA3",
+	}
+	SecurityProtocol_value = map[string]int32{
+		"SECURITY_PROTOCOL_UNSPECIFIED": 0,
+		"SECURITY_PROTOCOL_NONE":        1,
+		"SECURITY_PROTOCOL_WEP":         2,
+		"SECURITY_PROTOCOL_WPA":         3,
+		"SECURITY_PROTOCOL_WPA2":        4,
+		"SECURITY_PROTOCOL_WPA3":        5,
+	}
+)
+
+func (x SecurityProtocol) Enum() *SecurityProtocol {
+	p := new(SecurityProtocol)
+	*p = x
+	return p
+}
+
+func (x SecurityProtocol) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SecurityProtocol) Descriptor() protoreflect.EnumDescriptor {
+	return file_wifimanager_proto_enumTypes[0].Descriptor()
+}
+
+func (SecurityProtocol) Type() protoreflect.EnumType {
+	return &file_wifimanager_proto_enumTypes[0]
+}
+
+func (x SecurityProtocol) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SecurityProtocol.Descriptor instead.
+func (SecurityProtocol) EnumDescriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{0}
+}
+
+// EventType mirrors the Event constants of the library
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED       EventType = 0
+	EventType_EVENT_TYPE_SCAN              EventType = 1
+	EventType_EVENT_TYPE_CONNECT           EventType = 2
+	EventType_EVENT_TYPE_DISCONNECT        EventType = 3
+	EventType_EVENT_TYPE_POWER             EventType = 4
+	EventType_EVENT_TYPE_ERROR             EventType = 5
+	EventType_EVENT_TYPE_INTERFACE_ADDED   EventType = 6
+	EventType_EVENT_TYPE_INTERFACE_REMOVED EventType = 7
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "EVENT_TYPE_SCAN",
+		2: "EVENT_TYPE_CONNECT",
+		3: "EVENT_TYPE_DISCONNECT",
+		4: "EVENT_TYPE_POWER",
+		5: "EVENT_TYPE_ERROR",
+		6: "EVENT_TYPE_INTERFACE_ADDED",
+		7: "EVENT_TYPE_INTERFACE_REMOVED",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED":       0,
+		"EVENT_TYPE_SCAN":              1,
+		"EVENT_TYPE_CONNECT":           2,
+		"EVENT_TYPE_DISCONNECT":        3,
+		"EVENT_TYPE_POWER":             4,
+		"EVENT_TYPE_ERROR":             5,
+		"EVENT_TYPE_INTERFACE_ADDED":   6,
+		"EVENT_TYPE_INTERFACE_REMOVED": 7,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_wifimanager_proto_enumTypes[1].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_wifimanager_proto_enumTypes[1]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{1}
+}
+
+// Interface mirrors WifiInterface
+type Interface struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Index         int32                  `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Mtu           int32                  `protobuf:"varint,3,opt,name=mtu,proto3" json:"mtu,omitempty"`
+	HardwareAddr  []byte                 `protobuf:"bytes,4,opt,name=hardware_addr,json=hardwareAddr,proto3" json:"hardware_addr,omitempty"`
+	Flags         uint32                 `protobuf:"varint,5,opt,name=flags,proto3" json:"flags,omitempty"`
+	Model         string                 `protobuf:"bytes,6,opt,name=model,proto3" json:"model,omitempty"`
+	Vendor        string                 `protobuf:"bytes,7,opt,name=vendor,proto3" json:"vendor,omitempty"`
+	Connection    *Network               `protobuf:"bytes,8,opt,name=connection,proto3" json:"connection,omitempty"`
+	Backend       string                 `protobuf:"bytes,9,opt,name=backend,proto3" json:"backend,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Interface) Reset() {
+	*x = Interface{}
+	mi := &file_wifimanager_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Interface) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Interface) ProtoMessage() {}
+
+func (x *Interface) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Interface.ProtoReflect.Descriptor instead.
+func (*Interface) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Interface) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Interface) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Interface) GetMtu() int32 {
+	if x != nil {
+		return x.Mtu
+	}
+	return 0
+}
+
+func (x *Interface) GetHardwareAddr() []byte {
+	if x != nil {
+		return x.HardwareAddr
+	}
+	return nil
+}
+
+func (x *Interface) GetFlags() uint32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
+func (x *Interface) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Interface) GetVendor() string {
+	if x != nil {
+		return x.Vendor
+	}
+	return ""
+}
+
+func (x *Interface) GetConnection() *Network {
+	if x != nil {
+		return x.Connection
+	}
+	return nil
+}
+
+func (x *Interface) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+// Network mirrors WifiNetwork. The security key is only ever sent to
+// the server.
+type Network struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ssid          string                 `protobuf:"bytes,1,opt,name=ssid,proto3" json:"ssid,omitempty"`
+	Bssid         string                 `protobuf:"bytes,2,opt,name=bssid,proto3" json:"bssid,omitempty"`
+	Rssi          int32                  `protobuf:"varint,3,opt,name=rssi,proto3" json:"rssi,omitempty"`
+	Ht            bool                   `protobuf:"varint,4,opt,name=ht,proto3" json:"ht,omitempty"`
+	Channel       int32                  `protobuf:"varint,5,opt,name=channel,proto3" json:"channel,omitempty"`
+	Security      []*Security            `protobuf:"bytes,6,rep,name=security,proto3" json:"security,omitempty"`
+	SecurityKey   string                 `protobuf:"bytes,7,opt,name=security_key,json=securityKey,proto3" json:"security_key,omitempty"`
+	Hidden        bool                   `protobuf:"varint,8,opt,name=hidden,proto3" json:"hidden,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Network) Reset() {
+	*x = Network{}
+	mi := &file_wifimanager_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Network) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Network) ProtoMessage() {}
+
+func (x *Network) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Network.ProtoReflect.Descriptor instead.
+func (*Network) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Network) GetSsid() string {
+	if x != nil {
+		return x.Ssid
+	}
+	return ""
+}
+
+func (x *Network) GetBssid() string {
+	if x != nil {
+		return x.Bssid
+	}
+	return ""
+}
+
+func (x *Network) GetRssi() int32 {
+	if x != nil {
+		return x.Rssi
+	}
+	return 0
+}
+
+func (x *Network) GetHt() bool {
+	if x != nil {
+		return x.Ht
+	}
+	return false
+}
+
+func (x *Network) GetChannel() int32 {
+	if x != nil {
+		return x.Channel
+	}
+	return 0
+}
+
+func (x *Network) GetSecurity() []*Security {
+	if x != nil {
+		return x.Security
+	}
+	return nil
+}
+
+func (x *Network) GetSecurityKey() string {
+	if x != nil {
+		return x.SecurityKey
+	}
+	return ""
+}
+
+func (x *Network) GetHidden() bool {
+	if x != nil {
+		return x.Hidden
+	}
+	return false
+}
+
+// Security mirrors WifiNetworkSecurity
+type Security struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Protocol      SecurityProtocol       `protobuf:"varint,1,opt,name=protocol,proto3,enum=wifimanager.v1.SecurityProtocol" json:"protocol,omitempty"`
+	Method        int32                  `protobuf:"varint,2,opt,name=method,proto3" json:"method,omitempty"`
+	Unicasts      []int32                `protobuf:"varint,3,rep,packed,name=unicasts,proto3" json:"unicasts,omitempty"`
+	Group         int32                  `protobuf:"varint,4,opt,name=group,proto3" json:"group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Security) Reset() {
+	*x = Security{}
+	mi := &file_wifimanager_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Security) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Security) ProtoMessage() {}
+
+func (x *Security) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Security.ProtoReflect.Descriptor instead.
+func (*Security) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Security) GetProtocol() SecurityProtocol {
+	if x != nil {
+		return x.Protocol
+	}
+	return SecurityProtocol_SECURITY_PROTOCOL_UNSPECIFIED
+}
+
+func (x *Security) GetMethod() int32 {
+	if x != nil {
+		return x.Method
+	}
+	return 0
+}
+
+func (x *Security) GetUnicasts() []int32 {
+	if x != nil {
+		return x.Unicasts
+	}
+	return nil
+}
+
+func (x *Security) GetGroup() int32 {
+	if x != nil {
+		return x.Group
+	}
+	return 0
+}
+
+// Event mirrors the Event of the library
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=wifimanager.v1.EventType" json:"type,omitempty"`
+	Interface     string                 `protobuf:"bytes,2,opt,name=interface,proto3" json:"interface,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	Network       *Network               `protobuf:"bytes,4,opt,name=network,proto3" json:"network,omitempty"`
+	Networks      []*Network             `protobuf:"bytes,5,rep,name=networks,proto3" json:"networks,omitempty"`
+	Powered       bool                   `protobuf:"varint,6,opt,name=powered,proto3" json:"powered,omitempty"`
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_wifimanager_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Event) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *Event) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetNetwork() *Network {
+	if x != nil {
+		return x.Network
+	}
+	return nil
+}
+
+func (x *Event) GetNetworks() []*Network {
+	if x != nil {
+		return x.Networks
+	}
+	return nil
+}
+
+func (x *Event) GetPowered() bool {
+	if x != nil {
+		return x.Powered
+	}
+	return false
+}
+
+func (x *Event) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ListInterfacesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInterfacesRequest) Reset() {
+	*x = ListInterfacesRequest{}
+	mi := &file_wifimanager_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInterfacesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInterfacesRequest) ProtoMessage() {}
+
+func (x *ListInterfacesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInterfacesRequest.ProtoReflect.Descriptor instead.
+func (*ListInterfacesRequest) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{4}
+}
+
+type ListInterfacesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interfaces    []*Interface           `protobuf:"bytes,1,rep,name=interfaces,proto3" json:"interfaces,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInterfacesResponse) Reset() {
+	*x = ListInterfacesResponse{}
+	mi := &file_wifimanager_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInterfacesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInterfacesResponse) ProtoMessage() {}
+
+func (x *ListInterfacesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInterfacesResponse.ProtoReflect.Descriptor instead.
+func (*ListInterfacesResponse) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListInterfacesResponse) GetInterfaces() []*Interface {
+	if x != nil {
+		return x.Interfaces
+	}
+	return nil
+}
+
+type ScanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interface     string                 `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_wifimanager_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ScanRequest) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+type ScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Networks      []*Network             `protobuf:"bytes,1,rep,name=networks,proto3" json:"networks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_wifimanager_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ScanResponse) GetNetworks() []*Network {
+	if x != nil {
+		return x.Networks
+	}
+	return nil
+}
+
+type ConnectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interface     string                 `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	Network       *Network               `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectRequest) Reset() {
+	*x = ConnectRequest{}
+	mi := &file_wifimanager_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectRequest) ProtoMessage() {}
+
+func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectRequest.ProtoReflect.Descriptor instead.
+func (*ConnectRequest) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ConnectRequest) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *ConnectRequest) GetNetwork() *Network {
+	if x != nil {
+		return x.Network
+	}
+	return nil
+}
+
+type ConnectResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectResponse) Reset() {
+	*x = ConnectResponse{}
+	mi := &file_wifimanager_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectResponse) ProtoMessage() {}
+
+func (x *ConnectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectResponse.ProtoReflect.Descriptor instead.
+func (*ConnectResponse) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{9}
+}
+
+type DisconnectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interface     string                 `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisconnectRequest) Reset() {
+	*x = DisconnectRequest{}
+	mi := &file_wifimanager_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisconnectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisconnectRequest) ProtoMessage() {}
+
+func (x *DisconnectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisconnectRequest.ProtoReflect.Descriptor instead.
+func (*DisconnectRequest) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DisconnectRequest) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+type DisconnectResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisconnectResponse) Reset() {
+	*x = DisconnectResponse{}
+	mi := &file_wifimanager_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisconnectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisconnectResponse) ProtoMessage() {}
+
+func (x *DisconnectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisconnectResponse.ProtoReflect.Descriptor instead.
+func (*DisconnectResponse) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{11}
+}
+
+type SetPowerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interface     string                 `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	Powered       bool                   `protobuf:"varint,2,opt,name=powered,proto3" json:"powered,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetPowerRequest) Reset() {
+	*x = SetPowerRequest{}
+	mi := &file_wifimanager_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetPowerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetPowerRequest) ProtoMessage() {}
+
+func (x *SetPowerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetPowerRequest.ProtoReflect.Descriptor instead.
+func (*SetPowerRequest) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SetPowerRequest) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *SetPowerRequest) GetPowered() bool {
+	if x != nil {
+		return x.Powered
+	}
+	return false
+}
+
+type SetPowerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetPowerResponse) Reset() {
+	*x = SetPowerResponse{}
+	mi := &file_wifimanager_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetPowerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetPowerResponse) ProtoMessage() {}
+
+func (x *SetPowerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetPowerResponse.ProtoReflect.Descriptor instead.
+func (*SetPowerResponse) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{13}
+}
+
+type GetPowerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interface     string                 `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPowerRequest) Reset() {
+	*x = GetPowerRequest{}
+	mi := &file_wifimanager_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPowerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPowerRequest) ProtoMessage() {}
+
+func (x *GetPowerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPowerRequest.ProtoReflect.Descriptor instead.
+func (*GetPowerRequest) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetPowerRequest) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+type GetPowerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Powered       bool                   `protobuf:"varint,1,opt,name=powered,proto3" json:"powered,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPowerResponse) Reset() {
+	*x = GetPowerResponse{}
+	mi := &file_wifimanager_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPowerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPowerResponse) ProtoMessage() {}
+
+func (x *GetPowerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPowerResponse.ProtoReflect.Descriptor instead.
+func (*GetPowerResponse) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetPowerResponse) GetPowered() bool {
+	if x != nil {
+		return x.Powered
+	}
+	return false
+}
+
+type WatchEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_wifimanager_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wifimanager_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_wifimanager_proto_rawDescGZIP(), []int{16}
+}
+
+var File_wifimanager_proto protoreflect.FileDescriptor
+
+const file_wifimanager_proto_rawDesc = "" +
+	"\n" +
+	"\x11wifimanager.proto\x12\x0ewifimanager.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x83\x02\n" +
+	"\tInterface\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x05R\x05index\x12\x10\n" +
+	"\x03mtu\x18\x03 \x01(\x05R\x03mtu\x12#\n" +
+	"\rhardware_addr\x18\x04 \x01(\fR\fhardwareAddr\x12\x14\n" +
+	"\x05flags\x18\x05 \x01(\rR\x05flags\x12\x14\n" +
+	"\x05model\x18\x06 \x01(\tR\x05model\x12\x16\n" +
+	"\x06vendor\x18\a \x01(\tR\x06vendor\x127\n" +
+	"\n" +
+	"connection\x18\b \x01(\v2\x17.wifimanager.v1.NetworkR\n" +
+	"connection\x12\x18\n" +
+	"\abackend\x18\t \x01(\tR\abackend\"\xe2\x01\n" +
+	"\aNetwork\x12\x12\n" +
+	"\x04ssid\x18\x01 \x01(\tR\x04ssid\x12\x14\n" +
+	"\x05bssid\x18\x02 \x01(\tR\x05bssid\x12\x12\n" +
+	"\x04rssi\x18\x03 \x01(\x05R\x04rssi\x12\x0e\n" +
+	"\x02ht\x18\x04 \x01(\bR\x02ht\x12\x18\n" +
+	"\achannel\x18\x05 \x01(\x05R\achannel\x124\n" +
+	"\bsecurity\x18\x06 \x03(\v2\x18.wifimanager.v1.SecurityR\bsecurity\x12!\n" +
+	"\fsecurity_key\x18\a \x01(\tR\vsecurityKey\x12\x16\n" +
+	"\x06hidden\x18\b \x01(\bR\x06hidden\"\x92\x01\n" +
+	"\bSecurity\x12<\n" +
+	"\bprotocol\x18\x01 \x01(\x0e2 .wifimanager.v1.SecurityProtocolR\bprotocol\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\x05R\x06method\x12\x1a\n" +
+	"\bunicasts\x18\x03 \x03(\x05R\bunicasts\x12\x14\n" +
+	"\x05group\x18\x04 \x01(\x05R\x05group\"\x9c\x02\n" +
+	"\x05Event\x12-\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x19.wifimanager.v1.EventTypeR\x04type\x12\x1c\n" +
+	"\tinterface\x18\x02 \x01(\tR\tinterface\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x121\n" +
+	"\anetwork\x18\x04 \x01(\v2\x17.wifimanager.v1.NetworkR\anetwork\x123\n" +
+	"\bnetworks\x18\x05 \x03(\v2\x17.wifimanager.v1.NetworkR\bnetworks\x12\x18\n" +
+	"\apowered\x18\x06 \x01(\bR\apowered\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\"\x17\n" +
+	"\x15ListInterfacesRequest\"S\n" +
+	"\x16ListInterfacesResponse\x129\n" +
+	"\n" +
+	"interfaces\x18\x01 \x03(\v2\x19.wifimanager.v1.InterfaceR\n" +
+	"interfaces\"+\n" +
+	"\vScanRequest\x12\x1c\n" +
+	"\tinterface\x18\x01 \x01(\tR\tinterface\"C\n" +
+	"\fScanResponse\x123\n" +
+	"\bnetworks\x18\x01 \x03(\v2\x17.wifimanager.v1.NetworkR\bnetworks\"a\n" +
+	"\x0eConnectRequest\x12\x1c\n" +
+	"\tinterface\x18\x01 \x01(\tR\tinterface\x121\n" +
+	"\anetwork\x18\x02 \x01(\v2\x17.wifimanager.v1.NetworkR\anetwork\"\x11\n" +
+	"\x0fConnectResponse\"1\n" +
+	"\x11DisconnectRequest\x12\x1c\n" +
+	"\tinterface\x18\x01 \x01(\tR\tinterface\"\x14\n" +
+	"\x12DisconnectResponse\"I\n" +
+	"\x0fSetPowerRequest\x12\x1c\n" +
+	"\tinterface\x18\x01 \x01(\tR\tinterface\x12\x18\n" +
+	"\apowered\x18\x02 \x01(\bR\apowered\"\x12\n" +
+	"\x10SetPowerResponse\"/\n" +
+	"\x0fGetPowerRequest\x12\x1c\n" +
+	"\tinterface\x18\x01 \x01(\tR\tinterface\",\n" +
+	"\x10GetPowerResponse\x12\x18\n" +
+	"\apowered\x18\x01 \x01(\bR\apowered\"\x14\n" +
+	"\x12WatchEventsRequest*\xbf\x01\n" +
+	"\x10SecurityProtocol\x12!\n" +
+	"\x1dSECURITY_PROTOCOL_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16SECURITY_PROTOCOL_NONE\x10\x01\x12\x19\n" +
+	"\x15SECURITY_PROTOCOL_WEP\x10\x02\x12\x19\n" +
+	"\x15SECURITY_PROTOCOL_WPA\x10\x03\x12\x1a\n" +
+	"\x16SECURITY_PROTOCOL_WPA2\x10\x04\x12\x1a\n" +
+	"\x16SECURITY_PROTOCOL_WPA3\x10\x05*\xdd\x01\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x13\n" +
+	"\x0fEVENT_TYPE_SCAN\x10\x01\x12\x16\n" +
+	"\x12EVENT_TYPE_CONNECT\x10\x02\x12\x19\n" +
+	"\x15EVENT_TYPE_DISCONNECT\x10\x03\x12\x14\n" +
+	"\x10EVENT_TYPE_POWER\x10\x04\x12\x14\n" +
+	"\x10EVENT_TYPE_ERROR\x10\x05\x12\x1e\n" +
+	"\x1aEVENT_TYPE_INTERFACE_ADDED\x10\x06\x12 \n" +
+	"\x1cEVENT_TYPE_INTERFACE_REMOVED\x10\a2\xbc\x04\n" +
+	"\vWifiManager\x12_\n" +
+	"\x0eListInterfaces\x12%.wifimanager.v1.ListInterfacesRequest\x1a&.wifimanager.v1.ListInterfacesResponse\x12A\n" +
+	"\x04Scan\x12\x1b.wifimanager.v1.ScanRequest\x1a\x1c.wifimanager.v1.ScanResponse\x12J\n" +
+	"\aConnect\x12\x1e.wifimanager.v1.ConnectRequest\x1a\x1f.wifimanager.v1.ConnectResponse\x12S\n" +
+	"\n" +
+	"Disconnect\x12!.wifimanager.v1.DisconnectRequest\x1a\".wifimanager.v1.DisconnectResponse\x12M\n" +
+	"\bSetPower\x12\x1f.wifimanager.v1.SetPowerRequest\x1a .wifimanager.v1.SetPowerResponse\x12M\n" +
+	"\bGetPower\x12\x1f.wifimanager.v1.GetPowerRequest\x1a .wifimanager.v1.GetPowerResponse\x12J\n" +
+	"\vWatchEvents\x12\".wifimanager.v1.WatchEventsRequest\x1a\x15.wifimanager.v1.Event0\x01B*Z(github.com/ottopress/WifiManager/wifirpcb\x06proto3"
+
+var (
+	file_wifimanager_proto_rawDescOnce sync.Once
+	file_wifimanager_proto_rawDescData []byte
+)
+
+func file_wifimanager_proto_rawDescGZIP() []byte {
+	file_wifimanager_proto_rawDescOnce.Do(func() {
+		file_wifimanager_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_wifimanager_proto_rawDesc), len(file_wifimanager_proto_rawDesc)))
+	})
+	return file_wifimanager_proto_rawDescData
+}
+
+var file_wifimanager_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_wifimanager_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_wifimanager_proto_goTypes = []any{
+	(SecurityProtocol)(0),          // 0: wifimanager.v1.SecurityProtocol
+	(EventType)(0),                 // 1: wifimanager.v1.EventType
+	(*Interface)(nil),              // 2: wifimanager.v1.Interface
+	(*Network)(nil),                // 3: wifimanager.v1.Network
+	(*Security)(nil),               // 4: wifimanager.v1.Security
+	(*Event)(nil),                  // 5: wifimanager.v1.Event
+	(*ListInterfacesRequest)(nil),  // 6: wifimanager.v1.ListInterfacesRequest
+	(*ListInterfacesResponse)(nil), // 7: wifimanager.v1.ListInterfacesResponse
+	(*ScanRequest)(nil),            // 8: wifimanager.v1.ScanRequest
+	(*ScanResponse)(nil),           // 9: wifimanager.v1.ScanResponse
+	(*ConnectRequest)(nil),         // 10: wifimanager.v1.ConnectRequest
+	(*ConnectResponse)(nil),        // 11: wifimanager.v1.ConnectResponse
+	(*DisconnectRequest)(nil),      // 12: wifimanager.v1.DisconnectRequest
+	(*DisconnectResponse)(nil),     // 13: wifimanager.v1.DisconnectResponse
+	(*SetPowerRequest)(nil),        // 14: wifimanager.v1.SetPowerRequest
+	(*SetPowerResponse)(nil),       // 15: wifimanager.v1.SetPowerResponse
+	(*GetPowerRequest)(nil),        // 16: wifimanager.v1.GetPowerRequest
+	(*GetPowerResponse)(nil),       // 17: wifimanager.v1.GetPowerResponse
+	(*WatchEventsRequest)(nil),     // 18: wifimanager.v1.WatchEventsRequest
+	(*timestamppb.Timestamp)(nil),  // 19: google.protobuf.Timestamp
+}
+var file_wifimanager_proto_depIdxs = []int32{
+	3,  // 0: wifimanager.v1.Interface.connection:type_name -> wifimanager.v1.Network
+	4,  // 1: wifimanager.v1.Network.security:type_name -> wifimanager.v1.Security
+	0,  // 2: wifimanager.v1.Security.protocol:type_name -> wifimanager.v1.SecurityProtocol
+	1,  // 3: wifimanager.v1.Event.type:type_name -> wifimanager.v1.EventType
+	19, // 4: wifimanager.v1.Event.time:type_name -> google.protobuf.Timestamp
+	3,  // 5: wifimanager.v1.Event.network:type_name -> wifimanager.v1.Network
+	3,  // 6: wifimanager.v1.Event.networks:type_name -> wifimanager.v1.Network
+	2,  // 7: wifimanager.v1.ListInterfacesResponse.interfaces:type_name -> wifimanager.v1.Interface
+	3,  // 8: wifimanager.v1.ScanResponse.networks:type_name -> wifimanager.v1.Network
+	3,  // 9: wifimanager.v1.ConnectRequest.network:type_name -> wifimanager.v1.Network
+	6,  // 10: wifimanager.v1.WifiManager.ListInterfaces:input_type -> wifimanager.v1.ListInterfacesRequest
+	8,  // 11: wifimanager.v1.WifiManager.Scan:input_type -> wifimanager.v1.ScanRequest
+	10, // 12: wifimanager.v1.WifiManager.Connect:input_type -> wifimanager.v1.ConnectRequest
+	12, // 13: wifimanager.v1.WifiManager.Disconnect:input_type -> wifimanager.v1.DisconnectRequest
+	14, // 14: wifimanager.v1.WifiManager.SetPower:input_type -> wifimanager.v1.SetPowerRequest
+	16, // 15: wifimanager.v1.WifiManager.GetPower:input_type -> wifimanager.v1.GetPowerRequest
+	18, // 16: wifimanager.v1.WifiManager.WatchEvents:input_type -> wifimanager.v1.WatchEventsRequest
+	7,  // 17: wifimanager.v1.WifiManager.ListInterfaces:output_type -> wifimanager.v1.ListInterfacesResponse
+	9,  // 18: wifimanager.v1.WifiManager.Scan:output_type -> wifimanager.v1.ScanResponse
+	11, // 19: wifimanager.v1.WifiManager.Connect:output_type -> wifimanager.v1.ConnectResponse
+	13, // 20: wifimanager.v1.WifiManager.Disconnect:output_type -> wifimanager.v1.DisconnectResponse
+	15, // 21: wifimanager.v1.WifiManager.SetPower:output_type -> wifimanager.v1.SetPowerResponse
+	17, // 22: wifimanager.v1.WifiManager.GetPower:output_type -> wifimanager.v1.GetPowerResponse
+	5,  // 23: wifimanager.v1.WifiManager.WatchEvents:output_type -> wifimanager.v1.Event
+	17, // [17:24] is the sub-list for method output_type
+	10, // [10:17] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_wifimanager_proto_init() }
+func file_wifimanager_proto_init() {
+	if File_wifimanager_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_wifimanager_proto_rawDesc), len(file_wifimanager_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_wifimanager_proto_goTypes,
+		DependencyIndexes: file_wifimanager_proto_depIdxs,
+		EnumInfos:         file_wifimanager_proto_enumTypes,
+		MessageInfos:      file_wifimanager_proto_msgTypes,
+	}.Build()
+	File_wifimanager_proto = out.File
+	file_wifimanager_proto_goTypes = nil
+	file_wifimanager_proto_depIdxs = nil
+}
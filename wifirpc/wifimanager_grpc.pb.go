@@ -0,0 +1,373 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v5.29.3
+// source: wifimanager.proto
+
+package wifirpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	WifiManager_ListInterfaces_FullMethodName = "/wifimanager.v1.WifiManager/ListInterfaces"
+	WifiManager_Scan_FullMethodName           = "/wifimanager.v1.WifiManager/Scan"
+	WifiManager_Connect_FullMethodName        = "/wifimanager.v1.WifiManager/Connect"
+	WifiManager_Disconnect_FullMethodName     = "/wifimanager.v1.WifiManager/Disconnect"
+	WifiManager_SetPower_FullMethodName       = "/wifimanager.v1.WifiManager/SetPower"
+	WifiManager_GetPower_FullMethodName       = "/wifimanager.v1.WifiManager/GetPower"
+	WifiManager_WatchEvents_FullMethodName    = "/wifimanager.v1.WifiManager/WatchEvents"
+)
+
+// WifiManagerClient is the client API for WifiManager service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// WifiManager drives the WiFi interfaces of a remote device through
+// its Manager.
+type WifiManagerClient interface {
+	// ListInterfaces returns the WiFi interfaces of the device
+	ListInterfaces(ctx context.Context, in *ListInterfacesRequest, opts ...grpc.CallOption) (*ListInterfacesResponse, error)
+	// Scan returns the networks reachable from an interface
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error)
+	// Connect joins an interface to a network
+	Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*ConnectResponse, error)
+	// Disconnect leaves the network an interface is connected to
+	Disconnect(ctx context.Context, in *DisconnectRequest, opts ...grpc.CallOption) (*DisconnectResponse, error)
+	// SetPower turns an interface on or off
+	SetPower(ctx context.Context, in *SetPowerRequest, opts ...grpc.CallOption) (*SetPowerResponse, error)
+	// GetPower returns the power state of an interface
+	GetPower(ctx context.Context, in *GetPowerRequest, opts ...grpc.CallOption) (*GetPowerResponse, error)
+	// WatchEvents streams every change made through the Manager
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type wifiManagerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWifiManagerClient(cc grpc.ClientConnInterface) WifiManagerClient {
+	return &wifiManagerClient{cc}
+}
+
+func (c *wifiManagerClient) ListInterfaces(ctx context.Context, in *ListInterfacesRequest, opts ...grpc.CallOption) (*ListInterfacesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListInterfacesResponse)
+	err := c.cc.Invoke(ctx, WifiManager_ListInterfaces_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wifiManagerClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScanResponse)
+	err := c.cc.Invoke(ctx, WifiManager_Scan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wifiManagerClient) Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*ConnectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConnectResponse)
+	err := c.cc.Invoke(ctx, WifiManager_Connect_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wifiManagerClient) Disconnect(ctx context.Context, in *DisconnectRequest, opts ...grpc.CallOption) (*DisconnectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DisconnectResponse)
+	err := c.cc.Invoke(ctx, WifiManager_Disconnect_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wifiManagerClient) SetPower(ctx context.Context, in *SetPowerRequest, opts ...grpc.CallOption) (*SetPowerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetPowerResponse)
+	err := c.cc.Invoke(ctx, WifiManager_SetPower_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wifiManagerClient) GetPower(ctx context.Context, in *GetPowerRequest, opts ...grpc.CallOption) (*GetPowerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPowerResponse)
+	err := c.cc.Invoke(ctx, WifiManager_GetPower_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wifiManagerClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WifiManager_ServiceDesc.Streams[0], WifiManager_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WifiManager_WatchEventsClient = grpc.ServerStreamingClient[Event]
+
+// WifiManagerServer is the server API for WifiManager service.
+// All implementations must embed UnimplementedWifiManagerServer
+// for forward compatibility.
+//
+// WifiManager drives the WiFi interfaces of a remote device through
+// its Manager.
+type WifiManagerServer interface {
+	// ListInterfaces returns the WiFi interfaces of the device
+	ListInterfaces(context.Context, *ListInterfacesRequest) (*ListInterfacesResponse, error)
+	// Scan returns the networks reachable from an interface
+	Scan(context.Context, *ScanRequest) (*ScanResponse, error)
+	// Connect joins an interface to a network
+	Connect(context.Context, *ConnectRequest) (*ConnectResponse, error)
+	// Disconnect leaves the network an interface is connected to
+	Disconnect(context.Context, *DisconnectRequest) (*DisconnectResponse, error)
+	// SetPower turns an interface on or off
+	SetPower(context.Context, *SetPowerRequest) (*SetPowerResponse, error)
+	// GetPower returns the power state of an interface
+	GetPower(context.Context, *GetPowerRequest) (*GetPowerResponse, error)
+	// WatchEvents streams every change made through the Manager
+	WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedWifiManagerServer()
+}
+
+// UnimplementedWifiManagerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWifiManagerServer struct{}
+
+func (UnimplementedWifiManagerServer) ListInterfaces(context.Context, *ListInterfacesRequest) (*ListInterfacesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListInterfaces not implemented")
+}
+func (UnimplementedWifiManagerServer) Scan(context.Context, *ScanRequest) (*ScanResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedWifiManagerServer) Connect(context.Context, *ConnectRequest) (*ConnectResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedWifiManagerServer) Disconnect(context.Context, *DisconnectRequest) (*DisconnectResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Disconnect not implemented")
+}
+func (UnimplementedWifiManagerServer) SetPower(context.Context, *SetPowerRequest) (*SetPowerResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetPower not implemented")
+}
+func (UnimplementedWifiManagerServer) GetPower(context.Context, *GetPowerRequest) (*GetPowerResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetPower not implemented")
+}
+func (UnimplementedWifiManagerServer) WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Error(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedWifiManagerServer) mustEmbedUnimplementedWifiManagerServer() {}
+func (UnimplementedWifiManagerServer) testEmbeddedByValue()                     {}
+
+// UnsafeWifiManagerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WifiManagerServer will
+// result in compilation errors.
+type UnsafeWifiManagerServer interface {
+	mustEmbedUnimplementedWifiManagerServer()
+}
+
+func RegisterWifiManagerServer(s grpc.ServiceRegistrar, srv WifiManagerServer) {
+	// If the following call panics, it indicates UnimplementedWifiManagerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&WifiManager_ServiceDesc, srv)
+}
+
+func _WifiManager_ListInterfaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInterfacesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WifiManagerServer).ListInterfaces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WifiManager_ListInterfaces_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WifiManagerServer).ListInterfaces(ctx, req.(*ListInterfacesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WifiManager_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WifiManagerServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WifiManager_Scan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WifiManagerServer).Scan(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WifiManager_Connect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WifiManagerServer).Connect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WifiManager_Connect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WifiManagerServer).Connect(ctx, req.(*ConnectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WifiManager_Disconnect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisconnectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WifiManagerServer).Disconnect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WifiManager_Disconnect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WifiManagerServer).Disconnect(ctx, req.(*DisconnectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WifiManager_SetPower_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPowerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WifiManagerServer).SetPower(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WifiManager_SetPower_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WifiManagerServer).SetPower(ctx, req.(*SetPowerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WifiManager_GetPower_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPowerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WifiManagerServer).GetPower(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WifiManager_GetPower_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WifiManagerServer).GetPower(ctx, req.(*GetPowerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WifiManager_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WifiManagerServer).WatchEvents(m, &grpc.GenericServerStream[WatchEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WifiManager_WatchEventsServer = grpc.ServerStreamingServer[Event]
+
+// WifiManager_ServiceDesc is the grpc.ServiceDesc for WifiManager service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WifiManager_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wifimanager.v1.WifiManager",
+	HandlerType: (*WifiManagerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListInterfaces",
+			Handler:    _WifiManager_ListInterfaces_Handler,
+		},
+		{
+			MethodName: "Scan",
+			Handler:    _WifiManager_Scan_Handler,
+		},
+		{
+			MethodName: "Connect",
+			Handler:    _WifiManager_Connect_Handler,
+		},
+		{
+			MethodName: "Disconnect",
+			Handler:    _WifiManager_Disconnect_Handler,
+		},
+		{
+			MethodName: "SetPower",
+			Handler:    _WifiManager_SetPower_Handler,
+		},
+		{
+			MethodName: "GetPower",
+			Handler:    _WifiManager_GetPower_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _WifiManager_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "wifimanager.proto",
+}
@@ -0,0 +1,218 @@
+// Package wifirpc serves a Manager over gRPC and provides a client
+// that drives a remote Manager as a local Backend.
+package wifirpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative wifimanager.proto
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/ottopress/WifiManager"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// securityProtocols maps the library's security protocols to
+	// their protobuf values
+	securityProtocols = map[int]SecurityProtocol{
+		wifimanager.SecurityNone: SecurityProtocol_SECURITY_PROTOCOL_NONE,
+		wifimanager.SecurityWEP:  SecurityProtocol_SECURITY_PROTOCOL_WEP,
+		wifimanager.SecurityWPA:  SecurityProtocol_SECURITY_PROTOCOL_WPA,
+		wifimanager.SecurityWPA2: SecurityProtocol_SECURITY_PROTOCOL_WPA2,
+		wifimanager.SecurityWPA3: SecurityProtocol_SECURITY_PROTOCOL_WPA3,
+	}
+	// eventTypes maps the library's event types to their protobuf
+	// values
+	eventTypes = map[int]EventType{
+		wifimanager.EventScan:             EventType_EVENT_TYPE_SCAN,
+		wifimanager.EventConnect:          EventType_EVENT_TYPE_CONNECT,
+		wifimanager.EventDisconnect:       EventType_EVENT_TYPE_DISCONNECT,
+		wifimanager.EventPower:            EventType_EVENT_TYPE_POWER,
+		wifimanager.EventError:            EventType_EVENT_TYPE_ERROR,
+		wifimanager.EventInterfaceAdded:   EventType_EVENT_TYPE_INTERFACE_ADDED,
+		wifimanager.EventInterfaceRemoved: EventType_EVENT_TYPE_INTERFACE_REMOVED,
+	}
+)
+
+// TokenCredentials sends a bearer token with every call, matching the
+// token a Server is set up to require. Target is the address dialed,
+// as passed to grpc.Dial.
+type TokenCredentials struct {
+	Token  string
+	Target string
+}
+
+// NewTokenCredentials creates the credentials sending the token to the
+// provided target
+func NewTokenCredentials(token, target string) TokenCredentials {
+	return TokenCredentials{Token: token, Target: target}
+}
+
+// GetRequestMetadata returns the authorization header
+func (token TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + token.Token}, nil
+}
+
+// RequireTransportSecurity returns whether the token may only be sent
+// over TLS, which is the case unless the target is a unix socket or a
+// loopback address
+func (token TokenCredentials) RequireTransportSecurity() bool {
+	return !localTarget(token.Target)
+}
+
+// localTarget returns whether the gRPC target is a unix socket or a
+// loopback address, such as "unix:/run/wifimgrd.sock",
+// "localhost:50051" or "dns:///127.0.0.1:50051"
+func localTarget(target string) bool {
+	if strings.HasPrefix(target, "unix:") || strings.HasPrefix(target, "unix-abstract:") {
+		return true
+	}
+	if schemeEnd := strings.Index(target, "://"); schemeEnd >= 0 {
+		target = target[schemeEnd+3:]
+		target = target[strings.Index(target, "/")+1:]
+	}
+	host, _, splitErr := net.SplitHostPort(target)
+	if splitErr != nil {
+		host = strings.Trim(target, "[]")
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newInterface converts an interface to protobuf
+func newInterface(wifiInterface wifimanager.WifiInterface) *Interface {
+	return &Interface{
+		Name:         wifiInterface.Name,
+		Index:        int32(wifiInterface.Index),
+		Mtu:          int32(wifiInterface.MTU),
+		HardwareAddr: wifiInterface.HardwareAddr,
+		Flags:        uint32(wifiInterface.Flags),
+		Model:        wifiInterface.Model,
+		Vendor:       wifiInterface.Vendor,
+		Connection:   newNetwork(wifiInterface.Connection),
+		Backend:      wifiInterface.Backend().Name(),
+	}
+}
+
+// wifiInterface converts a protobuf interface, driven by the backend
+func (iface *Interface) wifiInterface(backend wifimanager.Backend) wifimanager.WifiInterface {
+	wifiInterface := wifimanager.NewBackendInterface(net.Interface{
+		Index:        int(iface.GetIndex()),
+		MTU:          int(iface.GetMtu()),
+		Name:         iface.GetName(),
+		HardwareAddr: net.HardwareAddr(iface.GetHardwareAddr()),
+		Flags:        net.Flags(iface.GetFlags()),
+	}, backend)
+	wifiInterface.Model = iface.GetModel()
+	wifiInterface.Vendor = iface.GetVendor()
+	if iface.GetConnection() != nil {
+		wifiInterface.Connection = iface.GetConnection().wifiNetwork()
+	}
+	return wifiInterface
+}
+
+// newNetwork converts a network to protobuf, security key included
+func newNetwork(network wifimanager.WifiNetwork) *Network {
+	converted := &Network{
+		Ssid:        network.SSID,
+		Bssid:       network.BSSID,
+		Rssi:        int32(network.RSSI),
+		Ht:          network.HT,
+		Channel:     int32(network.Channel),
+		SecurityKey: network.SecurityKey,
+		Hidden:      network.Hidden,
+	}
+	for _, security := range network.Security {
+		unicasts := []int32{}
+		for _, unicast := range security.Unicasts {
+			unicasts = append(unicasts, int32(unicast))
+		}
+		converted.Security = append(converted.Security, &Security{
+			Protocol: securityProtocols[security.Protocol],
+			Method:   int32(security.Method),
+			Unicasts: unicasts,
+			Group:    int32(security.Group),
+		})
+	}
+	return converted
+}
+
+// newNetworks converts networks to protobuf
+func newNetworks(networks []wifimanager.WifiNetwork) []*Network {
+	converted := []*Network{}
+	for _, network := range networks {
+		converted = append(converted, newNetwork(network))
+	}
+	return converted
+}
+
+// wifiNetwork converts a protobuf network
+func (network *Network) wifiNetwork() wifimanager.WifiNetwork {
+	converted := wifimanager.WifiNetwork{
+		SSID:        network.GetSsid(),
+		BSSID:       network.GetBssid(),
+		RSSI:        int(network.GetRssi()),
+		HT:          network.GetHt(),
+		Channel:     int(network.GetChannel()),
+		SecurityKey: network.GetSecurityKey(),
+		Hidden:      network.GetHidden(),
+	}
+	for _, security := range network.GetSecurity() {
+		unicasts := []int{}
+		for _, unicast := range security.GetUnicasts() {
+			unicasts = append(unicasts, int(unicast))
+		}
+		converted.Security = append(converted.Security, wifimanager.WifiNetworkSecurity{
+			Protocol: securityProtocol(security.GetProtocol()),
+			Method:   int(security.GetMethod()),
+			Unicasts: unicasts,
+			Group:    int(security.GetGroup()),
+		})
+	}
+	return converted
+}
+
+// wifiNetworks converts protobuf networks
+func wifiNetworks(networks []*Network) []wifimanager.WifiNetwork {
+	converted := []wifimanager.WifiNetwork{}
+	for _, network := range networks {
+		converted = append(converted, network.wifiNetwork())
+	}
+	return converted
+}
+
+// securityProtocol returns the library's value of a protobuf security
+// protocol, treating unknown values as no security
+func securityProtocol(protocol SecurityProtocol) int {
+	for libraryProtocol, protoProtocol := range securityProtocols {
+		if protoProtocol == protocol {
+			return libraryProtocol
+		}
+	}
+	return wifimanager.SecurityNone
+}
+
+// newEvent converts an event to protobuf
+func newEvent(event wifimanager.Event) *Event {
+	converted := &Event{
+		Type:      eventTypes[event.Type],
+		Interface: event.Interface,
+		Time:      timestamppb.New(event.Time),
+		Powered:   event.Powered,
+	}
+	if event.Network != nil {
+		converted.Network = newNetwork(*event.Network)
+	}
+	if event.Networks != nil {
+		converted.Networks = newNetworks(event.Networks)
+	}
+	if event.Err != nil {
+		converted.Error = event.Err.Error()
+	}
+	return converted
+}
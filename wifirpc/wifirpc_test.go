@@ -0,0 +1,76 @@
+package wifirpc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ottopress/WifiManager"
+)
+
+func TestTokenCredentialsTransportSecurity(t *testing.T) {
+	tests := map[string]bool{
+		"unix:/run/wifimgrd.sock":     false,
+		"unix:///run/wifimgrd.sock":   false,
+		"unix-abstract:wifimgrd":      false,
+		"localhost:50051":             false,
+		"127.0.0.1:50051":             false,
+		"[::1]:50051":                 false,
+		"dns:///127.0.0.1:50051":      false,
+		"passthrough:///localhost:1":  false,
+		"router.lan:50051":            true,
+		"192.168.1.1:50051":           true,
+		"dns:///wifi.example.com:443": true,
+		"dns://127.0.0.1/example:443": true,
+		"":                            true,
+	}
+	for target, secure := range tests {
+		credentials := NewTokenCredentials("secret-token", target)
+		if credentials.RequireTransportSecurity() != secure {
+			t.Errorf("RequireTransportSecurity for %q = %v, want %v", target, !secure, secure)
+		}
+	}
+	metadata, metadataErr := NewTokenCredentials("secret-token", "localhost:50051").GetRequestMetadata(context.Background())
+	if metadataErr != nil || metadata["authorization"] != "Bearer secret-token" {
+		t.Errorf("metadata = %v, %v", metadata, metadataErr)
+	}
+}
+
+func TestEventTypes(t *testing.T) {
+	want := map[int]string{
+		wifimanager.EventScan:             "EVENT_TYPE_SCAN",
+		wifimanager.EventConnect:          "EVENT_TYPE_CONNECT",
+		wifimanager.EventDisconnect:       "EVENT_TYPE_DISCONNECT",
+		wifimanager.EventPower:            "EVENT_TYPE_POWER",
+		wifimanager.EventError:            "EVENT_TYPE_ERROR",
+		wifimanager.EventInterfaceAdded:   "EVENT_TYPE_INTERFACE_ADDED",
+		wifimanager.EventInterfaceRemoved: "EVENT_TYPE_INTERFACE_REMOVED",
+	}
+	for eventType, name := range want {
+		// String reads the name from the file descriptor, which has to
+		// list the value as well
+		if protoType, known := eventTypes[eventType]; !known || protoType.String() != name {
+			t.Errorf("event %s maps to %v, want %s", wifimanager.EventName(eventType), protoType, name)
+		}
+	}
+	added := newEvent(wifimanager.Event{Type: wifimanager.EventInterfaceAdded, Interface: "wlan1"})
+	if added.Type != EventType_EVENT_TYPE_INTERFACE_ADDED || added.Interface != "wlan1" {
+		t.Errorf("added event = %v", added)
+	}
+}
+
+func TestNetworkRoundTrip(t *testing.T) {
+	network := wifimanager.WifiNetwork{
+		SSID:        "attic",
+		BSSID:       "00:11:22:33:44:55",
+		RSSI:        -61,
+		HT:          true,
+		Channel:     36,
+		Security:    []wifimanager.WifiNetworkSecurity{{Protocol: wifimanager.SecurityWPA2, Method: 1, Unicasts: []int{4}, Group: 4}},
+		SecurityKey: "correct horse",
+		Hidden:      true,
+	}
+	if converted := newNetwork(network).wifiNetwork(); !reflect.DeepEqual(converted, network) {
+		t.Errorf("round trip = %+v, want %+v", converted, network)
+	}
+}
@@ -0,0 +1,444 @@
+package wifimanager
+
+import (
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ottopress/WifiManager/darwin"
+	"github.com/ottopress/WifiManager/dpp"
+	"github.com/ottopress/WifiManager/linux"
+)
+
+var (
+	ipCommand = linux.NewIP()
+
+	// ErrDPPSecurity is returned when a network can't be handed out
+	// over DPP because of its security protocol
+	ErrDPPSecurity = errors.New("wifi: network security can't be configured over dpp")
+
+	// wpaCiphers maps the wpa_supplicant cipher names to the cipher
+	// values used in WifiNetworkSecurity
+	wpaCiphers = map[string]int{
+		"CCMP": darwin.AES,
+		"TKIP": darwin.TKIP,
+	}
+)
+
+// WPASupplicantBackend drives WiFi interfaces through the control
+// interface of a running wpa_supplicant daemon
+type WPASupplicantBackend struct {
+	CtrlDir string
+	mutex   sync.Mutex
+	// bootstraps holds the DPP bootstrapping information id registered
+	// for every interface that is waiting to be configured
+	bootstraps map[string]int
+}
+
+// NewWPASupplicantBackend creates a new instance of the wpa_supplicant
+// backend using the default control interface directory
+func NewWPASupplicantBackend() *WPASupplicantBackend {
+	return &WPASupplicantBackend{
+		CtrlDir:    linux.NewWPASupplicant("").CtrlDir,
+		bootstraps: map[string]int{},
+	}
+}
+
+// Name returns the name of the backend
+func (wpaBackend *WPASupplicantBackend) Name() string {
+	return "wpa_supplicant"
+}
+
+// IsInstalled returns whether or not wpa_supplicant and the ip
+// command are installed
+func (wpaBackend *WPASupplicantBackend) IsInstalled() bool {
+	return linux.NewWPASupplicant("").IsInstalled() && ipCommand.IsInstalled()
+}
+
+// client returns the control interface client for the interface
+func (wpaBackend *WPASupplicantBackend) client(iface string) *linux.WPASupplicant {
+	wpaSupplicant := linux.NewWPASupplicant(iface)
+	wpaSupplicant.CtrlDir = wpaBackend.CtrlDir
+	return wpaSupplicant
+}
+
+// Interfaces returns all interfaces wpa_supplicant manages
+func (wpaBackend *WPASupplicantBackend) Interfaces() ([]WifiInterface, error) {
+	wifiInterfaces := []WifiInterface{}
+	names, namesErr := linux.WPASupplicantInterfaces(wpaBackend.CtrlDir)
+	if namesErr != nil {
+		return wifiInterfaces, namesErr
+	}
+	for _, name := range names {
+		iface, ifaceErr := net.InterfaceByName(name)
+		if ifaceErr != nil {
+			continue
+		}
+		wifiInterfaces = append(wifiInterfaces, newSysfsInterface(*iface, wpaBackend))
+	}
+	return wifiInterfaces, nil
+}
+
+// Scan returns a list of all reachable WiFi networks
+func (wpaBackend *WPASupplicantBackend) Scan(iface string) ([]WifiNetwork, error) {
+	results, scanErr := wpaBackend.client(iface).Scan()
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	wifiNetworks := []WifiNetwork{}
+	for _, result := range results {
+		wifiNetworks = append(wifiNetworks, WifiNetwork{
+			SSID:     result.SSID,
+			BSSID:    result.BSSID,
+			RSSI:     result.Signal,
+			Channel:  linux.FrequencyChannel(result.Frequency),
+			Security: wpaSecurity(result.Flags),
+		})
+	}
+	return wifiNetworks, nil
+}
+
+// Connect connects the interface to the provided network, reusing the
+// network wpa_supplicant already has configured for the SSID
+func (wpaBackend *WPASupplicantBackend) Connect(iface string, network WifiNetwork) error {
+	RegisterSecret(network.SecurityKey)
+	defer ForgetSecret(network.SecurityKey)
+	return RedactError(wpaBackend.connect(iface, network))
+}
+
+// connect does the work of Connect
+func (wpaBackend *WPASupplicantBackend) connect(iface string, network WifiNetwork) error {
+	wpaSupplicant := wpaBackend.client(iface)
+	options, optionsErr := wpaNetworkOptions(network)
+	if optionsErr != nil {
+		return optionsErr
+	}
+	configured, listErr := wpaSupplicant.Networks()
+	if listErr != nil {
+		return listErr
+	}
+	id := -1
+	for _, existing := range configured {
+		if existing.SSID == network.SSID {
+			id = existing.ID
+			break
+		}
+	}
+	added := id < 0
+	if added {
+		var addErr error
+		id, addErr = wpaSupplicant.AddNetwork()
+		if addErr != nil {
+			return addErr
+		}
+	}
+	for _, option := range options {
+		setErr := wpaSupplicant.SetNetwork(id, option[0], option[1])
+		if setErr != nil {
+			if added {
+				wpaSupplicant.RemoveNetwork(id)
+			}
+			return setErr
+		}
+	}
+	return wpaSupplicant.Connect(id)
+}
+
+// SupportsRawPSK returns true as wpa_supplicant takes a PSK of 64 hex
+// digits in place of the passphrase
+func (wpaBackend *WPASupplicantBackend) SupportsRawPSK() bool {
+	return true
+}
+
+// Disconnect disconnects from the current network without shutting
+// down the interface
+func (wpaBackend *WPASupplicantBackend) Disconnect(iface string) error {
+	return wpaBackend.client(iface).Disconnect()
+}
+
+// Up turns on the interface
+func (wpaBackend *WPASupplicantBackend) Up(iface string) error {
+	if unblockErr := unblockRFKill(iface); unblockErr != nil {
+		return unblockErr
+	}
+	return ipCommand.Up(iface)
+}
+
+// Down turns off the interface
+func (wpaBackend *WPASupplicantBackend) Down(iface string) error {
+	return ipCommand.Down(iface)
+}
+
+// Status returns the power state of the interface
+func (wpaBackend *WPASupplicantBackend) Status(iface string) (bool, error) {
+	return ipCommand.Status(iface)
+}
+
+// State returns the connection state of the interface from the
+// wpa_state of wpa_supplicant
+func (wpaBackend *WPASupplicantBackend) State(iface string) (int, error) {
+	powered, statusErr := ipCommand.Status(iface)
+	if statusErr != nil || !powered {
+		return StateOff, statusErr
+	}
+	status, requestErr := wpaBackend.client(iface).Status()
+	if requestErr != nil {
+		return StateOff, requestErr
+	}
+	switch status["wpa_state"] {
+	case "COMPLETED":
+		return StateConnected, nil
+	case "SCANNING":
+		return StateScanning, nil
+	case "ASSOCIATING", "ASSOCIATED":
+		return StateAssociating, nil
+	case "AUTHENTICATING", "4WAY_HANDSHAKE", "GROUP_HANDSHAKE":
+		return StateAuthenticating, nil
+	case "INTERFACE_DISABLED":
+		return StateOff, nil
+	}
+	return StateDisconnected, nil
+}
+
+// Subscribe delivers the power, address and hotplug events the kernel
+// reports for the interface and the association events of
+// wpa_supplicant, which knows the reason of every disconnection
+func (wpaBackend *WPASupplicantBackend) Subscribe(iface string) (<-chan InterfaceEvent, func(), error) {
+	stream, streamErr := netlinkEvents(iface, false)
+	if streamErr != nil {
+		return nil, nil, streamErr
+	}
+	if supplicantErr := supplicantEvents(stream, wpaBackend.client(iface)); supplicantErr != nil {
+		stream.stop()
+		return nil, nil, supplicantErr
+	}
+	return stream.subscription()
+}
+
+// Diagnose reports on wpa_supplicant, its control sockets, the ip
+// command and hostapd. Talking to the control sockets takes membership
+// of the group they belong to, while ip link needs root.
+func (wpaBackend *WPASupplicantBackend) Diagnose() BackendReport {
+	wpaSupplicant := linux.NewWPASupplicant("")
+	hostapd := linux.NewHostapd("")
+	report := BackendReport{Checks: []Check{
+		commandCheck("wpa_supplicant", wpaSupplicant.IsInstalled(), true, wpaSupplicant.Version),
+		commandCheck("ip", ipCommand.IsInstalled(), true, ipCommand.Version),
+		commandCheck("hostapd", hostapd.IsInstalled(), false, hostapd.Version),
+	}}
+	ctrlCheck := Check{Name: wpaBackend.CtrlDir, Kind: CheckSocket, Required: true}
+	names, namesErr := linux.WPASupplicantInterfaces(wpaBackend.CtrlDir)
+	if namesErr != nil {
+		ctrlCheck.Detail = namesErr.Error()
+	} else if len(names) == 0 {
+		ctrlCheck.Detail = "no control sockets, is wpa_supplicant running?"
+	} else {
+		ctrlCheck.Present = true
+		ctrlCheck.Detail = strings.Join(names, ", ")
+	}
+	report.Checks = append(report.Checks, ctrlCheck)
+	report.Problems, report.Usable = missingRequired(report.Checks)
+
+	ctrlAccess := false
+	if ctrlCheck.Present {
+		reply, pingErr := wpaBackend.client(names[0]).Request("PING")
+		ctrlAccess = pingErr == nil && strings.TrimSpace(reply) == "PONG"
+		if !ctrlAccess {
+			report.Problems = append(report.Problems, "can't talk to the control socket of "+names[0]+", run as root or join the group of "+wpaBackend.CtrlDir)
+		}
+	}
+	root := os.Geteuid() == 0
+	if !root {
+		report.Problems = append(report.Problems, "turning interfaces on and off with ip needs root")
+	}
+	report.Privileged = ctrlAccess && root
+	report.Features = Features{
+		Scan:               report.Usable && ctrlAccess,
+		Connect:            report.Usable && ctrlAccess,
+		Hotspot:            hostapd.IsInstalled() && root,
+		Enterprise:         report.Usable && ctrlAccess,
+		EnterpriseProfiles: report.Usable && ctrlAccess,
+	}
+	return report
+}
+
+// DPPEnroll registers the device's bootstrapping information and
+// listens for a configurator on the provided channel. wpa_supplicant
+// saves the credentials it receives and connects to the network.
+func (wpaBackend *WPASupplicantBackend) DPPEnroll(iface string, bootstrap *dpp.Bootstrap, channel int) error {
+	wpaSupplicant := wpaBackend.client(iface)
+	key, keyErr := bootstrap.MarshalPrivateKey()
+	if keyErr != nil {
+		return keyErr
+	}
+	channels := []string{}
+	for _, bootstrapChannel := range bootstrap.Channels {
+		channels = append(channels, bootstrapChannel.String())
+	}
+	mac := ""
+	if len(bootstrap.MAC) > 0 {
+		mac = bootstrap.MAC.String()
+	}
+	processErr := wpaSupplicant.Set("dpp_config_processing", "2")
+	if processErr != nil {
+		return processErr
+	}
+
+	wpaBackend.mutex.Lock()
+	defer wpaBackend.mutex.Unlock()
+	if previous, registered := wpaBackend.bootstraps[iface]; registered {
+		wpaSupplicant.DPPStopListen()
+		wpaSupplicant.DPPBootstrapRemove(previous)
+		delete(wpaBackend.bootstraps, iface)
+	}
+	id, genErr := wpaSupplicant.DPPBootstrapGen(channels, mac, bootstrap.Info, key)
+	if genErr != nil {
+		return genErr
+	}
+	listenErr := wpaSupplicant.DPPListen(linux.ChannelFrequency(channel), "enrollee")
+	if listenErr != nil {
+		wpaSupplicant.DPPBootstrapRemove(id)
+		return listenErr
+	}
+	wpaBackend.bootstraps[iface] = id
+	return nil
+}
+
+// DPPStop stops listening for a configurator and removes the
+// bootstrapping information registered by DPPEnroll
+func (wpaBackend *WPASupplicantBackend) DPPStop(iface string) error {
+	wpaSupplicant := wpaBackend.client(iface)
+	wpaBackend.mutex.Lock()
+	defer wpaBackend.mutex.Unlock()
+	stopErr := wpaSupplicant.DPPStopListen()
+	if id, registered := wpaBackend.bootstraps[iface]; registered {
+		delete(wpaBackend.bootstraps, iface)
+		removeErr := wpaSupplicant.DPPBootstrapRemove(id)
+		if stopErr == nil {
+			stopErr = removeErr
+		}
+	}
+	return stopErr
+}
+
+// DPPConfigure acts as a configurator and hands the network, using its
+// SecurityKey, to the peer whose bootstrapping URI was scanned. Only
+// WPA2 and WPA3 personal networks can be handed out this way, and a
+// raw PSK only to WPA/WPA2 ones as SAE needs the passphrase.
+func (wpaBackend *WPASupplicantBackend) DPPConfigure(iface string, peer dpp.URI, network WifiNetwork) error {
+	conf := ""
+	if len(network.Security) > 0 {
+		switch network.Security[0].Protocol {
+		case SecurityWPA, SecurityWPA2:
+			conf = "sta-psk"
+		case SecurityWPA3:
+			conf = "sta-sae"
+		}
+	}
+	if conf == "" {
+		return ErrDPPSecurity
+	}
+	auth := linux.DPPAuth{Role: "configurator", Conf: conf, SSID: network.SSID, Passphrase: network.SecurityKey}
+	if IsRawPSK(network.SecurityKey) {
+		if conf == "sta-sae" {
+			return ErrRawPSK
+		}
+		auth.Passphrase = ""
+		auth.PSK = network.SecurityKey
+	}
+	wpaSupplicant := wpaBackend.client(iface)
+	peerID, qrErr := wpaSupplicant.DPPQRCode(peer.String())
+	if qrErr != nil {
+		return qrErr
+	}
+	auth.Peer = peerID
+	return wpaSupplicant.DPPAuthInit(auth)
+}
+
+// wpaSecurity converts the scan result flags, such as
+// [WPA2-PSK+SAE-CCMP] or [WEP], into security configurations
+func wpaSecurity(flags []string) []WifiNetworkSecurity {
+	security := []WifiNetworkSecurity{}
+	for _, flag := range flags {
+		if flag == "WEP" {
+			security = append(security, WifiNetworkSecurity{Protocol: SecurityWEP})
+			continue
+		}
+		parts := strings.Split(flag, "-")
+		if len(parts) < 3 || (parts[0] != "WPA" && parts[0] != "WPA2" && parts[0] != "RSN") {
+			continue
+		}
+		unicasts := []int{}
+		for _, cipher := range strings.Split(parts[len(parts)-1], "+") {
+			if value, known := wpaCiphers[cipher]; known {
+				unicasts = append(unicasts, value)
+			}
+		}
+		for _, method := range strings.Split(strings.Join(parts[1:len(parts)-1], "-"), "+") {
+			entry := WifiNetworkSecurity{Protocol: SecurityWPA2, Method: darwin.PSK, Unicasts: unicasts}
+			switch {
+			case method == "SAE":
+				entry.Protocol = SecurityWPA3
+			case strings.HasPrefix(method, "EAP"):
+				entry.Method = darwin.EAP
+			case method != "PSK":
+				continue
+			}
+			if parts[0] == "WPA" {
+				entry.Protocol = SecurityWPA
+			}
+			security = append(security, entry)
+		}
+	}
+	if len(security) == 0 {
+		security = append(security, WifiNetworkSecurity{Protocol: SecurityNone})
+	}
+	return security
+}
+
+// wpaNetworkOptions returns the SET_NETWORK variables for the network,
+// based on the protocol of its first security configuration. A raw
+// PSK is passed on unquoted so the passphrase is never needed.
+// Hidden networks are probed for by name with scan_ssid.
+func wpaNetworkOptions(network WifiNetwork) ([][2]string, error) {
+	options := [][2]string{{"ssid", hex.EncodeToString([]byte(network.SSID))}}
+	if network.Hidden {
+		options = append(options, [2]string{"scan_ssid", "1"})
+	}
+	protocol := SecurityNone
+	if len(network.Security) > 0 {
+		protocol = network.Security[0].Protocol
+	}
+	switch protocol {
+	case SecurityWPA, SecurityWPA2:
+		options = append(options, [2]string{"key_mgmt", "WPA-PSK"})
+		if protocol == SecurityWPA {
+			options = append(options, [2]string{"proto", "WPA"})
+		}
+		if IsRawPSK(network.SecurityKey) {
+			options = append(options, [2]string{"psk", network.SecurityKey})
+		} else {
+			options = append(options, [2]string{"psk", `"` + network.SecurityKey + `"`})
+		}
+	case SecurityWPA3:
+		if IsRawPSK(network.SecurityKey) {
+			return nil, ErrRawPSK
+		}
+		options = append(options, [2]string{"key_mgmt", "SAE"}, [2]string{"ieee80211w", "2"})
+		options = append(options, [2]string{"sae_password", `"` + network.SecurityKey + `"`})
+	case SecurityWEP:
+		options = append(options, [2]string{"key_mgmt", "NONE"}, [2]string{"wep_tx_keyidx", "0"})
+		// 10 and 26 digit WEP keys are hex, anything else is text
+		wepKey := `"` + network.SecurityKey + `"`
+		if _, hexErr := hex.DecodeString(network.SecurityKey); hexErr == nil && (len(network.SecurityKey) == 10 || len(network.SecurityKey) == 26) {
+			wepKey = network.SecurityKey
+		}
+		options = append(options, [2]string{"wep_key0", wepKey})
+	default:
+		options = append(options, [2]string{"key_mgmt", "NONE"})
+	}
+	return options, nil
+}
@@ -0,0 +1,146 @@
+package wifimanager
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ottopress/WifiManager/dpp"
+)
+
+func TestWPANetworkOptions(t *testing.T) {
+	wpa2 := []WifiNetworkSecurity{{Protocol: SecurityWPA2}}
+	tests := []struct {
+		name    string
+		network WifiNetwork
+		options [][2]string
+	}{
+		{
+			name:    "open",
+			network: WifiNetwork{SSID: "cafe"},
+			options: [][2]string{{"ssid", "63616665"}, {"key_mgmt", "NONE"}},
+		},
+		{
+			name:    "hidden open",
+			network: WifiNetwork{SSID: "cafe", Hidden: true},
+			options: [][2]string{{"ssid", "63616665"}, {"scan_ssid", "1"}, {"key_mgmt", "NONE"}},
+		},
+		{
+			name:    "hidden passphrase",
+			network: WifiNetwork{SSID: "home", SecurityKey: "correct horse", Security: wpa2, Hidden: true},
+			options: [][2]string{{"ssid", "686f6d65"}, {"scan_ssid", "1"}, {"key_mgmt", "WPA-PSK"}, {"psk", `"correct horse"`}},
+		},
+		{
+			name:    "raw psk",
+			network: WifiNetwork{SSID: "home", SecurityKey: "f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e", Security: wpa2},
+			options: [][2]string{{"ssid", "686f6d65"}, {"key_mgmt", "WPA-PSK"}, {"psk", "f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e"}},
+		},
+	}
+	for _, test := range tests {
+		options, optionsErr := wpaNetworkOptions(test.network)
+		if optionsErr != nil {
+			t.Fatalf("%s: %v", test.name, optionsErr)
+		}
+		if !reflect.DeepEqual(options, test.options) {
+			t.Errorf("%s: options = %q, want %q", test.name, options, test.options)
+		}
+	}
+}
+
+// fakeWPACtrl answers requests on the control socket of the interface
+// in dir with the provided replies, or "FAIL", and returns a function
+// listing the requests received
+func fakeWPACtrl(t *testing.T, dir, iface string, replies map[string]string) func() []string {
+	t.Helper()
+	path := filepath.Join(dir, iface)
+	conn, listenErr := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if listenErr != nil {
+		t.Fatal(listenErr)
+	}
+	t.Cleanup(func() { conn.Close() })
+	var mutex sync.Mutex
+	requests := []string{}
+	go func() {
+		buffer := make([]byte, 4096)
+		for {
+			length, addr, readErr := conn.ReadFromUnix(buffer)
+			if readErr != nil {
+				return
+			}
+			request := string(buffer[:length])
+			mutex.Lock()
+			requests = append(requests, request)
+			mutex.Unlock()
+			reply, found := replies[request]
+			if !found {
+				reply = "FAIL\n"
+			}
+			conn.WriteToUnix([]byte(reply), addr)
+		}
+	}()
+	return func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string{}, requests...)
+	}
+}
+
+func TestDPPConfigure(t *testing.T) {
+	peer, parseErr := dpp.ParseURI("DPP:K:MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgADM2206avxHJaHXgLMkq/24e0rsrfMP9K1Tm8gx+ovP0I=;;")
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	psk := "f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e"
+	wpa2 := []WifiNetworkSecurity{{Protocol: SecurityWPA2}}
+	wpa3 := []WifiNetworkSecurity{{Protocol: SecurityWPA3}}
+	tests := []struct {
+		name    string
+		network WifiNetwork
+		auth    string
+		err     error
+	}{
+		{
+			name:    "passphrase",
+			network: WifiNetwork{SSID: "IEEE", Security: wpa2, SecurityKey: "password"},
+			auth:    "DPP_AUTH_INIT peer=1 role=configurator conf=sta-psk ssid=49454545 pass=70617373776f7264",
+		},
+		{
+			name:    "raw psk",
+			network: WifiNetwork{SSID: "IEEE", Security: wpa2, SecurityKey: psk},
+			auth:    "DPP_AUTH_INIT peer=1 role=configurator conf=sta-psk ssid=49454545 psk=" + psk,
+		},
+		{
+			name:    "sae passphrase",
+			network: WifiNetwork{SSID: "IEEE", Security: wpa3, SecurityKey: "password"},
+			auth:    "DPP_AUTH_INIT peer=1 role=configurator conf=sta-sae ssid=49454545 pass=70617373776f7264",
+		},
+		{
+			name:    "sae raw psk",
+			network: WifiNetwork{SSID: "IEEE", Security: wpa3, SecurityKey: psk},
+			err:     ErrRawPSK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			replies := map[string]string{"DPP_QR_CODE " + peer.String(): "1\n"}
+			if test.auth != "" {
+				replies[test.auth] = "OK\n"
+			}
+			received := fakeWPACtrl(t, dir, "wlan0", replies)
+			wpaBackend := NewWPASupplicantBackend()
+			wpaBackend.CtrlDir = dir
+			configureErr := wpaBackend.DPPConfigure("wlan0", peer, test.network)
+			if configureErr != test.err {
+				t.Fatalf("DPPConfigure = %v, want %v, sent %q", configureErr, test.err, received())
+			}
+			if test.auth == "" {
+				if requests := received(); len(requests) != 0 {
+					t.Errorf("sent %q", requests)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package wpaconf
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/ottopress/WifiManager"
+)
+
+// profileKeys are the network options owned by ApplyProfile. Any of
+// them the profile doesn't set are removed so stale settings don't
+// linger.
+var profileKeys = []string{
+	"scan_ssid", "key_mgmt", "proto", "psk", "sae_password", "ieee80211w",
+	"wep_key0", "wep_tx_keyidx", "priority", "disabled", "eap", "identity",
+	"anonymous_identity", "password", "phase2", "ca_cert", "client_cert",
+	"private_key", "domain_suffix_match",
+}
+
+// Profile converts a network block into a profile
+func Profile(network *Block) (wifimanager.Profile, error) {
+	profile := wifimanager.Profile{AutoJoin: true}
+	ssid, _ := network.Get("ssid")
+	decodedSSID, ssidErr := DecodeString(ssid)
+	if ssidErr != nil {
+		return profile, ssidErr
+	}
+	profile.SSID = decodedSSID
+
+	if scanSSID, ok := network.Get("scan_ssid"); ok && scanSSID == "1" {
+		profile.Hidden = true
+	}
+	if disabled, ok := network.Get("disabled"); ok && disabled == "1" {
+		profile.AutoJoin = false
+	}
+	if priority, ok := network.Get("priority"); ok {
+		profile.Priority, _ = strconv.Atoi(priority)
+	}
+
+	keyMgmt := map[string]bool{}
+	if value, ok := network.Get("key_mgmt"); ok {
+		for _, method := range strings.Fields(value) {
+			keyMgmt[method] = true
+		}
+	} else {
+		keyMgmt["WPA-PSK"] = true
+		keyMgmt["WPA-EAP"] = true
+	}
+	proto, _ := network.Get("proto")
+
+	switch {
+	case keyMgmt["WPA-PSK"] || keyMgmt["WPA-PSK-SHA256"]:
+		profile.Security = wifimanager.SecurityWPA2
+		if proto == "WPA" {
+			profile.Security = wifimanager.SecurityWPA
+		}
+		if psk, ok := network.Get("psk"); ok {
+			key, keyErr := decodeKey(psk)
+			if keyErr != nil {
+				return profile, keyErr
+			}
+			profile.SecurityKey = key
+		} else if _, ok := network.Get("eap"); ok {
+			profile.EAP = eapConfig(network)
+		}
+	case keyMgmt["SAE"]:
+		profile.Security = wifimanager.SecurityWPA3
+		password, ok := network.Get("sae_password")
+		if !ok {
+			password, _ = network.Get("psk")
+		}
+		key, keyErr := decodeKey(password)
+		if keyErr != nil {
+			return profile, keyErr
+		}
+		profile.SecurityKey = key
+	case keyMgmt["WPA-EAP"] || keyMgmt["WPA-EAP-SHA256"] || keyMgmt["IEEE8021X"]:
+		profile.Security = wifimanager.SecurityWPA2
+		if proto == "WPA" {
+			profile.Security = wifimanager.SecurityWPA
+		}
+		profile.EAP = eapConfig(network)
+	default:
+		profile.Security = wifimanager.SecurityNone
+		if wepKey, ok := network.Get("wep_key0"); ok {
+			profile.Security = wifimanager.SecurityWEP
+			key, keyErr := decodeKey(wepKey)
+			if keyErr != nil {
+				return profile, keyErr
+			}
+			profile.SecurityKey = key
+		}
+	}
+	return profile, nil
+}
+
+// Profiles converts every network block of the config into a profile
+func (config *Config) Profiles() ([]wifimanager.Profile, error) {
+	profiles := []wifimanager.Profile{}
+	for _, network := range config.Networks() {
+		profile, profileErr := Profile(network)
+		if profileErr != nil {
+			return profiles, profileErr
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// NewNetwork creates a network block for the profile
+func NewNetwork(profile wifimanager.Profile) *Block {
+	network := NewBlock("network")
+	ApplyProfile(network, profile)
+	return network
+}
+
+// ApplyProfile updates an existing network block to match the profile,
+// leaving comments and options the profile doesn't cover untouched
+func ApplyProfile(network *Block, profile wifimanager.Profile) {
+	options := &optionList{}
+	options.add("ssid", EncodeString(profile.SSID))
+	if profile.Hidden {
+		options.add("scan_ssid", "1")
+	}
+	switch {
+	case profile.EAP != nil:
+		options.add("key_mgmt", "WPA-EAP")
+		if profile.Security == wifimanager.SecurityWPA {
+			options.add("proto", "WPA")
+		}
+		eapOptions(options, profile.EAP)
+	case profile.Security == wifimanager.SecurityWPA || profile.Security == wifimanager.SecurityWPA2:
+		options.add("key_mgmt", "WPA-PSK")
+		if profile.Security == wifimanager.SecurityWPA {
+			options.add("proto", "WPA")
+		}
+		options.add("psk", encodeKey(profile.SecurityKey, 64))
+	case profile.Security == wifimanager.SecurityWPA3:
+		options.add("key_mgmt", "SAE")
+		options.add("sae_password", EncodeString(profile.SecurityKey))
+		options.add("ieee80211w", "2")
+	case profile.Security == wifimanager.SecurityWEP:
+		options.add("key_mgmt", "NONE")
+		options.add("wep_key0", encodeKey(profile.SecurityKey, 10, 26))
+		options.add("wep_tx_keyidx", "0")
+	default:
+		options.add("key_mgmt", "NONE")
+	}
+	if profile.Priority != 0 {
+		options.add("priority", strconv.Itoa(profile.Priority))
+	}
+	if !profile.AutoJoin {
+		options.add("disabled", "1")
+	}
+
+	for _, key := range profileKeys {
+		if _, wanted := options.values[key]; !wanted {
+			network.Delete(key)
+		}
+	}
+	for _, key := range options.keys {
+		// keep the existing spelling of values that didn't change, such
+		// as a hex SSID that would now be written quoted
+		if current, ok := network.Get(key); ok && sameValue(current, options.values[key]) {
+			continue
+		}
+		network.Set(key, options.values[key])
+	}
+}
+
+// sameValue returns whether or not two raw values are equal once
+// decoded
+func sameValue(current, value string) bool {
+	if current == value {
+		return true
+	}
+	decodedCurrent, currentErr := DecodeString(current)
+	decodedValue, valueErr := DecodeString(value)
+	return currentErr == nil && valueErr == nil && decodedCurrent == decodedValue
+}
+
+// optionList is an ordered set of options to apply to a block
+type optionList struct {
+	keys   []string
+	values map[string]string
+}
+
+// add appends the option to the list
+func (options *optionList) add(key, value string) {
+	if options.values == nil {
+		options.values = map[string]string{}
+	}
+	options.keys = append(options.keys, key)
+	options.values[key] = value
+}
+
+// eapConfig reads the 802.1x settings of a network block
+func eapConfig(network *Block) *wifimanager.EAPConfig {
+	eap := &wifimanager.EAPConfig{}
+	if methods, ok := network.Get("eap"); ok {
+		fields := strings.Fields(methods)
+		if len(fields) > 0 {
+			eap.Method = fields[0]
+		}
+	}
+	eap.Identity = getString(network, "identity")
+	eap.AnonymousIdentity = getString(network, "anonymous_identity")
+	eap.Password = getString(network, "password")
+	eap.CACert = getString(network, "ca_cert")
+	eap.ClientCert = getString(network, "client_cert")
+	eap.PrivateKey = getString(network, "private_key")
+	eap.ServerDomain = getString(network, "domain_suffix_match")
+	phase2 := getString(network, "phase2")
+	for _, setting := range strings.Fields(phase2) {
+		if strings.HasPrefix(setting, "auth=") || strings.HasPrefix(setting, "autheap=") {
+			eap.Phase2 = setting[strings.Index(setting, "=")+1:]
+		}
+	}
+	return eap
+}
+
+// eapOptions adds the 802.1x settings to the option list
+func eapOptions(options *optionList, eap *wifimanager.EAPConfig) {
+	options.add("eap", eap.Method)
+	phase2 := ""
+	if eap.Phase2 != "" {
+		phase2 = "auth=" + eap.Phase2
+	}
+	for _, option := range [][2]string{
+		{"identity", eap.Identity},
+		{"anonymous_identity", eap.AnonymousIdentity},
+		{"password", eap.Password},
+		{"phase2", phase2},
+		{"ca_cert", eap.CACert},
+		{"client_cert", eap.ClientCert},
+		{"private_key", eap.PrivateKey},
+		{"domain_suffix_match", eap.ServerDomain},
+	} {
+		if option[1] != "" {
+			options.add(option[0], EncodeString(option[1]))
+		}
+	}
+}
+
+// getString returns the decoded string option, or an empty string if
+// it is unset or invalid
+func getString(network *Block, key string) string {
+	value, ok := network.Get(key)
+	if !ok {
+		return ""
+	}
+	decoded, _ := DecodeString(value)
+	return decoded
+}
+
+// decodeKey decodes a psk or wep_key value. Quoted values are
+// passphrases while unquoted hex values are raw keys, which are kept
+// in their hex form.
+func decodeKey(value string) (string, error) {
+	if strings.HasPrefix(value, "\"") || strings.HasPrefix(value, "P\"") {
+		return DecodeString(value)
+	}
+	if _, hexErr := hex.DecodeString(value); hexErr != nil {
+		return "", ErrInvalidString
+	}
+	return value, nil
+}
+
+// encodeKey encodes a psk or wep_key value, leaving it unquoted if
+// it is a raw hex key of one of the provided lengths
+func encodeKey(key string, rawLengths ...int) string {
+	for _, rawLength := range rawLengths {
+		if len(key) != rawLength {
+			continue
+		}
+		if _, hexErr := hex.DecodeString(key); hexErr == nil {
+			return key
+		}
+	}
+	return EncodeString(key)
+}
@@ -0,0 +1,368 @@
+package wpaconf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidString is returned when a value is neither a quoted,
+	// hex nor printf-escaped string
+	ErrInvalidString = errors.New("wpaconf: invalid string value")
+)
+
+// Line represents a single line of a configuration: either a
+// key=value option, or a comment or blank line when Key is empty.
+// Raw holds the line as it was read and is written back unchanged
+// until the option is modified.
+type Line struct {
+	Key   string
+	Value string
+	Raw   string
+}
+
+// Block represents a name={ ... } section such as a network or a
+// Hotspot 2.0 credential
+type Block struct {
+	Name  string
+	Lines []Line
+	open  string
+	close string
+}
+
+// Config represents a wpa_supplicant.conf file. Global options,
+// comments and blocks are kept in their original order so that an
+// unmodified config is written back byte for byte. Lines end the way
+// the first line of the file did, CRLF or LF, and a missing newline
+// at the end of the file stays missing.
+type Config struct {
+	nodes          []node
+	newline        string
+	noFinalNewline bool
+}
+
+// node is either a top level line or a block
+type node struct {
+	line  *Line
+	block *Block
+}
+
+// Parse parses the contents of a wpa_supplicant.conf file
+func Parse(data []byte) (*Config, error) {
+	config := &Config{newline: "\n"}
+	text := string(data)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		config.noFinalNewline = true
+	}
+	rawLines := strings.SplitAfter(text, "\n")
+	if rawLines[len(rawLines)-1] == "" {
+		rawLines = rawLines[:len(rawLines)-1]
+	}
+	if len(rawLines) > 0 && strings.HasSuffix(rawLines[0], "\r\n") {
+		config.newline = "\r\n"
+	}
+	var block *Block
+	lineNumber := 0
+	for _, raw := range rawLines {
+		lineNumber++
+		raw = strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+		trimmed := strings.TrimSpace(raw)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			line := Line{Raw: raw}
+			if block != nil {
+				block.Lines = append(block.Lines, line)
+			} else {
+				config.nodes = append(config.nodes, node{line: &line})
+			}
+		case strings.HasSuffix(trimmed, "={"):
+			if block != nil {
+				return nil, fmt.Errorf("wpaconf: line %d: nested block", lineNumber)
+			}
+			block = &Block{Name: strings.TrimSpace(strings.TrimSuffix(trimmed, "={")), open: raw}
+		case trimmed == "}":
+			if block == nil {
+				return nil, fmt.Errorf("wpaconf: line %d: unexpected end of block", lineNumber)
+			}
+			block.close = raw
+			config.nodes = append(config.nodes, node{block: block})
+			block = nil
+		default:
+			pair := strings.SplitN(trimmed, "=", 2)
+			if len(pair) != 2 {
+				return nil, fmt.Errorf("wpaconf: line %d: invalid option", lineNumber)
+			}
+			line := Line{Key: strings.TrimSpace(pair[0]), Value: pair[1], Raw: raw}
+			if block != nil {
+				block.Lines = append(block.Lines, line)
+			} else {
+				config.nodes = append(config.nodes, node{line: &line})
+			}
+		}
+	}
+	if block != nil {
+		return nil, fmt.Errorf("wpaconf: line %d: unterminated block %s", lineNumber, block.Name)
+	}
+	return config, nil
+}
+
+// Marshal returns the wpa_supplicant.conf representation of the config
+func (config *Config) Marshal() []byte {
+	newline := config.newline
+	if newline == "" {
+		newline = "\n"
+	}
+	var buffer bytes.Buffer
+	for _, configNode := range config.nodes {
+		if configNode.line != nil {
+			buffer.WriteString(configNode.line.String())
+			buffer.WriteString(newline)
+			continue
+		}
+		configNode.block.write(&buffer, newline)
+	}
+	if config.noFinalNewline {
+		return bytes.TrimSuffix(buffer.Bytes(), []byte(newline))
+	}
+	return buffer.Bytes()
+}
+
+// Get returns the raw value of a global option
+func (config *Config) Get(key string) (string, bool) {
+	for _, configNode := range config.nodes {
+		if configNode.line != nil && configNode.line.Key == key {
+			return configNode.line.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set replaces the raw value of a global option, adding it after the
+// last global option if it isn't set yet
+func (config *Config) Set(key, value string) {
+	index := 0
+	for nodeIndex, configNode := range config.nodes {
+		if configNode.line != nil && configNode.line.Key == key {
+			configNode.line.update(value)
+			return
+		}
+		if configNode.line != nil && configNode.line.Key != "" {
+			index = nodeIndex + 1
+		}
+	}
+	config.nodes = append(config.nodes, node{})
+	copy(config.nodes[index+1:], config.nodes[index:])
+	config.nodes[index] = node{line: &Line{Key: key, Value: value}}
+}
+
+// Delete removes a global option
+func (config *Config) Delete(key string) {
+	nodes := []node{}
+	for _, configNode := range config.nodes {
+		if configNode.line == nil || configNode.line.Key != key {
+			nodes = append(nodes, configNode)
+		}
+	}
+	config.nodes = nodes
+}
+
+// Blocks returns all blocks with the provided name
+func (config *Config) Blocks(name string) []*Block {
+	blocks := []*Block{}
+	for _, configNode := range config.nodes {
+		if configNode.block != nil && configNode.block.Name == name {
+			blocks = append(blocks, configNode.block)
+		}
+	}
+	return blocks
+}
+
+// Networks returns all network blocks
+func (config *Config) Networks() []*Block {
+	return config.Blocks("network")
+}
+
+// AddBlock appends a block to the end of the config
+func (config *Config) AddBlock(block *Block) {
+	config.nodes = append(config.nodes, node{block: block})
+}
+
+// RemoveBlock removes the provided block from the config
+func (config *Config) RemoveBlock(block *Block) {
+	nodes := []node{}
+	for _, configNode := range config.nodes {
+		if configNode.block != block {
+			nodes = append(nodes, configNode)
+		}
+	}
+	config.nodes = nodes
+}
+
+// NewBlock creates an empty block with the provided name
+func NewBlock(name string) *Block {
+	return &Block{Name: name}
+}
+
+// Get returns the raw value of an option in the block
+func (block *Block) Get(key string) (string, bool) {
+	for _, line := range block.Lines {
+		if line.Key == key {
+			return line.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set replaces the raw value of an option in the block, appending it
+// if it isn't set yet
+func (block *Block) Set(key, value string) {
+	for index := range block.Lines {
+		if block.Lines[index].Key == key {
+			block.Lines[index].update(value)
+			return
+		}
+	}
+	block.Lines = append(block.Lines, Line{Key: key, Value: value})
+}
+
+// Delete removes an option from the block
+func (block *Block) Delete(key string) {
+	lines := []Line{}
+	for _, line := range block.Lines {
+		if line.Key != key {
+			lines = append(lines, line)
+		}
+	}
+	block.Lines = lines
+}
+
+// String returns the configuration text of the block
+func (block *Block) String() string {
+	var buffer bytes.Buffer
+	block.write(&buffer, "\n")
+	return buffer.String()
+}
+
+// write writes the configuration text of the block, ending every line
+// with the provided newline
+func (block *Block) write(buffer *bytes.Buffer, newline string) {
+	if block.open != "" {
+		buffer.WriteString(block.open)
+	} else {
+		buffer.WriteString(block.Name + "={")
+	}
+	buffer.WriteString(newline)
+	for _, line := range block.Lines {
+		// options added programmatically get the usual indentation
+		if line.Raw == "" && line.Key != "" {
+			buffer.WriteByte('\t')
+		}
+		buffer.WriteString(line.String())
+		buffer.WriteString(newline)
+	}
+	if block.close != "" {
+		buffer.WriteString(block.close)
+	} else {
+		buffer.WriteString("}")
+	}
+	buffer.WriteString(newline)
+}
+
+// update replaces the value of the line, keeping its indentation
+func (line *Line) update(value string) {
+	line.Value = value
+	if line.Raw == "" {
+		return
+	}
+	indent := line.Raw[:len(line.Raw)-len(strings.TrimLeft(line.Raw, " \t"))]
+	line.Raw = indent + line.Key + "=" + value
+}
+
+// String returns the configuration text of the line
+func (line Line) String() string {
+	if line.Raw != "" || line.Key == "" {
+		return line.Raw
+	}
+	return line.Key + "=" + line.Value
+}
+
+// DecodeString decodes a string value written in any of the forms
+// wpa_supplicant accepts: "quoted", P"printf-escaped" or hex.
+func DecodeString(value string) (string, error) {
+	switch {
+	case len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\""):
+		return value[1 : len(value)-1], nil
+	case len(value) >= 3 && strings.HasPrefix(value, "P\"") && strings.HasSuffix(value, "\""):
+		return unescapePrintf(value[2 : len(value)-1])
+	default:
+		decoded, hexErr := hex.DecodeString(value)
+		if hexErr != nil {
+			return "", ErrInvalidString
+		}
+		return string(decoded), nil
+	}
+}
+
+// EncodeString encodes a string value, quoting it when it is plain
+// printable ASCII and falling back to hex otherwise
+func EncodeString(value string) string {
+	for _, char := range []byte(value) {
+		if char < 0x20 || char > 0x7e {
+			return hex.EncodeToString([]byte(value))
+		}
+	}
+	return "\"" + value + "\""
+}
+
+// unescapePrintf decodes the escapes allowed in P"" strings
+func unescapePrintf(value string) (string, error) {
+	var buffer bytes.Buffer
+	for index := 0; index < len(value); index++ {
+		if value[index] != '\\' {
+			buffer.WriteByte(value[index])
+			continue
+		}
+		index++
+		if index >= len(value) {
+			return "", ErrInvalidString
+		}
+		switch value[index] {
+		case 'n':
+			buffer.WriteByte('\n')
+		case 'r':
+			buffer.WriteByte('\r')
+		case 't':
+			buffer.WriteByte('\t')
+		case 'e':
+			buffer.WriteByte(0x1b)
+		case 'x':
+			if index+3 > len(value) {
+				return "", ErrInvalidString
+			}
+			char, parseErr := strconv.ParseUint(value[index+1:index+3], 16, 8)
+			if parseErr != nil {
+				return "", ErrInvalidString
+			}
+			buffer.WriteByte(byte(char))
+			index += 2
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			end := index + 1
+			for end < len(value) && end < index+3 && value[end] >= '0' && value[end] <= '7' {
+				end++
+			}
+			char, parseErr := strconv.ParseUint(value[index:end], 8, 8)
+			if parseErr != nil {
+				return "", ErrInvalidString
+			}
+			buffer.WriteByte(byte(char))
+			index = end - 1
+		default:
+			buffer.WriteByte(value[index])
+		}
+	}
+	return buffer.String(), nil
+}
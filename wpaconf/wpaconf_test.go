@@ -0,0 +1,132 @@
+package wpaconf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ottopress/WifiManager"
+)
+
+const testConfig = `ctrl_interface=DIR=/var/run/wpa_supplicant GROUP=netdev
+update_config=1
+# home network
+network={
+	ssid="home"
+	psk="correct horse"
+	# keep this comment
+	priority=5
+}
+
+network={
+    ssid=6869646465e6
+    key_mgmt=NONE
+}
+`
+
+func TestRoundTrip(t *testing.T) {
+	crlf := bytes.Replace([]byte(testConfig), []byte("\n"), []byte("\r\n"), -1)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "lf", data: []byte(testConfig)},
+		{name: "crlf", data: crlf},
+		{name: "no final newline", data: bytes.TrimSuffix([]byte(testConfig), []byte("\n"))},
+		{name: "crlf without final newline", data: bytes.TrimSuffix(crlf, []byte("\r\n"))},
+		{name: "empty", data: []byte{}},
+		{name: "blank lines", data: []byte("\n\n# only comments\n\n")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, parseErr := Parse(test.data)
+			if parseErr != nil {
+				t.Fatal(parseErr)
+			}
+			if marshaled := config.Marshal(); !bytes.Equal(marshaled, test.data) {
+				t.Fatalf("round trip changed the config:\n%q\nwant\n%q", marshaled, test.data)
+			}
+		})
+	}
+}
+
+func TestModifyKeepsLineEndings(t *testing.T) {
+	data := []byte("update_config=1\r\nnetwork={\r\n\tssid=\"home\"\r\n\tpsk=\"old passphrase\"\r\n}")
+	config, parseErr := Parse(data)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	config.Networks()[0].Set("psk", EncodeString("new passphrase"))
+	config.Set("country", "DE")
+	want := "update_config=1\r\ncountry=DE\r\nnetwork={\r\n\tssid=\"home\"\r\n\tpsk=\"new passphrase\"\r\n}"
+	if marshaled := string(config.Marshal()); marshaled != want {
+		t.Fatalf("marshaled %q, want %q", marshaled, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, data := range []string{
+		"network={\nnetwork={\n}\n}\n",
+		"}\n",
+		"network={\n\tssid=\"home\"\n",
+		"not an option\n",
+	} {
+		if _, parseErr := Parse([]byte(data)); parseErr == nil {
+			t.Errorf("parsed invalid config %q", data)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	config, parseErr := Parse([]byte(testConfig))
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	profiles, profilesErr := config.Profiles()
+	if profilesErr != nil {
+		t.Fatal(profilesErr)
+	}
+	if len(profiles) != 2 {
+		t.Fatalf("got %d profiles, want 2", len(profiles))
+	}
+	home := profiles[0]
+	if home.SSID != "home" || home.Security != wifimanager.SecurityWPA2 || home.SecurityKey != "correct horse" || home.Priority != 5 {
+		t.Errorf("unexpected profile %+v", home)
+	}
+	open := profiles[1]
+	if open.SSID != "hidde\xe6" || open.Security != wifimanager.SecurityNone {
+		t.Errorf("unexpected profile %+v", open)
+	}
+}
+
+func TestApplyProfileKeepsComments(t *testing.T) {
+	config, parseErr := Parse([]byte(testConfig))
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	network := config.Networks()[0]
+	ApplyProfile(network, wifimanager.Profile{SSID: "home", Security: wifimanager.SecurityWPA3, SecurityKey: "correct horse", AutoJoin: true})
+	want := "network={\n\tssid=\"home\"\n\t# keep this comment\n\tkey_mgmt=SAE\n\tsae_password=\"correct horse\"\n\tieee80211w=2\n}\n"
+	if text := network.String(); text != want {
+		t.Fatalf("block %q, want %q", text, want)
+	}
+}
+
+func TestDecodeString(t *testing.T) {
+	tests := []struct {
+		value   string
+		decoded string
+	}{
+		{`"plain"`, "plain"},
+		{"706c61696e", "plain"},
+		{`P"tab\there\x41\101\n"`, "tab\there\x41A\n"},
+	}
+	for _, test := range tests {
+		decoded, decodeErr := DecodeString(test.value)
+		if decodeErr != nil || decoded != test.decoded {
+			t.Errorf("DecodeString(%q) = %q, %v, want %q", test.value, decoded, decodeErr, test.decoded)
+		}
+	}
+	if _, decodeErr := DecodeString("not hex"); decodeErr != ErrInvalidString {
+		t.Errorf("decoded invalid value, got %v", decodeErr)
+	}
+}